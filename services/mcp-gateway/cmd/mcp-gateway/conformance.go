@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+)
+
+// runConformance implements `mcp-gateway conformance --server name`.
+// It connects to a single configured server, checks it and prints a report.
+// The exit code is 0 if every check passed or was skipped, 1 if any failed and 2 on usage or setup errors.
+func runConformance(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", configPathFromEnv(), "path to the gateway configuration")
+	server := fs.String("server", "", "name of the configured server to check (required)")
+	callAll := fs.Bool("call-all-tools", false, "also call tools that are not annotated readOnlyHint")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *server == "" || fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: mcp-gateway conformance --server name [--config path] [--call-all-tools] [--json]")
+		return 2
+	}
+
+	// Keep stdout for the report
+	slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+		return 2
+	}
+	// Tool names are checked as the gateway would accept them
+	validator.SetUnicodeToolNames(cfg.UnicodeToolNames)
+	var serverCfg *config.ServerConfig
+	for i := range cfg.Servers {
+		if cfg.Servers[i].Name == *server {
+			serverCfg = &cfg.Servers[i]
+			break
+		}
+	}
+	if serverCfg == nil {
+		fmt.Fprintf(stderr, "server %s is not configured in %s\n", *server, *configPath)
+		return 2
+	}
+
+	processManager := mcp.NewProcessManager(cfg.HealthCheckInterval, "never")
+	clientManager := mcp.NewClientManager(processManager)
+	clientManager.SetSecretStore(secrets.NewDefaultStore())
+	defer func() {
+		if err := clientManager.Close(); err != nil {
+			fmt.Fprintf(stderr, "failed to stop server: %v\n", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := clientManager.Initialize(ctx, []config.ServerConfig{*serverCfg}); err != nil {
+		fmt.Fprintf(stderr, "failed to connect: %v\n", err)
+		return 1
+	}
+
+	report, err := clientManager.CheckConformance(context.Background(), *server, mcp.ConformanceOptions{CallAllTools: *callAll})
+	if err != nil {
+		fmt.Fprintf(stderr, "conformance check failed: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "failed to encode report: %v\n", err)
+			return 2
+		}
+	} else {
+		printConformanceReport(stdout, report)
+	}
+
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+// printConformanceReport writes one line per check followed by a summary
+func printConformanceReport(w io.Writer, report *mcp.ConformanceReport) {
+	for _, check := range report.Checks {
+		line := fmt.Sprintf("%-4s  %s", statusLabel(check.Status), check.Name)
+		if check.Tool != "" {
+			line += " [" + check.Tool + "]"
+		}
+		if check.Detail != "" {
+			line += ": " + check.Detail
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "\n%s: %d passed, %d failed, %d skipped\n", report.Server, report.Passed, report.Failed, report.Skipped)
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case mcp.CheckPass:
+		return "PASS"
+	case mcp.CheckFail:
+		return "FAIL"
+	default:
+		return "SKIP"
+	}
+}
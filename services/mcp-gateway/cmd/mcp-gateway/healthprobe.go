@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// runHealthProbe implements `mcp-gateway healthprobe`, a probe for images without curl such as
+// distroless ones: a Docker HEALTHCHECK or a Kubernetes exec probe.
+// It requests GET /health of the gateway on this host, on the first listener and under the reverse proxy
+// base path of the configuration unless --url is given, and exits 0 if it answers 200, or with
+// --require-ok only if every server is available too. It exits 1 otherwise and 2 on usage errors.
+func runHealthProbe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("healthprobe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	url := fs.String("url", "", "health endpoint of the gateway (default: derived from the configuration)")
+	configPath := fs.String("config", configPathFromEnv(), "path to the gateway configuration the endpoint is derived from")
+	timeout := fs.Duration("timeout", 3*time.Second, "timeout of the request")
+	requireOK := fs.Bool("require-ok", false, "fail while any server is not available (status degraded)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 || *timeout <= 0 {
+		fmt.Fprintln(stderr, "usage: mcp-gateway healthprobe [--url http://127.0.0.1:3001/health | --config path] [--timeout 3s] [--require-ok]")
+		return 2
+	}
+
+	target := *url
+	if target == "" {
+		// Keep stdout for the result
+		slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+		var err error
+		if target, err = healthURLFromConfig(*configPath); err != nil {
+			fmt.Fprintf(stderr, "unhealthy: %v\n", err)
+			return 1
+		}
+	}
+
+	status, err := probeHealth(target, *timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "unhealthy: %v\n", err)
+		return 1
+	}
+	if *requireOK && status != "ok" {
+		fmt.Fprintf(stderr, "unhealthy: status %s\n", status)
+		return 1
+	}
+	fmt.Fprintf(stdout, "healthy: status %s\n", status)
+	return 0
+}
+
+// healthURLFromConfig loads the configuration like the gateway does, with the .env file of DOTENV_PATH,
+// and returns its health endpoint
+func healthURLFromConfig(path string) (string, error) {
+	if dotenv := os.Getenv("DOTENV_PATH"); dotenv != "" {
+		if _, err := config.LoadDotEnv(dotenv); err != nil {
+			return "", err
+		}
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return "", fmt.Errorf("load %s: %w", path, err)
+	}
+	return healthURL(cfg), nil
+}
+
+// healthURL is the health endpoint of the first listener of cfg, which PORT provides without listeners.
+// Every listener serves /health, under the reverse proxy base path. A listener on all addresses is
+// reached on loopback.
+func healthURL(cfg *config.Config) string {
+	host, port, err := net.SplitHostPort(listenersFromConfig(cfg)[0].Address)
+	if err != nil {
+		// Validated when the config is loaded
+		host, port = "", "3001"
+	}
+	if ip := net.ParseIP(host); host == "" || ip.IsUnspecified() {
+		host = "127.0.0.1"
+		if ip != nil && ip.To4() == nil {
+			host = "::1"
+		}
+	}
+	basePath := ""
+	if cfg.ReverseProxy != nil {
+		basePath = cfg.ReverseProxy.BasePath
+	}
+	return "http://" + net.JoinHostPort(host, port) + basePath + "/health"
+}
+
+// probeHealth requests the health endpoint and returns the gateway's status, ok or degraded
+func probeHealth(url string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%s: invalid response: %w", url, err)
+	}
+	return body.Status, nil
+}
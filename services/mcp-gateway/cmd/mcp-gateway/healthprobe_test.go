@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// healthServer answers GET /health with status and body
+func healthServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunHealthProbe(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{name: "ok", status: http.StatusOK, body: `{"status":"ok"}`, wantCode: 0, wantOut: "healthy: status ok"},
+		{name: "degraded", status: http.StatusOK, body: `{"status":"degraded"}`, wantCode: 0, wantOut: "healthy: status degraded"},
+		{name: "degraded with --require-ok", status: http.StatusOK, body: `{"status":"degraded"}`, args: []string{"--require-ok"}, wantCode: 1, wantOut: "unhealthy: status degraded"},
+		{name: "ok with --require-ok", status: http.StatusOK, body: `{"status":"ok"}`, args: []string{"--require-ok"}, wantCode: 0, wantOut: "healthy: status ok"},
+		{name: "non-200", status: http.StatusServiceUnavailable, body: `{"status":"ok"}`, wantCode: 1, wantOut: "503 Service Unavailable"},
+		{name: "invalid JSON", status: http.StatusOK, body: `<html>`, wantCode: 1, wantOut: "invalid response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := healthServer(t, tt.status, tt.body)
+			var stdout, stderr bytes.Buffer
+
+			code := runHealthProbe(append([]string{"--url", server.URL + "/health"}, tt.args...), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stdout.String()+stderr.String(), tt.wantOut)
+		})
+	}
+}
+
+func TestRunHealthProbe_Unreachable(t *testing.T) {
+	server := healthServer(t, http.StatusOK, `{"status":"ok"}`)
+	url := server.URL + "/health"
+	server.Close()
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, 1, runHealthProbe([]string{"--url", url}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "unhealthy")
+}
+
+func TestRunHealthProbe_Usage(t *testing.T) {
+	for name, args := range map[string][]string{
+		"unknown flag":      {"--port", "3001"},
+		"extra argument":    {"http://127.0.0.1:3001/health"},
+		"non-positive time": {"--timeout", "0s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, 2, runHealthProbe(args, &stdout, &stderr))
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func TestRunHealthProbe_URLFromConfig(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	t.Cleanup(server.Close)
+	address := strings.TrimPrefix(server.URL, "http://")
+	configPath := writeConfig(t, `
+servers:
+  - name: test-server
+    command: /bin/true
+listeners:
+  - address: "`+address+`"
+    routes: admin
+  - address: "127.0.0.1:1"
+    routes: api
+reverseProxy:
+  basePath: /gateway
+`)
+	var stdout, stderr bytes.Buffer
+
+	code := runHealthProbe([]string{"--config", configPath}, &stdout, &stderr)
+
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "/gateway/health", path)
+}
+
+func TestRunHealthProbe_InvalidConfig(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := runHealthProbe([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "unhealthy: load")
+}
+
+func TestHealthURL(t *testing.T) {
+	tests := []struct {
+		name string
+		port string
+		cfg  config.Config
+		want string
+	}{
+		{name: "default port", want: "http://127.0.0.1:3001/health"},
+		{name: "PORT", port: "8080", want: "http://127.0.0.1:8080/health"},
+		{
+			name: "listener on all addresses",
+			port: "8080",
+			cfg:  config.Config{Listeners: []config.ListenerConfig{{Address: ":3002"}}},
+			want: "http://127.0.0.1:3002/health",
+		},
+		{
+			name: "listener on IPv4 any address",
+			cfg:  config.Config{Listeners: []config.ListenerConfig{{Address: "0.0.0.0:3002"}}},
+			want: "http://127.0.0.1:3002/health",
+		},
+		{
+			name: "listener on IPv6 any address",
+			cfg:  config.Config{Listeners: []config.ListenerConfig{{Address: "[::]:3002"}}},
+			want: "http://[::1]:3002/health",
+		},
+		{
+			name: "first of several listeners",
+			cfg: config.Config{Listeners: []config.ListenerConfig{
+				{Address: "10.0.0.5:3003", Routes: config.ListenerRoutesAdmin},
+				{Address: ":3002", Routes: config.ListenerRoutesAPI},
+			}},
+			want: "http://10.0.0.5:3003/health",
+		},
+		{
+			name: "reverse proxy base path",
+			cfg:  config.Config{ReverseProxy: &config.ReverseProxyConfig{BasePath: "/gateway"}},
+			want: "http://127.0.0.1:3001/gateway/health",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PORT", tt.port)
+			assert.Equal(t, tt.want, healthURL(&tt.cfg))
+		})
+	}
+}
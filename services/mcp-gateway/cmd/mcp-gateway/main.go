@@ -2,26 +2,66 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"sync"
 	"syscall"
+	"text/template"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/http"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/notify"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/toolsearch"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/tracing"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/usage"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
 )
 
+// version is set at build time via -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "conformance" {
+		os.Exit(runConformance(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "healthprobe" {
+		os.Exit(runHealthProbe(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Setup logger
-	setupLogger()
+	logLevel := setupLogger()
 
-	// Load configuration
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "config/config.yaml"
+	// Suppress Gin's debug banner and route dump unless explicitly requested
+	if os.Getenv(gin.EnvGinMode) == "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// Variables of the .env file are visible to the config file's ${VAR} references
+	if path := os.Getenv("DOTENV_PATH"); path != "" {
+		n, err := config.LoadDotEnv(path)
+		if err != nil {
+			slog.Error("Failed to load .env file", "path", path, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Loaded .env file", "path", path, "variables", n)
 	}
+
+	// Load configuration
+	configPath := configPathFromEnv()
 	slog.Info("Loading configuration", "path", configPath)
 
 	cfg, err := config.LoadConfig(configPath)
@@ -29,10 +69,83 @@ func main() {
 		slog.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	secretStore := secrets.NewDefaultStore()
+	if err := config.FetchSecrets(cfg, secretStore); err != nil {
+		slog.Error("Failed to fetch secrets", "error", err)
+		os.Exit(1)
+	}
+
+	validator.SetUnicodeToolNames(cfg.UnicodeToolNames)
+
+	// Compile authorization before spawning servers so a bad policy fails fast
+	routerOpts, err := authorizationOptions(cfg.Authorization)
+	if err != nil {
+		slog.Error("Failed to configure authorization", "error", err)
+		os.Exit(1)
+	}
+	if len(cfg.APIKeys) > 0 {
+		slog.Info("Requiring API keys", "keys", len(cfg.APIKeys), "profiles", len(cfg.Profiles))
+		routerOpts = append(routerOpts, http.WithAPIKeys(cfg.APIKeys, cfg.Profiles))
+	}
+	routerOpts = append(routerOpts, http.WithLogLevel(logLevel))
+	if os.Getenv("HTTP_STRUCTURED_LOGGING") == "true" {
+		routerOpts = append(routerOpts, http.WithStructuredLogging())
+	}
+	if cfg.ResponseHeaders != nil {
+		routerOpts = append(routerOpts, http.WithResponseHeaders(*cfg.ResponseHeaders))
+	}
+	if cfg.ReverseProxy != nil {
+		routerOpts = append(routerOpts, http.WithReverseProxy(*cfg.ReverseProxy))
+	}
+	if cfg.LargePayloads != nil {
+		slog.Info("Accepting large payloads on /mcp/call",
+			"maxRequestBytes", cfg.LargePayloads.MaxRequestBytes,
+			"maxResponseBytes", cfg.LargePayloads.MaxResponseBytes,
+		)
+		validator.SetMaxInputSize(cfg.LargePayloads.MaxRequestBytes)
+		routerOpts = append(routerOpts, http.WithLargePayloads(*cfg.LargePayloads))
+	}
+	if os.Getenv("HEALTH_SELF_DIAGNOSTICS") == "true" {
+		routerOpts = append(routerOpts, http.WithSelfDiagnostics())
+	}
+	if os.Getenv("EXPERIMENTAL_TRANSACTIONS") == "true" {
+		routerOpts = append(routerOpts, http.WithTransactions())
+	}
+	if cfg.ToolSearch != nil && cfg.ToolSearch.Embedder != nil {
+		embedder := cfg.ToolSearch.Embedder
+		slog.Info("Ranking tool search by embeddings", "url", embedder.URL, "model", embedder.Model)
+		routerOpts = append(routerOpts, http.WithToolSearchEmbedder(toolsearch.NewHTTPEmbedder(toolsearch.HTTPEmbedderOptions{
+			URL:     embedder.URL,
+			Model:   embedder.Model,
+			APIKey:  embedder.APIKey,
+			Timeout: time.Duration(embedder.Timeout) * time.Millisecond,
+		})))
+	}
+
+	shutdownTracing, err := setupTracing(cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to configure tracing", "error", err)
+		os.Exit(1)
+	}
+	usageOpts, stopUsageExport := setupUsageExport(cfg.UsageExport)
+	routerOpts = append(routerOpts, usageOpts...)
+	stopSecretsRefresh := setupSecretsRefresh(secretStore, cfg.Secrets)
 
 	// Initialize managers
 	processManager := mcp.NewProcessManager(cfg.HealthCheckInterval, cfg.RestartPolicy)
+	processManager.SetRestartBackoff(cfg.RestartBackoff)
+	if seed := os.Getenv("DETERMINISTIC_SEED"); seed != "" {
+		routerOpts = append(routerOpts, setupDeterministicMode(processManager, seed)...)
+	}
 	clientManager := mcp.NewClientManager(processManager)
+	clientManager.SetStartupFailurePolicy(cfg.StartupFailurePolicy)
+	clientManager.SetSecretStore(secretStore)
+	clientManager.SetHealthCheckJitter(*cfg.HealthCheckJitter)
+	clientManager.SetProcessGracePeriod(time.Duration(*cfg.Shutdown.ProcessGracePeriod) * time.Millisecond)
+	if rs := cfg.RestartState; rs != nil {
+		restoreRestartState(clientManager, rs)
+	}
+	stopNotifications := setupNotifications(clientManager, cfg.Notifications)
 
 	// Connect to MCP servers
 	// Note: This context only controls the connection establishment timeout.
@@ -50,24 +163,40 @@ func main() {
 	}
 	slog.Info("Connected to MCP servers")
 
-	// Setup HTTP server
+	if snap := cfg.MetricsSnapshot; snap != nil {
+		restoreCounterSnapshots(clientManager, snap)
+	}
+
+	// Setup HTTP servers, one per listener
 	handler := http.NewHandler(clientManager, processManager)
-	router := http.SetupRouter(handler)
+	listeners := listenersFromConfig(cfg)
+	routers := http.SetupListenerRouters(handler, listeners, routerOpts...)
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	serverManagers := make([]*http.ServerManager, len(listeners))
+	for i, l := range listeners {
+		serverManagers[i] = http.NewServerManagerAt(routers[i], l.Address)
+		serverManagers[i].SetShutdownTimeout(time.Duration(*cfg.Shutdown.HTTPTimeout) * time.Millisecond)
+		if err := serverManagers[i].Listen(); err != nil {
+			slog.Error("Failed to bind server port", "address", l.Address, "error", err)
+			if closeErr := clientManager.Close(); closeErr != nil {
+				slog.Error("Failed to cleanup clients during shutdown", "error", closeErr)
+			}
+			os.Exit(1)
+		}
 	}
 
-	serverManager := http.NewServerManager(router, port)
-	serverErr := make(chan error, 1)
-	go func() {
-		if err := serverManager.Start(); err != nil {
-			slog.Error("Server failed", "error", err)
-			serverErr <- err
-		}
-	}()
+	readinessFile := os.Getenv("READINESS_FILE")
+	announceReady(apiPort(listeners), len(cfg.Servers), readinessFile)
+
+	serverErr := make(chan error, len(serverManagers))
+	for _, sm := range serverManagers {
+		go func() {
+			if err := sm.Start(); err != nil {
+				slog.Error("Server failed", "error", err)
+				serverErr <- err
+			}
+		}()
+	}
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -75,14 +204,27 @@ func main() {
 	select {
 	case <-quit:
 		slog.Info("Shutting down server...")
-		if err := serverManager.Shutdown(); err != nil {
-			slog.Error("Failed to shutdown server", "error", err)
+		removeReadinessFile(readinessFile)
+		// Listeners drain concurrently so that the shutdown takes at most one httpTimeout
+		var wg sync.WaitGroup
+		for _, sm := range serverManagers {
+			wg.Go(func() {
+				if err := sm.Shutdown(); err != nil {
+					slog.Error("Failed to shutdown server", "error", err)
+				}
+			})
 		}
+		wg.Wait()
 	case err := <-serverErr:
 		slog.Error("Server startup failed", "error", err)
 		if closeErr := clientManager.Close(); closeErr != nil {
 			slog.Error("Failed to cleanup clients during shutdown", "error", closeErr)
 		}
+		removeReadinessFile(readinessFile)
+		stopNotifications()
+		stopUsageExport()
+		stopSecretsRefresh()
+		shutdownTracing()
 		os.Exit(1)
 	}
 
@@ -90,17 +232,316 @@ func main() {
 	if err := clientManager.Close(); err != nil {
 		slog.Error("Error closing clients", "error", err)
 	}
+	stopNotifications()
+	stopUsageExport()
+	stopSecretsRefresh()
+	shutdownTracing()
 
 	slog.Info("Server exited")
 }
 
-func setupLogger() {
-	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+// listenersFromConfig returns the configured listeners, or a single one serving all routes on PORT
+func listenersFromConfig(cfg *config.Config) []config.ListenerConfig {
+	if len(cfg.Listeners) > 0 {
+		return cfg.Listeners
+	}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3001"
 	}
+	return []config.ListenerConfig{{Address: ":" + port, Routes: config.ListenerRoutesAll}}
+}
+
+// apiPort returns the port of the first listener serving the API, which the ready event reports
+func apiPort(listeners []config.ListenerConfig) string {
+	for _, l := range listeners {
+		if l.Routes != config.ListenerRoutesAdmin {
+			_, port, _ := net.SplitHostPort(l.Address)
+			return port
+		}
+	}
+	return ""
+}
+
+// setupLogger installs the default logger and returns its level, which PUT /admin/loglevel can change
+func setupLogger() *slog.LevelVar {
+	level := new(slog.LevelVar)
 	if os.Getenv("LOG_LEVEL") == "DEBUG" {
-		opts.Level = slog.LevelDebug
+		level.Set(slog.LevelDebug)
 	}
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
 	slog.SetDefault(logger)
+	return level
+}
+
+// configPathFromEnv returns CONFIG_PATH, defaulting to config/config.yaml
+func configPathFromEnv() string {
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
+	}
+	return "config/config.yaml"
+}
+
+// readyEvent is the machine-readable payload emitted once the gateway can serve traffic
+type readyEvent struct {
+	Event       string    `json:"event"`
+	Port        string    `json:"port"`
+	ServerCount int       `json:"serverCount"`
+	Version     string    `json:"version"`
+	PID         int       `json:"pid"`
+	ReadyAt     time.Time `json:"readyAt"`
+}
+
+// announceReady emits a single structured "ready" log line and, if path is set,
+// writes the same payload to a readiness file (for systemd/K8s postStart hooks)
+func announceReady(port string, serverCount int, path string) {
+	ev := readyEvent{
+		Event:       "ready",
+		Port:        port,
+		ServerCount: serverCount,
+		Version:     version,
+		PID:         os.Getpid(),
+		ReadyAt:     time.Now().UTC(),
+	}
+
+	slog.Info("Gateway ready",
+		"event", ev.Event,
+		"port", ev.Port,
+		"serverCount", ev.ServerCount,
+		"version", ev.Version,
+		"pid", ev.PID,
+	)
+
+	if path == "" {
+		return
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		slog.Error("Failed to encode readiness file", "error", err)
+		return
+	}
+	// Write to a temp file and rename so watchers never observe a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		slog.Error("Failed to write readiness file", "path", path, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Error("Failed to write readiness file", "path", path, "error", err)
+	}
+}
+
+// removeReadinessFile deletes the readiness file so that probes stop reporting ready during shutdown
+func removeReadinessFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove readiness file", "path", path, "error", err)
+	}
+}
+
+// setupTracing starts exporting tool call traces when tracing is configured.
+// The returned function flushes pending spans and is safe to call when tracing is disabled.
+func setupTracing(cfg *config.TracingConfig) (func(), error) {
+	if cfg == nil {
+		return func() {}, nil
+	}
+
+	opts := tracing.Options{
+		SampleRatio:   *cfg.SampleRatio,
+		SlowThreshold: time.Duration(cfg.SlowThreshold) * time.Millisecond,
+	}
+	shutdown, err := tracing.Setup(context.Background(), cfg.Endpoint, opts)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Exporting traces", "endpoint", cfg.Endpoint, "sampleRatio", opts.SampleRatio, "slowThreshold", opts.SlowThreshold)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}, nil
+}
+
+// setupUsageExport records tool usage and exports it periodically when configured.
+// The returned function exports what is left and is safe to call when the export is disabled.
+func setupUsageExport(cfg *config.UsageExportConfig) ([]http.RouterOption, func()) {
+	if cfg == nil {
+		return nil, func() {}
+	}
+
+	var sink usage.Sink = usage.FileSink{Path: cfg.Path}
+	destination := cfg.Path
+	if cfg.URL != "" {
+		sink = usage.HTTPSink{URL: cfg.URL}
+		destination = cfg.URL
+	}
+	interval := time.Duration(cfg.Interval) * time.Millisecond
+	recorder := usage.NewRecorder()
+	stop := recorder.Start(sink, interval)
+	slog.Info("Exporting tool usage", "destination", destination, "interval", interval)
+
+	return []http.RouterOption{http.WithUsageRecorder(recorder)}, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := stop(ctx); err != nil {
+			slog.Error("Failed to export remaining tool usage", "error", err)
+		}
+	}
+}
+
+// setupNotifications tells the configured webhooks and Slack and Discord channels when servers crash, restart
+// or are quarantined. The returned function waits briefly for pending notifications and is safe to call when
+// none are configured.
+func setupNotifications(cm *mcp.ClientManager, cfg *config.NotificationsConfig) func() {
+	if cfg == nil || len(cfg.Webhooks)+len(cfg.Slack)+len(cfg.Discord) == 0 {
+		return func() {}
+	}
+
+	var targets []notify.Target
+	for _, webhook := range cfg.Webhooks {
+		targets = append(targets, notify.Target{
+			Name:    webhook.URL,
+			Sender:  notify.Webhook{URL: webhook.URL, Headers: webhook.Headers},
+			Kinds:   notificationKinds(webhook.Events),
+			Timeout: time.Duration(webhook.Timeout) * time.Millisecond,
+			Retries: *webhook.Retries,
+		})
+		slog.Info("Sending notifications", "url", webhook.URL, "events", notificationKinds(webhook.Events))
+	}
+	for i, chat := range cfg.Slack {
+		targets = append(targets, chatTarget(fmt.Sprintf("slack[%d]", i), chat, func(tmpl *template.Template) notify.Sender {
+			return notify.Slack{URL: chat.URL, Template: tmpl}
+		}))
+	}
+	for i, chat := range cfg.Discord {
+		targets = append(targets, chatTarget(fmt.Sprintf("discord[%d]", i), chat, func(tmpl *template.Template) notify.Sender {
+			return notify.Discord{URL: chat.URL, Template: tmpl}
+		}))
+	}
+	notifier := notify.New(targets...)
+	unsubscribe := cm.Events().Subscribe(notifier, notify.EventTypes...)
+
+	return func() {
+		unsubscribe()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := notifier.Close(ctx); err != nil {
+			slog.Error("Failed to deliver pending notifications", "error", err)
+		}
+	}
+}
+
+// chatTarget makes the notification target of a Slack or Discord channel. Their webhook URLs embed
+// credentials, so the target is named by its position in the config instead.
+func chatTarget(name string, chat config.ChatConfig, sender func(*template.Template) notify.Sender) notify.Target {
+	// The template was validated when the config was loaded
+	tmpl := template.Must(notify.ParseTemplate(chat.Template))
+	slog.Info("Sending notifications", "target", name, "events", notificationKinds(chat.Events))
+	return notify.Target{
+		Name:    name,
+		Sender:  sender(tmpl),
+		Kinds:   notificationKinds(chat.Events),
+		Timeout: time.Duration(chat.Timeout) * time.Millisecond,
+		Retries: *chat.Retries,
+	}
+}
+
+// notificationKinds converts configured event names, returning nil, meaning all kinds, for none
+func notificationKinds(names []string) []notify.Kind {
+	if len(names) == 0 {
+		return nil
+	}
+	kinds := make([]notify.Kind, len(names))
+	for i, name := range names {
+		kinds[i] = notify.Kind(name)
+	}
+	return kinds
+}
+
+// setupSecretsRefresh periodically fetches the secrets referenced by env values again when configured.
+// Servers started afterwards, including restarts, receive the refreshed values.
+func setupSecretsRefresh(store *secrets.Store, cfg *config.SecretsConfig) func() {
+	if cfg == nil || cfg.RefreshInterval == 0 {
+		return func() {}
+	}
+	interval := time.Duration(cfg.RefreshInterval) * time.Millisecond
+	slog.Info("Refreshing secrets periodically", "interval", interval)
+	return store.Start(interval)
+}
+
+// deterministicEpoch is where the fake clock of deterministic mode starts
+var deterministicEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// setupDeterministicMode seeds the jitter of health checks and restart backoffs and replaces the clock
+// with a fake one that only POST /admin/clock/advance moves, for reproducible integration tests
+func setupDeterministicMode(pm *mcp.ProcessManager, rawSeed string) []http.RouterOption {
+	seed, err := strconv.ParseUint(rawSeed, 10, 64)
+	if err != nil {
+		slog.Error("Invalid DETERMINISTIC_SEED", "value", rawSeed, "error", err)
+		os.Exit(1)
+	}
+	fake := clock.NewFake(deterministicEpoch)
+	pm.SetRandSeed(seed)
+	pm.SetClock(fake)
+	slog.Warn("Running in deterministic mode, time only advances through POST /admin/clock/advance", "seed", seed)
+	return []http.RouterOption{http.WithFakeClock(fake)}
+}
+
+// restoreCounterSnapshots reloads persisted counters and keeps saving them until shutdown.
+// An unreadable snapshot is logged and skipped so that it never blocks startup.
+func restoreCounterSnapshots(cm *mcp.ClientManager, cfg *config.MetricsSnapshotConfig) {
+	snap, err := mcp.LoadCounterSnapshot(cfg.Path)
+	if err != nil {
+		slog.Warn("Ignoring metrics snapshot", "path", cfg.Path, "error", err)
+	} else {
+		cm.RestoreCounters(snap)
+		slog.Info("Restored metrics snapshot", "path", cfg.Path, "savedAt", snap.SavedAt)
+	}
+	cm.StartCounterSnapshots(cfg.Path, time.Duration(cfg.Interval)*time.Millisecond)
+}
+
+// restoreRestartState reloads the restart history of the servers, which Initialize applies, and keeps
+// saving it until shutdown. An unreadable state is logged and skipped so that it never blocks startup.
+func restoreRestartState(cm *mcp.ClientManager, cfg *config.RestartStateConfig) {
+	state, err := mcp.LoadRestartState(cfg.Path)
+	if err != nil {
+		slog.Warn("Ignoring restart state", "path", cfg.Path, "error", err)
+	} else {
+		cm.RestoreRestartState(state, time.Duration(cfg.ExpireAfter)*time.Millisecond)
+		slog.Info("Restored restart state", "path", cfg.Path, "savedAt", state.SavedAt)
+	}
+	cm.StartRestartStatePersistence(cfg.Path)
+}
+
+// authorizationOptions builds the router options for the configured authorizer, if any
+func authorizationOptions(cfg config.AuthorizationConfig) ([]http.RouterOption, error) {
+	switch {
+	case cfg.ExtAuthz != nil:
+		slog.Info("Delegating authorization to external service", "url", cfg.ExtAuthz.URL)
+		return []http.RouterOption{http.WithAuthorizer(authz.NewExtAuthz(authz.ExtAuthzOptions{
+			URL:            cfg.ExtAuthz.URL,
+			Timeout:        time.Duration(cfg.ExtAuthz.Timeout) * time.Millisecond,
+			CacheTTL:       time.Duration(cfg.ExtAuthz.CacheTTL) * time.Millisecond,
+			FailOpen:       cfg.ExtAuthz.FailOpen,
+			AllowedHeaders: cfg.ExtAuthz.AllowedHeaders,
+		}))}, nil
+	case cfg.Rego != nil:
+		slog.Info("Evaluating authorization with Rego policy", "policy", cfg.Rego.PolicyPath)
+		policy, err := authz.NewRego(context.Background(), authz.RegoOptions{
+			PolicyPath: cfg.Rego.PolicyPath,
+			Query:      cfg.Rego.Query,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []http.RouterOption{http.WithAuthorizer(policy)}, nil
+	default:
+		return nil, nil
+	}
 }
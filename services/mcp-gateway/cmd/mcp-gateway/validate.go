@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
+)
+
+// validationCheck is the outcome of one check of `mcp-gateway validate`
+type validationCheck struct {
+	server string
+	name   string
+	status string // mcp.CheckPass, mcp.CheckFail or mcp.CheckSkip
+	detail string
+}
+
+// runValidate implements `mcp-gateway validate [--config path]`.
+// It loads the configuration like the gateway does, which also rejects duplicate server and tool
+// names and reads env values from files, then checks what only the deployment environment can tell:
+// commands exist and are executable, remote servers are reachable and secrets can be fetched.
+// The exit code is 0 if every check passed or was skipped, 1 if any failed and 2 on usage errors.
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", configPathFromEnv(), "path to the gateway configuration")
+	offline := fs.Bool("offline", false, "skip checks that connect to remote servers")
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout of each network check")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 || *timeout <= 0 {
+		fmt.Fprintln(stderr, "usage: mcp-gateway validate [--config path] [--offline] [--timeout 5s]")
+		return 2
+	}
+
+	// Keep stdout for the report
+	slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "%-4s  load %s: %v\n", statusLabel(mcp.CheckFail), *configPath, err)
+		return 1
+	}
+
+	store := secrets.NewDefaultStore()
+	var checks []validationCheck
+	for _, server := range cfg.Servers {
+		checks = append(checks, validateServer(server, *offline, *timeout)...)
+		checks = append(checks, validateSecrets(server, store, *offline)...)
+	}
+	failed := printValidationReport(stdout, *configPath, checks)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateServer checks that the server can be started or reached
+func validateServer(server config.ServerConfig, offline bool, timeout time.Duration) []validationCheck {
+	check := func(name string, err error, detail string) validationCheck {
+		return newCheck(server.Name, name, err, detail)
+	}
+	skip := func(name, detail string) validationCheck {
+		return validationCheck{server: server.Name, name: name, status: mcp.CheckSkip, detail: detail}
+	}
+
+	var checks []validationCheck
+	switch server.Transport {
+	case config.TransportStdio:
+		switch server.Runtime {
+		case config.RuntimeDocker:
+			// The command runs inside the image, which is only pulled on start
+			path, err := exec.LookPath("docker")
+			checks = append(checks, check("docker", err, path))
+		case config.RuntimeSSH:
+			path, err := exec.LookPath("ssh")
+			checks = append(checks, check("ssh", err, path))
+			if server.SSH.KeyPath != "" {
+				checks = append(checks, check("ssh key", readable(server.SSH.KeyPath), server.SSH.KeyPath))
+			}
+		default:
+			path, err := exec.LookPath(server.Command)
+			checks = append(checks, check("command", err, path))
+		}
+	case config.TransportWASM:
+		checks = append(checks, check("module", readable(server.WASM.Path), server.WASM.Path))
+	case config.TransportSSE, config.TransportStreamableHTTP:
+		if offline {
+			checks = append(checks, skip("url", "offline"))
+			break
+		}
+		status, err := probeURL(server.URL, timeout)
+		checks = append(checks, check("url", err, status))
+	case config.TransportTCP, config.TransportUnix:
+		locator := server.Address
+		if server.Transport == config.TransportUnix {
+			locator = server.SocketPath
+		}
+		if offline {
+			checks = append(checks, skip(server.Transport, "offline"))
+			break
+		}
+		conn, err := net.DialTimeout(server.Transport, locator, timeout)
+		if err == nil {
+			conn.Close()
+		}
+		checks = append(checks, check(server.Transport, err, locator))
+	default:
+		checks = append(checks, skip("transport", "custom transport "+server.Transport+" is checked by its dialer"))
+	}
+	return checks
+}
+
+// validateSecrets checks that the env values of the server kept in secrets managers can be fetched
+func validateSecrets(server config.ServerConfig, store *secrets.Store, offline bool) []validationCheck {
+	var checks []validationCheck
+	for _, env := range server.Envs {
+		if env.ValueFrom == nil || env.ValueFrom.File != "" {
+			continue
+		}
+		name := "env " + env.Name
+		if offline {
+			checks = append(checks, validationCheck{server: server.Name, name: name, status: mcp.CheckSkip, detail: "offline"})
+			continue
+		}
+		_, err := env.Resolve(store)
+		checks = append(checks, newCheck(server.Name, name, err, "fetched"))
+	}
+	return checks
+}
+
+// newCheck returns a failed check for a non-nil err and a passed one otherwise
+func newCheck(server, name string, err error, detail string) validationCheck {
+	if err != nil {
+		return validationCheck{server: server, name: name, status: mcp.CheckFail, detail: err.Error()}
+	}
+	return validationCheck{server: server, name: name, status: mcp.CheckPass, detail: detail}
+}
+
+// readable checks that a file can be opened
+func readable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// probeURL checks that the URL answers HTTP. Any status counts, since MCP endpoints may reject a plain GET.
+func probeURL(url string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	// SSE endpoints keep the response open, so the body is not read
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return url + " responded with " + resp.Status, nil
+}
+
+// printValidationReport writes one line per check followed by a summary and returns the number of failures
+func printValidationReport(w io.Writer, configPath string, checks []validationCheck) int {
+	var passed, failed, skipped int
+	for _, check := range checks {
+		switch check.status {
+		case mcp.CheckPass:
+			passed++
+		case mcp.CheckFail:
+			failed++
+		default:
+			skipped++
+		}
+		line := fmt.Sprintf("%-4s  %s: %s", statusLabel(check.status), check.server, check.name)
+		if check.detail != "" {
+			line += ": " + check.detail
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "\n%s: %d passed, %d failed, %d skipped\n", configPath, passed, failed, skipped)
+	return failed
+}
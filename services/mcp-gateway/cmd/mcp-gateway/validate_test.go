@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes a gateway configuration to a temporary file and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestRunValidate(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	configPath := writeConfig(t, `
+servers:
+  - name: local
+    command: /bin/true
+  - name: tcp-server
+    transport: tcp
+    address: "`+listener.Addr().String()+`"
+`)
+	var stdout, stderr bytes.Buffer
+
+	code := runValidate([]string{"--config", configPath}, &stdout, &stderr)
+
+	assert.Equal(t, 0, code, stdout.String())
+	assert.Contains(t, stdout.String(), "PASS  local: command: /bin/true")
+	assert.Contains(t, stdout.String(), "PASS  tcp-server: tcp: "+listener.Addr().String())
+	assert.Contains(t, stdout.String(), "2 passed, 0 failed, 0 skipped")
+}
+
+func TestRunValidate_Failures(t *testing.T) {
+	configPath := writeConfig(t, `
+servers:
+  - name: missing
+    command: /nonexistent/mcp-server
+    envs:
+      - name: API_KEY
+        valueFrom:
+          vault: secret/data/app#api_key
+  - name: remote
+    transport: streamable-http
+    url: http://127.0.0.1:1/mcp
+`)
+	var stdout, stderr bytes.Buffer
+
+	code := runValidate([]string{"--config", configPath, "--offline"}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), "FAIL  missing: command: ")
+	assert.Contains(t, stdout.String(), "SKIP  missing: env API_KEY: offline")
+	assert.Contains(t, stdout.String(), "SKIP  remote: url: offline")
+	assert.Contains(t, stdout.String(), "0 passed, 1 failed, 2 skipped")
+}
+
+func TestRunValidate_Secrets(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	configPath := writeConfig(t, `
+servers:
+  - name: local
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          vault: secret/data/app#api_key
+`)
+	var stdout, stderr bytes.Buffer
+
+	code := runValidate([]string{"--config", configPath}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), "FAIL  local: env API_KEY: ")
+	assert.Contains(t, stdout.String(), "VAULT_ADDR is not set")
+}
+
+func TestRunValidate_InvalidConfig(t *testing.T) {
+	configPath := writeConfig(t, "servers: []\n")
+	var stdout, stderr bytes.Buffer
+
+	code := runValidate([]string{"--config", configPath}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), "FAIL  load "+configPath)
+}
+
+func TestRunValidate_Usage(t *testing.T) {
+	for name, args := range map[string][]string{
+		"unknown flag":      {"--strict"},
+		"extra argument":    {filepath.Join(t.TempDir(), "config.yaml")},
+		"non-positive time": {"--timeout", "-1s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, 2, runValidate(args, &stdout, &stderr))
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package age decrypts values encrypted with age (https://age-encryption.org/v1) for X25519
+// recipients, as produced by `age -r age1... -a` or sops. Only ASCII-armored input is accepted,
+// so that encrypted values can be written inline in YAML. The format is handled by filippo.io/age,
+// the reference implementation behind the age CLI.
+package age
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ErrNoIdentity is returned when none of the identities can decrypt the file key
+var ErrNoIdentity = errors.New("no identity matched any of the recipients")
+
+// Identity is an X25519 private key, AGE-SECRET-KEY-1...
+type Identity struct {
+	key *age.X25519Identity
+}
+
+// IsArmored reports whether value is an ASCII-armored age file
+func IsArmored(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), armor.Header)
+}
+
+// ParseIdentity parses an AGE-SECRET-KEY-1... string, in either case like any bech32 string
+func ParseIdentity(s string) (*Identity, error) {
+	key, err := age.ParseX25519Identity(strings.ToUpper(s))
+	if err != nil {
+		return nil, fmt.Errorf("malformed age secret key: %w", err)
+	}
+	return &Identity{key: key}, nil
+}
+
+// ParseIdentities parses identities in the format of age key files: one secret key per line,
+// ignoring blank lines and # comments
+func ParseIdentities(s string) ([]*Identity, error) {
+	var ids []*Identity
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no age secret keys found")
+	}
+	return ids, nil
+}
+
+// Decrypt decrypts an ASCII-armored age file with the first identity matching a recipient
+func Decrypt(armored string, identities ...*Identity) ([]byte, error) {
+	if !IsArmored(armored) {
+		return nil, errors.New("not an ASCII-armored age file")
+	}
+	if len(identities) == 0 {
+		return nil, ErrNoIdentity
+	}
+	ids := make([]age.Identity, len(identities))
+	for i, id := range identities {
+		ids[i] = id.key
+	}
+
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(armored))), ids...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, ErrNoIdentity
+		}
+		return nil, err
+	}
+	// The payload is authenticated chunk by chunk while it is read
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
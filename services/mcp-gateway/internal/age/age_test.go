@@ -0,0 +1,207 @@
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	agetest "c2sp.org/CCTV/age"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is the secret key 0x42 repeated, from the age test vectors
+const testKey = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+
+// encrypt produces an armored age file for the recipients with the age implementation
+func encrypt(t *testing.T, plaintext []byte, recipients ...age.Recipient) string {
+	t.Helper()
+	var out bytes.Buffer
+	aw := armor.NewWriter(&out)
+	w, err := age.Encrypt(aw, recipients...)
+	require.NoError(t, err)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, aw.Close())
+	return out.String()
+}
+
+func testIdentity(t *testing.T) *Identity {
+	t.Helper()
+	id, err := ParseIdentity(testKey)
+	require.NoError(t, err)
+	return id
+}
+
+func TestParseIdentity(t *testing.T) {
+	id := testIdentity(t)
+	assert.Equal(t, testKey, id.key.String())
+
+	_, err := ParseIdentity(strings.ToLower(testKey))
+	assert.NoError(t, err, "keys are case-insensitive")
+
+	_, err = ParseIdentity(testKey[:len(testKey)-1] + "Q")
+	assert.ErrorContains(t, err, "malformed age secret key")
+}
+
+func TestParseIdentities(t *testing.T) {
+	ids, err := ParseIdentities("# created: 2026-01-01\n# public key: age1...\n" + testKey + "\n\n")
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	_, err = ParseIdentities("# only a comment\n")
+	assert.Error(t, err)
+
+	_, err = ParseIdentities(testKey + "\nnot-a-key\n")
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestDecrypt(t *testing.T) {
+	id := testIdentity(t)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{name: "Short", plaintext: []byte("s3cr3t-api-key")},
+		{name: "Empty", plaintext: []byte{}},
+		{name: "Exactly one chunk", plaintext: bytes.Repeat([]byte{'a'}, 64<<10)},
+		{name: "Several chunks", plaintext: bytes.Repeat([]byte{'b'}, 2*64<<10+10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			armored := encrypt(t, tt.plaintext, other.Recipient(), id.key.Recipient())
+			assert.True(t, IsArmored(armored))
+
+			plaintext, err := Decrypt(armored, &Identity{key: other}, id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.plaintext, plaintext)
+		})
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	id := testIdentity(t)
+	armored := encrypt(t, []byte("value"), id.key.Recipient())
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	_, err = Decrypt(armored, &Identity{key: other})
+	assert.ErrorIs(t, err, ErrNoIdentity)
+	_, err = Decrypt(armored)
+	assert.ErrorIs(t, err, ErrNoIdentity)
+
+	_, err = Decrypt(armored+"trailing", id)
+	assert.Error(t, err)
+
+	_, err = Decrypt("plain value", id)
+	assert.Error(t, err)
+	assert.False(t, IsArmored("plain value"))
+}
+
+// vector is a test vector of the age specification, see https://c2sp.org/CCTV/age
+type vector struct {
+	expect     string
+	payload    string // hex SHA-256 of the plaintext
+	identities []string
+	armored    bool
+	body       []byte
+}
+
+// parseVector parses the headers of a test vector, which end at an empty line, and its file
+func parseVector(t *testing.T, data []byte) vector {
+	t.Helper()
+	var v vector
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		switch name {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payload = value
+		case "identity":
+			v.identities = append(v.identities, value)
+		case "armored":
+			v.armored = value == "yes"
+		}
+	}
+	body, err := io.ReadAll(r)
+	require.NoError(t, err)
+	v.body = body
+	return v
+}
+
+// TestDecrypt_TestVectors decrypts the X25519 test vectors of the age specification, which include
+// single and several chunks (x25519, stream_two_chunks, stream_three_chunks), a last chunk of exactly
+// 64 KiB (stream_last_chunk_full), several recipients (x25519_multiple_recipients) and a stanza of
+// another type before the X25519 one (x25519_grease)
+func TestDecrypt_TestVectors(t *testing.T) {
+	files, err := fs.ReadDir(agetest.Vectors, ".")
+	require.NoError(t, err)
+
+	tested := 0
+	for _, file := range files {
+		data, err := fs.ReadFile(agetest.Vectors, file.Name())
+		require.NoError(t, err)
+		v := parseVector(t, data)
+		// Passphrase-only vectors need scrypt identities, which encrypted env values do not use
+		if len(v.identities) == 0 {
+			continue
+		}
+		tested++
+
+		t.Run(file.Name(), func(t *testing.T) {
+			armored := string(v.body)
+			if !v.armored {
+				// Values are only accepted armored, the way `age -a` writes them
+				var out bytes.Buffer
+				aw := armor.NewWriter(&out)
+				_, err := aw.Write(v.body)
+				require.NoError(t, err)
+				require.NoError(t, aw.Close())
+				armored = out.String()
+			}
+
+			var ids []*Identity
+			for _, s := range v.identities {
+				id, err := ParseIdentity(s)
+				if err != nil {
+					assert.NotEqual(t, "success", v.expect, "identity %s: %v", s, err)
+					return
+				}
+				ids = append(ids, id)
+			}
+
+			plaintext, err := Decrypt(armored, ids...)
+			switch v.expect {
+			case "success":
+				require.NoError(t, err)
+				sum := sha256.Sum256(plaintext)
+				assert.Equal(t, v.payload, hex.EncodeToString(sum[:]))
+			case "no match":
+				assert.ErrorIs(t, err, ErrNoIdentity)
+			default:
+				assert.Error(t, err, "expected %s", v.expect)
+			}
+		})
+	}
+	assert.NotZero(t, tested)
+}
@@ -0,0 +1,47 @@
+// Package authz delegates per-request authorization decisions to pluggable authorizers.
+package authz
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request describes the incoming call an authorizer decides on
+type Request struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	ClientIP string            `json:"clientIp"`
+	Caller   string            `json:"caller,omitempty"` // API key name, when API keys are configured
+	Headers  map[string]string `json:"headers"`
+	Server   string            `json:"server,omitempty"`
+	Tool     string            `json:"tool,omitempty"`
+	Input    map[string]any    `json:"input,omitempty"`
+}
+
+// Decision is the verdict returned by an authorizer
+type Decision struct {
+	Allowed bool
+	// Status is the HTTP status to return when the request is denied (401 or 403)
+	Status int
+	// Reason is a human-readable explanation for a denial
+	Reason string
+	// Input, when non-nil on an allowed tool call, replaces the tool input forwarded to the MCP server
+	Input map[string]any
+}
+
+// Allow is the decision for an authorized request
+var Allow = Decision{Allowed: true}
+
+// Deny builds a denial decision, normalizing the status to 401 or 403
+func Deny(status int, reason string) Decision {
+	if status != http.StatusUnauthorized {
+		status = http.StatusForbidden
+	}
+	return Decision{Allowed: false, Status: status, Reason: reason}
+}
+
+// Authorizer decides whether a request may proceed.
+// A non-nil error means no decision could be made (e.g. the policy service is unreachable).
+type Authorizer interface {
+	Authorize(ctx context.Context, req *Request) (Decision, error)
+}
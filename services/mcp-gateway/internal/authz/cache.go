@@ -0,0 +1,68 @@
+package authz
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds memory used by cached verdicts
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	decision  Decision
+	expiresAt time.Time
+}
+
+// verdictCache is a TTL cache of authorization decisions
+type verdictCache struct {
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	mu      sync.Mutex
+}
+
+func newVerdictCache(ttl time.Duration) *verdictCache {
+	return &verdictCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *verdictCache) get(key string) (Decision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return Decision{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return Decision{}, false
+	}
+	return entry.decision, true
+}
+
+func (c *verdictCache) put(key string, decision Decision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCacheEntries {
+		// Evict expired entries first; if still full, drop everything rather than grow unbounded
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	c.entries[key] = cacheEntry{decision: decision, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *verdictCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
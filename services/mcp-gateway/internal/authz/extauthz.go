@@ -0,0 +1,159 @@
+package authz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultExtAuthzTimeout is used when no timeout is configured
+	DefaultExtAuthzTimeout = 1 * time.Second
+	// maxReasonBytes caps how much of the authorizer's denial body is returned to clients
+	maxReasonBytes = 1024
+)
+
+// ExtAuthzOptions configures an ExtAuthz authorizer
+type ExtAuthzOptions struct {
+	// URL is the base URL of the authorization service; the original request path is appended
+	URL string
+	// Timeout bounds each check request
+	Timeout time.Duration
+	// CacheTTL enables verdict caching when positive
+	CacheTTL time.Duration
+	// FailOpen allows requests when the authorization service cannot be reached
+	FailOpen bool
+	// AllowedHeaders lists additional request headers forwarded to the authorization service.
+	// Authorization is always forwarded.
+	AllowedHeaders []string
+}
+
+// ExtAuthz implements the Envoy ext_authz HTTP service contract:
+// the original method, path and selected headers are sent to the authorization service,
+// a 2xx response allows the request and any other response denies it.
+type ExtAuthz struct {
+	url            string
+	client         *http.Client
+	failOpen       bool
+	allowedHeaders []string
+	cache          *verdictCache
+}
+
+// NewExtAuthz creates an ext_authz-style HTTP authorizer
+func NewExtAuthz(opts ExtAuthzOptions) *ExtAuthz {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExtAuthzTimeout
+	}
+
+	headers := []string{"Authorization"}
+	for _, h := range opts.AllowedHeaders {
+		h = http.CanonicalHeaderKey(h)
+		if !slices.Contains(headers, h) {
+			headers = append(headers, h)
+		}
+	}
+
+	a := &ExtAuthz{
+		url:            strings.TrimSuffix(opts.URL, "/"),
+		client:         &http.Client{Timeout: timeout},
+		failOpen:       opts.FailOpen,
+		allowedHeaders: headers,
+	}
+	if opts.CacheTTL > 0 {
+		a.cache = newVerdictCache(opts.CacheTTL)
+	}
+	return a
+}
+
+// ForwardedHeaders returns the canonical names of request headers sent to the authorization service
+func (a *ExtAuthz) ForwardedHeaders() []string {
+	return a.allowedHeaders
+}
+
+// Authorize implements Authorizer
+func (a *ExtAuthz) Authorize(ctx context.Context, req *Request) (Decision, error) {
+	key := a.cacheKey(req)
+	if a.cache != nil {
+		if decision, ok := a.cache.get(key); ok {
+			return decision, nil
+		}
+	}
+
+	decision, err := a.check(ctx, req)
+	if err != nil {
+		if a.failOpen {
+			slog.Warn("Authorization service unavailable, failing open", "error", err)
+			return Allow, nil
+		}
+		return Decision{}, err
+	}
+
+	if a.cache != nil {
+		a.cache.put(key, decision)
+	}
+	return decision, nil
+}
+
+func (a *ExtAuthz) check(ctx context.Context, req *Request) (Decision, error) {
+	checkReq, err := http.NewRequestWithContext(ctx, req.Method, a.url+req.Path, nil)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to build authorization request: %w", err)
+	}
+	for _, name := range a.allowedHeaders {
+		if v, ok := req.Headers[name]; ok {
+			checkReq.Header.Set(name, v)
+		}
+	}
+	if req.ClientIP != "" {
+		checkReq.Header.Set("X-Forwarded-For", req.ClientIP)
+	}
+	if req.Server != "" {
+		checkReq.Header.Set("X-Mcp-Server", req.Server)
+	}
+	if req.Tool != "" {
+		checkReq.Header.Set("X-Mcp-Tool", req.Tool)
+	}
+	if req.Caller != "" {
+		checkReq.Header.Set("X-Mcp-Caller", req.Caller)
+	}
+
+	resp, err := a.client.Do(checkReq)
+	if err != nil {
+		return Decision{}, fmt.Errorf("authorization request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return Allow, nil
+	}
+	if resp.StatusCode >= 500 {
+		return Decision{}, fmt.Errorf("authorization service returned status %d", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxReasonBytes))
+	reason := strings.TrimSpace(string(body))
+	if reason == "" {
+		reason = http.StatusText(resp.StatusCode)
+	}
+	return Deny(resp.StatusCode, reason), nil
+}
+
+// cacheKey identifies requests that are guaranteed to receive the same verdict
+func (a *ExtAuthz) cacheKey(req *Request) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s", req.Method, req.Path, req.ClientIP, req.Caller, req.Server, req.Tool)
+	for _, name := range a.allowedHeaders {
+		fmt.Fprintf(h, "\x00%s=%s", name, req.Headers[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
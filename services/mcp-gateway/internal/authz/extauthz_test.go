@@ -0,0 +1,168 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRequest() *Request {
+	return &Request{
+		Method:   http.MethodPost,
+		Path:     "/mcp/call",
+		ClientIP: "10.0.0.1",
+		Headers: map[string]string{
+			"Authorization": "Bearer token",
+			"X-Tenant":      "acme",
+			"Cookie":        "secret",
+		},
+		Server: "weather-server",
+		Tool:   "get-forecast",
+	}
+}
+
+func TestExtAuthz_Allow(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	a := NewExtAuthz(ExtAuthzOptions{URL: srv.URL + "/check", AllowedHeaders: []string{"x-tenant"}})
+
+	decision, err := a.Authorize(context.Background(), newTestRequest())
+
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	require.NotNil(t, got)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/check/mcp/call", got.URL.Path)
+	assert.Equal(t, "Bearer token", got.Header.Get("Authorization"))
+	assert.Equal(t, "acme", got.Header.Get("X-Tenant"))
+	assert.Empty(t, got.Header.Get("Cookie"), "headers outside the allowlist must not be forwarded")
+	assert.Equal(t, "10.0.0.1", got.Header.Get("X-Forwarded-For"))
+	assert.Equal(t, "weather-server", got.Header.Get("X-Mcp-Server"))
+	assert.Equal(t, "get-forecast", got.Header.Get("X-Mcp-Tool"))
+}
+
+func TestExtAuthz_Deny(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "forbidden with reason", status: http.StatusForbidden, body: "tool not permitted", wantStatus: http.StatusForbidden, wantReason: "tool not permitted"},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: "", wantStatus: http.StatusUnauthorized, wantReason: "Unauthorized"},
+		{name: "other 4xx maps to forbidden", status: http.StatusTeapot, body: "nope", wantStatus: http.StatusForbidden, wantReason: "nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			a := NewExtAuthz(ExtAuthzOptions{URL: srv.URL})
+
+			decision, err := a.Authorize(context.Background(), newTestRequest())
+
+			require.NoError(t, err)
+			assert.False(t, decision.Allowed)
+			assert.Equal(t, tt.wantStatus, decision.Status)
+			assert.Equal(t, tt.wantReason, decision.Reason)
+		})
+	}
+}
+
+func TestExtAuthz_ServiceError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	a := NewExtAuthz(ExtAuthzOptions{URL: srv.URL})
+	_, err := a.Authorize(context.Background(), newTestRequest())
+	assert.Error(t, err)
+
+	failOpen := NewExtAuthz(ExtAuthzOptions{URL: srv.URL, FailOpen: true})
+	decision, err := failOpen.Authorize(context.Background(), newTestRequest())
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestExtAuthz_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	a := NewExtAuthz(ExtAuthzOptions{URL: srv.URL, Timeout: 20 * time.Millisecond})
+
+	_, err := a.Authorize(context.Background(), newTestRequest())
+
+	assert.Error(t, err)
+}
+
+func TestExtAuthz_CachesVerdicts(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("X-Mcp-Tool") == "blocked" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	a := NewExtAuthz(ExtAuthzOptions{URL: srv.URL, CacheTTL: time.Minute})
+
+	for range 3 {
+		decision, err := a.Authorize(context.Background(), newTestRequest())
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// A different tool is a different cache key; denials are cached too
+	blocked := newTestRequest()
+	blocked.Tool = "blocked"
+	for range 2 {
+		decision, err := a.Authorize(context.Background(), blocked)
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+
+	// A different credential must not reuse the cached verdict
+	other := newTestRequest()
+	other.Headers["Authorization"] = "Bearer other"
+	_, err := a.Authorize(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestVerdictCache_Expiry(t *testing.T) {
+	c := newVerdictCache(10 * time.Millisecond)
+	c.put("key", Allow)
+
+	_, ok := c.get("key")
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok = c.get("key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.len())
+}
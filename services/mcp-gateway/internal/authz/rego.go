@@ -0,0 +1,153 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// DefaultRegoQuery is evaluated when no query is configured
+const DefaultRegoQuery = "data.mcpgateway.authz.decision"
+
+// RegoOptions configures a Rego authorizer
+type RegoOptions struct {
+	// PolicyPath is the path to the .rego policy file
+	PolicyPath string
+	// Query is the Rego query whose result is the decision
+	Query string
+}
+
+// Rego evaluates an embedded OPA policy against the request context.
+//
+// The query result may be a boolean (allow/deny) or an object:
+//
+//	{"allow": bool, "status": 401|403, "reason": string, "input": object}
+//
+// where "input", if present on an allowed tool call, replaces the tool input.
+type Rego struct {
+	query rego.PreparedEvalQuery
+}
+
+// NewRego loads and compiles the policy
+func NewRego(ctx context.Context, opts RegoOptions) (*Rego, error) {
+	src, err := os.ReadFile(opts.PolicyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	return NewRegoFromSource(ctx, opts.PolicyPath, string(src), opts.Query)
+}
+
+// NewRegoFromSource compiles a policy given as source text
+func NewRegoFromSource(ctx context.Context, name, src, query string) (*Rego, error) {
+	if query == "" {
+		query = DefaultRegoQuery
+	}
+	pq, err := rego.New(
+		rego.Query(query),
+		rego.Module(name, src),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile policy: %w", err)
+	}
+	return &Rego{query: pq}, nil
+}
+
+// Authorize implements Authorizer
+func (r *Rego) Authorize(ctx context.Context, req *Request) (Decision, error) {
+	input, err := toRegoInput(req)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	rs, err := r.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return Decision{}, fmt.Errorf("policy evaluation failed: %w", err)
+	}
+	// An undefined result means the policy did not allow the request
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return Deny(http.StatusForbidden, "denied by policy"), nil
+	}
+
+	return decisionFromValue(rs[0].Expressions[0].Value)
+}
+
+// toRegoInput converts the request into the generic JSON shape OPA expects
+func toRegoInput(req *Request) (map[string]any, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	var input map[string]any
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	return input, nil
+}
+
+// decisionFromValue interprets a policy result
+func decisionFromValue(v any) (Decision, error) {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			return Allow, nil
+		}
+		return Deny(http.StatusForbidden, "denied by policy"), nil
+	case map[string]any:
+		allow, ok := val["allow"].(bool)
+		if !ok {
+			return Decision{}, fmt.Errorf("policy result must contain a boolean \"allow\" field")
+		}
+		if !allow {
+			status := http.StatusForbidden
+			if n, ok := val["status"].(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					status = int(i)
+				}
+			}
+			reason, _ := val["reason"].(string)
+			if reason == "" {
+				reason = "denied by policy"
+			}
+			return Deny(status, reason), nil
+		}
+		decision := Allow
+		if raw, present := val["input"]; present {
+			input, ok := raw.(map[string]any)
+			if !ok {
+				return Decision{}, fmt.Errorf("policy result \"input\" must be an object")
+			}
+			decision.Input = normalizeNumbers(input).(map[string]any)
+		}
+		return decision, nil
+	default:
+		return Decision{}, fmt.Errorf("unexpected policy result type %T", v)
+	}
+}
+
+// normalizeNumbers converts json.Number values produced by OPA into float64,
+// matching what encoding/json yields for tool inputs decoded from requests
+func normalizeNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalizeNumbers(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = normalizeNumbers(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
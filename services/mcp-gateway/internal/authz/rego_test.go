@@ -0,0 +1,133 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `package mcpgateway.authz
+
+default decision := {"allow": false, "status": 403, "reason": "tool not permitted"}
+
+decision := {"allow": true} if {
+	input.tool == "get-forecast"
+	input.headers.Authorization == "Bearer token"
+}
+
+decision := {"allow": false, "status": 401, "reason": "missing credentials"} if {
+	not input.headers.Authorization
+}
+
+decision := {"allow": true, "input": object.union(input.input, {"units": "metric"})} if {
+	input.tool == "get-current"
+	input.headers.Authorization
+}
+`
+
+func newTestRego(t *testing.T, src, query string) *Rego {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.rego")
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o600))
+	r, err := NewRego(context.Background(), RegoOptions{PolicyPath: path, Query: query})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRego_Decisions(t *testing.T) {
+	r := newTestRego(t, testPolicy, "")
+
+	tests := []struct {
+		name       string
+		mutate     func(req *Request)
+		wantAllow  bool
+		wantStatus int
+		wantReason string
+		wantInput  map[string]any
+	}{
+		{
+			name:      "allowed tool",
+			mutate:    func(*Request) {},
+			wantAllow: true,
+		},
+		{
+			name:       "denied tool",
+			mutate:     func(req *Request) { req.Tool = "delete-everything" },
+			wantStatus: http.StatusForbidden,
+			wantReason: "tool not permitted",
+		},
+		{
+			name:       "missing credentials",
+			mutate:     func(req *Request) { delete(req.Headers, "Authorization") },
+			wantStatus: http.StatusUnauthorized,
+			wantReason: "missing credentials",
+		},
+		{
+			name: "input transform",
+			mutate: func(req *Request) {
+				req.Tool = "get-current"
+				req.Input = map[string]any{"city": "Tokyo", "days": 3}
+			},
+			wantAllow: true,
+			wantInput: map[string]any{"city": "Tokyo", "days": float64(3), "units": "metric"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest()
+			tt.mutate(req)
+
+			decision, err := r.Authorize(context.Background(), req)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAllow, decision.Allowed)
+			if !tt.wantAllow {
+				assert.Equal(t, tt.wantStatus, decision.Status)
+				assert.Equal(t, tt.wantReason, decision.Reason)
+			}
+			assert.Equal(t, tt.wantInput, decision.Input)
+		})
+	}
+}
+
+func TestRego_BooleanQuery(t *testing.T) {
+	r := newTestRego(t, `package mcpgateway.authz
+
+allow if input.server == "weather-server"
+`, "data.mcpgateway.authz.allow")
+
+	decision, err := r.Authorize(context.Background(), newTestRequest())
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	req := newTestRequest()
+	req.Server = "other"
+	decision, err = r.Authorize(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed, "undefined result must deny")
+	assert.Equal(t, http.StatusForbidden, decision.Status)
+}
+
+func TestRego_InvalidResult(t *testing.T) {
+	r := newTestRego(t, `package mcpgateway.authz
+
+decision := "yes"
+`, "")
+
+	_, err := r.Authorize(context.Background(), newTestRequest())
+	assert.Error(t, err)
+}
+
+func TestNewRego_Errors(t *testing.T) {
+	_, err := NewRego(context.Background(), RegoOptions{PolicyPath: filepath.Join(t.TempDir(), "missing.rego")})
+	assert.Error(t, err)
+
+	_, err = NewRegoFromSource(context.Background(), "bad.rego", "package x\n\nallow if {", "")
+	assert.Error(t, err)
+}
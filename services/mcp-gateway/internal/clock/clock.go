@@ -0,0 +1,147 @@
+// Package clock abstracts the passage of time for code that waits on timers, such as health checks and
+// restart backoffs, so that tests can drive it with a Fake instead of sleeping.
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the part of *time.Timer that the gateway uses
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+
+// Fake is a Clock that only moves when Advance is called
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake creates a Fake clock set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.scheduleLocked(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers that become due
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.timers = slices.DeleteFunc(f.timers, func(t *fakeTimer) bool {
+		if t.when.After(f.now) {
+			return false
+		}
+		select {
+		case t.c <- f.now:
+		default:
+			// Like a time.Timer, a timer whose last value was not received does not queue another
+		}
+		return true
+	})
+}
+
+// Waiters returns how many timers are pending, so that tests can wait for code to start waiting
+// before they call Advance
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// scheduleLocked makes t fire after d. Caller must hold f.mu.
+func (f *Fake) scheduleLocked(t *fakeTimer, d time.Duration) {
+	t.when = f.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.c <- f.now:
+		default:
+		}
+		return
+	}
+	f.timers = append(f.timers, t)
+}
+
+// removeLocked cancels t and reports whether it was pending. Caller must hold f.mu.
+func (f *Fake) removeLocked(t *fakeTimer) bool {
+	i := slices.Index(f.timers, t)
+	if i < 0 {
+		return false
+	}
+	f.timers = slices.Delete(f.timers, i, i+1)
+	return true
+}
+
+type fakeTimer struct {
+	clock *Fake
+	c     chan time.Time
+	when  time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.removeLocked(t)
+	t.clock.scheduleLocked(t, d)
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
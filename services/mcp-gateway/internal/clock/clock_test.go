@@ -0,0 +1,44 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake_AdvanceFiresDueTimers(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	early := f.After(time.Second)
+	late := f.NewTimer(time.Minute)
+
+	f.Advance(30 * time.Second)
+	assert.Equal(t, start.Add(30*time.Second), <-early)
+	select {
+	case <-late.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+	assert.Equal(t, 1, f.Waiters())
+	assert.Equal(t, 30*time.Second, f.Since(start))
+
+	assert.True(t, late.Reset(10*time.Second), "reset of a pending timer")
+	f.Advance(10 * time.Second)
+	assert.Equal(t, start.Add(40*time.Second), <-late.C())
+	assert.False(t, late.Stop(), "stop of a fired timer")
+	assert.Zero(t, f.Waiters())
+}
+
+func TestFake_StopCancelsTimer(t *testing.T) {
+	f := NewFake(time.Now())
+	timer := f.NewTimer(time.Second)
+
+	assert.True(t, timer.Stop())
+	f.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
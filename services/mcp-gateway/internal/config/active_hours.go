@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// weekdays maps the day names of activeHours windows to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseClockTime parses an HH:MM time of day into minutes after midnight
+func parseClockTime(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (must be HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Location returns the time zone the windows are in
+func (h ActiveHoursConfig) Location() *time.Location {
+	if h.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(h.Timezone)
+	if err != nil {
+		// Validated when the config is loaded
+		return time.Local
+	}
+	return loc
+}
+
+// Active reports whether t falls into one of the windows
+func (h ActiveHoursConfig) Active(t time.Time) bool {
+	t = t.In(h.Location())
+	for _, w := range h.Windows {
+		// A window over midnight may have started the day before
+		for offset := -1; offset <= 0; offset++ {
+			if start, end, ok := w.on(t, offset); ok && !t.Before(start) && t.Before(end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextChange returns the first time after t at which the server becomes active or inactive, or the zero
+// time if it never does, e.g. with a window over the whole day
+func (h ActiveHoursConfig) NextChange(t time.Time) time.Time {
+	t = t.In(h.Location())
+	// Windows repeat weekly, so any change happens within the week after the window that holds t
+	var bounds []time.Time
+	for _, w := range h.Windows {
+		for offset := -1; offset <= 7; offset++ {
+			if start, end, ok := w.on(t, offset); ok {
+				bounds = append(bounds, start, end)
+			}
+		}
+	}
+	slices.SortFunc(bounds, time.Time.Compare)
+
+	active := h.Active(t)
+	for _, bound := range bounds {
+		if bound.After(t) && h.Active(bound) != active {
+			return bound
+		}
+	}
+	return time.Time{}
+}
+
+// on returns the window starting offset days after the day of t, and whether the window is open that day
+func (w ActiveWindowConfig) on(t time.Time, offset int) (start, end time.Time, ok bool) {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d+offset, 0, 0, 0, 0, t.Location())
+	if len(w.Days) > 0 && !slices.ContainsFunc(w.Days, func(name string) bool { return weekdays[name] == day.Weekday() }) {
+		return time.Time{}, time.Time{}, false
+	}
+	startMin, err := parseClockTime(w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endMin, err := parseClockTime(w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	if endMin <= startMin {
+		endMin += 24 * 60
+	}
+	start = time.Date(y, m, d+offset, 0, startMin, 0, 0, t.Location())
+	end = time.Date(y, m, d+offset, 0, endMin, 0, 0, t.Location())
+	return start, end, true
+}
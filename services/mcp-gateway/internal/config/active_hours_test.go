@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveHours_Active(t *testing.T) {
+	businessHours := ActiveHoursConfig{
+		Timezone: "Asia/Tokyo",
+		Windows:  []ActiveWindowConfig{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00"}},
+	}
+	nightShift := ActiveHoursConfig{
+		Timezone: "UTC",
+		Windows:  []ActiveWindowConfig{{Days: []string{"fri"}, Start: "22:00", End: "02:00"}},
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	tests := []struct {
+		name  string
+		hours ActiveHoursConfig
+		at    time.Time
+		want  bool
+	}{
+		{"Weekday morning", businessHours, time.Date(2026, 10, 14, 9, 0, 0, 0, tokyo), true},
+		{"Weekday evening", businessHours, time.Date(2026, 10, 14, 18, 0, 0, 0, tokyo), false},
+		{"Weekday in UTC", businessHours, time.Date(2026, 10, 14, 1, 0, 0, 0, time.UTC), true},
+		{"Saturday", businessHours, time.Date(2026, 10, 17, 12, 0, 0, 0, tokyo), false},
+		{"Before midnight", nightShift, time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC), true},
+		{"After midnight", nightShift, time.Date(2026, 10, 17, 1, 59, 0, 0, time.UTC), true},
+		{"After the window over midnight", nightShift, time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC), false},
+		{"Window starts on Friday only", nightShift, time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hours.Active(tt.at); got != tt.want {
+				t.Errorf("Active(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveHours_NextChange(t *testing.T) {
+	hours := ActiveHoursConfig{
+		Timezone: "UTC",
+		Windows: []ActiveWindowConfig{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "12:00"},
+			// Adjacent to the morning window, so the server stays up over lunch
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "12:00", End: "18:00"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"Before the window", time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC), time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)},
+		{"Across adjacent windows", time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC), time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC)},
+		{"At the start", time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC)},
+		{"Over the weekend", time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hours.NextChange(tt.at); !got.Equal(tt.want) {
+				t.Errorf("NextChange(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveHours_NextChangeNever(t *testing.T) {
+	hours := ActiveHoursConfig{Timezone: "UTC", Windows: []ActiveWindowConfig{{Start: "00:00", End: "12:00"}, {Start: "12:00", End: "00:00"}}}
+	at := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	if !hours.Active(at) {
+		t.Fatalf("expected windows over the whole day to be active")
+	}
+	if got := hours.NextChange(at); !got.IsZero() {
+		t.Errorf("expected no change, got %v", got)
+	}
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envNamePattern matches variable names in .env files
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseDotEnv reads NAME=VALUE lines in the common .env format: blank lines and # comments are
+// skipped, an `export ` prefix is allowed, single-quoted values are literal and double-quoted values
+// support \n, \r, \t, \" and \\ escapes. Unquoted values end at a # preceded by whitespace.
+// Values are not expanded.
+func parseDotEnv(r io.Reader) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, raw, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("line %d: expected NAME=VALUE", lineNo)
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, name, err)
+		}
+		vars = append(vars, EnvVar{Name: name, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	var value, rest string
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		value, rest = raw[1:end+1], raw[end+2:]
+	case '"':
+		var b strings.Builder
+		i := 1
+		for ; i < len(raw) && raw[i] != '"'; i++ {
+			if raw[i] != '\\' || i+1 == len(raw) {
+				b.WriteByte(raw[i])
+				continue
+			}
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(raw[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(raw[i])
+			}
+		}
+		if i == len(raw) {
+			return "", fmt.Errorf("unterminated double quote")
+		}
+		value, rest = b.String(), raw[i+1:]
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		} else if i := strings.Index(raw, "\t#"); i >= 0 {
+			raw = raw[:i]
+		}
+		return strings.TrimSpace(raw), nil
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected characters after the closing quote")
+	}
+	return value, nil
+}
+
+// readEnvFile reads the variables of a .env file
+func readEnvFile(path string) ([]EnvVar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vars, err := parseDotEnv(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// LoadDotEnv sets the variables of a .env file in the gateway's environment so that the config file
+// can reference them. Variables that are already set keep their value. It returns the number of
+// variables set.
+func LoadDotEnv(path string) (int, error) {
+	vars, err := readEnvFile(path)
+	if err != nil {
+		return 0, err
+	}
+	preset := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		if _, ok := os.LookupEnv(v.Name); ok {
+			preset[v.Name] = true
+		}
+	}
+	set := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		if preset[v.Name] {
+			continue
+		}
+		if err := os.Setenv(v.Name, v.Value); err != nil {
+			return len(set), err
+		}
+		set[v.Name] = true
+	}
+	return len(set), nil
+}
+
+// mergeEnvFile prepends the variables of the server's envFile to its envs.
+// Variables also defined in envs are taken from envs.
+func mergeEnvFile(server *ServerConfig) error {
+	if server.EnvFile == "" {
+		return nil
+	}
+	vars, err := readEnvFile(server.EnvFile)
+	if err != nil {
+		return fmt.Errorf("server %s: envFile: %w", server.Name, err)
+	}
+
+	defined := make(map[string]bool, len(server.Envs))
+	for _, e := range server.Envs {
+		defined[e.Name] = true
+	}
+	envs := make([]EnvVar, 0, len(vars)+len(server.Envs))
+	for _, v := range vars {
+		if !defined[v.Name] {
+			// A name repeated in the file takes its last value, as when the file is sourced
+			defined[v.Name] = true
+			envs = append(envs, lastEnvVar(vars, v.Name))
+		}
+	}
+	server.Envs = append(envs, server.Envs...)
+	return nil
+}
+
+func lastEnvVar(vars []EnvVar, name string) EnvVar {
+	for i := len(vars) - 1; i >= 0; i-- {
+		if vars[i].Name == name {
+			return vars[i]
+		}
+	}
+	return EnvVar{Name: name}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []EnvVar
+		expectError string
+	}{
+		{
+			name:     "Comments and blank lines",
+			input:    "# weather\n\nAPI_KEY=abc\n  # indented comment\nREGION=eu\n",
+			expected: []EnvVar{{Name: "API_KEY", Value: "abc"}, {Name: "REGION", Value: "eu"}},
+		},
+		{
+			name:     "Export prefix and spaces",
+			input:    "export API_KEY = abc \n",
+			expected: []EnvVar{{Name: "API_KEY", Value: "abc"}},
+		},
+		{
+			name:     "Inline comment",
+			input:    "URL=http://host/#anchor # comment\nCOLOR=#fff",
+			expected: []EnvVar{{Name: "URL", Value: "http://host/#anchor"}, {Name: "COLOR", Value: "#fff"}},
+		},
+		{
+			name:     "Single quotes are literal",
+			input:    `PASSWORD='p@ss # word\n' # comment`,
+			expected: []EnvVar{{Name: "PASSWORD", Value: `p@ss # word\n`}},
+		},
+		{
+			name:     "Double quote escapes",
+			input:    `GREETING="hello\n\"world\"\t\\ $HOME \d"`,
+			expected: []EnvVar{{Name: "GREETING", Value: "hello\n\"world\"\t\\ $HOME \\d"}},
+		},
+		{
+			name:     "Empty values",
+			input:    "A=\nB=''\nC=\"\"",
+			expected: []EnvVar{{Name: "A"}, {Name: "B"}, {Name: "C"}},
+		},
+		{name: "Missing equals", input: "A=1\nJUST_A_NAME", expectError: "line 2: expected NAME=VALUE"},
+		{name: "Invalid name", input: "1ABC=x", expectError: "line 1: expected NAME=VALUE"},
+		{name: "Unterminated double quote", input: `A="abc`, expectError: "line 1: A: unterminated double quote"},
+		{name: "Unterminated single quote", input: `A='abc`, expectError: "unterminated single quote"},
+		{name: "Text after quote", input: `A="abc"def`, expectError: "unexpected characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDotEnv(strings.NewReader(tt.input))
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDotEnv failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadDotEnv_KeepsExistingVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "TEST_DOTENV_NEW=first\nTEST_DOTENV_NEW=second\nTEST_DOTENV_SET=from-file\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create .env file: %v", err)
+	}
+	t.Setenv("TEST_DOTENV_SET", "from-env")
+	// Registers cleanup of the variable the file sets
+	t.Setenv("TEST_DOTENV_NEW", "")
+	os.Unsetenv("TEST_DOTENV_NEW")
+
+	n, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatalf("LoadDotEnv failed: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 variable set, got %d", n)
+	}
+	if got := os.Getenv("TEST_DOTENV_NEW"); got != "second" {
+		t.Fatalf("expected the last value in the file, got %q", got)
+	}
+	if got := os.Getenv("TEST_DOTENV_SET"); got != "from-env" {
+		t.Fatalf("expected the existing value to win, got %q", got)
+	}
+}
+
+func TestLoadConfig_EnvFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+include: conf.d/*.yaml
+servers:
+  - name: weather
+    command: /bin/true
+    envFile: weather.env
+    envs:
+      - name: REGION
+        value: from-envs
+`,
+		"weather.env": "API_KEY=abc\nREGION=from-file\nAPI_KEY=def\n",
+		"conf.d/db.yaml": `
+servers:
+  - name: db
+    command: /bin/true
+    envFile: db.env
+`,
+		"conf.d/db.env": "DB_PASSWORD='secret'\n",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	expected := []EnvVar{{Name: "API_KEY", Value: "def"}, {Name: "REGION", Value: "from-envs"}}
+	if !reflect.DeepEqual(cfg.Servers[0].Envs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, cfg.Servers[0].Envs)
+	}
+	// Relative to the included file
+	expected = []EnvVar{{Name: "DB_PASSWORD", Value: "secret"}}
+	if !reflect.DeepEqual(cfg.Servers[1].Envs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, cfg.Servers[1].Envs)
+	}
+}
+
+func TestLoadConfig_EnvFileErrors(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"missing.yaml": `
+servers:
+  - name: weather
+    command: /bin/true
+    envFile: missing.env
+`,
+		"invalid.yaml": `
+servers:
+  - name: weather
+    command: /bin/true
+    envFile: invalid.env
+`,
+		"invalid.env": "API_KEY=abc\nnot a variable\n",
+	})
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.yaml")); err == nil || !strings.Contains(err.Error(), "server weather: envFile:") {
+		t.Fatalf("expected missing envFile error, got %v", err)
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "invalid.yaml")); err == nil || !strings.Contains(err.Error(), "invalid.env: line 2") {
+		t.Fatalf("expected parse error with line number, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/age"
+)
+
+// AgeKeyEnvVar names the environment variable holding the age secret keys that decrypt
+// encrypted env values. AgeKeyEnvVar_FILE may name a key file instead.
+const AgeKeyEnvVar = "CONFIG_AGE_KEY"
+
+// decryptEnvValues replaces env values that are ASCII-armored age files with their plaintext,
+// so that config files can be committed with their secrets encrypted
+func decryptEnvValues(servers []ServerConfig) error {
+	var identities []*age.Identity
+	for i := range servers {
+		for j := range servers[i].Envs {
+			env := &servers[i].Envs[j]
+			if !age.IsArmored(env.Value) {
+				continue
+			}
+			if identities == nil {
+				ids, err := ageIdentities()
+				if err != nil {
+					return fmt.Errorf("server %s: env %s: %w", servers[i].Name, env.Name, err)
+				}
+				identities = ids
+			}
+			plaintext, err := age.Decrypt(env.Value, identities...)
+			if err != nil {
+				return fmt.Errorf("server %s: env %s: failed to decrypt: %w", servers[i].Name, env.Name, err)
+			}
+			env.Value = string(plaintext)
+		}
+	}
+	return nil
+}
+
+// ageIdentities reads the age secret keys from the environment
+func ageIdentities() ([]*age.Identity, error) {
+	var env secretFileLookup
+	keys, ok := env.lookup(AgeKeyEnvVar)
+	if env.err != nil {
+		return nil, env.err
+	}
+	if !ok {
+		return nil, fmt.Errorf("value is encrypted but neither %s nor %s_FILE is set", AgeKeyEnvVar, AgeKeyEnvVar)
+	}
+	identities, err := age.ParseIdentities(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", AgeKeyEnvVar, err)
+	}
+	return identities, nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expandEnv replaces environment variable references in s:
+//
+//	$NAME, ${NAME}     the value of NAME
+//	${NAME:-default}   default when NAME is unset or empty
+//	${NAME-default}    default when NAME is unset
+//	${NAME:?message}   an error when NAME is unset or empty
+//	${NAME?message}    an error when NAME is unset
+//	$$                 a literal $
+//
+// Defaults may reference other variables. A $ that starts none of the above is kept as is.
+// The names of variables that are unset and have no default are returned in missing.
+func expandEnv(s string, lookup func(string) (string, bool)) (expanded string, missing []string, err error) {
+	var buf strings.Builder
+	buf.Grow(len(s))
+	for i := 0; i < len(s); {
+		if s[i] != '$' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			buf.WriteByte('$')
+			i += 2
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", nil, fmt.Errorf("line %d: unterminated ${", lineOf(s, i))
+			}
+			value, refMissing, err := expandReference(s[i+2:end], lookup)
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: %w", lineOf(s, i), err)
+			}
+			buf.WriteString(value)
+			missing = append(missing, refMissing...)
+			i = end + 1
+		case isNameStart(next):
+			j := i + 2
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			name := s[i+1 : j]
+			value, ok := lookup(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			buf.WriteString(value)
+			i = j
+		default:
+			buf.WriteByte('$')
+			i++
+		}
+	}
+	return buf.String(), missing, nil
+}
+
+// expandReference expands the inside of ${...}
+func expandReference(ref string, lookup func(string) (string, bool)) (string, []string, error) {
+	n := 0
+	for n < len(ref) && isNameChar(ref[n]) {
+		n++
+	}
+	name, op := ref[:n], ref[n:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", nil, fmt.Errorf("invalid variable reference ${%s}", ref)
+	}
+	value, ok := lookup(name)
+
+	switch {
+	case op == "":
+		if !ok {
+			return "", []string{name}, nil
+		}
+		return value, nil, nil
+	case strings.HasPrefix(op, ":-"), strings.HasPrefix(op, "-"):
+		colon := op[0] == ':'
+		if ok && (!colon || value != "") {
+			return value, nil, nil
+		}
+		return expandEnv(strings.TrimPrefix(strings.TrimPrefix(op, ":"), "-"), lookup)
+	case strings.HasPrefix(op, ":?"), strings.HasPrefix(op, "?"):
+		colon := op[0] == ':'
+		if ok && (!colon || value != "") {
+			return value, nil, nil
+		}
+		message := strings.TrimPrefix(strings.TrimPrefix(op, ":"), "?")
+		if message == "" {
+			message = "is not set"
+		}
+		return "", nil, fmt.Errorf("%s: %s", name, message)
+	default:
+		return "", nil, fmt.Errorf("invalid variable reference ${%s}", ref)
+	}
+}
+
+// closingBrace returns the index of the } closing a ${ whose content starts at start, or -1
+func closingBrace(s string, start int) int {
+	depth := 1
+	for j := start; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+func lineOf(s string, i int) int {
+	return strings.Count(s[:i], "\n") + 1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"HOST": "db.internal", "EMPTY": "", "PORT": "5432"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		missing     []string
+		expectError string
+	}{
+		{name: "Plain and braced", input: "$HOST:${PORT}", expected: "db.internal:5432"},
+		{name: "Unset", input: "a${UNSET}b$UNSET2", expected: "ab", missing: []string{"UNSET", "UNSET2"}},
+		{name: "Set but empty is not missing", input: "[${EMPTY}]", expected: "[]"},
+		{name: "Default when unset", input: "${UNSET:-localhost}", expected: "localhost"},
+		{name: "Default when empty", input: "${EMPTY:-fallback}", expected: "fallback"},
+		{name: "Default not used", input: "${HOST:-localhost}", expected: "db.internal"},
+		{name: "Default only when unset", input: "[${EMPTY-fallback}][${UNSET-fallback}]", expected: "[][fallback]"},
+		{name: "Empty default", input: "[${UNSET:-}]", expected: "[]"},
+		{name: "Default referencing variables", input: "${UNSET:-$HOST:${PORT}}", expected: "db.internal:5432"},
+		{name: "Missing variable in default", input: "${UNSET:-${OTHER}}", expected: "", missing: []string{"OTHER"}},
+		{name: "Default with braces", input: `${UNSET:-{"a": 1}}`, expected: `{"a": 1}`},
+		{name: "Escaped dollar", input: "pa$$word $${HOST}", expected: "pa$word ${HOST}"},
+		{name: "Dollar without a name", input: "costs $5, 100$ and $", expected: "costs $5, 100$ and $"},
+		{name: "Required and set", input: "${HOST:?is required}", expected: "db.internal"},
+		{name: "Required", input: "a\n${API_KEY:?set it in .env}", expectError: "line 2: API_KEY: set it in .env"},
+		{name: "Required and empty", input: "${EMPTY:?}", expectError: "EMPTY: is not set"},
+		{name: "Required only when unset", input: "[${EMPTY?}]", expected: "[]"},
+		{name: "Unterminated", input: "${HOST", expectError: "unterminated ${"},
+		{name: "Invalid name", input: "${1ABC}", expectError: "invalid variable reference ${1ABC}"},
+		{name: "Invalid operator", input: "${HOST:=x}", expectError: "invalid variable reference ${HOST:=x}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing, err := expandEnv(tt.input, lookup)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandEnv failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+			if !reflect.DeepEqual(missing, tt.missing) {
+				t.Fatalf("expected missing %v, got %v", tt.missing, missing)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_UndefinedEnvironmentVariables(t *testing.T) {
+	tmpFile := t.TempDir() + "/config.yaml"
+	content := `
+servers:
+  - name: test-server
+    command: ${SERVER_COMMAND:-/bin/true}
+    envs:
+      - name: API_KEY
+        value: ${TEST_UNDEFINED_API_KEY}
+      - name: REGION
+        value: ${TEST_UNDEFINED_REGION}
+`
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	// Expanded to empty strings unless strict
+	t.Setenv(StrictEnvVar, "")
+	cfg, err := LoadConfig(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Servers[0].Command != "/bin/true" || cfg.Servers[0].Envs[0].Value != "" {
+		t.Fatalf("unexpected expansion: %+v", cfg.Servers[0])
+	}
+
+	t.Setenv(StrictEnvVar, "true")
+	_, err = LoadConfig(tmpFile)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "undefined environment variables in config file: TEST_UNDEFINED_API_KEY, TEST_UNDEFINED_REGION") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Setenv("TEST_UNDEFINED_API_KEY", "key")
+	t.Setenv("TEST_UNDEFINED_REGION", "")
+	if _, err := LoadConfig(tmpFile); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+}
+
+func TestLoadConfig_SecretFileConvention(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "api_key")
+	if err := os.WriteFile(secret, []byte("from-file\r\n"), 0600); err != nil {
+		t.Fatalf("failed to create secret file: %v", err)
+	}
+	tmpFile := filepath.Join(dir, "config.yaml")
+	content := `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        value: ${TEST_SECRET_API_KEY}
+`
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	// NAME_FILE is used when NAME is unset
+	t.Setenv(StrictEnvVar, "true")
+	t.Setenv("TEST_SECRET_API_KEY_FILE", secret)
+	cfg, err := LoadConfig(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Servers[0].Envs[0].Value; got != "from-file" {
+		t.Fatalf("expected value from file, got %q", got)
+	}
+
+	// NAME wins over NAME_FILE
+	t.Setenv("TEST_SECRET_API_KEY", "from-env")
+	cfg, err = LoadConfig(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Servers[0].Envs[0].Value; got != "from-env" {
+		t.Fatalf("expected value from environment, got %q", got)
+	}
+
+	os.Unsetenv("TEST_SECRET_API_KEY")
+	t.Setenv("TEST_SECRET_API_KEY_FILE", filepath.Join(dir, "missing"))
+	_, err = LoadConfig(tmpFile)
+	if err == nil || !strings.Contains(err.Error(), "TEST_SECRET_API_KEY_FILE") {
+		t.Fatalf("expected error naming the _FILE variable, got %v", err)
+	}
+}
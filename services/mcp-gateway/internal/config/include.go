@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Include lists files, or glob patterns, whose servers are merged into the configuration.
+// It may be written as a single pattern or a list. Relative patterns are resolved
+// against the directory of the main config file.
+type Include []string
+
+// UnmarshalYAML accepts both `include: conf.d/*.yaml` and a list of patterns
+func (i *Include) UnmarshalYAML(unmarshal func(any) error) error {
+	var pattern string
+	if err := unmarshal(&pattern); err == nil {
+		*i = Include{pattern}
+		return nil
+	}
+	var patterns []string
+	if err := unmarshal(&patterns); err != nil {
+		return err
+	}
+	*i = patterns
+	return nil
+}
+
+// includedFile is the content of an included file, which may only define servers
+type includedFile struct {
+	Servers []ServerConfig `yaml:"servers"`
+}
+
+// includeServers appends the servers of the included files to config.Servers, in pattern order
+// and then file name order, rejecting server names that are already defined in another file
+func includeServers(config *Config, configPath string) error {
+	if len(config.Include) == 0 {
+		return nil
+	}
+
+	mainPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	definedIn := make(map[string]string, len(config.Servers))
+	for _, server := range config.Servers {
+		if _, ok := definedIn[server.Name]; !ok {
+			definedIn[server.Name] = configPath
+		}
+	}
+
+	included := map[string]bool{mainPath: true}
+	for _, pattern := range config.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(mainPath), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %s: %w", pattern, err)
+		}
+		// An empty conf.d is fine, a missing file named explicitly is not
+		if len(matches) == 0 && !strings.ContainsAny(pattern, `*?[\`) {
+			return fmt.Errorf("included config file not found: %s", pattern)
+		}
+
+		for _, path := range matches {
+			if included[path] {
+				continue
+			}
+			included[path] = true
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				continue
+			}
+
+			servers, err := readIncludedFile(path)
+			if err != nil {
+				return err
+			}
+			for _, server := range servers {
+				if other, dup := definedIn[server.Name]; dup {
+					return fmt.Errorf("duplicate server name found: %s (in %s and %s)", server.Name, other, path)
+				}
+				definedIn[server.Name] = path
+			}
+			config.Servers = append(config.Servers, servers...)
+		}
+	}
+	return nil
+}
+
+// readIncludedFile reads the servers of an included file
+func readIncludedFile(path string) ([]ServerConfig, error) {
+	var keys map[string]any
+	var file includedFile
+	if err := readConfigFile(path, &keys, &file); err != nil {
+		return nil, fmt.Errorf("included config %s: %w", path, err)
+	}
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if key != "servers" {
+			return nil, fmt.Errorf("included config %s: only servers can be defined, found %s", path, key)
+		}
+	}
+	resolveServerPaths(file.Servers, filepath.Dir(path))
+	return file.Servers, nil
+}
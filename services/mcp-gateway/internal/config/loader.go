@@ -1,9 +1,19 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/goccy/go-yaml"
@@ -13,52 +23,597 @@ const (
 	MinHealthCheckIntervalMs     = 5000   // 5 seconds
 	MaxHealthCheckIntervalMs     = 300000 // 5 minutes
 	DefaultHealthCheckIntervalMs = 30000  // 30 seconds
+	DefaultHealthCheckJitter     = 10     // percent of the interval
+)
+
+// Load balancing modes for a server and its equivalents
+const (
+	LoadBalancingSpillover    = "spillover"     // use equivalents only when the server cannot take the call
+	LoadBalancingLeastLatency = "least-latency" // prefer the member with the lowest load-adjusted EWMA latency
 )
 
 // Config represents the root configuration structure
 type Config struct {
-	Servers             []ServerConfig `yaml:"servers" validate:"required,min=1,dive"`
-	HealthCheckInterval int            `yaml:"healthCheckInterval"`
-	RestartPolicy       string         `yaml:"restartPolicy"`
+	Servers              []ServerConfig         `yaml:"servers" validate:"required,min=1,dive"`
+	HealthCheckInterval  int                    `yaml:"healthCheckInterval"`
+	HealthCheckJitter    *int                   `yaml:"healthCheckJitter" validate:"omitempty,min=0,max=50"` // percent by which each interval varies, default 10
+	RestartPolicy        string                 `yaml:"restartPolicy"`
+	RestartBackoff       RestartBackoffConfig   `yaml:"restartBackoff"`
+	StartupFailurePolicy string                 `yaml:"startupFailurePolicy" validate:"omitempty,oneof=abort continue"` // default: abort
+	UnicodeToolNames     bool                   `yaml:"unicodeToolNames"`                                               // accept localized tool names, not only ASCII
+	Authorization        AuthorizationConfig    `yaml:"authorization"`
+	APIKeys              []APIKeyConfig         `yaml:"apiKeys" validate:"dive"`
+	Profiles             []ProfileConfig        `yaml:"profiles" validate:"dive"`
+	MetricsSnapshot      *MetricsSnapshotConfig `yaml:"metricsSnapshot"`
+	Notifications        *NotificationsConfig   `yaml:"notifications"`
+	RestartState         *RestartStateConfig    `yaml:"restartState"`
+	ServerLocale         *ServerLocaleConfig    `yaml:"serverLocale"`
+	Tracing              *TracingConfig         `yaml:"tracing"`
+	UsageExport          *UsageExportConfig     `yaml:"usageExport"`
+	ResponseHeaders      *ResponseHeadersConfig `yaml:"responseHeaders"`
+	Include              Include                `yaml:"include" validate:"dive,required"`
+	ReverseProxy         *ReverseProxyConfig    `yaml:"reverseProxy"`
+	LargePayloads        *LargePayloadsConfig   `yaml:"largePayloads"`
+	Secrets              *SecretsConfig         `yaml:"secrets"`
+	ToolSearch           *ToolSearchConfig      `yaml:"toolSearch"`
+	Shutdown             ShutdownConfig         `yaml:"shutdown"`
+	Listeners            []ListenerConfig       `yaml:"listeners" validate:"dive"` // default: all routes on PORT
+}
+
+// ListenerConfig is an address the gateway serves HTTP on, and the routes it serves there
+type ListenerConfig struct {
+	Address string         `yaml:"address" validate:"required"`                     // host:port, e.g. 127.0.0.1:3002 or [::1]:3002
+	Routes  string         `yaml:"routes" validate:"omitempty,oneof=all api admin"` // default: all
+	APIKeys []APIKeyConfig `yaml:"apiKeys" validate:"dive"`                         // accepted instead of the global apiKeys
+}
+
+// Route groups a listener can serve. /health is served by every listener.
+const (
+	ListenerRoutesAll   = "all"   // API and admin routes and /metrics
+	ListenerRoutesAPI   = "api"   // /mcp and /rpc, the data plane
+	ListenerRoutesAdmin = "admin" // /admin, /metrics and /debug/pprof
+)
+
+// RestartBackoffConfig shapes the delays before the restarts of a crashed server: initialDelay before the
+// first, multiplied by multiplier for each further attempt up to maxDelay, each varied randomly by jitter
+type RestartBackoffConfig struct {
+	InitialDelay int     `yaml:"initialDelay" validate:"min=0,max=600000"` // ms, default 1000
+	Multiplier   float64 `yaml:"multiplier" validate:"min=1,max=10"`       // default 2
+	MaxDelay     int     `yaml:"maxDelay" validate:"min=0,max=3600000"`    // ms, default 4000
+	Jitter       int     `yaml:"jitter" validate:"min=0,max=100"`          // percent of each delay, default 0
+	MaxAttempts  int     `yaml:"maxAttempts" validate:"min=0,max=1000"`    // restarts before giving up, default 3
+}
+
+// Restart backoff defaults: 1s, 2s, 4s, then give up
+const (
+	DefaultRestartInitialDelayMs = 1000
+	DefaultRestartMultiplier     = 2
+	DefaultRestartMaxDelayMs     = 4000
+	DefaultRestartMaxAttempts    = 3
+)
+
+// ShutdownConfig bounds how long the gateway waits for in-flight work when it is stopped
+type ShutdownConfig struct {
+	HTTPTimeout        *int `yaml:"httpTimeout" validate:"omitempty,min=0,max=600000"`        // ms to drain HTTP requests, default 5000
+	ProcessGracePeriod *int `yaml:"processGracePeriod" validate:"omitempty,min=0,max=600000"` // ms between SIGTERM and SIGKILL, default 5000
+}
+
+// DefaultShutdownTimeoutMs is the HTTP drain timeout and process grace period when none is configured
+const DefaultShutdownTimeoutMs = 5000
+
+// ToolSearchConfig configures the ranking of POST /mcp/tools/search
+type ToolSearchConfig struct {
+	Embedder *EmbedderConfig `yaml:"embedder"` // rank by embedding similarity; tools are ranked by keywords without one
+}
+
+// EmbedderConfig configures an OpenAI-compatible embeddings API (POST /v1/embeddings)
+type EmbedderConfig struct {
+	URL     string `yaml:"url" validate:"required,http_url"`
+	Model   string `yaml:"model"`
+	APIKey  string `yaml:"apiKey"`                             // sent as a bearer token
+	Timeout int    `yaml:"timeout" validate:"min=0,max=60000"` // ms, default 5000
+}
+
+// SecretsConfig controls secrets fetched from Vault or AWS Secrets Manager for env values
+type SecretsConfig struct {
+	RefreshInterval int `yaml:"refreshInterval" validate:"omitempty,min=10000,max=86400000"` // ms, 0 fetches once at startup
+}
+
+// MetricsSnapshotConfig persists cumulative counters so that they survive gateway restarts
+type MetricsSnapshotConfig struct {
+	Path     string `yaml:"path" validate:"required"`
+	Interval int    `yaml:"interval" validate:"omitempty,min=1000,max=3600000"` // ms, default 60000
+}
+
+// DefaultMetricsSnapshotIntervalMs is the snapshot interval when none is configured
+const DefaultMetricsSnapshotIntervalMs = 60000
+
+// NotificationsConfig tells external receivers when servers crash, restart or are quarantined
+type NotificationsConfig struct {
+	Webhooks []WebhookConfig `yaml:"webhooks" validate:"dive"`
+	Slack    []ChatConfig    `yaml:"slack" validate:"dive"`
+	Discord  []ChatConfig    `yaml:"discord" validate:"dive"`
+}
+
+// WebhookConfig posts a JSON notification to URL for each of Events
+type WebhookConfig struct {
+	URL     string            `yaml:"url" validate:"required,http_url"`
+	Headers map[string]string `yaml:"headers"`                                               // added to each request, e.g. Authorization
+	Events  []string          `yaml:"events" validate:"dive,oneof=crash restart quarantine"` // default: all
+	Timeout int               `yaml:"timeout" validate:"min=0,max=60000"`                    // ms per attempt, default 5000
+	Retries *int              `yaml:"retries" validate:"omitempty,min=0,max=10"`             // attempts after a failed one, default 3
+}
+
+// ChatConfig posts a message rendered from Template to a Slack or Discord incoming webhook for each of Events
+type ChatConfig struct {
+	URL      string   `yaml:"url" validate:"required,http_url"`
+	Template string   `yaml:"template"`                                              // Go text/template over the notification, default: a one-line summary
+	Events   []string `yaml:"events" validate:"dive,oneof=crash restart quarantine"` // default: all
+	Timeout  int      `yaml:"timeout" validate:"min=0,max=60000"`                    // ms per attempt, default 5000
+	Retries  *int     `yaml:"retries" validate:"omitempty,min=0,max=10"`             // attempts after a failed one, default 3
+}
+
+const (
+	DefaultWebhookTimeoutMs = 5000
+	DefaultWebhookRetries   = 3
+)
+
+// RestartStateConfig persists restart attempts and quarantines, so that restarting the gateway does not
+// give a crash-looping server a fresh set of restart attempts
+type RestartStateConfig struct {
+	Path        string `yaml:"path" validate:"required"`
+	ExpireAfter int    `yaml:"expireAfter" validate:"omitempty,min=1000,max=604800000"` // ms after the last failure, default 3600000
+}
+
+// ServerLocaleConfig standardizes the time zone and locale of the servers the gateway spawns, whatever the
+// environment of the gateway. A server's own envs take precedence.
+type ServerLocaleConfig struct {
+	Timezone string `yaml:"timezone" validate:"omitempty,timezone"` // TZ, e.g. UTC or Asia/Tokyo
+	Lang     string `yaml:"lang" validate:"omitempty,printascii"`   // LANG and LC_ALL, e.g. C.UTF-8
+}
+
+// DefaultRestartStateExpireAfterMs is how long restart attempts are remembered when none is configured
+const DefaultRestartStateExpireAfterMs = 3600000
+
+// TracingConfig exports traces of tool calls over OTLP/HTTP
+type TracingConfig struct {
+	Endpoint      string   `yaml:"endpoint" validate:"required,http_url"`        // e.g. http://otel-collector:4318/v1/traces
+	SampleRatio   *float64 `yaml:"sampleRatio" validate:"omitempty,min=0,max=1"` // fraction of calls traced, default 1
+	SlowThreshold int      `yaml:"slowThreshold" validate:"min=0,max=300000"`    // ms; slower calls are always traced, 0 disables
+}
+
+// DefaultTraceSampleRatio is the sample ratio when none is configured
+const DefaultTraceSampleRatio = 1.0
+
+// UsageExportConfig periodically exports tool usage per hour, tool and API key as NDJSON,
+// either appended to a file or posted to a collector
+type UsageExportConfig struct {
+	Path     string `yaml:"path" validate:"required_without=URL,excluded_with=URL"`
+	URL      string `yaml:"url" validate:"omitempty,http_url"`
+	Interval int    `yaml:"interval" validate:"omitempty,min=1000,max=3600000"` // ms, default 60000
+}
+
+// DefaultUsageExportIntervalMs is the export interval when none is configured
+const DefaultUsageExportIntervalMs = 60000
+
+// LargePayloadsConfig lets POST /mcp/call carry inputs and results of many MB. Inputs are passed on to the
+// server as sent rather than decoded into a map, and results are written back one content item at a time,
+// so that a call holds as few copies of its payloads in memory as the MCP transport allows.
+type LargePayloadsConfig struct {
+	MaxRequestBytes  int64 `yaml:"maxRequestBytes" validate:"min=0,max=1073741824"`  // ceiling of a /mcp/call body, default 16 MiB
+	MaxResponseBytes int64 `yaml:"maxResponseBytes" validate:"min=0,max=1073741824"` // ceiling of the content of a result, default 64 MiB
+}
+
+// Large payload ceilings when none are configured
+const (
+	DefaultMaxRequestBytes  = 16 << 20
+	DefaultMaxResponseBytes = 64 << 20
+)
+
+// ResponseHeadersConfig adds headers to HTTP responses, e.g. security headers or Cache-Control
+type ResponseHeadersConfig struct {
+	Headers map[string]string   `yaml:"headers"` // added to every response
+	Paths   []PathHeadersConfig `yaml:"paths" validate:"dive"`
+}
+
+// PathHeadersConfig adds headers to responses for matching request paths, overriding headers of the same name.
+// A path ending in * matches every path with that prefix.
+type PathHeadersConfig struct {
+	Path    string            `yaml:"path" validate:"required,startswith=/"`
+	Headers map[string]string `yaml:"headers" validate:"required,min=1"`
+}
+
+// ReverseProxyConfig describes how the gateway is exposed behind a reverse proxy or ingress controller
+type ReverseProxyConfig struct {
+	BasePath        string   `yaml:"basePath"`                               // prefix of every route, e.g. /gateway
+	TrustedProxies  []string `yaml:"trustedProxies" validate:"dive,cidr|ip"` // proxies whose client IP headers are honored
+	ClientIPHeaders []string `yaml:"clientIPHeaders" validate:"dive,required"`
+}
+
+// DefaultClientIPHeaders are the headers read for the client IP when none are configured
+var DefaultClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// basePathPattern matches a path prefix made of plain segments without a trailing slash
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// headerNamePattern matches an HTTP header field name (RFC 9110 token)
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// Transports for connecting to MCP servers
+const (
+	TransportStdio          = "stdio"           // spawn a local process and talk over stdin/stdout
+	TransportSSE            = "sse"             // connect to a remote server over HTTP with Server-Sent Events
+	TransportStreamableHTTP = "streamable-http" // connect to a remote server over MCP Streamable HTTP
+	TransportTCP            = "tcp"             // connect to a remote server speaking newline-delimited JSON-RPC over TCP
+	TransportUnix           = "unix"            // connect to a local server speaking newline-delimited JSON-RPC on a unix socket
+	TransportWASM           = "wasm"            // load a WebAssembly module in-process and serve its exported functions as tools
+)
+
+var (
+	customTransportsMu sync.RWMutex
+	customTransports   = make(map[string]bool)
+)
+
+// RegisterTransport allows servers to use a transport provided by code embedding the gateway.
+// The gateway does not check url, address or command for custom transports; their dialer does.
+func RegisterTransport(name string) {
+	customTransportsMu.Lock()
+	defer customTransportsMu.Unlock()
+	customTransports[name] = true
+}
+
+// isCustomTransport reports whether name was registered with RegisterTransport
+func isCustomTransport(name string) bool {
+	customTransportsMu.RLock()
+	defer customTransportsMu.RUnlock()
+	return customTransports[name]
+}
+
+// Runtimes for starting stdio servers
+const (
+	RuntimeProcess = "process" // run command directly on the gateway host
+	RuntimeDocker  = "docker"  // run the server in a container with stdio attached
+	RuntimeSSH     = "ssh"     // run command on a remote host with stdio piped over SSH
+)
+
+// Restart policies, globally or per server
+const (
+	RestartPolicyNever     = "never"      // leave crashed servers down
+	RestartPolicyOnFailure = "on-failure" // restart servers that crash or fail health checks
+	RestartPolicyAlways    = "always"     // also restart servers that exit cleanly, e.g. after idling
+)
+
+// Health check methods: how a server is probed for being responsive
+const (
+	HealthCheckPing      = "ping"      // MCP ping
+	HealthCheckListTools = "listTools" // tools/list, for servers that do not answer ping
+	HealthCheckTool      = "tool"      // a call to a cheap tool; a result with isError counts as a failure
+)
+
+// Startup failure policies: what Initialize does when a server fails to connect
+const (
+	StartupFailurePolicyAbort    = "abort"    // stop the gateway
+	StartupFailurePolicyContinue = "continue" // serve the other servers and retry the failed one per its restart policy
+)
+
+// Caller priorities assignable through profiles
+const (
+	PriorityNormal = "normal"
+	PriorityLow    = "low" // may only use part of each server's maxConcurrentCalls
+)
+
+// APIKeyConfig identifies a caller. When any key is configured, /mcp and /admin require one.
+type APIKeyConfig struct {
+	Name    string `yaml:"name" validate:"required,hostname_rfc1123,max=50"`
+	Key     string `yaml:"key" validate:"required,min=16"`
+	Profile string `yaml:"profile"` // name of a profile; empty applies no limits
+}
+
+// ProfileConfig groups the call limits and priority applied to the API keys assigned to it
+type ProfileConfig struct {
+	Name               string `yaml:"name" validate:"required,hostname_rfc1123,max=50"`
+	Timeout            int    `yaml:"timeout" validate:"min=0,max=300000"`            // ms, caps the tool timeout; 0 keeps the server setting
+	Priority           string `yaml:"priority" validate:"omitempty,oneof=normal low"` // default normal
+	MaxConcurrentCalls int    `yaml:"maxConcurrentCalls" validate:"min=0,max=10000"`  // per API key, 0 means unlimited
+	RequestsPerMinute  int    `yaml:"requestsPerMinute" validate:"min=0,max=1000000"` // per API key, 0 means unlimited
+	Burst              int    `yaml:"burst" validate:"min=0,max=1000000"`             // default requestsPerMinute
+}
+
+// AuthorizationConfig configures delegation of per-request authorization
+type AuthorizationConfig struct {
+	ExtAuthz *ExtAuthzConfig `yaml:"extAuthz"`
+	Rego     *RegoConfig     `yaml:"rego"`
+}
+
+// RegoConfig configures an embedded OPA policy evaluated for every request
+type RegoConfig struct {
+	PolicyPath string `yaml:"policyPath" validate:"required"`
+	Query      string `yaml:"query"` // default data.mcpgateway.authz.decision
+}
+
+// ExtAuthzConfig configures an Envoy ext_authz-compatible HTTP authorization service
+type ExtAuthzConfig struct {
+	URL            string   `yaml:"url" validate:"required,url"`
+	Timeout        int      `yaml:"timeout" validate:"min=0,max=60000"`      // ms, default 1000
+	CacheTTL       int      `yaml:"cacheTTL" validate:"min=0,max=3600000"`   // ms, 0 disables caching
+	FailOpen       bool     `yaml:"failOpen"`                                // allow requests when the service is unreachable
+	AllowedHeaders []string `yaml:"allowedHeaders" validate:"dive,required"` // forwarded in addition to Authorization
 }
 
 // ServerConfig represents a single MCP server configuration
 type ServerConfig struct {
-	Name    string   `yaml:"name" validate:"required,hostname_rfc1123,max=50"`
-	Command string   `yaml:"command" validate:"required"`
-	Args    []string `yaml:"args"`
-	Envs    []EnvVar `yaml:"envs" validate:"dive"`
-	Timeout int      `yaml:"timeout" validate:"min=0,max=300000"` // Max 5 minutes
+	Name               string             `yaml:"name" validate:"required,hostname_rfc1123,max=50"`
+	Command            string             `yaml:"command"`                                               // required for stdio
+	Transport          string             `yaml:"transport"`                                             // default stdio; stdio, sse, streamable-http, tcp, unix or a registered transport
+	URL                string             `yaml:"url" validate:"omitempty,http_url"`                     // required for remote transports
+	Address            string             `yaml:"address" validate:"omitempty,hostname_port"`            // host:port, required for the tcp transport
+	SocketPath         string             `yaml:"socketPath" validate:"omitempty,startswith=/"`          // absolute path, required for the unix transport
+	Runtime            string             `yaml:"runtime" validate:"omitempty,oneof=process docker ssh"` // default process, stdio only
+	Docker             *DockerConfig      `yaml:"docker"`                                                // required for the docker runtime
+	SSH                *SSHConfig         `yaml:"ssh"`                                                   // required for the ssh runtime
+	WASM               *WASMConfig        `yaml:"wasm"`                                                  // required for the wasm transport
+	Args               []string           `yaml:"args"`
+	Envs               []EnvVar           `yaml:"envs" validate:"dive"`
+	EnvFile            string             `yaml:"envFile"`                                                   // .env file whose variables are added to envs
+	WorkDir            string             `yaml:"workDir"`                                                   // working directory; relative to the config file for the process runtime, absolute for docker and ssh
+	RunAs              *RunAsConfig       `yaml:"runAs"`                                                     // user and group the server runs as, stdio process or docker runtime only
+	InheritEnv         []string           `yaml:"inheritEnv" validate:"dive,required,printascii"`            // gateway variables passed to the server; default PATH, HOME, USER, LANG, LC_ALL, TZ, TMPDIR
+	InheritAll         bool               `yaml:"inheritAll"`                                                // pass the gateway's whole environment, process runtime only
+	Timeout            int                `yaml:"timeout" validate:"min=0,max=300000"`                       // Max 5 minutes
+	RestartPolicy      string             `yaml:"restartPolicy"`                                             // never, on-failure or always, default: the global restartPolicy
+	EquivalentTo       string             `yaml:"equivalentTo" validate:"omitempty,hostname_rfc1123,max=50"` // primary server whose calls this one can take over
+	MaxConcurrentCalls int                `yaml:"maxConcurrentCalls" validate:"min=0,max=10000"`             // 0 means unlimited
+	LoadBalancing      string             `yaml:"loadBalancing" validate:"omitempty,oneof=spillover least-latency"`
+	HedgeDelay         int                `yaml:"hedgeDelay" validate:"min=0,max=300000"`    // ms, 0 disables hedging of read-only tools
+	IdleTimeout        int                `yaml:"idleTimeout" validate:"min=0,max=86400000"` // ms without tool calls after which the process is stopped, 0 keeps it running
+	StartDelayMs       int                `yaml:"startDelayMs" validate:"min=0,max=3600000"` // ms after gateway startup before the server is started
+	ActiveHours        *ActiveHoursConfig `yaml:"activeHours"`                               // windows outside which the server is stopped and its tools hidden
+	MaxMemoryMB        int                `yaml:"maxMemoryMB" validate:"min=0,max=1048576"`  // resident memory above which the process is restarted, 0 means unlimited
+	CoerceInput        bool               `yaml:"coerceInput"`                               // coerce string arguments to the types the tool schema requires
+	HealthCheck        *HealthCheckConfig `yaml:"healthCheck"`                               // default: MCP ping
+	Canary             *CanaryConfig      `yaml:"canary"`                                    // periodic tool call checking the result
+	Tools              []ToolConfig       `yaml:"tools" validate:"dive"`                     // per-tool overrides
+}
+
+// HealthCheckConfig chooses how a server is probed by the periodic health check
+type HealthCheckConfig struct {
+	Method   string         `yaml:"method" validate:"omitempty,oneof=ping listTools tool"` // default ping
+	ToolName string         `yaml:"toolName"`                                              // required for the tool method
+	Input    map[string]any `yaml:"input"`                                                 // arguments of the tool call, default {}
+}
+
+// CanaryConfig periodically calls a tool of a server and checks its result. The server is marked
+// unhealthy while the call fails, times out or returns something other than expected, which catches
+// servers that answer pings while their tools are broken.
+type CanaryConfig struct {
+	Tool     string              `yaml:"tool" validate:"required"`
+	Input    map[string]any      `yaml:"input"`                     // arguments of the call, default {}
+	Interval int                 `yaml:"interval" validate:"min=0"` // ms between calls, default 300000
+	Timeout  int                 `yaml:"timeout" validate:"min=0"`  // ms, default: the server's timeout
+	Expect   *CanaryExpectConfig `yaml:"expect"`                    // default: any result that is not an error
+}
+
+// CanaryExpectConfig is what a canary call must return besides not being an error
+type CanaryExpectConfig struct {
+	Contains          string         `yaml:"contains"`          // text the text content must contain
+	StructuredContent map[string]any `yaml:"structuredContent"` // fields the structured content must have, with these values
+}
+
+const (
+	DefaultCanaryIntervalMs = 300000 // 5 minutes
+	MinCanaryIntervalMs     = 1000
+)
+
+// ActiveHoursConfig limits a server to windows of the week, e.g. the business hours an upstream system is
+// licensed or available for. Outside them the server is stopped and its tools are hidden.
+type ActiveHoursConfig struct {
+	Timezone string               `yaml:"timezone" validate:"omitempty,timezone"` // e.g. Asia/Tokyo, default: the gateway's local time
+	Windows  []ActiveWindowConfig `yaml:"windows" validate:"required,min=1,dive"`
+}
+
+// ActiveWindowConfig is a daily window from start to end, e.g. 09:00 to 18:00. A window that ends before
+// its start runs over midnight into the next day.
+type ActiveWindowConfig struct {
+	Days  []string `yaml:"days" validate:"dive,oneof=mon tue wed thu fri sat sun"` // days the window starts on, default: every day
+	Start string   `yaml:"start" validate:"required"`                              // HH:MM
+	End   string   `yaml:"end" validate:"required"`                                // HH:MM
+}
+
+// ToolConfig overrides server settings for a single tool
+type ToolConfig struct {
+	Name        string            `yaml:"name" validate:"required"`
+	CoerceInput *bool             `yaml:"coerceInput"` // default: the server's coerceInput
+	Sanitize    []SanitizerConfig `yaml:"sanitize" validate:"dive"`
+}
+
+// SanitizerConfig cleans up an input field before the call is sent to the server.
+// String filters run in the order stripControl, trim, lowercase.
+type SanitizerConfig struct {
+	Field        string       `yaml:"field" validate:"required"` // dot-separated path, e.g. options.query
+	Trim         bool         `yaml:"trim"`                      // remove leading and trailing whitespace
+	Lowercase    bool         `yaml:"lowercase"`
+	StripControl bool         `yaml:"stripControl"` // remove control characters such as NUL and escape sequences
+	Clamp        *ClampConfig `yaml:"clamp"`        // limit numbers to a range
+}
+
+// ClampConfig bounds a numeric field. Either bound may be omitted.
+type ClampConfig struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+}
+
+// DockerConfig describes the container a server runs in when runtime is docker.
+// command and args become the container command; envs are passed into the container.
+type DockerConfig struct {
+	Image   string   `yaml:"image" validate:"required"`
+	Volumes []string `yaml:"volumes" validate:"dive,required"` // docker run -v syntax, e.g. /data:/data:ro
+}
+
+// SSHConfig describes the host a server runs on when runtime is ssh.
+// command and args are run there by the user's login shell.
+type SSHConfig struct {
+	Host    string `yaml:"host" validate:"required,hostname_rfc1123|ip"`
+	User    string `yaml:"user"`                            // default: the ssh client's default
+	Port    int    `yaml:"port" validate:"min=0,max=65535"` // 0 uses the ssh client's default
+	KeyPath string `yaml:"keyPath"`                         // private key, default: the ssh client's identities
+}
+
+// RunAsConfig is the numeric user and group a server runs as instead of the gateway's.
+// Switching users requires the gateway to run as root or with CAP_SETUID and CAP_SETGID.
+type RunAsConfig struct {
+	UID *uint32 `yaml:"uid" validate:"required"`
+	GID *uint32 `yaml:"gid" validate:"required"`
+}
+
+// WASMConfig describes the WebAssembly module a server loads when transport is wasm.
+// Exported functions whose parameters and results are all numbers become tools.
+type WASMConfig struct {
+	Path      string   `yaml:"path" validate:"required"`
+	Functions []string `yaml:"functions" validate:"dive,required"` // exports to serve, default: every eligible export
 }
 
 // EnvVar represents an environment variable for the server
 type EnvVar struct {
-	Name  string `yaml:"name" validate:"required,printascii"`
-	Value string `yaml:"value"`
+	Name      string        `yaml:"name" validate:"required,printascii"`
+	Value     string        `yaml:"value" validate:"excluded_with=ValueFrom"`
+	ValueFrom *EnvVarSource `yaml:"valueFrom"`
+}
+
+// EnvVarSource reads an environment variable's value from outside the config file: a file, e.g. a
+// Docker or Kubernetes secret, or a secrets manager. Exactly one of the fields is set.
+type EnvVarSource struct {
+	File              string `yaml:"file" validate:"required_without_all=Vault AWSSecretsManager,excluded_with=Vault AWSSecretsManager"`
+	Vault             string `yaml:"vault" validate:"excluded_with=AWSSecretsManager"` // <path>[#<key>]
+	AWSSecretsManager string `yaml:"awsSecretsManager"`                                // <secret name or ARN>[#<key>]
 }
 
 // LoadConfig loads and validates the configuration from the specified path
 func LoadConfig(path string) (*Config, error) {
-	// Read file
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	// Expand environment variables
-	expandedData := os.ExpandEnv(string(data))
-
-	// Parse YAML
 	var config Config
-	if err := yaml.Unmarshal([]byte(expandedData), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := readConfigFile(path, &config); err != nil {
+		return nil, err
+	}
+	resolveServerPaths(config.Servers, filepath.Dir(path))
+	if err := includeServers(&config, path); err != nil {
+		return nil, err
+	}
+	for i := range config.Servers {
+		if err := mergeEnvFile(&config.Servers[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := decryptEnvValues(config.Servers); err != nil {
+		return nil, err
 	}
 
-	// Set default timeout if not specified
+	// Set default timeout and transport if not specified
 	for i := range config.Servers {
 		if config.Servers[i].Timeout == 0 {
 			config.Servers[i].Timeout = 30000 // 30秒をデフォルトに
 		}
+		if config.Servers[i].Transport == "" {
+			config.Servers[i].Transport = TransportStdio
+		}
+		if config.Servers[i].Runtime == "" && !config.Servers[i].IsRemote() {
+			config.Servers[i].Runtime = RuntimeProcess
+		}
+		if hc := config.Servers[i].HealthCheck; hc != nil && hc.Method == "" {
+			hc.Method = HealthCheckPing
+		}
+		if canary := config.Servers[i].Canary; canary != nil && canary.Interval == 0 {
+			canary.Interval = DefaultCanaryIntervalMs
+		}
+		if config.ServerLocale != nil && !config.Servers[i].IsRemote() {
+			applyServerLocale(&config.Servers[i], *config.ServerLocale)
+		}
+	}
+
+	if config.MetricsSnapshot != nil && config.MetricsSnapshot.Interval == 0 {
+		config.MetricsSnapshot.Interval = DefaultMetricsSnapshotIntervalMs
+	}
+
+	if config.Notifications != nil {
+		for i := range config.Notifications.Webhooks {
+			webhook := &config.Notifications.Webhooks[i]
+			if webhook.Timeout == 0 {
+				webhook.Timeout = DefaultWebhookTimeoutMs
+			}
+			if webhook.Retries == nil {
+				retries := DefaultWebhookRetries
+				webhook.Retries = &retries
+			}
+		}
+		for _, chats := range [][]ChatConfig{config.Notifications.Slack, config.Notifications.Discord} {
+			for i := range chats {
+				if chats[i].Timeout == 0 {
+					chats[i].Timeout = DefaultWebhookTimeoutMs
+				}
+				if chats[i].Retries == nil {
+					retries := DefaultWebhookRetries
+					chats[i].Retries = &retries
+				}
+			}
+		}
+	}
+
+	if config.RestartState != nil && config.RestartState.ExpireAfter == 0 {
+		config.RestartState.ExpireAfter = DefaultRestartStateExpireAfterMs
+	}
+
+	if config.UsageExport != nil && config.UsageExport.Interval == 0 {
+		config.UsageExport.Interval = DefaultUsageExportIntervalMs
+	}
+
+	if config.LargePayloads != nil {
+		if config.LargePayloads.MaxRequestBytes == 0 {
+			config.LargePayloads.MaxRequestBytes = DefaultMaxRequestBytes
+		}
+		if config.LargePayloads.MaxResponseBytes == 0 {
+			config.LargePayloads.MaxResponseBytes = DefaultMaxResponseBytes
+		}
+	}
+
+	if config.ReverseProxy != nil && len(config.ReverseProxy.ClientIPHeaders) == 0 {
+		config.ReverseProxy.ClientIPHeaders = slices.Clone(DefaultClientIPHeaders)
+	}
+
+	for i := range config.Listeners {
+		if config.Listeners[i].Routes == "" {
+			config.Listeners[i].Routes = ListenerRoutesAll
+		}
+	}
+
+	if config.Shutdown.HTTPTimeout == nil {
+		timeout := DefaultShutdownTimeoutMs
+		config.Shutdown.HTTPTimeout = &timeout
+	}
+	if config.Shutdown.ProcessGracePeriod == nil {
+		grace := DefaultShutdownTimeoutMs
+		config.Shutdown.ProcessGracePeriod = &grace
+	}
+
+	if config.Tracing != nil && config.Tracing.SampleRatio == nil {
+		ratio := DefaultTraceSampleRatio
+		config.Tracing.SampleRatio = &ratio
+	}
+
+	backoff := &config.RestartBackoff
+	if backoff.InitialDelay == 0 {
+		backoff.InitialDelay = DefaultRestartInitialDelayMs
+	}
+	if backoff.Multiplier == 0 {
+		backoff.Multiplier = DefaultRestartMultiplier
+	}
+	if backoff.MaxDelay == 0 {
+		backoff.MaxDelay = max(DefaultRestartMaxDelayMs, backoff.InitialDelay)
+	}
+	if backoff.MaxAttempts == 0 {
+		backoff.MaxAttempts = DefaultRestartMaxAttempts
+	}
+
+	if config.HealthCheckJitter == nil {
+		jitter := DefaultHealthCheckJitter
+		config.HealthCheckJitter = &jitter
 	}
 
 	// Validate YAML-provided value first
@@ -90,13 +645,17 @@ func LoadConfig(path string) (*Config, error) {
 	if config.RestartPolicy == "" {
 		config.RestartPolicy = os.Getenv("MCP_SERVER_RESTART_POLICY")
 		if config.RestartPolicy == "" {
-			config.RestartPolicy = "never" // default
+			config.RestartPolicy = RestartPolicyNever // default
 		}
 	}
 
 	// Validate restart policy
-	if config.RestartPolicy != "never" && config.RestartPolicy != "on-failure" {
-		return nil, fmt.Errorf("invalid restart policy: %s (must be 'never' or 'on-failure')", config.RestartPolicy)
+	if !validRestartPolicy(config.RestartPolicy) {
+		return nil, fmt.Errorf("invalid restart policy: %s (must be 'never', 'on-failure' or 'always')", config.RestartPolicy)
+	}
+
+	if config.StartupFailurePolicy == "" {
+		config.StartupFailurePolicy = StartupFailurePolicyAbort
 	}
 
 	// Validate config
@@ -105,6 +664,10 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
+	if config.Authorization.ExtAuthz != nil && config.Authorization.Rego != nil {
+		return nil, fmt.Errorf("authorization.extAuthz and authorization.rego are mutually exclusive")
+	}
+
 	// Check for duplicate server names
 	serverNames := make(map[string]bool)
 	for _, server := range config.Servers {
@@ -114,5 +677,544 @@ func LoadConfig(path string) (*Config, error) {
 		serverNames[server.Name] = true
 	}
 
+	for _, server := range config.Servers {
+		if err := validateTransport(server); err != nil {
+			return nil, err
+		}
+		if err := validateInheritEnv(server); err != nil {
+			return nil, err
+		}
+		if err := validateWorkDir(server); err != nil {
+			return nil, err
+		}
+		if err := validateRunAs(server); err != nil {
+			return nil, err
+		}
+		if server.IdleTimeout != 0 && server.Transport != TransportStdio {
+			return nil, fmt.Errorf("server %s: idleTimeout requires the stdio transport", server.Name)
+		}
+		// The gateway can only measure processes on its own host
+		if server.MaxMemoryMB != 0 && (server.Transport != TransportStdio || server.Runtime != RuntimeProcess) {
+			return nil, fmt.Errorf("server %s: maxMemoryMB requires the stdio transport and the process runtime", server.Name)
+		}
+		if err := validateHealthCheck(server); err != nil {
+			return nil, err
+		}
+		if err := validateActiveHours(server.ActiveHours); err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.Name, err)
+		}
+		if server.Canary != nil && server.Canary.Interval < MinCanaryIntervalMs {
+			return nil, fmt.Errorf("server %s: canary.interval must be at least %dms", server.Name, MinCanaryIntervalMs)
+		}
+		if server.RestartPolicy != "" && !validRestartPolicy(server.RestartPolicy) {
+			return nil, fmt.Errorf("server %s: invalid restart policy: %s (must be 'never', 'on-failure' or 'always')", server.Name, server.RestartPolicy)
+		}
+		toolNames := make(map[string]bool, len(server.Tools))
+		for _, tool := range server.Tools {
+			if toolNames[tool.Name] {
+				return nil, fmt.Errorf("server %s: duplicate tool name found: %s", server.Name, tool.Name)
+			}
+			toolNames[tool.Name] = true
+			for _, sanitizer := range tool.Sanitize {
+				if err := validateSanitizer(sanitizer); err != nil {
+					return nil, fmt.Errorf("server %s: tool %s: sanitize %s: %w", server.Name, tool.Name, sanitizer.Field, err)
+				}
+			}
+		}
+		// Fail at startup rather than when the server is spawned; secrets managers are queried by FetchSecrets
+		for _, env := range server.Envs {
+			if env.ValueFrom == nil || env.ValueFrom.File == "" {
+				continue
+			}
+			if _, err := env.Resolve(nil); err != nil {
+				return nil, fmt.Errorf("server %s: %w", server.Name, err)
+			}
+		}
+	}
+
+	if config.RestartBackoff.MaxDelay < config.RestartBackoff.InitialDelay {
+		return nil, fmt.Errorf("restartBackoff.maxDelay %d is less than initialDelay %d", config.RestartBackoff.MaxDelay, config.RestartBackoff.InitialDelay)
+	}
+
+	if err := validateAPIKeys(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateResponseHeaders(config.ResponseHeaders); err != nil {
+		return nil, err
+	}
+
+	if err := validateReverseProxy(config.ReverseProxy); err != nil {
+		return nil, err
+	}
+
+	if err := validateListeners(config.Listeners); err != nil {
+		return nil, err
+	}
+
+	if err := validateNotifications(config.Notifications); err != nil {
+		return nil, err
+	}
+
+	// Check spillover mappings
+	equivalents := make(map[string]string, len(config.Servers))
+	for _, server := range config.Servers {
+		equivalents[server.Name] = server.EquivalentTo
+	}
+	for _, server := range config.Servers {
+		if server.EquivalentTo == "" {
+			continue
+		}
+		if server.LoadBalancing != "" {
+			return nil, fmt.Errorf("server %s: loadBalancing can only be set on a primary server", server.Name)
+		}
+		if server.HedgeDelay != 0 {
+			return nil, fmt.Errorf("server %s: hedgeDelay can only be set on a primary server", server.Name)
+		}
+		primary, ok := equivalents[server.EquivalentTo]
+		if !ok {
+			return nil, fmt.Errorf("server %s: equivalentTo references unknown server %s", server.Name, server.EquivalentTo)
+		}
+		if server.EquivalentTo == server.Name {
+			return nil, fmt.Errorf("server %s: equivalentTo cannot reference itself", server.Name)
+		}
+		if primary != "" {
+			return nil, fmt.Errorf("server %s: equivalentTo must reference a primary server, but %s is itself equivalentTo %s", server.Name, server.EquivalentTo, primary)
+		}
+	}
+
 	return &config, nil
 }
+
+// CoercesInput reports whether arguments of the tool are coerced before they are sent to the server
+func (c ServerConfig) CoercesInput(tool string) bool {
+	if t, ok := c.Tool(tool); ok && t.CoerceInput != nil {
+		return *t.CoerceInput
+	}
+	return c.CoerceInput
+}
+
+// Tool returns the overrides configured for a tool
+func (c ServerConfig) Tool(name string) (ToolConfig, bool) {
+	for _, t := range c.Tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return ToolConfig{}, false
+}
+
+// validateSanitizer rejects sanitizers that do nothing and empty clamp ranges
+func validateSanitizer(s SanitizerConfig) error {
+	for _, part := range strings.Split(s.Field, ".") {
+		if part == "" {
+			return fmt.Errorf("field must be a dot-separated path")
+		}
+	}
+	if !s.Trim && !s.Lowercase && !s.StripControl && s.Clamp == nil {
+		return fmt.Errorf("no filter is enabled")
+	}
+	if c := s.Clamp; c != nil {
+		if c.Min == nil && c.Max == nil {
+			return fmt.Errorf("clamp requires min or max")
+		}
+		if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+			return fmt.Errorf("clamp min %v is greater than max %v", *c.Min, *c.Max)
+		}
+	}
+	return nil
+}
+
+// IsRemote reports whether the server is reached over the network instead of being spawned locally
+func (c ServerConfig) IsRemote() bool {
+	return c.Transport != "" && c.Transport != TransportStdio
+}
+
+func validRestartPolicy(policy string) bool {
+	return policy == RestartPolicyNever || policy == RestartPolicyOnFailure || policy == RestartPolicyAlways
+}
+
+// IsContainer reports whether the server is started in a Docker container
+func (c ServerConfig) IsContainer() bool {
+	return c.Runtime == RuntimeDocker
+}
+
+// IsSSH reports whether the server is started on a remote host over SSH
+func (c ServerConfig) IsSSH() bool {
+	return c.Runtime == RuntimeSSH
+}
+
+// validateHealthCheck checks that a tool is named exactly when the health check calls one
+func validateHealthCheck(server ServerConfig) error {
+	hc := server.HealthCheck
+	if hc == nil {
+		return nil
+	}
+	if hc.Method == HealthCheckTool && hc.ToolName == "" {
+		return fmt.Errorf("server %s: healthCheck.toolName is required for the tool method", server.Name)
+	}
+	if hc.Method != HealthCheckTool && (hc.ToolName != "" || hc.Input != nil) {
+		return fmt.Errorf("server %s: healthCheck.toolName and healthCheck.input require the tool method", server.Name)
+	}
+	return nil
+}
+
+// validateActiveHours checks the times of the windows
+func validateActiveHours(hours *ActiveHoursConfig) error {
+	if hours == nil {
+		return nil
+	}
+	for i, w := range hours.Windows {
+		start, err := parseClockTime(w.Start)
+		if err != nil {
+			return fmt.Errorf("activeHours.windows[%d].start: %w", i, err)
+		}
+		end, err := parseClockTime(w.End)
+		if err != nil {
+			return fmt.Errorf("activeHours.windows[%d].end: %w", i, err)
+		}
+		if start == end {
+			return fmt.Errorf("activeHours.windows[%d]: start and end are both %s", i, w.Start)
+		}
+	}
+	return nil
+}
+
+// applyServerLocale prepends the variables of the locale to the server's envs, except those it defines itself
+func applyServerLocale(server *ServerConfig, locale ServerLocaleConfig) {
+	var vars []EnvVar
+	add := func(name, value string) {
+		if value != "" && !slices.ContainsFunc(server.Envs, func(e EnvVar) bool { return e.Name == name }) {
+			vars = append(vars, EnvVar{Name: name, Value: value})
+		}
+	}
+	add("TZ", locale.Timezone)
+	add("LANG", locale.Lang)
+	add("LC_ALL", locale.Lang)
+	server.Envs = append(vars, server.Envs...)
+}
+
+// validateInheritEnv checks that inherited variables are only configured for servers the gateway spawns
+func validateInheritEnv(server ServerConfig) error {
+	if server.InheritEnv == nil && !server.InheritAll {
+		return nil
+	}
+	if server.Transport != TransportStdio {
+		return fmt.Errorf("server %s: inheritEnv and inheritAll require the stdio transport", server.Name)
+	}
+	if server.InheritAll && server.InheritEnv != nil {
+		return fmt.Errorf("server %s: inheritEnv and inheritAll are mutually exclusive", server.Name)
+	}
+	if server.InheritAll && server.Runtime != RuntimeProcess {
+		return fmt.Errorf("server %s: inheritAll requires the process runtime", server.Name)
+	}
+	return nil
+}
+
+// validateWorkDir checks that a process's working directory exists, since the server would otherwise
+// only fail when it is spawned. Directories in containers and on remote hosts cannot be checked.
+func validateWorkDir(server ServerConfig) error {
+	if server.WorkDir == "" {
+		return nil
+	}
+	if server.Transport != TransportStdio {
+		return fmt.Errorf("server %s: workDir requires the stdio transport", server.Name)
+	}
+	if server.Runtime != RuntimeProcess {
+		if !strings.HasPrefix(server.WorkDir, "/") {
+			return fmt.Errorf("server %s: workDir must be an absolute path for the %s runtime", server.Name, server.Runtime)
+		}
+		return nil
+	}
+	info, err := os.Stat(server.WorkDir)
+	if err != nil {
+		return fmt.Errorf("server %s: workDir: %w", server.Name, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("server %s: workDir: %s is not a directory", server.Name, server.WorkDir)
+	}
+	return nil
+}
+
+// validateRunAs checks that runAs is only set for servers the gateway spawns locally.
+// Over SSH the remote account is chosen with ssh.user.
+func validateRunAs(server ServerConfig) error {
+	if server.RunAs == nil {
+		return nil
+	}
+	if server.Transport != TransportStdio || server.IsSSH() {
+		return fmt.Errorf("server %s: runAs requires the stdio transport with the process or docker runtime", server.Name)
+	}
+	return nil
+}
+
+// resolveServerPaths makes relative envFile paths, and workDir paths of local processes, relative to
+// dir, the directory of the file defining the servers
+func resolveServerPaths(servers []ServerConfig, dir string) {
+	for i := range servers {
+		if path := servers[i].EnvFile; path != "" && !filepath.IsAbs(path) {
+			servers[i].EnvFile = filepath.Join(dir, path)
+		}
+		// The working directory of a container or remote process is not on this host
+		local := servers[i].Runtime == "" || servers[i].Runtime == RuntimeProcess
+		if path := servers[i].WorkDir; path != "" && local && !filepath.IsAbs(path) {
+			servers[i].WorkDir = filepath.Join(dir, path)
+		}
+	}
+}
+
+// validateTransport checks that a server has exactly the fields its transport and runtime need
+func validateTransport(server ServerConfig) error {
+	if (server.Transport == TransportWASM) != (server.WASM != nil) {
+		return fmt.Errorf("server %s: wasm settings are required for, and only allowed with, transport: wasm", server.Name)
+	}
+
+	switch server.Transport {
+	case TransportStdio, TransportSSE, TransportStreamableHTTP, TransportTCP, TransportUnix:
+	case TransportWASM:
+		if server.Runtime != "" || server.Docker != nil || server.SSH != nil {
+			return fmt.Errorf("server %s: runtime cannot be used with the wasm transport", server.Name)
+		}
+		if server.Command != "" || len(server.Args) > 0 || len(server.Envs) > 0 ||
+			server.URL != "" || server.Address != "" || server.SocketPath != "" {
+			return fmt.Errorf("server %s: command, args, envs, url, address and socketPath cannot be used with the wasm transport", server.Name)
+		}
+		return nil
+	default:
+		if !isCustomTransport(server.Transport) {
+			return fmt.Errorf("server %s: unsupported transport %q", server.Name, server.Transport)
+		}
+		if server.Runtime != "" || server.Docker != nil || server.SSH != nil {
+			return fmt.Errorf("server %s: runtime cannot be used with the %s transport", server.Name, server.Transport)
+		}
+		return nil
+	}
+
+	if server.IsContainer() != (server.Docker != nil) {
+		return fmt.Errorf("server %s: docker settings are required for, and only allowed with, runtime: docker", server.Name)
+	}
+	if server.IsSSH() != (server.SSH != nil) {
+		return fmt.Errorf("server %s: ssh settings are required for, and only allowed with, runtime: ssh", server.Name)
+	}
+
+	if !server.IsRemote() {
+		// Containers may rely on the image's default command
+		if server.Command == "" && !server.IsContainer() {
+			return fmt.Errorf("server %s: command is required for the stdio transport", server.Name)
+		}
+		if server.URL != "" {
+			return fmt.Errorf("server %s: url requires a remote transport (sse or streamable-http)", server.Name)
+		}
+		if server.Address != "" {
+			return fmt.Errorf("server %s: address requires the tcp transport", server.Name)
+		}
+		if server.SocketPath != "" {
+			return fmt.Errorf("server %s: socketPath requires the unix transport", server.Name)
+		}
+		return nil
+	}
+
+	// Each remote transport locates its server with exactly one of url, address and socketPath
+	locator := "url"
+	switch server.Transport {
+	case TransportTCP:
+		locator = "address"
+	case TransportUnix:
+		locator = "socketPath"
+	}
+	fields := []struct{ name, value string }{
+		{"url", server.URL},
+		{"address", server.Address},
+		{"socketPath", server.SocketPath},
+	}
+	for _, f := range fields {
+		if f.name == locator && f.value == "" {
+			return fmt.Errorf("server %s: %s is required for the %s transport", server.Name, f.name, server.Transport)
+		}
+		if f.name != locator && f.value != "" {
+			return fmt.Errorf("server %s: %s cannot be used with the %s transport, use %s", server.Name, f.name, server.Transport, locator)
+		}
+	}
+	if server.Runtime != "" {
+		return fmt.Errorf("server %s: runtime cannot be used with the %s transport", server.Name, server.Transport)
+	}
+	if server.Command != "" || len(server.Args) > 0 || len(server.Envs) > 0 {
+		return fmt.Errorf("server %s: command, args and envs cannot be used with the %s transport", server.Name, server.Transport)
+	}
+	return nil
+}
+
+// StrictEnvVar names the environment variable that makes undefined variables in config files an error
+const StrictEnvVar = "CONFIG_STRICT_ENV"
+
+// readConfigFile reads a YAML or JSON config file into each of v, expanding environment variables
+func readConfigFile(path string, v ...any) error {
+	// Read file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Expand environment variables
+	var env secretFileLookup
+	expandedData, missing, err := expandEnv(string(data), env.lookup)
+	if err == nil {
+		err = env.err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to expand environment variables in config file: %w", err)
+	}
+	if missing = slices.Compact(slices.Sorted(slices.Values(missing))); len(missing) > 0 {
+		if os.Getenv(StrictEnvVar) == "true" {
+			return fmt.Errorf("undefined environment variables in config file: %s (use ${VAR:-default} for optional ones)", strings.Join(missing, ", "))
+		}
+		slog.Warn("Undefined environment variables in config file expand to empty strings", "path", path, "variables", missing)
+	}
+
+	// JSON is a subset of YAML, so both are decoded by the YAML parser with the same field names.
+	// .json files must still be strictly valid JSON.
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var syntax any
+		if err := json.Unmarshal([]byte(expandedData), &syntax); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	// Parse YAML
+	for _, target := range v {
+		if err := yaml.Unmarshal([]byte(expandedData), target); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateResponseHeaders checks that header names are valid and values fit on a single line
+func validateResponseHeaders(cfg *ResponseHeadersConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	sets := []map[string]string{cfg.Headers}
+	for _, p := range cfg.Paths {
+		sets = append(sets, p.Headers)
+	}
+	for _, headers := range sets {
+		for _, name := range slices.Sorted(maps.Keys(headers)) {
+			value := headers[name]
+			if !headerNamePattern.MatchString(name) {
+				return fmt.Errorf("responseHeaders: invalid header name %q", name)
+			}
+			if strings.ContainsAny(value, "\r\n\x00") {
+				return fmt.Errorf("responseHeaders: value of %s must not contain line breaks", name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateReverseProxy checks the base path and client IP header names
+func validateReverseProxy(cfg *ReverseProxyConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.BasePath != "" && !basePathPattern.MatchString(cfg.BasePath) {
+		return fmt.Errorf("reverseProxy: basePath %q must start with / and must not end with / or contain wildcards", cfg.BasePath)
+	}
+	for _, name := range cfg.ClientIPHeaders {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("reverseProxy: invalid header name %q", name)
+		}
+	}
+	return nil
+}
+
+// validateNotifications checks that the Slack and Discord message templates parse
+func validateNotifications(cfg *NotificationsConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	for i, chat := range cfg.Slack {
+		if _, err := template.New("notification").Parse(chat.Template); err != nil {
+			return fmt.Errorf("notifications.slack[%d]: invalid template: %w", i, err)
+		}
+	}
+	for i, chat := range cfg.Discord {
+		if _, err := template.New("notification").Parse(chat.Template); err != nil {
+			return fmt.Errorf("notifications.discord[%d]: invalid template: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateListeners checks that listener addresses are valid and distinct and that the API is served
+func validateListeners(listeners []ListenerConfig) error {
+	if len(listeners) == 0 {
+		return nil
+	}
+	addresses := make(map[string]bool, len(listeners))
+	serveAPI := false
+	for _, l := range listeners {
+		_, port, err := net.SplitHostPort(l.Address)
+		if err != nil {
+			return fmt.Errorf("listeners: invalid address %q: %w", l.Address, err)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("listeners: invalid port in address %q", l.Address)
+		}
+		if addresses[l.Address] {
+			return fmt.Errorf("listeners: duplicate address %s", l.Address)
+		}
+		addresses[l.Address] = true
+		serveAPI = serveAPI || l.Routes != ListenerRoutesAdmin
+
+		names := make(map[string]bool, len(l.APIKeys))
+		keys := make(map[string]bool, len(l.APIKeys))
+		for _, k := range l.APIKeys {
+			if names[k.Name] {
+				return fmt.Errorf("listeners: %s: duplicate API key name found: %s", l.Address, k.Name)
+			}
+			if keys[k.Key] {
+				return fmt.Errorf("listeners: %s: API key %s reuses the key of another entry", l.Address, k.Name)
+			}
+			// Listener keys authenticate operators; call limits belong to the global keys of agents
+			if k.Profile != "" {
+				return fmt.Errorf("listeners: %s: API key %s cannot have a profile", l.Address, k.Name)
+			}
+			names[k.Name] = true
+			keys[k.Key] = true
+		}
+	}
+	if !serveAPI {
+		return fmt.Errorf("listeners: no listener serves the api routes")
+	}
+	return nil
+}
+
+// validateAPIKeys checks that key names, keys and profile names are unique and that
+// every profile referenced by a key exists
+func validateAPIKeys(config *Config) error {
+	profiles := make(map[string]bool, len(config.Profiles))
+	for _, p := range config.Profiles {
+		if profiles[p.Name] {
+			return fmt.Errorf("duplicate profile name found: %s", p.Name)
+		}
+		profiles[p.Name] = true
+	}
+
+	names := make(map[string]bool, len(config.APIKeys))
+	keys := make(map[string]bool, len(config.APIKeys))
+	for _, k := range config.APIKeys {
+		if names[k.Name] {
+			return fmt.Errorf("duplicate API key name found: %s", k.Name)
+		}
+		names[k.Name] = true
+		if keys[k.Key] {
+			return fmt.Errorf("API key %s reuses the key of another entry", k.Name)
+		}
+		keys[k.Key] = true
+		if k.Profile != "" && !profiles[k.Profile] {
+			return fmt.Errorf("API key %s references unknown profile %s", k.Name, k.Profile)
+		}
+	}
+	return nil
+}
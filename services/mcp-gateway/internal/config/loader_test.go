@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -84,3 +87,328 @@ func TestLoadConfig(t *testing.T) {
 		t.Fatalf("expected timeout 30000, got %d", config.Servers[0].Timeout)
 	}
 }
+
+func TestLoadConfig_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("API_KEY", "secret-key-12345")
+
+	yamlContent := `servers:
+  - name: weather-server
+    command: /mcp-servers/weather/server
+    args: ['--port', '8080']
+    envs:
+      - name: API_KEY
+        value: ${API_KEY}
+    timeout: 60000
+  - name: remote-docs
+    transport: streamable-http
+    url: https://docs.example.com/mcp
+healthCheckInterval: 10000
+apiKeys:
+  - name: dashboard
+    key: dashboard-key-0123456789
+tracing:
+  endpoint: http://otel-collector:4318/v1/traces`
+	// Tab indentation is common in generated JSON
+	jsonContent := "{\n\t\"servers\": [\n\t\t{\n" +
+		`"name": "weather-server", "command": "/mcp-servers/weather/server", "args": ["--port", "8080"],
+		"envs": [{"name": "API_KEY", "value": "${API_KEY}"}], "timeout": 60000},
+		{"name": "remote-docs", "transport": "streamable-http", "url": "https://docs.example.com/mcp"}
+	],
+	"healthCheckInterval": 10000,
+	"apiKeys": [{"name": "dashboard", "key": "dashboard-key-0123456789"}],
+	"tracing": {"endpoint": "http://otel-collector:4318/v1/traces"}
+}`
+
+	yamlFile := tmpDir + "/config.yaml"
+	jsonFile := tmpDir + "/config.JSON"
+	if err := os.WriteFile(yamlFile, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+	if err := os.WriteFile(jsonFile, []byte(jsonContent), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	fromYAML, err := LoadConfig(yamlFile)
+	if err != nil {
+		t.Fatalf("LoadConfig(yaml) failed: %v", err)
+	}
+	fromJSON, err := LoadConfig(jsonFile)
+	if err != nil {
+		t.Fatalf("LoadConfig(json) failed: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Fatalf("JSON config differs from YAML:\nyaml: %+v\njson: %+v", fromYAML, fromJSON)
+	}
+	if fromJSON.Servers[0].Envs[0].Value != "secret-key-12345" {
+		t.Fatalf("expected environment variables to be expanded, got %q", fromJSON.Servers[0].Envs[0].Value)
+	}
+}
+
+func TestLoadConfig_JSONErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError string
+	}{
+		{
+			name:        "Trailing comma",
+			content:     `{"servers": [{"name": "test-server", "command": "/bin/true"},]}`,
+			expectError: "failed to parse config file",
+		},
+		{
+			name:        "YAML in a .json file",
+			content:     "servers:\n  - name: test-server\n    command: /bin/true",
+			expectError: "failed to parse config file",
+		},
+		{
+			name:        "Validation is the same as for YAML",
+			content:     `{"servers": [{"name": "test-server"}]}`,
+			expectError: "command is required for the stdio transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.json"
+			if err := os.WriteFile(tmpFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+// writeConfigFiles writes files relative to a new temporary directory and returns the directory
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create config file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfig_ServerLocale(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+serverLocale:
+  timezone: Asia/Tokyo
+  lang: C.UTF-8
+servers:
+  - name: plain
+    command: /bin/true
+  - name: own-tz
+    command: /bin/true
+    envs:
+      - name: TZ
+        value: UTC
+  - name: remote
+    transport: sse
+    url: https://mcp.example.com/sse`})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	envs := func(server ServerConfig) map[string]string {
+		m := make(map[string]string)
+		for _, e := range server.Envs {
+			m[e.Name] = e.Value
+		}
+		return m
+	}
+	if got := envs(cfg.Servers[0]); got["TZ"] != "Asia/Tokyo" || got["LANG"] != "C.UTF-8" || got["LC_ALL"] != "C.UTF-8" {
+		t.Fatalf("expected the locale in the envs, got %v", got)
+	}
+	if got := envs(cfg.Servers[1]); got["TZ"] != "UTC" || got["LANG"] != "C.UTF-8" {
+		t.Fatalf("expected the server's own TZ to win, got %v", got)
+	}
+	if len(cfg.Servers[1].Envs) != 3 {
+		t.Fatalf("expected TZ once, got %v", cfg.Servers[1].Envs)
+	}
+	if len(cfg.Servers[2].Envs) != 0 {
+		t.Fatalf("expected no envs for a remote server, got %v", cfg.Servers[2].Envs)
+	}
+
+	dir = writeConfigFiles(t, map[string]string{"config.yaml": `
+serverLocale:
+  timezone: Mars/Olympus_Mons
+servers:
+  - name: plain
+    command: /bin/true`})
+	if _, err := LoadConfig(filepath.Join(dir, "config.yaml")); err == nil {
+		t.Fatalf("expected error for an unknown time zone")
+	}
+}
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+include: conf.d/*.yaml
+servers:
+  - name: main-server
+    command: /bin/true
+`,
+		"conf.d/20-weather.yaml": `
+servers:
+  - name: weather
+    command: /usr/bin/weather
+`,
+		"conf.d/10-github.yaml": `
+servers:
+  - name: github
+    command: /usr/bin/github
+    timeout: 5000
+`,
+		"conf.d/notes.txt": "not a config file",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	var names []string
+	for _, s := range cfg.Servers {
+		names = append(names, s.Name)
+	}
+	if want := []string{"main-server", "github", "weather"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected servers %v, got %v", want, names)
+	}
+	// Included servers get the same defaults
+	if cfg.Servers[1].Timeout != 5000 || cfg.Servers[2].Timeout != 30000 {
+		t.Fatalf("unexpected timeouts: %d, %d", cfg.Servers[1].Timeout, cfg.Servers[2].Timeout)
+	}
+	if cfg.Servers[2].Transport != TransportStdio {
+		t.Fatalf("expected default transport, got %s", cfg.Servers[2].Transport)
+	}
+}
+
+func TestLoadConfig_IncludeList(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+include:
+  - github.yaml
+  - extra/*.json
+  - "*.yaml"
+`,
+		"github.yaml":      "servers:\n  - name: github\n    command: /usr/bin/github\n",
+		"extra/slack.json": `{"servers": [{"name": "slack", "command": "/usr/bin/slack"}]}`,
+		"extra/empty.json": `{"servers": []}`,
+		"weather.yaml":     "servers:\n  - name: weather\n    command: /usr/bin/weather\n",
+		"extra/dir.json/x": "",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	// github.yaml is matched twice but included once, and config.yaml doesn't include itself
+	var names []string
+	for _, s := range cfg.Servers {
+		names = append(names, s.Name)
+	}
+	if want := []string{"github", "slack", "weather"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected servers %v, got %v", want, names)
+	}
+}
+
+func TestLoadConfig_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		expectError string
+	}{
+		{
+			name: "Duplicate name across files",
+			files: map[string]string{
+				"config.yaml":    "include: conf.d/*.yaml\nservers:\n  - name: github\n    command: /bin/true\n",
+				"conf.d/gh.yaml": "servers:\n  - name: github\n    command: /usr/bin/github\n",
+			},
+			expectError: "duplicate server name found: github (in ",
+		},
+		{
+			name: "Duplicate name between included files",
+			files: map[string]string{
+				"config.yaml":   "include: conf.d/*.yaml\n",
+				"conf.d/a.yaml": "servers:\n  - name: github\n    command: /bin/true\n",
+				"conf.d/b.yaml": "servers:\n  - name: github\n    command: /bin/true\n",
+			},
+			expectError: "b.yaml)",
+		},
+		{
+			name: "Missing file",
+			files: map[string]string{
+				"config.yaml": "include: github.yaml\nservers:\n  - name: test-server\n    command: /bin/true\n",
+			},
+			expectError: "included config file not found",
+		},
+		{
+			name: "Settings other than servers",
+			files: map[string]string{
+				"config.yaml":   "include: conf.d/*.yaml\n",
+				"conf.d/a.yaml": "healthCheckInterval: 1000\nservers:\n  - name: github\n    command: /bin/true\n",
+			},
+			expectError: "only servers can be defined, found healthCheckInterval",
+		},
+		{
+			name: "Nested include",
+			files: map[string]string{
+				"config.yaml":   "include: conf.d/*.yaml\n",
+				"conf.d/a.yaml": "include: other/*.yaml\n",
+			},
+			expectError: "only servers can be defined, found include",
+		},
+		{
+			name: "Invalid included file",
+			files: map[string]string{
+				"config.yaml":   "include: conf.d/*.yaml\n",
+				"conf.d/a.yaml": "servers: [",
+			},
+			expectError: "a.yaml: failed to parse config file",
+		},
+		{
+			name: "Validation applies to included servers",
+			files: map[string]string{
+				"config.yaml":   "include: conf.d/*.yaml\n",
+				"conf.d/a.yaml": "servers:\n  - name: github\n",
+			},
+			expectError: "command is required for the stdio transport",
+		},
+		{
+			name: "Empty pattern",
+			files: map[string]string{
+				"config.yaml": "include: ['']\nservers:\n  - name: test-server\n    command: /bin/true\n",
+			},
+			expectError: "Include",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+
+			_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
 )
 
 func TestLoadConfig_HealthCheckInterval(t *testing.T) {
@@ -149,3 +155,3316 @@ servers:
 		})
 	}
 }
+
+func TestLoadConfig_ExtAuthz(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid extAuthz",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+authorization:
+  extAuthz:
+    url: http://authz:9000/check
+    timeout: 500
+    cacheTTL: 30000
+    allowedHeaders: [x-api-key]`,
+			expectError: false,
+		},
+		{
+			name: "Missing URL",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+authorization:
+  extAuthz:
+    timeout: 500`,
+			expectError: true,
+		},
+		{
+			name: "Invalid URL",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+authorization:
+  extAuthz:
+    url: not a url`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Authorization.ExtAuthz == nil {
+				t.Fatalf("expected extAuthz config to be set")
+			}
+			if cfg.Authorization.ExtAuthz.CacheTTL != 30000 {
+				t.Fatalf("expected cacheTTL 30000, got %d", cfg.Authorization.ExtAuthz.CacheTTL)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Rego(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid rego",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+authorization:
+  rego:
+    policyPath: /etc/mcp-gateway/policy.rego`,
+			expectError: false,
+		},
+		{
+			name: "Rego missing policyPath",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+authorization:
+  rego:
+    query: data.example.allow`,
+			expectError: true,
+		},
+		{
+			name: "extAuthz and rego are exclusive",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+authorization:
+  extAuthz:
+    url: http://authz:9000/check
+  rego:
+    policyPath: /etc/mcp-gateway/policy.rego`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Authorization.Rego == nil {
+				t.Fatalf("expected rego config to be set")
+			}
+			if cfg.Authorization.Rego.PolicyPath != "/etc/mcp-gateway/policy.rego" {
+				t.Fatalf("unexpected policyPath: %s", cfg.Authorization.Rego.PolicyPath)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EquivalentTo(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid spillover mapping",
+			yamlContent: `
+servers:
+  - name: search-primary
+    command: /bin/true
+    maxConcurrentCalls: 4
+  - name: search-secondary
+    command: /bin/true
+    equivalentTo: search-primary`,
+			expectError: false,
+		},
+		{
+			name: "Unknown primary",
+			yamlContent: `
+servers:
+  - name: search-secondary
+    command: /bin/true
+    equivalentTo: search-primary`,
+			expectError: true,
+		},
+		{
+			name: "Self reference",
+			yamlContent: `
+servers:
+  - name: search
+    command: /bin/true
+    equivalentTo: search`,
+			expectError: true,
+		},
+		{
+			name: "Chained mapping",
+			yamlContent: `
+servers:
+  - name: a
+    command: /bin/true
+  - name: b
+    command: /bin/true
+    equivalentTo: a
+  - name: c
+    command: /bin/true
+    equivalentTo: b`,
+			expectError: true,
+		},
+		{
+			name: "Least-latency group",
+			yamlContent: `
+servers:
+  - name: search-primary
+    command: /bin/true
+    loadBalancing: least-latency
+  - name: search-secondary
+    command: /bin/true
+    equivalentTo: search-primary`,
+			expectError: false,
+		},
+		{
+			name: "Unknown loadBalancing",
+			yamlContent: `
+servers:
+  - name: search
+    command: /bin/true
+    loadBalancing: round-robin`,
+			expectError: true,
+		},
+		{
+			name: "loadBalancing on a secondary",
+			yamlContent: `
+servers:
+  - name: search-primary
+    command: /bin/true
+  - name: search-secondary
+    command: /bin/true
+    equivalentTo: search-primary
+    loadBalancing: least-latency`,
+			expectError: true,
+		},
+		{
+			name: "hedgeDelay on a secondary",
+			yamlContent: `
+servers:
+  - name: search-primary
+    command: /bin/true
+  - name: search-secondary
+    command: /bin/true
+    equivalentTo: search-primary
+    hedgeDelay: 100`,
+			expectError: true,
+		},
+		{
+			name: "Negative maxConcurrentCalls",
+			yamlContent: `
+servers:
+  - name: search
+    command: /bin/true
+    maxConcurrentCalls: -1`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_APIKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Keys with profiles",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+profiles:
+  - name: interactive
+    timeout: 10000
+  - name: batch
+    priority: low
+    maxConcurrentCalls: 2
+    requestsPerMinute: 60
+apiKeys:
+  - name: dashboard
+    key: dashboard-key-0123456789
+    profile: interactive
+  - name: nightly
+    key: nightly-key-0123456789
+    profile: batch
+  - name: ops
+    key: ops-key-0123456789abcdef`,
+			expectError: false,
+		},
+		{
+			name: "Unknown profile",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+apiKeys:
+  - name: dashboard
+    key: dashboard-key-0123456789
+    profile: interactive`,
+			expectError: true,
+		},
+		{
+			name: "Key too short",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+apiKeys:
+  - name: dashboard
+    key: short`,
+			expectError: true,
+		},
+		{
+			name: "Duplicate key",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+apiKeys:
+  - name: a
+    key: shared-key-0123456789
+  - name: b
+    key: shared-key-0123456789`,
+			expectError: true,
+		},
+		{
+			name: "Unknown priority",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+profiles:
+  - name: urgent
+    priority: critical`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Transport(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid sse server",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: https://mcp.example.com/sse`,
+			expectError: false,
+		},
+		{
+			name: "Valid streamable-http server",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: streamable-http
+    url: https://mcp.example.com/mcp`,
+			expectError: false,
+		},
+		{
+			name: "streamable-http with args",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: streamable-http
+    url: https://mcp.example.com/mcp
+    args: [--verbose]`,
+			expectError: true,
+		},
+		{
+			name: "Valid tcp server",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: tcp
+    address: mcp-host.internal:7000`,
+			expectError: false,
+		},
+		{
+			name: "tcp without address",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: tcp`,
+			expectError: true,
+		},
+		{
+			name: "tcp with url",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: tcp
+    address: mcp-host.internal:7000
+    url: https://mcp.example.com/mcp`,
+			expectError: true,
+		},
+		{
+			name: "tcp address without port",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: tcp
+    address: mcp-host.internal`,
+			expectError: true,
+		},
+		{
+			name: "stdio with address",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    address: mcp-host.internal:7000`,
+			expectError: true,
+		},
+		{
+			name: "sse without url",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse`,
+			expectError: true,
+		},
+		{
+			name: "sse with command",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: https://mcp.example.com/sse
+    command: /bin/true`,
+			expectError: true,
+		},
+		{
+			name: "stdio with url",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    url: https://mcp.example.com/sse`,
+			expectError: true,
+		},
+		{
+			name: "stdio without command",
+			yamlContent: `
+servers:
+  - name: local`,
+			expectError: true,
+		},
+		{
+			name: "Non-HTTP url",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: ftp://mcp.example.com/sse`,
+			expectError: true,
+		},
+		{
+			name: "Valid unix server",
+			yamlContent: `
+servers:
+  - name: indexer
+    transport: unix
+    socketPath: /run/mcp/indexer.sock`,
+			expectError: false,
+		},
+		{
+			name: "unix without socketPath",
+			yamlContent: `
+servers:
+  - name: indexer
+    transport: unix`,
+			expectError: true,
+		},
+		{
+			name: "unix with relative socketPath",
+			yamlContent: `
+servers:
+  - name: indexer
+    transport: unix
+    socketPath: run/indexer.sock`,
+			expectError: true,
+		},
+		{
+			name: "unix with address",
+			yamlContent: `
+servers:
+  - name: indexer
+    transport: unix
+    socketPath: /run/mcp/indexer.sock
+    address: localhost:7000`,
+			expectError: true,
+		},
+		{
+			name: "tcp with socketPath",
+			yamlContent: `
+servers:
+  - name: indexer
+    transport: tcp
+    address: localhost:7000
+    socketPath: /run/mcp/indexer.sock`,
+			expectError: true,
+		},
+		{
+			name: "stdio with socketPath",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    socketPath: /run/mcp/indexer.sock`,
+			expectError: true,
+		},
+		{
+			name: "Unknown transport",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: websocket
+    url: https://mcp.example.com/ws`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if !cfg.Servers[0].IsRemote() {
+				t.Fatalf("expected a remote server, got transport %q", cfg.Servers[0].Transport)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_CustomTransport(t *testing.T) {
+	RegisterTransport("grpc-bridge")
+
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Registered transport",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: grpc-bridge
+    address: bridge.internal:9000`,
+			expectError: false,
+		},
+		{
+			name: "Registered transport with runtime",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: grpc-bridge
+    command: /bin/true
+    runtime: process`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Servers[0].Transport != "grpc-bridge" {
+				t.Fatalf("expected transport grpc-bridge, got %q", cfg.Servers[0].Transport)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_WASMTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid wasm server",
+			yamlContent: `
+servers:
+  - name: math
+    transport: wasm
+    wasm:
+      path: /opt/tools/math.wasm
+      functions: [add, mul]`,
+			expectError: false,
+		},
+		{
+			name: "wasm without settings",
+			yamlContent: `
+servers:
+  - name: math
+    transport: wasm`,
+			expectError: true,
+		},
+		{
+			name: "wasm settings without the wasm transport",
+			yamlContent: `
+servers:
+  - name: math
+    command: /bin/true
+    wasm:
+      path: /opt/tools/math.wasm`,
+			expectError: true,
+		},
+		{
+			name: "wasm without path",
+			yamlContent: `
+servers:
+  - name: math
+    transport: wasm
+    wasm:
+      functions: [add]`,
+			expectError: true,
+		},
+		{
+			name: "wasm with an empty function name",
+			yamlContent: `
+servers:
+  - name: math
+    transport: wasm
+    wasm:
+      path: /opt/tools/math.wasm
+      functions: [""]`,
+			expectError: true,
+		},
+		{
+			name: "wasm with command",
+			yamlContent: `
+servers:
+  - name: math
+    transport: wasm
+    command: /bin/true
+    wasm:
+      path: /opt/tools/math.wasm`,
+			expectError: true,
+		},
+		{
+			name: "wasm with runtime",
+			yamlContent: `
+servers:
+  - name: math
+    transport: wasm
+    runtime: docker
+    docker:
+      image: alpine
+    wasm:
+      path: /opt/tools/math.wasm`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Servers[0].Runtime != "" {
+				t.Fatalf("expected no runtime for a wasm server, got %q", cfg.Servers[0].Runtime)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_DockerRuntime(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Image default command",
+			yamlContent: `
+servers:
+  - name: files
+    runtime: docker
+    docker:
+      image: example/mcp-files:1.0
+      volumes: [/srv/data:/data:ro]
+    envs:
+      - name: API_TOKEN
+        value: secret`,
+			expectError: false,
+		},
+		{
+			name: "Missing image",
+			yamlContent: `
+servers:
+  - name: files
+    runtime: docker
+    docker:
+      volumes: [/srv/data:/data:ro]`,
+			expectError: true,
+		},
+		{
+			name: "docker settings without runtime",
+			yamlContent: `
+servers:
+  - name: files
+    command: /bin/true
+    docker:
+      image: example/mcp-files:1.0`,
+			expectError: true,
+		},
+		{
+			name: "runtime docker without settings",
+			yamlContent: `
+servers:
+  - name: files
+    runtime: docker`,
+			expectError: true,
+		},
+		{
+			name: "Remote transport with runtime",
+			yamlContent: `
+servers:
+  - name: files
+    transport: sse
+    url: https://mcp.example.com/sse
+    runtime: docker
+    docker:
+      image: example/mcp-files:1.0`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if !cfg.Servers[0].IsContainer() {
+				t.Fatalf("expected a container server, got runtime %q", cfg.Servers[0].Runtime)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_SSHRuntime(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid ssh server",
+			yamlContent: `
+servers:
+  - name: search
+    runtime: ssh
+    command: /opt/mcp/search-server
+    args: [--index, /var/lib/search]
+    ssh:
+      host: build-01.internal
+      user: mcp
+      port: 2222
+      keyPath: /run/secrets/mcp_ed25519`,
+			expectError: false,
+		},
+		{
+			name: "IP address host",
+			yamlContent: `
+servers:
+  - name: search
+    runtime: ssh
+    command: /opt/mcp/search-server
+    ssh:
+      host: 10.0.0.12`,
+			expectError: false,
+		},
+		{
+			name: "Missing host",
+			yamlContent: `
+servers:
+  - name: search
+    runtime: ssh
+    command: /opt/mcp/search-server
+    ssh:
+      user: mcp`,
+			expectError: true,
+		},
+		{
+			name: "Missing command",
+			yamlContent: `
+servers:
+  - name: search
+    runtime: ssh
+    ssh:
+      host: build-01.internal`,
+			expectError: true,
+		},
+		{
+			name: "Port out of range",
+			yamlContent: `
+servers:
+  - name: search
+    runtime: ssh
+    command: /opt/mcp/search-server
+    ssh:
+      host: build-01.internal
+      port: 70000`,
+			expectError: true,
+		},
+		{
+			name: "ssh settings without runtime",
+			yamlContent: `
+servers:
+  - name: search
+    command: /opt/mcp/search-server
+    ssh:
+      host: build-01.internal`,
+			expectError: true,
+		},
+		{
+			name: "runtime ssh without settings",
+			yamlContent: `
+servers:
+  - name: search
+    runtime: ssh
+    command: /opt/mcp/search-server`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if !cfg.Servers[0].IsSSH() {
+				t.Fatalf("expected an ssh server, got runtime %q", cfg.Servers[0].Runtime)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_MetricsSnapshot(t *testing.T) {
+	tests := []struct {
+		name             string
+		yamlContent      string
+		expectError      bool
+		expectedInterval int
+	}{
+		{
+			name: "Default interval",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+metricsSnapshot:
+  path: /var/lib/mcp-gateway/metrics.json`,
+			expectedInterval: 60000,
+		},
+		{
+			name: "Custom interval",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+metricsSnapshot:
+  path: /var/lib/mcp-gateway/metrics.json
+  interval: 10000`,
+			expectedInterval: 10000,
+		},
+		{
+			name: "Missing path",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+metricsSnapshot:
+  interval: 10000`,
+			expectError: true,
+		},
+		{
+			name: "Interval too small",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+metricsSnapshot:
+  path: /var/lib/mcp-gateway/metrics.json
+  interval: 500`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.MetricsSnapshot.Interval != tt.expectedInterval {
+				t.Fatalf("expected interval %d, got %d", tt.expectedInterval, cfg.MetricsSnapshot.Interval)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RestartState(t *testing.T) {
+	tests := []struct {
+		name                string
+		yamlContent         string
+		expectError         bool
+		expectedExpireAfter int
+	}{
+		{
+			name: "Default expireAfter",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+restartState:
+  path: /var/lib/mcp-gateway/restarts.json`,
+			expectedExpireAfter: 3600000,
+		},
+		{
+			name: "Custom expireAfter",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+restartState:
+  path: /var/lib/mcp-gateway/restarts.json
+  expireAfter: 600000`,
+			expectedExpireAfter: 600000,
+		},
+		{
+			name: "Missing path",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+restartState:
+  expireAfter: 600000`,
+			expectError: true,
+		},
+		{
+			name: "expireAfter too small",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+restartState:
+  path: /var/lib/mcp-gateway/restarts.json
+  expireAfter: 10`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.RestartState.ExpireAfter != tt.expectedExpireAfter {
+				t.Fatalf("expected expireAfter %d, got %d", tt.expectedExpireAfter, cfg.RestartState.ExpireAfter)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Notifications(t *testing.T) {
+	tests := []struct {
+		name            string
+		yamlContent     string
+		expectError     bool
+		expectedTimeout int
+		expectedRetries int
+	}{
+		{
+			name: "Defaults",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  webhooks:
+    - url: https://hooks.example.com/mcp`,
+			expectedTimeout: 5000,
+			expectedRetries: 3,
+		},
+		{
+			name: "Custom webhook",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  webhooks:
+    - url: https://hooks.example.com/mcp
+      headers:
+        Authorization: Bearer secret
+      events: [crash, quarantine]
+      timeout: 2000
+      retries: 0`,
+			expectedTimeout: 2000,
+			expectedRetries: 0,
+		},
+		{
+			name: "Missing url",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  webhooks:
+    - events: [crash]`,
+			expectError: true,
+		},
+		{
+			name: "Unknown event",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  webhooks:
+    - url: https://hooks.example.com/mcp
+      events: [crashed]`,
+			expectError: true,
+		},
+		{
+			name: "Too many retries",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  webhooks:
+    - url: https://hooks.example.com/mcp
+      retries: 11`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			webhook := cfg.Notifications.Webhooks[0]
+			if webhook.Timeout != tt.expectedTimeout {
+				t.Fatalf("expected timeout %d, got %d", tt.expectedTimeout, webhook.Timeout)
+			}
+			if *webhook.Retries != tt.expectedRetries {
+				t.Fatalf("expected retries %d, got %d", tt.expectedRetries, *webhook.Retries)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ChatNotifications(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Slack and Discord",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  slack:
+    - url: https://hooks.slack.com/services/T000/B000/XXXX
+      events: [quarantine]
+  discord:
+    - url: https://discord.com/api/webhooks/1/abc
+      template: "{{.Server}}: {{.Event}}"
+      retries: 1`,
+		},
+		{
+			name: "Missing url",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  slack:
+    - events: [crash]`,
+			expectError: true,
+		},
+		{
+			name: "Invalid template",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+notifications:
+  discord:
+    - url: https://discord.com/api/webhooks/1/abc
+      template: "{{.Server"`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			slack := cfg.Notifications.Slack[0]
+			if slack.Timeout != DefaultWebhookTimeoutMs || *slack.Retries != DefaultWebhookRetries {
+				t.Fatalf("expected default timeout and retries, got %d and %d", slack.Timeout, *slack.Retries)
+			}
+			if retries := *cfg.Notifications.Discord[0].Retries; retries != 1 {
+				t.Fatalf("expected retries 1, got %d", retries)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_UsageExport(t *testing.T) {
+	tests := []struct {
+		name             string
+		yamlContent      string
+		expectError      bool
+		expectedInterval int
+	}{
+		{
+			name: "File with default interval",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+usageExport:
+  path: /var/lib/mcp-gateway/usage.ndjson`,
+			expectedInterval: 60000,
+		},
+		{
+			name: "Collector with custom interval",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+usageExport:
+  url: https://collector.example.com/usage
+  interval: 300000`,
+			expectedInterval: 300000,
+		},
+		{
+			name: "Neither path nor url",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+usageExport:
+  interval: 10000`,
+			expectError: true,
+		},
+		{
+			name: "Both path and url",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+usageExport:
+  path: /var/lib/mcp-gateway/usage.ndjson
+  url: https://collector.example.com/usage`,
+			expectError: true,
+		},
+		{
+			name: "Invalid url",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+usageExport:
+  url: collector`,
+			expectError: true,
+		},
+		{
+			name: "Interval too small",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+usageExport:
+  path: /var/lib/mcp-gateway/usage.ndjson
+  interval: 500`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.UsageExport.Interval != tt.expectedInterval {
+				t.Fatalf("expected interval %d, got %d", tt.expectedInterval, cfg.UsageExport.Interval)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ResponseHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid headers",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+responseHeaders:
+  headers:
+    Strict-Transport-Security: max-age=31536000
+    X-Content-Type-Options: nosniff
+  paths:
+    - path: /mcp/call
+      headers:
+        Cache-Control: no-store
+    - path: /admin/*
+      headers:
+        Cache-Control: private`,
+			expectError: false,
+		},
+		{
+			name: "Invalid header name",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+responseHeaders:
+  headers:
+    "X Frame Options": DENY`,
+			expectError: true,
+		},
+		{
+			name: "Line break in value",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+responseHeaders:
+  paths:
+    - path: /mcp/call
+      headers:
+        Cache-Control: "no-store\r\nSet-Cookie: a=b"`,
+			expectError: true,
+		},
+		{
+			name: "Relative path",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+responseHeaders:
+  paths:
+    - path: mcp/call
+      headers:
+        Cache-Control: no-store`,
+			expectError: true,
+		},
+		{
+			name: "Path without headers",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+responseHeaders:
+  paths:
+    - path: /mcp/call`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ReverseProxy(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid settings",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  basePath: /gateway/v1
+  trustedProxies: [10.0.0.0/8, 192.168.1.10, "fd00::/8"]
+  clientIPHeaders: [X-Forwarded-For]`,
+			expectError: false,
+		},
+		{
+			name: "Trailing slash in base path",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  basePath: /gateway/`,
+			expectError: true,
+		},
+		{
+			name: "Relative base path",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  basePath: gateway`,
+			expectError: true,
+		},
+		{
+			name: "Wildcard in base path",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  basePath: /:tenant`,
+			expectError: true,
+		},
+		{
+			name: "Invalid trusted proxy",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  trustedProxies: [proxy.internal]`,
+			expectError: true,
+		},
+		{
+			name: "Invalid header name",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  clientIPHeaders: ["X Forwarded For"]`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ReverseProxyDefaults(t *testing.T) {
+	tmpFile := t.TempDir() + "/config.yaml"
+	content := `
+servers:
+  - name: test-server
+    command: /bin/true
+reverseProxy:
+  basePath: /gateway`
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.ReverseProxy.TrustedProxies) != 0 {
+		t.Fatalf("expected no trusted proxies, got %v", cfg.ReverseProxy.TrustedProxies)
+	}
+	if got := cfg.ReverseProxy.ClientIPHeaders; len(got) != 2 || got[0] != "X-Forwarded-For" || got[1] != "X-Real-IP" {
+		t.Fatalf("expected default client IP headers, got %v", got)
+	}
+}
+
+func TestLoadConfig_Schedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid settings",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    startDelayMs: 30000
+    activeHours:
+      timezone: Asia/Tokyo
+      windows:
+        - days: [mon, tue, wed, thu, fri]
+          start: "09:00"
+          end: "18:00"
+        - start: "22:00"
+          end: "02:00"`,
+			expectError: false,
+		},
+		{
+			name: "Negative start delay",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    startDelayMs: -1`,
+			expectError: true,
+		},
+		{
+			name: "No windows",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    activeHours:
+      timezone: UTC`,
+			expectError: true,
+		},
+		{
+			name: "Invalid time",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    activeHours:
+      windows:
+        - start: "9am"
+          end: "18:00"`,
+			expectError: true,
+		},
+		{
+			name: "Empty window",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    activeHours:
+      windows:
+        - start: "09:00"
+          end: "09:00"`,
+			expectError: true,
+		},
+		{
+			name: "Invalid day",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    activeHours:
+      windows:
+        - days: [monday]
+          start: "09:00"
+          end: "18:00"`,
+			expectError: true,
+		},
+		{
+			name: "Invalid time zone",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    activeHours:
+      timezone: Mars/Olympus
+      windows:
+        - start: "09:00"
+          end: "18:00"`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_LargePayloads(t *testing.T) {
+	tests := []struct {
+		name             string
+		yamlContent      string
+		expectError      bool
+		expectedRequest  int64
+		expectedResponse int64
+	}{
+		{
+			name: "Defaults",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+largePayloads: {}`,
+			expectedRequest:  DefaultMaxRequestBytes,
+			expectedResponse: DefaultMaxResponseBytes,
+		},
+		{
+			name: "Custom ceilings",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+largePayloads:
+  maxRequestBytes: 4194304
+  maxResponseBytes: 268435456`,
+			expectedRequest:  4 << 20,
+			expectedResponse: 256 << 20,
+		},
+		{
+			name: "Negative ceiling",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+largePayloads:
+  maxRequestBytes: -1`,
+			expectError: true,
+		},
+		{
+			name: "Ceiling above 1 GiB",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+largePayloads:
+  maxResponseBytes: 2147483648`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.LargePayloads.MaxRequestBytes != tt.expectedRequest {
+				t.Errorf("expected maxRequestBytes %d, got %d", tt.expectedRequest, cfg.LargePayloads.MaxRequestBytes)
+			}
+			if cfg.LargePayloads.MaxResponseBytes != tt.expectedResponse {
+				t.Errorf("expected maxResponseBytes %d, got %d", tt.expectedResponse, cfg.LargePayloads.MaxResponseBytes)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvValueFrom(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "api_key")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatalf("failed to create secret file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError string
+	}{
+		{
+			name: "Value from file",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          file: ` + secret,
+		},
+		{
+			name: "Both value and valueFrom",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        value: inline
+        valueFrom:
+          file: ` + secret,
+			expectError: "Value",
+		},
+		{
+			name: "valueFrom without file",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom: {}`,
+			expectError: "File",
+		},
+		{
+			name: "Missing file",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          file: ` + filepath.Join(dir, "missing"),
+			expectError: "server test-server: env API_KEY:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			value, err := cfg.Servers[0].Envs[0].Resolve(nil)
+			if err != nil || value != "s3cret" {
+				t.Fatalf("expected s3cret without the trailing newline, got %q (%v)", value, err)
+			}
+		})
+	}
+}
+
+// mapProvider serves secrets from a map
+type mapProvider map[string]string
+
+func (p mapProvider) Fetch(_ context.Context, ref string) (string, error) {
+	value, ok := p[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func TestLoadConfig_EnvSecretsManagers(t *testing.T) {
+	store := secrets.NewStore(map[string]secrets.Provider{
+		secrets.Vault:             mapProvider{"secret/data/loader-test#api_key": "from-vault"},
+		secrets.AWSSecretsManager: mapProvider{"prod/db#password": "from-aws"},
+	})
+
+	tests := []struct {
+		name        string
+		yamlContent string
+		want        string
+		expectError string
+	}{
+		{
+			name: "Vault",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          vault: secret/data/loader-test#api_key
+secrets:
+  refreshInterval: 60000`,
+			want: "from-vault",
+		},
+		{
+			name: "AWS Secrets Manager",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          awsSecretsManager: prod/db#password`,
+			want: "from-aws",
+		},
+		{
+			name: "Plain value that looks like a reference",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        value: vault:secret/data/loader-test#api_key`,
+			want: "vault:secret/data/loader-test#api_key",
+		},
+		{
+			name: "Unknown secret",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          vault: secret/data/loader-missing#api_key`,
+			expectError: "server test-server: env API_KEY: failed to fetch vault secret secret/data/loader-missing#api_key",
+		},
+		{
+			name: "Several sources",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    envs:
+      - name: API_KEY
+        valueFrom:
+          vault: secret/data/loader-test#api_key
+          awsSecretsManager: prod/db#password`,
+			expectError: "Vault",
+		},
+		{
+			name: "Refresh interval too short",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+secrets:
+  refreshInterval: 1000`,
+			expectError: "RefreshInterval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if err == nil {
+				err = FetchSecrets(cfg, store)
+			}
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			value, err := cfg.Servers[0].Envs[0].Resolve(store)
+			if err != nil || value != tt.want {
+				t.Fatalf("expected %s, got %q (%v)", tt.want, value, err)
+			}
+		})
+	}
+}
+
+func TestEnvVar_ResolveWithoutStore(t *testing.T) {
+	env := EnvVar{Name: "API_KEY", ValueFrom: &EnvVarSource{Vault: "secret/data/app#key"}}
+
+	_, err := env.Resolve(nil)
+	if err == nil || !strings.Contains(err.Error(), "env API_KEY: no secrets store") {
+		t.Fatalf("expected an error without a store, got %v", err)
+	}
+}
+
+func TestLoadConfig_ToolOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Valid settings",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    coerceInput: true
+    tools:
+      - name: forecast
+        coerceInput: false
+      - name: alerts`,
+			expectError: false,
+		},
+		{
+			name: "Missing tool name",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    tools:
+      - coerceInput: true`,
+			expectError: true,
+		},
+		{
+			name: "Duplicate tool name",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    tools:
+      - name: forecast
+      - name: forecast`,
+			expectError: true,
+		},
+		{
+			name: "Sanitizers",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    coerceInput: true
+    tools:
+      - name: forecast
+        coerceInput: false
+        sanitize:
+          - field: options.city
+            trim: true
+            lowercase: true
+            stripControl: true
+          - field: days
+            clamp: {min: 1, max: 14}`,
+			expectError: false,
+		},
+		{
+			name: "Sanitizer without filters",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    tools:
+      - name: forecast
+        sanitize:
+          - field: city`,
+			expectError: true,
+		},
+		{
+			name: "Sanitizer with empty path segment",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    tools:
+      - name: forecast
+        sanitize:
+          - field: options..city
+            trim: true`,
+			expectError: true,
+		},
+		{
+			name: "Clamp without bounds",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    tools:
+      - name: forecast
+        sanitize:
+          - field: days
+            clamp: {}`,
+			expectError: true,
+		},
+		{
+			name: "Clamp min greater than max",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    tools:
+      - name: forecast
+        sanitize:
+          - field: days
+            clamp: {min: 10, max: 1}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if err == nil {
+				server := cfg.Servers[0]
+				if server.CoercesInput("forecast") || !server.CoercesInput("alerts") || !server.CoercesInput("other") {
+					t.Fatalf("unexpected coerceInput resolution: %+v", server.Tools)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InheritEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectError bool
+	}{
+		{
+			name: "Allowlist",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    inheritEnv: [PATH, HOME, AWS_REGION]`,
+			expectError: false,
+		},
+		{
+			name: "Inherit all",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    inheritAll: true`,
+			expectError: false,
+		},
+		{
+			name: "Allowlist for a container",
+			yamlContent: `
+servers:
+  - name: test-server
+    runtime: docker
+    docker:
+      image: example/server:1.0
+    inheritEnv: [AWS_REGION]`,
+			expectError: false,
+		},
+		{
+			name: "Both",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    inheritEnv: [PATH]
+    inheritAll: true`,
+			expectError: true,
+		},
+		{
+			name: "Inherit all for a container",
+			yamlContent: `
+servers:
+  - name: test-server
+    runtime: docker
+    docker:
+      image: example/server:1.0
+    inheritAll: true`,
+			expectError: true,
+		},
+		{
+			name: "Remote transport",
+			yamlContent: `
+servers:
+  - name: test-server
+    transport: sse
+    url: http://localhost:8080/sse
+    inheritEnv: [PATH]`,
+			expectError: true,
+		},
+		{
+			name: "Empty name",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+    inheritEnv: [""]`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			_, err := LoadConfig(tmpFile)
+			if tt.expectError && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InheritEnvEmptyList(t *testing.T) {
+	tmpFile := t.TempDir() + "/config.yaml"
+	content := `
+servers:
+  - name: test-server
+    command: /bin/true
+    inheritEnv: []`
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	// An empty list inherits nothing, unlike an omitted one
+	if cfg.Servers[0].InheritEnv == nil {
+		t.Fatalf("expected an empty, non-nil inheritEnv")
+	}
+}
+
+func TestLoadConfig_ServerRestartPolicy(t *testing.T) {
+	tmpFile := t.TempDir() + "/config.yaml"
+	content := `
+restartPolicy: always
+servers:
+  - name: flaky
+    command: /bin/true
+    restartPolicy: on-failure
+  - name: critical
+    command: /bin/true`
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Servers[0].RestartPolicy != "on-failure" {
+		t.Fatalf("expected on-failure, got %q", cfg.Servers[0].RestartPolicy)
+	}
+	// Servers without a policy follow the global one at runtime
+	if cfg.Servers[1].RestartPolicy != "" {
+		t.Fatalf("expected no policy, got %q", cfg.Servers[1].RestartPolicy)
+	}
+
+	content = `
+servers:
+  - name: flaky
+    command: /bin/true
+    restartPolicy: sometimes`
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create config file: %v", err)
+	}
+	if _, err := LoadConfig(tmpFile); err == nil || !strings.Contains(err.Error(), "server flaky: invalid restart policy: sometimes") {
+		t.Fatalf("expected invalid restart policy error, got %v", err)
+	}
+}
+
+func TestLoadConfig_WorkDir(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+servers:
+  - name: local
+    command: /bin/true
+    workDir: data
+  - name: container
+    runtime: docker
+    docker:
+      image: example/server:1.0
+    workDir: /app`,
+		"data/.keep": "",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	// Relative to the config file
+	if want := filepath.Join(dir, "data"); cfg.Servers[0].WorkDir != want {
+		t.Fatalf("expected %s, got %s", want, cfg.Servers[0].WorkDir)
+	}
+	if cfg.Servers[1].WorkDir != "/app" {
+		t.Fatalf("expected /app, got %s", cfg.Servers[1].WorkDir)
+	}
+
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Missing directory",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    workDir: missing`,
+			expectedError: "server local: workDir:",
+		},
+		{
+			name: "Not a directory",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    workDir: data/.keep`,
+			expectedError: "is not a directory",
+		},
+		{
+			name: "Relative path in a container",
+			yamlContent: `
+servers:
+  - name: container
+    runtime: docker
+    docker:
+      image: example/server:1.0
+    workDir: app`,
+			expectedError: "workDir must be an absolute path for the docker runtime",
+		},
+		{
+			name: "Remote transport",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: http://localhost:8080/sse
+    workDir: /app`,
+			expectedError: "workDir requires the stdio transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "invalid.yaml")
+			if err := os.WriteFile(path, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RunAs(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+servers:
+  - name: local
+    command: /bin/true
+    runAs:
+      uid: 1000
+      gid: 0
+  - name: container
+    runtime: docker
+    docker:
+      image: example/server:1.0
+    runAs: {uid: 65534, gid: 65534}`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if runAs := cfg.Servers[0].RunAs; *runAs.UID != 1000 || *runAs.GID != 0 {
+		t.Fatalf("expected uid 1000 gid 0, got %d %d", *runAs.UID, *runAs.GID)
+	}
+	if runAs := cfg.Servers[1].RunAs; *runAs.UID != 65534 || *runAs.GID != 65534 {
+		t.Fatalf("expected uid 65534 gid 65534, got %d %d", *runAs.UID, *runAs.GID)
+	}
+
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Missing gid",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    runAs:
+      uid: 1000`,
+			expectedError: "RunAs.GID",
+		},
+		{
+			name: "Negative uid",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    runAs:
+      uid: -1
+      gid: 1000`,
+			expectedError: "failed to parse",
+		},
+		{
+			name: "SSH runtime",
+			yamlContent: `
+servers:
+  - name: remote-host
+    runtime: ssh
+    command: /opt/server
+    ssh:
+      host: build.example.com
+    runAs: {uid: 1000, gid: 1000}`,
+			expectedError: "runAs requires the stdio transport with the process or docker runtime",
+		},
+		{
+			name: "Remote transport",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: http://localhost:8080/sse
+    runAs: {uid: 1000, gid: 1000}`,
+			expectedError: "runAs requires the stdio transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "invalid.yaml")
+			if err := os.WriteFile(path, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_IdleTimeout(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+servers:
+  - name: local
+    command: /bin/true
+    idleTimeout: 600000`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Servers[0].IdleTimeout != 600000 {
+		t.Fatalf("expected idleTimeout 600000, got %d", cfg.Servers[0].IdleTimeout)
+	}
+
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Negative",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    idleTimeout: -1`,
+			expectedError: "IdleTimeout",
+		},
+		{
+			name: "Longer than a day",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    idleTimeout: 86400001`,
+			expectedError: "IdleTimeout",
+		},
+		{
+			name: "Remote transport",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: http://localhost:8080/sse
+    idleTimeout: 60000`,
+			expectedError: "idleTimeout requires the stdio transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "invalid.yaml")
+			if err := os.WriteFile(path, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_MaxMemoryMB(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+servers:
+  - name: local
+    command: /bin/true
+    maxMemoryMB: 512`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Servers[0].MaxMemoryMB != 512 {
+		t.Fatalf("expected maxMemoryMB 512, got %d", cfg.Servers[0].MaxMemoryMB)
+	}
+
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Negative",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    maxMemoryMB: -1`,
+			expectedError: "MaxMemoryMB",
+		},
+		{
+			name: "Docker runtime",
+			yamlContent: `
+servers:
+  - name: boxed
+    command: server
+    runtime: docker
+    docker:
+      image: example/server
+    maxMemoryMB: 512`,
+			expectedError: "maxMemoryMB requires the stdio transport and the process runtime",
+		},
+		{
+			name: "Remote transport",
+			yamlContent: `
+servers:
+  - name: remote
+    transport: sse
+    url: http://localhost:8080/sse
+    maxMemoryMB: 512`,
+			expectedError: "maxMemoryMB requires the stdio transport and the process runtime",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "invalid.yaml")
+			if err := os.WriteFile(path, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_HealthCheck(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+servers:
+  - name: default
+    command: /bin/true
+    healthCheck: {}
+  - name: probed
+    command: /bin/true
+    healthCheck:
+      method: tool
+      toolName: status
+      input:
+        verbose: false`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Servers[0].HealthCheck.Method != HealthCheckPing {
+		t.Fatalf("expected default method ping, got %q", cfg.Servers[0].HealthCheck.Method)
+	}
+	hc := cfg.Servers[1].HealthCheck
+	if hc.Method != HealthCheckTool || hc.ToolName != "status" || hc.Input["verbose"] != false {
+		t.Fatalf("unexpected healthCheck: %+v", hc)
+	}
+
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Unknown method",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    healthCheck:
+      method: http`,
+			expectedError: "Method",
+		},
+		{
+			name: "Tool method without toolName",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    healthCheck:
+      method: tool`,
+			expectedError: "healthCheck.toolName is required for the tool method",
+		},
+		{
+			name: "toolName with listTools",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    healthCheck:
+      method: listTools
+      toolName: status`,
+			expectedError: "healthCheck.toolName and healthCheck.input require the tool method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "invalid.yaml")
+			if err := os.WriteFile(path, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Canary(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+servers:
+  - name: default
+    command: /bin/true
+    canary:
+      tool: status
+  - name: checked
+    command: /bin/true
+    canary:
+      tool: calculate-bmi
+      input:
+        weight_kg: 70
+        height_m: 1.75
+      interval: 60000
+      timeout: 5000
+      expect:
+        contains: "22.8"
+        structuredContent:
+          unit: kg/m2`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if interval := cfg.Servers[0].Canary.Interval; interval != DefaultCanaryIntervalMs {
+		t.Fatalf("expected default interval %d, got %d", DefaultCanaryIntervalMs, interval)
+	}
+	canary := cfg.Servers[1].Canary
+	if canary.Tool != "calculate-bmi" || canary.Interval != 60000 || canary.Timeout != 5000 || canary.Input["height_m"] != 1.75 {
+		t.Fatalf("unexpected canary: %+v", canary)
+	}
+	if canary.Expect.Contains != "22.8" || canary.Expect.StructuredContent["unit"] != "kg/m2" {
+		t.Fatalf("unexpected canary expectation: %+v", canary.Expect)
+	}
+
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Missing tool",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    canary:
+      interval: 60000`,
+			expectedError: "Tool",
+		},
+		{
+			name: "Interval too short",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true
+    canary:
+      tool: status
+      interval: 500`,
+			expectedError: "canary.interval must be at least 1000ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "invalid.yaml")
+			if err := os.WriteFile(path, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_HealthCheckJitter(t *testing.T) {
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expected      int
+		expectedError string
+	}{
+		{
+			name: "Default",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: DefaultHealthCheckJitter,
+		},
+		{
+			name: "Disabled",
+			yamlContent: `
+healthCheckJitter: 0
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: 0,
+		},
+		{
+			name: "Too large",
+			yamlContent: `
+healthCheckJitter: 60
+servers:
+  - name: local
+    command: /bin/true`,
+			expectedError: "HealthCheckJitter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{"config.yaml": tt.yamlContent})
+			cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if *cfg.HealthCheckJitter != tt.expected {
+				t.Fatalf("expected healthCheckJitter %d, got %d", tt.expected, *cfg.HealthCheckJitter)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RestartBackoff(t *testing.T) {
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expected      RestartBackoffConfig
+		expectedError string
+	}{
+		{
+			name: "Default",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: RestartBackoffConfig{InitialDelay: 1000, Multiplier: 2, MaxDelay: 4000, MaxAttempts: 3},
+		},
+		{
+			name: "Custom",
+			yamlContent: `
+restartBackoff:
+  initialDelay: 500
+  multiplier: 1.5
+  maxDelay: 60000
+  jitter: 25
+  maxAttempts: 10
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: RestartBackoffConfig{InitialDelay: 500, Multiplier: 1.5, MaxDelay: 60000, Jitter: 25, MaxAttempts: 10},
+		},
+		{
+			name: "Initial delay above the default max delay",
+			yamlContent: `
+restartBackoff:
+  initialDelay: 10000
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: RestartBackoffConfig{InitialDelay: 10000, Multiplier: 2, MaxDelay: 10000, MaxAttempts: 3},
+		},
+		{
+			name: "Max delay below initial delay",
+			yamlContent: `
+restartBackoff:
+  initialDelay: 5000
+  maxDelay: 2000
+servers:
+  - name: local
+    command: /bin/true`,
+			expectedError: "restartBackoff.maxDelay 2000 is less than initialDelay 5000",
+		},
+		{
+			name: "Multiplier below 1",
+			yamlContent: `
+restartBackoff:
+  multiplier: 0.5
+servers:
+  - name: local
+    command: /bin/true`,
+			expectedError: "Multiplier",
+		},
+		{
+			name: "Jitter above 100",
+			yamlContent: `
+restartBackoff:
+  jitter: 150
+servers:
+  - name: local
+    command: /bin/true`,
+			expectedError: "Jitter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{"config.yaml": tt.yamlContent})
+			cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.RestartBackoff != tt.expected {
+				t.Fatalf("expected restartBackoff %+v, got %+v", tt.expected, cfg.RestartBackoff)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ToolSearch(t *testing.T) {
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectedError string
+	}{
+		{
+			name: "Embedder",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+toolSearch:
+  embedder:
+    url: http://embeddings:8080/v1/embeddings
+    model: bge-small-en-v1.5
+    timeout: 2000`,
+		},
+		{
+			name: "Missing url",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+toolSearch:
+  embedder:
+    model: bge-small-en-v1.5`,
+			expectedError: "Embedder.URL",
+		},
+		{
+			name: "Timeout too long",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+toolSearch:
+  embedder:
+    url: http://embeddings:8080/v1/embeddings
+    timeout: 60001`,
+			expectedError: "Embedder.Timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{"config.yaml": tt.yamlContent})
+			cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			embedder := cfg.ToolSearch.Embedder
+			if embedder.URL != "http://embeddings:8080/v1/embeddings" || embedder.Model != "bge-small-en-v1.5" || embedder.Timeout != 2000 {
+				t.Fatalf("unexpected embedder config: %+v", embedder)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_StartupFailurePolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expected      string
+		expectedError string
+	}{
+		{
+			name: "Default",
+			yamlContent: `
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: StartupFailurePolicyAbort,
+		},
+		{
+			name: "Continue",
+			yamlContent: `
+startupFailurePolicy: continue
+servers:
+  - name: local
+    command: /bin/true`,
+			expected: StartupFailurePolicyContinue,
+		},
+		{
+			name: "Unknown",
+			yamlContent: `
+startupFailurePolicy: ignore
+servers:
+  - name: local
+    command: /bin/true`,
+			expectedError: "StartupFailurePolicy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{"config.yaml": tt.yamlContent})
+
+			cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.StartupFailurePolicy != tt.expected {
+				t.Fatalf("expected startupFailurePolicy %q, got %q", tt.expected, cfg.StartupFailurePolicy)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EncryptedEnv(t *testing.T) {
+	const key = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+	// "s3cr3t-api-key" encrypted for key with `age -a`
+	yamlContent := `
+servers:
+  - name: weather
+    command: /bin/true
+    envs:
+      - name: PLAIN
+        value: visible
+      - name: API_KEY
+        value: |
+          -----BEGIN AGE ENCRYPTED FILE-----
+          YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBhamZhaW9lUkl6OXZ5TXJ0
+          NDIzdVhiMHZZUFEzUGpHMWhsaEJHbVN5QXhvCk9OV2d6dk53ZFJ6eTd6bjRSbmEx
+          WWNtWm5nTFF2SFdFbmtzZUdZL21oVGsKLS0tIEtFZUp0K1E3NDFEaktaYjQxWWFv
+          Yk9TbUVGWU02b1pXR0F5VkEwM1huLzgKZK83W8cLYxoQVIC7vwnzImRd6U4xx/gt
+          ZE3IQ8LcGWqdvx5lVRDaS+2RE6GDDw==
+          -----END AGE ENCRYPTED FILE-----`
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": yamlContent,
+		"keys.txt":    "# created: 2026-10-01T00:00:00Z\n" + key + "\n",
+	})
+
+	t.Run("Key in the environment", func(t *testing.T) {
+		t.Setenv(AgeKeyEnvVar, key)
+		cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		envs := cfg.Servers[0].Envs
+		if envs[0].Value != "visible" || envs[1].Value != "s3cr3t-api-key" {
+			t.Fatalf("unexpected envs: %+v", envs)
+		}
+	})
+
+	t.Run("Key file", func(t *testing.T) {
+		t.Setenv(AgeKeyEnvVar+"_FILE", filepath.Join(dir, "keys.txt"))
+		cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if value := cfg.Servers[0].Envs[1].Value; value != "s3cr3t-api-key" {
+			t.Fatalf("expected decrypted value, got %q", value)
+		}
+	})
+
+	t.Run("No key", func(t *testing.T) {
+		_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "env API_KEY") || !strings.Contains(err.Error(), AgeKeyEnvVar) {
+			t.Fatalf("expected missing key error, got %v", err)
+		}
+	})
+
+	t.Run("Malformed key", func(t *testing.T) {
+		t.Setenv(AgeKeyEnvVar, "AGE-SECRET-KEY-1INVALID")
+		_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "malformed age secret key") {
+			t.Fatalf("expected malformed key error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_Shutdown(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"default.yaml": `
+servers:
+  - name: local
+    command: /bin/true`,
+		"custom.yaml": `
+shutdown:
+  httpTimeout: 120000
+  processGracePeriod: 0
+servers:
+  - name: local
+    command: /bin/true`,
+		"invalid.yaml": `
+shutdown:
+  processGracePeriod: -1
+servers:
+  - name: local
+    command: /bin/true`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "default.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if *cfg.Shutdown.HTTPTimeout != DefaultShutdownTimeoutMs || *cfg.Shutdown.ProcessGracePeriod != DefaultShutdownTimeoutMs {
+		t.Fatalf("expected default shutdown timeouts, got %d and %d", *cfg.Shutdown.HTTPTimeout, *cfg.Shutdown.ProcessGracePeriod)
+	}
+
+	cfg, err = LoadConfig(filepath.Join(dir, "custom.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if *cfg.Shutdown.HTTPTimeout != 120000 {
+		t.Fatalf("expected httpTimeout 120000, got %d", *cfg.Shutdown.HTTPTimeout)
+	}
+	if *cfg.Shutdown.ProcessGracePeriod != 0 {
+		t.Fatalf("expected an explicit processGracePeriod of 0 to be kept, got %d", *cfg.Shutdown.ProcessGracePeriod)
+	}
+
+	_, err = LoadConfig(filepath.Join(dir, "invalid.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "ProcessGracePeriod") {
+		t.Fatalf("expected ProcessGracePeriod validation error, got %v", err)
+	}
+}
+
+func TestLoadConfig_Listeners(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+listeners:
+  - address: "[::]:3001"
+    routes: api
+  - address: 127.0.0.1:3002
+    routes: admin
+    apiKeys:
+      - name: ops
+        key: ops-key-0123456789
+  - address: localhost:3003
+servers:
+  - name: local
+    command: /bin/true`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.Listeners) != 3 || cfg.Listeners[1].Routes != ListenerRoutesAdmin {
+		t.Fatalf("unexpected listeners: %+v", cfg.Listeners)
+	}
+	if cfg.Listeners[2].Routes != ListenerRoutesAll {
+		t.Fatalf("expected routes to default to all, got %q", cfg.Listeners[2].Routes)
+	}
+	if len(cfg.Listeners[1].APIKeys) != 1 || cfg.Listeners[1].APIKeys[0].Name != "ops" {
+		t.Fatalf("unexpected listener API keys: %+v", cfg.Listeners[1].APIKeys)
+	}
+
+	tests := []struct {
+		name          string
+		listeners     string
+		expectedError string
+	}{
+		{
+			name: "Missing port",
+			listeners: `
+  - address: 127.0.0.1`,
+			expectedError: "invalid address",
+		},
+		{
+			name: "Port out of range",
+			listeners: `
+  - address: ":70000"`,
+			expectedError: "invalid port",
+		},
+		{
+			name: "Duplicate address",
+			listeners: `
+  - address: ":3001"
+  - address: ":3001"
+    routes: admin`,
+			expectedError: "duplicate address",
+		},
+		{
+			name: "Unknown routes",
+			listeners: `
+  - address: ":3001"
+    routes: internal`,
+			expectedError: "Routes",
+		},
+		{
+			name: "Admin only",
+			listeners: `
+  - address: 127.0.0.1:3002
+    routes: admin`,
+			expectedError: "no listener serves the api routes",
+		},
+		{
+			name: "Listener API key with a profile",
+			listeners: `
+  - address: ":3001"
+    apiKeys:
+      - name: ops
+        key: ops-key-0123456789
+        profile: interactive`,
+			expectedError: "cannot have a profile",
+		},
+		{
+			name: "Duplicate listener API key",
+			listeners: `
+  - address: ":3001"
+    apiKeys:
+      - name: ops
+        key: ops-key-0123456789
+      - name: oncall
+        key: ops-key-0123456789`,
+			expectedError: "reuses the key",
+		},
+		{
+			name: "Short listener API key",
+			listeners: `
+  - address: ":3001"
+    apiKeys:
+      - name: ops
+        key: short`,
+			expectedError: "Key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{
+				"config.yaml": "listeners:" + tt.listeners + `
+servers:
+  - name: local
+    command: /bin/true`,
+			})
+
+			_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Tracing(t *testing.T) {
+	tests := []struct {
+		name          string
+		yamlContent   string
+		expectError   bool
+		expectedRatio float64
+	}{
+		{
+			name: "Default sample ratio",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+tracing:
+  endpoint: http://otel-collector:4318/v1/traces`,
+			expectedRatio: 1,
+		},
+		{
+			name: "Explicit zero ratio with slow threshold",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+tracing:
+  endpoint: http://otel-collector:4318/v1/traces
+  sampleRatio: 0
+  slowThreshold: 2000`,
+			expectedRatio: 0,
+		},
+		{
+			name: "Missing endpoint",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+tracing:
+  sampleRatio: 0.1`,
+			expectError: true,
+		},
+		{
+			name: "Ratio above 1",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+tracing:
+  endpoint: http://otel-collector:4318/v1/traces
+  sampleRatio: 1.5`,
+			expectError: true,
+		},
+		{
+			name: "Negative slow threshold",
+			yamlContent: `
+servers:
+  - name: test-server
+    command: /bin/true
+tracing:
+  endpoint: http://otel-collector:4318/v1/traces
+  slowThreshold: -1`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := t.TempDir() + "/config.yaml"
+			if err := os.WriteFile(tmpFile, []byte(tt.yamlContent), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if *cfg.Tracing.SampleRatio != tt.expectedRatio {
+				t.Fatalf("expected sample ratio %v, got %v", tt.expectedRatio, *cfg.Tracing.SampleRatio)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
+)
+
+// maxSecretFileSize bounds secret files, which hold a single value
+const maxSecretFileSize = 64 * 1024
+
+// readSecretFile reads a value from a file, dropping the trailing newline that editors and
+// `kubectl create secret --from-file` commonly leave
+func readSecretFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSecretFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxSecretFileSize {
+		return "", fmt.Errorf("%s is larger than %d bytes", path, maxSecretFileSize)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// Resolve returns the value of the variable, reading it from its file or fetching it from a
+// secrets manager through store when valueFrom is set. Files are read on every call so that a
+// restarted server picks up rotated secrets; fetched secrets are cached by store and updated by
+// the refresh configured in secrets.refreshInterval.
+func (e EnvVar) Resolve(store *secrets.Store) (string, error) {
+	if e.ValueFrom == nil {
+		return e.Value, nil
+	}
+	var value string
+	var err error
+	if provider, ref, ok := e.ValueFrom.secret(); ok {
+		if store == nil {
+			return "", fmt.Errorf("env %s: no secrets store to fetch the %s secret from", e.Name, provider)
+		}
+		value, err = store.Get(provider, ref)
+	} else {
+		value, err = readSecretFile(e.ValueFrom.File)
+	}
+	if err != nil {
+		return "", fmt.Errorf("env %s: %w", e.Name, err)
+	}
+	return value, nil
+}
+
+// secret returns the provider and reference of a value fetched from a secrets manager
+func (s *EnvVarSource) secret() (provider, ref string, ok bool) {
+	switch {
+	case s.Vault != "":
+		return secrets.Vault, s.Vault, true
+	case s.AWSSecretsManager != "":
+		return secrets.AWSSecretsManager, s.AWSSecretsManager, true
+	}
+	return "", "", false
+}
+
+// FetchSecrets fetches the secrets of every server's env values into store, so that a missing
+// secret fails startup rather than the spawn of the server
+func FetchSecrets(cfg *Config, store *secrets.Store) error {
+	for _, server := range cfg.Servers {
+		for _, env := range server.Envs {
+			if env.ValueFrom == nil || env.ValueFrom.File != "" {
+				continue
+			}
+			if _, err := env.Resolve(store); err != nil {
+				return fmt.Errorf("server %s: %w", server.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// secretFileLookup looks variables up in the environment and, for unset NAME, falls back to the
+// file named by NAME_FILE, the convention of Docker and Kubernetes secrets
+type secretFileLookup struct {
+	err error // the first file that could not be read
+}
+
+func (l *secretFileLookup) lookup(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", false
+	}
+	value, err := readSecretFile(path)
+	if err != nil {
+		l.err = errors.Join(l.err, fmt.Errorf("%s_FILE: %w", name, err))
+		return "", true
+	}
+	return value, true
+}
@@ -0,0 +1,157 @@
+// Package events is the gateway's internal event bus. The MCP client manager publishes what happens to
+// servers and tool calls, and consumers such as the event stream of the HTTP API subscribe to it instead
+// of hooking into the client manager.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Type classifies events
+type Type string
+
+const (
+	ServerStarted     Type = "server_started"      // the server connected and serves calls
+	HealthCheckFailed Type = "health_check_failed" // a health check of the server failed
+	CanaryFailed      Type = "canary_failed"       // a canary call of the server failed or returned something unexpected
+	StatusChanged     Type = "status_changed"      // the server changed its status
+	RestartAttempted  Type = "restart_attempted"   // a restart started waiting for its backoff
+	RestartExhausted  Type = "restart_exhausted"   // the server used up its restart attempts and was quarantined
+	ToolCallStarted   Type = "tool_call_started"   // a call was sent to the server
+	ToolCallFinished  Type = "tool_call_finished"  // the server answered a call, or the call failed
+)
+
+// LifecycleTypes are the types of the events about servers rather than calls
+var LifecycleTypes = []Type{ServerStarted, HealthCheckFailed, CanaryFailed, StatusChanged, RestartAttempted, RestartExhausted}
+
+// AllTypes are the types of all events
+var AllTypes = append(slices.Clone(LifecycleTypes), ToolCallStarted, ToolCallFinished)
+
+// Event describes something that happened to a server. Which fields are set depends on Type.
+type Event struct {
+	Type        Type      `json:"type"`
+	Server      string    `json:"server"`
+	At          time.Time `json:"at"`
+	From        string    `json:"from,omitempty"`        // status_changed
+	To          string    `json:"to,omitempty"`          // status_changed
+	Failures    int       `json:"failures,omitempty"`    // health_check_failed, canary_failed: consecutive failures
+	Attempt     int       `json:"attempt,omitempty"`     // restart_attempted, restart_exhausted
+	MaxAttempts int       `json:"maxAttempts,omitempty"` // restart_attempted, restart_exhausted
+	BackoffMs   int64     `json:"backoffMs,omitempty"`   // restart_attempted
+	Reason      string    `json:"reason,omitempty"`      // restart_attempted, restart_exhausted
+	Tool        string    `json:"tool,omitempty"`        // canary_failed, tool_call_started, tool_call_finished
+	DurationMs  int64     `json:"durationMs,omitempty"`  // tool_call_finished
+	Failed      bool      `json:"failed,omitempty"`      // tool_call_finished: the call errored or the tool reported an error
+	Error       string    `json:"error,omitempty"`       // health_check_failed, canary_failed, tool_call_finished
+}
+
+// Subscriber consumes the events published on a Bus. HandleEvent runs synchronously on the publishing
+// goroutine, e.g. one serving a tool call, so it must not block.
+type Subscriber interface {
+	HandleEvent(Event)
+}
+
+// SubscriberFunc adapts a function to a Subscriber
+type SubscriberFunc func(Event)
+
+func (f SubscriberFunc) HandleEvent(ev Event) {
+	f(ev)
+}
+
+type subscription struct {
+	subscriber Subscriber
+	types      []Type // nil for all types
+}
+
+// Bus delivers published events to its subscribers
+type Bus struct {
+	mu   sync.Mutex
+	subs []*subscription // copied on write, so that Publish iterates its snapshot without the lock
+}
+
+// NewBus creates a Bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Publish delivers an event to the subscribers of its type, stamping it with the current time if it has none
+func (b *Bus) Publish(ev Event) {
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	b.mu.Lock()
+	subs := b.subs
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		if s.types == nil || slices.Contains(s.types, ev.Type) {
+			s.subscriber.HandleEvent(ev)
+		}
+	}
+}
+
+// Subscribe delivers the events of the given types, or of all types if none are given, to s until unsubscribe
+// is called. Unsubscribing more than once is harmless.
+func (b *Bus) Subscribe(s Subscriber, types ...Type) (unsubscribe func()) {
+	sub := &subscription{subscriber: s}
+	if len(types) > 0 {
+		sub.types = slices.Clone(types)
+	}
+	b.mu.Lock()
+	b.subs = append(slices.Clip(b.subs), sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subs = slices.DeleteFunc(slices.Clone(b.subs), func(other *subscription) bool { return other == sub })
+	}
+}
+
+// Watch returns a channel receiving the events of the given types, or of all types if none are given.
+// The channel is closed by unwatch, which must be called, or when the reader falls more than buffer
+// events behind, so that a slow reader never blocks publishers.
+func (b *Bus) Watch(buffer int, types ...Type) (events <-chan Event, unwatch func()) {
+	w := &watcher{ch: make(chan Event, buffer)}
+	// Held until unsubscribe is set, in case the first event already overflows the buffer
+	w.mu.Lock()
+	w.unsubscribe = b.Subscribe(w, types...)
+	w.mu.Unlock()
+	return w.ch, w.close
+}
+
+// watcher is the subscriber behind Watch
+type watcher struct {
+	mu          sync.Mutex
+	ch          chan Event
+	closed      bool
+	unsubscribe func()
+}
+
+func (w *watcher) HandleEvent(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.ch <- ev:
+	default:
+		// A watcher that missed events learns it from the closed channel
+		w.closed = true
+		close(w.ch)
+		w.unsubscribe()
+	}
+}
+
+func (w *watcher) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.ch)
+	}
+	w.mu.Unlock()
+	w.unsubscribe()
+}
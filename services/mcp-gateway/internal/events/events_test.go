@@ -0,0 +1,45 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_SubscribeFiltersTypes(t *testing.T) {
+	bus := NewBus()
+	var all, restarts []Event
+	unsubscribeAll := bus.Subscribe(SubscriberFunc(func(ev Event) { all = append(all, ev) }))
+	unsubscribeRestarts := bus.Subscribe(SubscriberFunc(func(ev Event) { restarts = append(restarts, ev) }), RestartAttempted, RestartExhausted)
+
+	bus.Publish(Event{Type: StatusChanged, Server: "weather"})
+	bus.Publish(Event{Type: RestartAttempted, Server: "weather", Attempt: 1})
+	unsubscribeRestarts()
+	unsubscribeRestarts()
+	bus.Publish(Event{Type: RestartExhausted, Server: "weather"})
+	unsubscribeAll()
+	bus.Publish(Event{Type: StatusChanged, Server: "weather"})
+
+	require.Len(t, all, 3)
+	assert.False(t, all[0].At.IsZero(), "Publish stamps the time")
+	require.Len(t, restarts, 1)
+	assert.Equal(t, 1, restarts[0].Attempt)
+}
+
+func TestBus_WatchClosesOnSlowReader(t *testing.T) {
+	bus := NewBus()
+	ch, unwatch := bus.Watch(1)
+
+	bus.Publish(Event{Type: StatusChanged, Server: "weather"})
+	bus.Publish(Event{Type: StatusChanged, Server: "weather"})
+
+	ev, ok := <-ch
+	assert.True(t, ok)
+	assert.Equal(t, "weather", ev.Server)
+	_, ok = <-ch
+	assert.False(t, ok, "the channel is closed once the reader falls behind")
+	// The dropped watcher is unsubscribed, and unwatch is still safe to call
+	bus.Publish(Event{Type: StatusChanged, Server: "weather"})
+	unwatch()
+}
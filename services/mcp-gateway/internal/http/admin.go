@@ -0,0 +1,173 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+const (
+	// bulkActionConcurrency caps how many servers a bulk action touches at once
+	bulkActionConcurrency = 8
+	// bulkActionTimeout bounds the whole bulk action, independent of the client connection
+	bulkActionTimeout = 2 * time.Minute
+)
+
+// BulkActionResult is the outcome of a bulk action for a single server
+type BulkActionResult struct {
+	Server         string           `json:"server"`
+	PreviousStatus mcp.ServerStatus `json:"previousStatus"`
+	OK             bool             `json:"ok"`
+	Error          string           `json:"error,omitempty"`
+}
+
+// BulkActionSummary aggregates the per-server results of a bulk action
+type BulkActionSummary struct {
+	Action    string             `json:"action"`
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkActionResult `json:"results"`
+}
+
+// RestartAll force-restarts every server matching the optional ?status= filter
+func (h *Handler) RestartAll(c *gin.Context) {
+	h.runBulkAction(c, "restart", h.clientManager.ForceRestartServer)
+}
+
+// StopAll stops every server matching the optional ?status= filter
+func (h *Handler) StopAll(c *gin.Context) {
+	h.runBulkAction(c, "stop", func(_ context.Context, server string) error {
+		return h.clientManager.StopServer(server)
+	})
+}
+
+// RefreshToolsAll re-fetches the tool list of every server matching the optional ?status= filter
+func (h *Handler) RefreshToolsAll(c *gin.Context) {
+	h.runBulkAction(c, "refresh-tools", h.clientManager.RefreshTools)
+}
+
+// runBulkAction applies action to all servers selected by the ?status= query
+// (comma-separated, e.g. "crashed,unavailable") and responds with a summary.
+func (h *Handler) runBulkAction(c *gin.Context, action string, fn func(ctx context.Context, server string) error) {
+	filter, err := parseStatusFilter(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    mcpErrors.ErrCodeValidation,
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+
+	statuses := h.processManager.GetAllStatuses()
+	targets := make([]string, 0, len(statuses))
+	for name, status := range statuses {
+		if len(filter) == 0 || slices.Contains(filter, status) {
+			targets = append(targets, name)
+		}
+	}
+	slices.Sort(targets)
+
+	// Detach from the client connection so an impatient caller cannot leave servers half-restarted
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), bulkActionTimeout)
+	defer cancel()
+
+	results := make([]BulkActionResult, len(targets))
+	sem := make(chan struct{}, bulkActionConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := BulkActionResult{Server: name, PreviousStatus: statuses[name], OK: true}
+			if err := fn(ctx, name); err != nil {
+				result.OK = false
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	summary := BulkActionSummary{
+		Action:  action,
+		Total:   len(results),
+		Results: results,
+	}
+	for _, r := range results {
+		if r.OK {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result":  summary,
+	})
+}
+
+// ResetRestarts clears the restart attempts of one server, so that a quarantined or crashed server is
+// restarted again once the cause of its crashes has been fixed, without restarting the gateway
+func (h *Handler) ResetRestarts(c *gin.Context) {
+	server := c.Param("server")
+	previousStatus := h.processManager.GetStatus(server)
+	previousAttempts := h.processManager.GetRestartAttempts(server)
+
+	restarting, err := h.clientManager.ResetRestarts(c.Request.Context(), server)
+	if err != nil {
+		status, code := http.StatusInternalServerError, mcpErrors.ErrCodeInternal
+		if gatewayErr, ok := mcpErrors.As(err); ok {
+			status, code = gatewayErr.HTTPStatus, gatewayErr.Code
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    code,
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result": gin.H{
+			"server":           server,
+			"previousStatus":   previousStatus,
+			"previousAttempts": previousAttempts,
+			"restarting":       restarting,
+		},
+	})
+}
+
+// parseStatusFilter parses a comma-separated list of server statuses
+func parseStatusFilter(raw string) ([]mcp.ServerStatus, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	filter := make([]mcp.ServerStatus, 0, len(parts))
+	for _, part := range parts {
+		status, err := mcp.ParseServerStatus(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, status)
+	}
+	return filter, nil
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandler_StopAll_StatusFilter verifies that only servers matching ?status= are targeted.
+func TestHandler_StopAll_StatusFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pm := mcp.NewProcessManager(30000, "never")
+	pm.SetStatus("server-1", mcp.StatusAvailable)
+	pm.SetStatus("server-2", mcp.StatusCrashed)
+	pm.SetStatus("server-3", mcp.StatusCrashed)
+
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/servers/stop-all?status=crashed", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Success bool              `json:"success"`
+		Result  BulkActionSummary `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Success)
+	assert.Equal(t, "stop", resp.Result.Action)
+	assert.Equal(t, 2, resp.Result.Total)
+	require.Len(t, resp.Result.Results, 2)
+	assert.Equal(t, "server-2", resp.Result.Results[0].Server)
+	assert.Equal(t, "server-3", resp.Result.Results[1].Server)
+	assert.Equal(t, mcp.StatusCrashed, resp.Result.Results[0].PreviousStatus)
+
+	// The servers have no configuration, so every action fails and is reported individually
+	assert.Equal(t, 2, resp.Result.Failed)
+	assert.False(t, resp.Result.Results[0].OK)
+	assert.Equal(t, "server not found", resp.Result.Results[0].Error)
+}
+
+// TestHandler_RestartAll_NoServers verifies an empty summary when nothing matches.
+func TestHandler_RestartAll_NoServers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pm := mcp.NewProcessManager(30000, "never")
+	pm.SetStatus("server-1", mcp.StatusAvailable)
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/servers/restart-all?status=crashed,stopped", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Result BulkActionSummary `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Result.Total)
+	assert.Empty(t, resp.Result.Results)
+}
+
+// TestHandler_RefreshToolsAll_InvalidStatus verifies that unknown statuses are rejected.
+func TestHandler_RefreshToolsAll_InvalidStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/servers/refresh-tools-all?status=exploded", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp["success"].(bool))
+	assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+}
+
+// TestHandler_ResetRestarts verifies that a quarantined server gets a fresh set of restart attempts.
+func TestHandler_ResetRestarts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pm := mcp.NewProcessManager(30000, "on-failure")
+	cm := mcp.NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	router := SetupRouter(NewHandler(cm, pm))
+
+	// Out of restarts after repeated crashes
+	for range 3 {
+		pm.IncrementRestartAttempts("embedded")
+	}
+	require.NoError(t, pm.Transition("embedded", mcp.StatusCrashed))
+	require.NoError(t, pm.Transition("embedded", mcp.StatusQuarantined))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/servers/embedded/reset-restarts", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Result struct {
+			PreviousStatus   mcp.ServerStatus `json:"previousStatus"`
+			PreviousAttempts int              `json:"previousAttempts"`
+			Restarting       bool             `json:"restarting"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, mcp.StatusQuarantined, resp.Result.PreviousStatus)
+	assert.Equal(t, 3, resp.Result.PreviousAttempts)
+	assert.True(t, resp.Result.Restarting)
+	assert.Eventually(t, func() bool { return pm.GetStatus("embedded") == mcp.StatusAvailable }, 5*time.Second, 10*time.Millisecond)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/servers/missing/reset-restarts", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "SERVER_NOT_FOUND")
+}
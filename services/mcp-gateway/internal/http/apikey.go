@@ -0,0 +1,220 @@
+package http
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// headerAPIKey carries the API key; "Authorization: Bearer <key>" is accepted as well
+	headerAPIKey = "X-API-Key"
+	// callerContextKey stores the authenticated *caller in the Gin context
+	callerContextKey = "caller"
+)
+
+// caller is an authenticated API key together with the limits of its profile
+type caller struct {
+	name    string
+	profile config.ProfileConfig
+
+	mu                 sync.Mutex
+	limiter            *rate.Limiter // nil when the profile sets no request rate
+	maxConcurrentCalls int           // 0 means unlimited
+	inFlight           int
+}
+
+// profileLimits are the limits of a profile, which can be changed at runtime
+type profileLimits struct {
+	RequestsPerMinute  int `json:"requestsPerMinute"`  // 0 means unlimited
+	Burst              int `json:"burst"`              // 0 means requestsPerMinute
+	MaxConcurrentCalls int `json:"maxConcurrentCalls"` // 0 means unlimited
+}
+
+func limitsOf(p config.ProfileConfig) profileLimits {
+	return profileLimits{RequestsPerMinute: p.RequestsPerMinute, Burst: p.Burst, MaxConcurrentCalls: p.MaxConcurrentCalls}
+}
+
+// setLimits applies limits to the caller. The request rate starts with a full burst;
+// calls already in flight keep their slots.
+func (c *caller) setLimits(l profileLimits) {
+	var limiter *rate.Limiter
+	if l.RequestsPerMinute > 0 {
+		burst := l.Burst
+		if burst == 0 {
+			burst = l.RequestsPerMinute
+		}
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(l.RequestsPerMinute)), burst)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limiter = limiter
+	c.maxConcurrentCalls = l.MaxConcurrentCalls
+}
+
+// allow reports whether the request rate admits another call
+func (c *caller) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limiter == nil || c.limiter.Allow()
+}
+
+// acquire takes a call slot, reporting false when the concurrency limit is reached
+func (c *caller) acquire() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxConcurrentCalls > 0 && c.inFlight >= c.maxConcurrentCalls {
+		return false
+	}
+	c.inFlight++
+	return true
+}
+
+// release frees a slot taken by acquire
+func (c *caller) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+}
+
+// apiKeyAuthenticator resolves API keys to callers.
+// Keys are looked up by their SHA-256 digest so that comparisons do not leak key prefixes through timing.
+type apiKeyAuthenticator struct {
+	callers map[[sha256.Size]byte]*caller
+
+	mu        sync.Mutex
+	limits    map[string]profileLimits // limits in effect per profile
+	byProfile map[string][]*caller
+}
+
+// newAPIKeyAuthenticator builds the key table from configuration, which has already been validated
+func newAPIKeyAuthenticator(keys []config.APIKeyConfig, profiles []config.ProfileConfig) *apiKeyAuthenticator {
+	byName := make(map[string]config.ProfileConfig, len(profiles))
+	for _, p := range profiles {
+		byName[p.Name] = p
+	}
+
+	a := &apiKeyAuthenticator{
+		callers:   make(map[[sha256.Size]byte]*caller, len(keys)),
+		limits:    make(map[string]profileLimits, len(profiles)),
+		byProfile: make(map[string][]*caller, len(profiles)),
+	}
+	for _, p := range profiles {
+		a.limits[p.Name] = limitsOf(p)
+	}
+	for _, k := range keys {
+		profile := byName[k.Profile]
+		c := &caller{name: k.Name, profile: profile}
+		c.setLimits(limitsOf(profile))
+		a.callers[sha256.Sum256([]byte(k.Key))] = c
+		if k.Profile != "" {
+			a.byProfile[k.Profile] = append(a.byProfile[k.Profile], c)
+		}
+	}
+	return a
+}
+
+// profileLimits returns the limits in effect for a profile
+func (a *apiKeyAuthenticator) profileLimits(profile string) (profileLimits, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	l, ok := a.limits[profile]
+	return l, ok
+}
+
+// setProfileLimits changes the limits of every API key assigned to a profile
+func (a *apiKeyAuthenticator) setProfileLimits(profile string, l profileLimits) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.limits[profile] = l
+	for _, c := range a.byProfile[profile] {
+		c.setLimits(l)
+	}
+}
+
+// lookup returns the caller for a presented key
+func (a *apiKeyAuthenticator) lookup(key string) (*caller, bool) {
+	c, ok := a.callers[sha256.Sum256([]byte(key))]
+	return c, ok
+}
+
+// presentedAPIKey extracts the API key from X-API-Key or a bearer Authorization header
+func presentedAPIKey(r *http.Request) string {
+	if key := r.Header.Get(headerAPIKey); key != "" {
+		return key
+	}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// apiKeyMiddleware rejects requests without a valid API key and records the caller in the context
+func apiKeyMiddleware(a *apiKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller, ok := a.lookup(presentedAPIKey(c.Request))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    mcpErrors.ErrCodeUnauthorized,
+					"message": "A valid API key is required",
+				},
+			})
+			return
+		}
+		c.Set(callerContextKey, caller)
+		c.Next()
+	}
+}
+
+// callQuotaMiddleware enforces the request rate and concurrency limits of the caller's profile
+func callQuotaMiddleware(c *gin.Context) {
+	caller := callerFrom(c)
+	if caller == nil {
+		c.Next()
+		return
+	}
+
+	if !caller.allow() {
+		abortQuotaExceeded(c, "Request rate limit exceeded for API key "+caller.name)
+		return
+	}
+
+	if !caller.acquire() {
+		abortQuotaExceeded(c, "Too many concurrent calls for API key "+caller.name)
+		return
+	}
+	defer caller.release()
+
+	c.Next()
+}
+
+// abortQuotaExceeded responds with 429 QUOTA_EXCEEDED
+func abortQuotaExceeded(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    mcpErrors.ErrCodeQuotaExceeded,
+			"message": message,
+		},
+	})
+}
+
+// callerFrom returns the authenticated caller, or nil when API keys are not configured
+func callerFrom(c *gin.Context) *caller {
+	v, ok := c.Get(callerContextKey)
+	if !ok {
+		return nil
+	}
+	caller, _ := v.(*caller)
+	return caller
+}
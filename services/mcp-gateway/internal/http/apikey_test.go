@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAPIKeys = []config.APIKeyConfig{
+	{Name: "dashboard", Key: "dashboard-key-0123456789", Profile: "interactive"},
+	{Name: "nightly", Key: "nightly-key-0123456789", Profile: "batch"},
+}
+
+var testProfiles = []config.ProfileConfig{
+	{Name: "interactive", Timeout: 5000},
+	{Name: "batch", Priority: config.PriorityLow, RequestsPerMinute: 1},
+}
+
+func newAPIKeyTestRouter(opts ...RouterOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	return SetupRouter(NewHandler(cm, pm), append([]RouterOption{WithAPIKeys(testAPIKeys, testProfiles)}, opts...)...)
+}
+
+func TestAPIKey_Required(t *testing.T) {
+	router := newAPIKeyTestRouter()
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{name: "missing key", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", header: "X-API-Key", value: "not-a-configured-key", wantStatus: http.StatusUnauthorized},
+		{name: "X-API-Key", header: "X-API-Key", value: "dashboard-key-0123456789", wantStatus: http.StatusOK},
+		{name: "bearer token", header: "Authorization", value: "Bearer dashboard-key-0123456789", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				var resp map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "UNAUTHORIZED", resp["error"].(map[string]any)["code"])
+			}
+		})
+	}
+}
+
+func TestAPIKey_HealthIsPublic(t *testing.T) {
+	router := newAPIKeyTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAPIKey_RateLimit(t *testing.T) {
+	router := newAPIKeyTestRouter()
+	body, _ := json.Marshal(map[string]any{"server": "missing", "toolName": "tool", "input": map[string]any{}})
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "nightly-key-0123456789")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The first call passes the quota and reaches the handler
+	assert.Equal(t, http.StatusNotFound, call().Code)
+
+	w := call()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "QUOTA_EXCEEDED", resp["error"].(map[string]any)["code"])
+}
+
+func TestCallQuotaMiddleware_ConcurrencyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := &caller{name: "batch", maxConcurrentCalls: 1, inFlight: 1} // one call already in flight
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/mcp/call", nil)
+	ctx.Set(callerContextKey, c)
+
+	callQuotaMiddleware(ctx)
+
+	assert.True(t, ctx.IsAborted())
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Once the slot frees up the call is admitted and the slot is returned afterwards
+	c.release()
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/mcp/call", nil)
+	ctx.Set(callerContextKey, c)
+
+	callQuotaMiddleware(ctx)
+
+	assert.False(t, ctx.IsAborted())
+	assert.Zero(t, c.inFlight)
+}
+
+func TestAPIKey_CallerIsPassedToAuthorizer(t *testing.T) {
+	var seen *authz.Request
+	router := newAPIKeyTestRouter(WithAuthorizer(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+		seen = req
+		return authz.Allow, nil
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+	req.Header.Set("X-API-Key", "dashboard-key-0123456789")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "dashboard", seen.Caller)
+}
+
+func TestWithAPIKeys_NoKeysLeavesRoutesOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm), WithAPIKeys(nil, testProfiles))
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// headerForwarder is implemented by authorizers that only need a subset of request headers
+type headerForwarder interface {
+	ForwardedHeaders() []string
+}
+
+// authorizationMiddleware asks the authorizer for a verdict before the request reaches its handler
+func authorizationMiddleware(a authz.Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := buildAuthzRequest(c, a)
+
+		decision, err := a.Authorize(c.Request.Context(), req)
+		if err != nil {
+			slog.Error("Authorization check failed", "path", req.Path, "error", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    mcpErrors.ErrCodeAuthorization,
+					"message": "Authorization service unavailable",
+				},
+			})
+			return
+		}
+
+		if !decision.Allowed {
+			code := mcpErrors.ErrCodeForbidden
+			if decision.Status == http.StatusUnauthorized {
+				code = mcpErrors.ErrCodeUnauthorized
+			}
+			slog.Info("Request denied by authorizer",
+				"path", req.Path,
+				"server", req.Server,
+				"tool", req.Tool,
+				"status", decision.Status,
+			)
+			c.AbortWithStatusJSON(decision.Status, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    code,
+					"message": decision.Reason,
+				},
+			})
+			return
+		}
+
+		if decision.Input != nil {
+			if err := rewriteToolInput(c, decision.Input); err != nil {
+				slog.Error("Failed to apply authorizer input transform", "path", req.Path, "error", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    mcpErrors.ErrCodeInternal,
+						"message": "Failed to apply authorization policy",
+					},
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// rewriteToolInput replaces the "input" field of a tool call body, leaving other fields untouched
+func rewriteToolInput(c *gin.Context, input map[string]any) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	fields["input"] = raw
+	body, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	return nil
+}
+
+// buildAuthzRequest extracts the authorization context from the request.
+// For tool calls, the body is peeked to obtain server and tool names and then restored.
+func buildAuthzRequest(c *gin.Context, a authz.Authorizer) *authz.Request {
+	req := &authz.Request{
+		Method:   c.Request.Method,
+		Path:     routePath(c),
+		ClientIP: c.ClientIP(),
+		Headers:  make(map[string]string),
+	}
+	if caller := callerFrom(c); caller != nil {
+		req.Caller = caller.name
+	}
+
+	if hf, ok := a.(headerForwarder); ok {
+		for _, name := range hf.ForwardedHeaders() {
+			if v := c.GetHeader(name); v != "" {
+				req.Headers[name] = v
+			}
+		}
+	} else {
+		for name := range c.Request.Header {
+			req.Headers[name] = c.Request.Header.Get(name)
+		}
+	}
+
+	if c.Request.Method == http.MethodPost && c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			// Replay the read error (e.g. body too large) to the handler
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		} else {
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			var peek CallToolRequest
+			if json.Unmarshal(body, &peek) == nil {
+				req.Server = peek.Server
+				req.Tool = peek.ToolName
+				req.Input, _ = peek.Input.(map[string]any)
+			}
+		}
+	}
+
+	return req
+}
+
+// errReader is an io.Reader that always fails with err
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorizerFunc adapts a function to the authz.Authorizer interface.
+type authorizerFunc func(ctx context.Context, req *authz.Request) (authz.Decision, error)
+
+func (f authorizerFunc) Authorize(ctx context.Context, req *authz.Request) (authz.Decision, error) {
+	return f(ctx, req)
+}
+
+func newAuthzTestRouter(a authz.Authorizer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	return SetupRouter(NewHandler(cm, pm), WithAuthorizer(a))
+}
+
+// TestAuthorization_DeniedToolCall verifies denials use the standard error envelope.
+func TestAuthorization_DeniedToolCall(t *testing.T) {
+	var seen *authz.Request
+	router := newAuthzTestRouter(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+		seen = req
+		return authz.Deny(http.StatusForbidden, "tool not permitted"), nil
+	}))
+
+	body, _ := json.Marshal(map[string]any{"server": "weather", "toolName": "forecast", "input": map[string]any{}})
+	req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp["success"].(bool))
+	assert.Equal(t, "FORBIDDEN", resp["error"].(map[string]any)["code"])
+	assert.Equal(t, "tool not permitted", resp["error"].(map[string]any)["message"])
+
+	require.NotNil(t, seen)
+	assert.Equal(t, "weather", seen.Server)
+	assert.Equal(t, "forecast", seen.Tool)
+	assert.Equal(t, "/mcp/call", seen.Path)
+}
+
+// TestAuthorization_AllowedRequestBodyIsPreserved verifies the handler still sees the full body.
+func TestAuthorization_AllowedRequestBodyIsPreserved(t *testing.T) {
+	router := newAuthzTestRouter(authorizerFunc(func(context.Context, *authz.Request) (authz.Decision, error) {
+		return authz.Allow, nil
+	}))
+
+	body, _ := json.Marshal(map[string]any{"server": "missing-server", "toolName": "tool", "input": map[string]any{}})
+	req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// The handler parsed the body and reached the client manager
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "SERVER_NOT_FOUND", resp["error"].(map[string]any)["code"])
+}
+
+// TestAuthorization_Unauthorized verifies that 401 verdicts map to UNAUTHORIZED.
+func TestAuthorization_Unauthorized(t *testing.T) {
+	router := newAuthzTestRouter(authorizerFunc(func(context.Context, *authz.Request) (authz.Decision, error) {
+		return authz.Deny(http.StatusUnauthorized, "missing credentials"), nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "UNAUTHORIZED", resp["error"].(map[string]any)["code"])
+}
+
+// TestAuthorization_ServiceError verifies that authorizer failures return 503.
+func TestAuthorization_ServiceError(t *testing.T) {
+	router := newAuthzTestRouter(authorizerFunc(func(context.Context, *authz.Request) (authz.Decision, error) {
+		return authz.Decision{}, errors.New("connection refused")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/servers/stop-all", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "AUTHORIZATION_ERROR", resp["error"].(map[string]any)["code"])
+}
+
+// TestAuthorization_HealthIsPublic verifies that /health bypasses the authorizer.
+func TestAuthorization_HealthIsPublic(t *testing.T) {
+	router := newAuthzTestRouter(authorizerFunc(func(context.Context, *authz.Request) (authz.Decision, error) {
+		return authz.Deny(http.StatusForbidden, "denied"), nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAuthorization_InputTransform verifies that an authorizer can rewrite the tool input.
+func TestAuthorization_InputTransform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var seen *authz.Request
+	r := gin.New()
+	r.Use(authorizationMiddleware(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+		seen = req
+		return authz.Decision{Allowed: true, Input: map[string]any{"city": "Tokyo", "units": "metric"}}, nil
+	})))
+	var forwarded CallToolRequest
+	r.POST("/mcp/call", func(c *gin.Context) {
+		require.NoError(t, c.ShouldBindJSON(&forwarded))
+		c.Status(http.StatusOK)
+	})
+
+	body, _ := json.Marshal(map[string]any{"server": "weather", "toolName": "forecast", "input": map[string]any{"city": "Tokyo"}})
+	req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, map[string]any{"city": "Tokyo"}, seen.Input)
+	assert.Equal(t, "weather", forwarded.Server)
+	assert.Equal(t, "forecast", forwarded.ToolName)
+	assert.Equal(t, map[string]any{"city": "Tokyo", "units": "metric"}, forwarded.Input)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// benchRouter serves an in-process "calc" server whose "add" tool answers with a short text, quietly
+func benchRouter(b *testing.B) *gin.Engine {
+	b.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.Cleanup(func() { slog.SetDefault(logger) })
+
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "calc", Version: "test"}, nil)
+	server.AddTool(&mcpSDK.Tool{Name: "add", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+			return &mcpSDK.CallToolResult{Content: []mcpSDK.Content{&mcpSDK.TextContent{Text: "3"}}}, nil
+		})
+	if err := cm.RegisterInProcess("calc", server); err != nil {
+		b.Fatal(err)
+	}
+	if err := cm.Initialize(context.Background(), nil); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = cm.Close() })
+	return SetupRouter(NewHandler(cm, pm), WithStructuredLogging())
+}
+
+// benchmarkCall posts body to /mcp/call and reports the median latency as p50-ns besides the mean
+func benchmarkCall(b *testing.B, body string, status int) {
+	router := benchRouter(b)
+	data := []byte(body)
+	latencies := make([]time.Duration, 0, b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		latencies = append(latencies, time.Since(start))
+		if w.Code != status {
+			b.Fatalf("status %d: %s", w.Code, w.Body.String())
+		}
+	}
+	b.StopTimer()
+	slices.Sort(latencies)
+	b.ReportMetric(float64(latencies[len(latencies)/2].Nanoseconds()), "p50-ns")
+}
+
+func BenchmarkCallTool(b *testing.B) {
+	benchmarkCall(b, `{"server":"calc","toolName":"add","input":{"a":1,"b":2}}`, http.StatusOK)
+}
+
+func BenchmarkCallTool_InvalidRequest(b *testing.B) {
+	benchmarkCall(b, `{"server":"calc","toolName":"add","input":[]}`, http.StatusBadRequest)
+}
+
+func BenchmarkCallTool_UnknownServer(b *testing.B) {
+	benchmarkCall(b, `{"server":"missing","toolName":"add","input":{}}`, http.StatusNotFound)
+}
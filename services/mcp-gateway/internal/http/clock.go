@@ -0,0 +1,37 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+)
+
+// AdvanceClockRequest is the body of POST /admin/clock/advance
+type AdvanceClockRequest struct {
+	Duration int `json:"duration" binding:"required,min=1,max=604800000"` // ms
+}
+
+// fakeClockEndpoint moves the fake clock of a gateway in deterministic mode, so that integration tests
+// decide when health checks and restarts happen
+type fakeClockEndpoint struct {
+	clock *clock.Fake
+}
+
+// Advance moves the clock forward, firing the health checks and restarts that become due
+func (e *fakeClockEndpoint) Advance(c *gin.Context) {
+	var req AdvanceClockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+
+	previous := e.clock.Now()
+	e.clock.Advance(time.Duration(req.Duration) * time.Millisecond)
+	now := e.clock.Now()
+	slog.Info("Fake clock advanced", "durationMs", req.Duration, "now", now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "result": gin.H{"previous": previous, "now": now}})
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postJSONRequest(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdvanceClock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	pm := mcp.NewProcessManager(30000, "never")
+	pm.SetClock(fake)
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm), WithFakeClock(fake))
+
+	w := postJSONRequest(router, "/admin/clock/advance", `{"duration":90000}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, start.Add(90*time.Second), fake.Now())
+	assert.Contains(t, w.Body.String(), `"now":"2000-01-01T00:01:30Z"`)
+
+	for _, body := range []string{`{}`, `{"duration":0}`, `{"duration":-5}`, `not json`} {
+		w = postJSONRequest(router, "/admin/clock/advance", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Contains(t, w.Body.String(), "VALIDATION_ERROR")
+	}
+	assert.Equal(t, start.Add(90*time.Second), fake.Now())
+}
+
+func TestAdvanceClock_NotEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm))
+
+	w := postJSONRequest(router, "/admin/clock/advance", `{"duration":1000}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
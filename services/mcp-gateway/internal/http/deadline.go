@@ -0,0 +1,75 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// headerRequestDeadline carries an absolute RFC 3339 deadline set by the caller
+	headerRequestDeadline = "X-Request-Deadline"
+	// headerGRPCTimeout carries a relative timeout in the gRPC wire format, e.g. "500m" or "2S"
+	headerGRPCTimeout = "Grpc-Timeout"
+)
+
+// grpcTimeoutUnits maps gRPC timeout unit suffixes to durations
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// clientTimeout returns the time the caller is still willing to wait, derived from the
+// X-Request-Deadline and grpc-timeout headers. If both are present the shorter one applies.
+// The returned duration may be zero or negative when the deadline has already passed.
+func clientTimeout(h http.Header, now time.Time) (time.Duration, bool, error) {
+	var (
+		limit time.Duration
+		found bool
+	)
+
+	if v := h.Get(headerRequestDeadline); v != "" {
+		deadline, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s header: must be an RFC 3339 timestamp", headerRequestDeadline)
+		}
+		limit, found = deadline.Sub(now), true
+	}
+
+	if v := h.Get(headerGRPCTimeout); v != "" {
+		timeout, err := parseGRPCTimeout(v)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid grpc-timeout header: %w", err)
+		}
+		if !found || timeout < limit {
+			limit, found = timeout, true
+		}
+	}
+
+	return limit, found, nil
+}
+
+// parseGRPCTimeout parses a gRPC timeout value: up to 8 digits followed by a unit (H, M, S, m, u, n)
+func parseGRPCTimeout(v string) (time.Duration, error) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, fmt.Errorf("must be 1-8 digits followed by a unit")
+	}
+	unit, ok := grpcTimeoutUnits[v[len(v)-1]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", v[len(v)-1])
+	}
+	n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("must be 1-8 digits followed by a unit")
+	}
+	if n > uint64(math.MaxInt64/int64(unit)) {
+		return time.Duration(math.MaxInt64), nil // avoid overflow for very long timeouts
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTimeout(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		wantLimit time.Duration
+		wantFound bool
+		wantErr   bool
+	}{
+		{
+			name:      "no headers",
+			headers:   map[string]string{},
+			wantFound: false,
+		},
+		{
+			name:      "absolute deadline",
+			headers:   map[string]string{"X-Request-Deadline": "2025-01-01T12:00:05Z"},
+			wantLimit: 5 * time.Second,
+			wantFound: true,
+		},
+		{
+			name:      "deadline in the past",
+			headers:   map[string]string{"X-Request-Deadline": "2025-01-01T11:59:59Z"},
+			wantLimit: -time.Second,
+			wantFound: true,
+		},
+		{
+			name:      "grpc-timeout milliseconds",
+			headers:   map[string]string{"grpc-timeout": "1500m"},
+			wantLimit: 1500 * time.Millisecond,
+			wantFound: true,
+		},
+		{
+			name:      "grpc-timeout hours does not overflow",
+			headers:   map[string]string{"grpc-timeout": "99999999H"},
+			wantLimit: time.Duration(math.MaxInt64),
+			wantFound: true,
+		},
+		{
+			name: "shorter of both applies",
+			headers: map[string]string{
+				"X-Request-Deadline": "2025-01-01T12:00:10Z",
+				"grpc-timeout":       "2S",
+			},
+			wantLimit: 2 * time.Second,
+			wantFound: true,
+		},
+		{
+			name:    "invalid deadline",
+			headers: map[string]string{"X-Request-Deadline": "in 5 seconds"},
+			wantErr: true,
+		},
+		{
+			name:    "grpc-timeout without unit",
+			headers: map[string]string{"grpc-timeout": "100"},
+			wantErr: true,
+		},
+		{
+			name:    "grpc-timeout too many digits",
+			headers: map[string]string{"grpc-timeout": "123456789S"},
+			wantErr: true,
+		},
+		{
+			name:    "grpc-timeout negative",
+			headers: map[string]string{"grpc-timeout": "-5S"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			limit, found, err := clientTimeout(h, now)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantLimit, limit)
+		})
+	}
+}
+
+func newDeadlineTestRequest(t *testing.T, header, value string) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	jsonBody, _ := json.Marshal(map[string]any{
+		"server":   "test-server",
+		"toolName": "test",
+		"input":    map[string]any{},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	return c, w
+}
+
+// TestHandler_CallTool_DeadlineExceeded verifies that expired client deadlines are rejected without calling the server.
+func TestHandler_CallTool_DeadlineExceeded(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	c, w := newDeadlineTestRequest(t, "X-Request-Deadline", time.Now().Add(-time.Second).Format(time.RFC3339Nano))
+	handler.CallTool(c)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "TIMEOUT_ERROR", resp["error"].(map[string]any)["code"])
+}
+
+// TestHandler_CallTool_InvalidDeadline verifies that malformed deadline headers are validation errors.
+func TestHandler_CallTool_InvalidDeadline(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	c, w := newDeadlineTestRequest(t, "grpc-timeout", "soon")
+	handler.CallTool(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+}
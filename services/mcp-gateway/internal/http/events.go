@@ -0,0 +1,63 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+)
+
+// eventStreamBuffer is how many events a client of GET /events may fall behind before its stream ends
+const eventStreamBuffer = 256
+
+// Events streams the events of the client manager's bus as Server-Sent Events named after the event type,
+// e.g. status_changed or restart_exhausted, so that dashboards react to them without polling /health.
+// ?types= selects a comma-separated list of types; by default only lifecycle events are sent.
+// The stream ends when the client disconnects, falls too far behind, or the gateway shuts down.
+func (h *Handler) Events(c *gin.Context) {
+	types := events.LifecycleTypes
+	if raw := c.Query("types"); raw != "" {
+		types = nil
+		for t := range strings.SplitSeq(raw, ",") {
+			typ := events.Type(strings.TrimSpace(t))
+			if !slices.Contains(events.AllTypes, typ) {
+				respondValidationError(c, "unknown event type: "+string(typ))
+				return
+			}
+			types = append(types, typ)
+		}
+	}
+	stream, unwatch := h.clientManager.Events().Watch(eventStreamBuffer, types...)
+	defer unwatch()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(streamKeepaliveInterval)
+	defer keepalive.Stop()
+	shutdown := shutdownNotice(c.Request.Context())
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-stream:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(ev.Type), ev)
+			return true
+		case <-keepalive.C:
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-shutdown:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
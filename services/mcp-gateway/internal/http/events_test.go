@@ -0,0 +1,89 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)
+	require.NoError(t, cm.RegisterInProcess("embedded", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	sm := NewServerManagerAt(SetupRouter(NewHandler(cm, pm)), "127.0.0.1:0")
+	require.NoError(t, sm.Listen())
+	go func() { _ = sm.Start() }()
+	t.Cleanup(func() { _ = sm.Shutdown() })
+
+	resp, err := http.Get("http://" + sm.listener.Addr().String() + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	require.NoError(t, cm.StopServer("embedded"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "event:status_changed", scanner.Text())
+	require.True(t, scanner.Scan())
+	var ev map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data:")), &ev))
+	assert.Equal(t, "status_changed", ev["type"])
+	assert.Equal(t, "embedded", ev["server"])
+	assert.Equal(t, "available", ev["from"])
+	assert.Equal(t, "stopped", ev["to"])
+}
+
+func TestEvents_Types(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)
+	server.AddTool(&mcpSDK.Tool{Name: "get", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+			return &mcpSDK.CallToolResult{}, nil
+		})
+	require.NoError(t, cm.RegisterInProcess("embedded", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	sm := NewServerManagerAt(SetupRouter(NewHandler(cm, pm)), "127.0.0.1:0")
+	require.NoError(t, sm.Listen())
+	go func() { _ = sm.Start() }()
+	t.Cleanup(func() { _ = sm.Shutdown() })
+	base := "http://" + sm.listener.Addr().String() + "/events"
+
+	resp, err := http.Get(base + "?types=tool_call_finished,crashed")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(base + "?types=tool_call_finished")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = cm.CallTool(context.Background(), "embedded", "get", map[string]any{})
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "event:tool_call_finished", scanner.Text())
+	require.True(t, scanner.Scan())
+	var ev map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data:")), &ev))
+	assert.Equal(t, "embedded", ev["server"])
+	assert.Equal(t, "get", ev["tool"])
+}
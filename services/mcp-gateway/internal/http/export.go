@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+)
+
+// Views of GET /mcp/tools selectable with ?view=
+const (
+	toolViewFull    = "full"
+	toolViewCompact = "compact"
+)
+
+// Export formats selectable with ?format= on list endpoints
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatTSV  = "tsv"
+)
+
+// exportFormat returns the ?format= of the request, json by default
+func exportFormat(c *gin.Context) (string, error) {
+	switch format := c.DefaultQuery("format", formatJSON); format {
+	case formatJSON, formatCSV, formatTSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format %q (must be json, csv or tsv)", format)
+	}
+}
+
+// respondTable writes rows as a CSV or TSV attachment named <name>.csv or <name>.tsv
+func respondTable(c *gin.Context, format, name string, header []string, rows [][]string) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	contentType := "text/csv; charset=utf-8"
+	if format == formatTSV {
+		w.Comma = '\t'
+		contentType = "text/tab-separated-values; charset=utf-8"
+	}
+	// Writing to a buffer cannot fail
+	_ = w.Write(header)
+	_ = w.WriteAll(rows)
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// toolRows flattens tools into one row per tool, with the schemas as compact JSON
+func toolRows(tools []mcp.ToolInfo) ([]string, [][]string) {
+	header := []string{"server", "name", "description", "readOnly", "timeout", "inputSchema", "outputSchema"}
+	rows := make([][]string, 0, len(tools))
+	for _, tool := range tools {
+		rows = append(rows, []string{
+			tool.Server,
+			tool.Name,
+			tool.Description,
+			strconv.FormatBool(tool.ReadOnly),
+			strconv.Itoa(tool.Timeout),
+			compactJSON(tool.InputSchema),
+			compactJSON(tool.OutputSchema),
+		})
+	}
+	return header, rows
+}
+
+// compactToolRows flattens compact tools into one row per tool, with the required parameters as
+// name:type pairs separated by spaces
+func compactToolRows(tools []mcp.CompactToolInfo) ([]string, [][]string) {
+	header := []string{"server", "name", "description", "required"}
+	rows := make([][]string, 0, len(tools))
+	for _, tool := range tools {
+		required := make([]string, len(tool.Required))
+		for i, param := range tool.Required {
+			required[i] = param.Name
+			if param.Type != "" {
+				required[i] += ":" + param.Type
+			}
+		}
+		rows = append(rows, []string{tool.Server, tool.Name, tool.Description, strings.Join(required, " ")})
+	}
+	return header, rows
+}
+
+// compactJSON encodes v on one line, or returns "" for nil
+func compactJSON(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
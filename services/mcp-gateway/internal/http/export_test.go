@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newExportTestRouter serves two in-process servers registered out of alphabetical order
+func newExportTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	noop := func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+		return &mcpSDK.CallToolResult{}, nil
+	}
+	for _, name := range []string{"weather", "billing"} {
+		server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: name, Version: "test"}, nil)
+		server.AddTool(&mcpSDK.Tool{Name: "lookup", Description: "Look up, with a comma\nand details on a second line", InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"id": map[string]any{"type": "string"}, "limit": map[string]any{"type": "integer"}},
+			"required":   []any{"id"},
+		}}, noop)
+		server.AddTool(&mcpSDK.Tool{Name: "get", Description: "Get\tone", InputSchema: map[string]any{"type": "object"}}, noop)
+		require.NoError(t, cm.RegisterInProcess(name, server))
+	}
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	t.Cleanup(func() { _ = cm.Close() })
+	return SetupRouter(NewHandler(cm, pm))
+}
+
+func TestGetTools_ExportFormats(t *testing.T) {
+	router := newExportTestRouter(t)
+
+	tests := []struct {
+		format      string
+		comma       rune
+		contentType string
+	}{
+		{format: "csv", comma: ',', contentType: "text/csv; charset=utf-8"},
+		{format: "tsv", comma: '\t', contentType: "text/tab-separated-values; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/mcp/tools?format="+tt.format, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="tools.`+tt.format+`"`, w.Header().Get("Content-Disposition"))
+
+			r := csv.NewReader(strings.NewReader(w.Body.String()))
+			r.Comma = tt.comma
+			records, err := r.ReadAll()
+			require.NoError(t, err)
+			require.Len(t, records, 5)
+			assert.Equal(t, []string{"server", "name", "description", "readOnly", "timeout", "inputSchema", "outputSchema"}, records[0])
+
+			var order []string
+			for _, record := range records[1:] {
+				order = append(order, record[0]+"/"+record[1])
+			}
+			assert.Equal(t, []string{"billing/get", "billing/lookup", "weather/get", "weather/lookup"}, order)
+			assert.Equal(t, "Get\tone", records[1][2])
+			assert.Equal(t, "Look up, with a comma\nand details on a second line", records[2][2])
+			assert.Equal(t, "false", records[1][3])
+
+			var schema map[string]any
+			require.NoError(t, json.Unmarshal([]byte(records[1][5]), &schema))
+			assert.Equal(t, "object", schema["type"])
+		})
+	}
+}
+
+func TestGetTools_InvalidFormat(t *testing.T) {
+	router := newExportTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools?format=xlsx", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+	assert.Contains(t, resp["error"].(map[string]any)["message"], `invalid format "xlsx"`)
+}
+
+func TestGetTools_CompactView(t *testing.T) {
+	router := newExportTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools?view=compact", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Tools []map[string]any `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Tools, 4)
+	assert.Equal(t, map[string]any{
+		"server":      "billing",
+		"name":        "lookup",
+		"description": "Look up, with a comma",
+		"required":    []any{map[string]any{"name": "id", "type": "string"}},
+		"available":   true,
+	}, resp.Tools[1])
+	assert.Equal(t, []any{}, resp.Tools[0]["required"])
+	assert.NotContains(t, w.Body.String(), "inputSchema")
+
+	req = httptest.NewRequest(http.MethodGet, "/mcp/tools?view=compact&format=csv", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"server", "name", "description", "required"}, records[0])
+	assert.Equal(t, []string{"billing", "lookup", "Look up, with a comma", "id:string"}, records[2])
+}
+
+func TestGetTools_AvailableOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	noop := func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+		return &mcpSDK.CallToolResult{}, nil
+	}
+	for _, name := range []string{"weather", "billing"} {
+		server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: name, Version: "test"}, nil)
+		server.AddTool(&mcpSDK.Tool{Name: "lookup", InputSchema: map[string]any{"type": "object"}}, noop)
+		require.NoError(t, cm.RegisterInProcess(name, server))
+	}
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	t.Cleanup(func() { _ = cm.Close() })
+	router := SetupRouter(NewHandler(cm, pm))
+	require.NoError(t, pm.Transition("billing", mcp.StatusCrashed))
+
+	list := func(query string) []mcp.ToolInfo {
+		req := httptest.NewRequest(http.MethodGet, "/mcp/tools"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Tools []mcp.ToolInfo `json:"tools"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Tools
+	}
+
+	tools := list("")
+	require.Len(t, tools, 2)
+	assert.Equal(t, "billing", tools[0].Server)
+	assert.False(t, tools[0].Available)
+	assert.True(t, tools[1].Available)
+
+	tools = list("?availableOnly=true&view=compact")
+	require.Len(t, tools, 1)
+	assert.Equal(t, "weather", tools[0].Server)
+	assert.True(t, tools[0].Available)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools?availableOnly=maybe", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "availableOnly must be true or false")
+}
+
+func TestGetTools_InvalidView(t *testing.T) {
+	router := newExportTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools?view=tiny", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `invalid view \"tiny\"`)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// FeedbackRequest is the body of POST /mcp/feedback. Server and toolName are named as in
+// POST /mcp/call so that authorizers see them the same way.
+type FeedbackRequest struct {
+	Server    string `json:"server" binding:"required"`
+	ToolName  string `json:"toolName" binding:"required"`
+	Satisfied *bool  `json:"satisfied" binding:"required"` // whether the tool did what the task needed
+}
+
+// Feedback records whether a tool selected by an agent satisfied its task. The aggregated
+// feedback of each tool is listed by GET /mcp/tools.
+func (h *Handler) Feedback(c *gin.Context) {
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+
+	feedback, err := h.clientManager.RecordFeedback(req.Server, req.ToolName, *req.Satisfied)
+	if err != nil {
+		code, message := mcpErrors.ErrCodeToolNotFound, "Tool not found: "+req.ToolName
+		if errors.Is(err, mcpErrors.ErrServerNotFound) {
+			code, message = mcpErrors.ErrCodeServerNotFound, "Server not found: "+req.Server
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    code,
+				"message": message,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"feedback": feedback,
+	})
+}
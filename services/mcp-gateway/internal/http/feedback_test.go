@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postFeedback(t *testing.T, router *gin.Engine, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp/feedback", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestFeedback_AggregatedInToolList(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	code, resp := postFeedback(t, router, `{"server": "calc", "toolName": "add", "satisfied": true}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"satisfied": float64(1), "unsatisfied": float64(0), "score": 2.0 / 3}, resp["feedback"])
+
+	code, _ = postFeedback(t, router, `{"server": "calc", "toolName": "add", "satisfied": false}`)
+	require.Equal(t, http.StatusOK, code)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var list struct {
+		Tools []map[string]any `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Tools, 2)
+	assert.Equal(t, map[string]any{"satisfied": float64(1), "unsatisfied": float64(1), "score": 0.5}, list.Tools[0]["feedback"])
+	assert.NotContains(t, list.Tools[1], "feedback")
+}
+
+func TestFeedback_Errors(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "Missing satisfied", body: `{"server": "calc", "toolName": "add"}`, status: http.StatusBadRequest, code: "VALIDATION_ERROR"},
+		{name: "Missing tool", body: `{"server": "calc", "satisfied": true}`, status: http.StatusBadRequest, code: "VALIDATION_ERROR"},
+		{name: "Unknown server", body: `{"server": "missing", "toolName": "add", "satisfied": true}`, status: http.StatusNotFound, code: "SERVER_NOT_FOUND"},
+		{name: "Unknown tool", body: `{"server": "calc", "toolName": "missing", "satisfied": false}`, status: http.StatusNotFound, code: "TOOL_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := postFeedback(t, router, tt.body)
+			assert.Equal(t, tt.status, code)
+			assert.Equal(t, tt.code, resp["error"].(map[string]any)["code"])
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fuzzHandler serves the API without servers, quietly. Its router recovers panics, so fuzz targets check
+// the handler's panic count rather than relying on crashes.
+func fuzzHandler(f *testing.F) (*gin.Engine, *Handler) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	f.Cleanup(func() { slog.SetDefault(logger) })
+
+	pm := mcp.NewProcessManager(30000, "never")
+	handler := NewHandler(mcp.NewClientManager(pm), pm)
+	return SetupRouter(handler, WithStructuredLogging()), handler
+}
+
+// checkFuzzResponse fails unless the gateway answered body with a JSON response and without panicking
+func checkFuzzResponse(t *testing.T, handler *Handler, body []byte, w *httptest.ResponseRecorder) {
+	if n := handler.panics.Load(); n != 0 {
+		t.Fatalf("handler panicked on %q", body)
+	}
+	if w.Body.Len() > 0 && !json.Valid(w.Body.Bytes()) {
+		t.Fatalf("invalid JSON response for %q: %s", body, w.Body.String())
+	}
+}
+
+// FuzzCallToolRequest posts arbitrary bodies to /mcp/call
+func FuzzCallToolRequest(f *testing.F) {
+	f.Add([]byte(`{"server":"test","toolName":"calc","input":{}}`))
+	f.Add([]byte(`{"server":"test","toolName":"calc","input":[]}`))
+	f.Add([]byte(`{"server":"test","toolName":"calc","input":"text"}`))
+	f.Add([]byte(`{"server":"test","toolName":"calc","input":{"__proto__":{}}}`))
+	f.Add([]byte(`{"server":123,"toolName":null}`))
+	f.Add([]byte(`{invalid json}`))
+	f.Add([]byte(`[{"server":"test"}]`))
+	f.Add([]byte(``))
+	f.Add([]byte(`{"server":"\u0000","toolName":"\ud800","input":{"a":1e999}}`))
+	router, handler := fuzzHandler(f)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		checkFuzzResponse(t, handler, body, w)
+		// Without servers, every request is rejected by validation or as calling an unknown server
+		if w.Code >= http.StatusInternalServerError {
+			t.Fatalf("unexpected status %d for %q: %s", w.Code, body, w.Body.String())
+		}
+	})
+}
+
+// FuzzRPCRequest posts arbitrary bodies to the JSON-RPC endpoint
+func FuzzRPCRequest(f *testing.F) {
+	f.Add([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","id":"a","method":"tools/list"}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test.calc","arguments":{"a":1}}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"nodot","arguments":[1]}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","id":{"nested":[]},"method":"ping"}`))
+	f.Add([]byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`))
+	f.Add([]byte(`{"jsonrpc":"1.0"}`))
+	router, handler := fuzzHandler(f)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		checkFuzzResponse(t, handler, body, w)
+		// Without servers, every request is rejected by validation or as calling an unknown server
+		if w.Code >= http.StatusInternalServerError {
+			t.Fatalf("unexpected status %d for %q: %s", w.Code, body, w.Body.String())
+		}
+	})
+}
+
+// FuzzToolResult renders arbitrary tool results, as returned by MCP servers, into responses
+func FuzzToolResult(f *testing.F) {
+	f.Add([]byte(`{"content":[{"type":"text","text":"ok"}]}`))
+	f.Add([]byte(`{"content":[{"type":"text","text":""}],"isError":true}`))
+	f.Add([]byte(`{"content":[],"isError":true}`))
+	f.Add([]byte(`{"content":[{"type":"image","data":"iVBORw0KGgo=","mimeType":"image/png"}],"isError":true}`))
+	f.Add([]byte(`{"content":[{"type":"audio","data":"AAE=","mimeType":"audio/wav"}]}`))
+	f.Add([]byte(`{"content":[{"type":"resource","resource":{"uri":"file:///a","blob":"AAE="}}]}`))
+	f.Add([]byte(`{"content":[{"type":"resource_link","uri":"file:///a","name":"a"}]}`))
+	f.Add([]byte(`{"structuredContent":{"result":22.9},"content":[{"type":"text","text":"{\"result\":22.9}"}]}`))
+	_, handler := fuzzHandler(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var result mcpSDK.CallToolResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/mcp/call", nil)
+		handler.respondToolResult(c, CallToolRequest{Server: "test", ToolName: "calc"}, &result)
+		checkFuzzResponse(t, handler, data, w)
+		if w.Code != http.StatusOK && w.Code != http.StatusInternalServerError {
+			t.Fatalf("unexpected status %d for %q", w.Code, data)
+		}
+	})
+}
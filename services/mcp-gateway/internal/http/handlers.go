@@ -1,29 +1,27 @@
 package http
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strings"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
 	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
 	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// isUnknownToolError checks if the error is from an unknown tool call
-// The MCP SDK returns an error with the message pattern:
-// "calling "tools/call": unknown tool "toolName""
-func isUnknownToolError(err error) bool {
-	errMsg := err.Error()
-	return strings.Contains(errMsg, "unknown tool")
-}
-
 // extractErrorMessage extracts error message from CallToolResult Content.
 // Returns the error message and true if result is an error, empty string and false otherwise.
 func extractErrorMessage(result any) (string, bool) {
@@ -67,13 +65,22 @@ type Handler struct {
 	clientManager  *mcp.ClientManager
 	processManager *mcp.ProcessManager
 	startTime      time.Time
+	clock          clock.Clock                 // the process manager's, for uptime
+	panics         atomic.Uint64               // handler panics recovered by recoveryMiddleware
+	selfDiagnose   bool                        // include gateway process diagnostics in /health
+	largePayloads  *config.LargePayloadsConfig // pass /mcp/call inputs on as sent and stream results
 }
 
 func NewHandler(cm *mcp.ClientManager, pm *mcp.ProcessManager) *Handler {
+	clk := clock.Real
+	if pm != nil {
+		clk = pm.Clock()
+	}
 	return &Handler{
 		clientManager:  cm,
 		processManager: pm,
-		startTime:      time.Now(),
+		startTime:      clk.Now(),
+		clock:          clk,
 	}
 }
 
@@ -84,117 +91,174 @@ type CallToolRequest struct {
 }
 
 func (h *Handler) CallTool(c *gin.Context) {
-	var req CallToolRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    mcpErrors.ErrCodeValidation,
-				"message": err.Error(),
-			},
-		})
+	req, err := h.bindCallToolRequest(c)
+	if err != nil {
+		writeJSON(c, http.StatusBadRequest, failure(mcpErrors.ErrCodeValidation, err.Error(), nil))
 		return
 	}
 
 	// Validate request
 	if err := validator.ValidateRequest(req.Server, req.ToolName, req.Input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    mcpErrors.ErrCodeValidation,
-				"message": err.Error(),
-			},
-		})
+		respondViolations(c, err)
 		return
 	}
+	annotateCallSpan(c, req.Server, req.ToolName)
+	c.Set(callTargetContextKey, callTarget{server: req.Server, tool: req.ToolName})
 
 	// Call tool
-	// tool info からタイムアウト時間を取得 (デフォルト: 30s)
-	var timeout time.Duration
-
-	if toolInfo, found := h.clientManager.GetToolInfo(req.Server, req.ToolName); found {
-		timeout = time.Duration(toolInfo.Timeout) * time.Millisecond
-	} else {
-		slog.Warn("Tool not found in cache, using default timeout", "toolName", req.ToolName, "server", req.Server)
+	resolved, err := h.resolveTimeout(c, req.Server, req.ToolName)
+	if err != nil {
+		writeJSON(c, http.StatusBadRequest, failure(mcpErrors.ErrCodeValidation, err.Error(), nil))
+		return
+	}
+	// Never work past a deadline the caller has already given up on
+	if resolved.source == timeoutSourceRequest && resolved.timeout <= 0 {
+		writeJSON(c, http.StatusGatewayTimeout, failure(mcpErrors.ErrCodeTimeout,
+			"Client deadline exceeded before the tool was called", toolDetails{ToolName: req.ToolName, ServerName: req.Server}))
+		return
 	}
-	if timeout == 0 {
-		timeout = 30 * time.Second
+	timeout := resolved.timeout
+
+	// Apply the caller's profile
+	ctx := c.Request.Context()
+	if caller := callerFrom(c); caller != nil && caller.profile.Priority != "" {
+		ctx = mcp.WithPriority(ctx, caller.profile.Priority)
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	result, err := h.clientManager.CallTool(ctx, req.Server, req.ToolName, req.Input)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			c.JSON(http.StatusGatewayTimeout, gin.H{
-				"success": false,
-				"error": gin.H{
-					"code":    mcpErrors.ErrCodeTimeout,
-					"message": fmt.Sprintf("Tool execution timed out after %dms", timeout.Milliseconds()),
-					"details": gin.H{
-						"toolName":   req.ToolName,
-						"serverName": req.Server,
-						"timeout":    timeout.Milliseconds(),
-					},
-				},
-			})
+			writeJSON(c, http.StatusGatewayTimeout, failure(mcpErrors.ErrCodeTimeout,
+				fmt.Sprintf("Tool execution timed out after %dms", timeout.Milliseconds()),
+				toolDetails{ToolName: req.ToolName, ServerName: req.Server, Timeout: timeout.Milliseconds()}))
 			return
 		}
 
-		// Map errors (simplified)
-		status := http.StatusInternalServerError
-		code := mcpErrors.ErrCodeToolExecution
-
-		if errors.Is(err, mcpErrors.ErrServerNotFound) {
-			status = http.StatusNotFound
-			code = mcpErrors.ErrCodeServerNotFound
-		} else if errors.Is(err, mcpErrors.ErrServerNotRunning) {
-			status = http.StatusServiceUnavailable
-			code = mcpErrors.ErrCodeServerNotRunning
-		} else if errors.Is(err, mcpErrors.ErrServerCrashed) {
-			status = http.StatusBadGateway
-			code = mcpErrors.ErrCodeServerCrashed
-		} else if isUnknownToolError(err) {
-			status = http.StatusNotFound
-			code = mcpErrors.ErrCodeToolNotFound
+		var panicErr *mcp.PanicError
+		if errors.As(err, &panicErr) {
+			// Already logged with its stack by the client manager
+			h.panics.Add(1)
+			respondInternalError(c)
+			return
 		}
 
-		c.JSON(status, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    code,
-				"message": err.Error(),
-			},
-		})
+		respondCallError(c, err)
 		return
 	}
 
+	h.respondToolResult(c, req, result)
+}
+
+// callErrorStatus maps an error returned by ClientManager.CallTool to an HTTP status and error code.
+// Errors other than GatewayErrors were returned by the tool call itself.
+func callErrorStatus(err error) (int, mcpErrors.ErrorCode) {
+	if gatewayErr, ok := mcpErrors.As(err); ok {
+		return gatewayErr.HTTPStatus, gatewayErr.Code
+	}
+	return http.StatusInternalServerError, mcpErrors.ErrCodeToolExecution
+}
+
+// respondToolResult writes the response for a tool result. A panic while normalizing or rendering
+// a pathological result, e.g. one with an unexpected content type, fails only this request.
+func (h *Handler) respondToolResult(c *gin.Context, req CallToolRequest, result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.panics.Add(1)
+			slog.Error("Panic while rendering tool result",
+				"server", req.Server,
+				"tool", req.ToolName,
+				"panic", r,
+				"requestId", requestID(c),
+				"stack", string(debug.Stack()),
+			)
+			if !c.Writer.Written() {
+				respondInternalError(c)
+			}
+		}
+	}()
+
 	// Check if tool returned an error (MCP-level tool error)
 	if errMsg, isToolError := extractErrorMessage(result); isToolError {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    mcpErrors.ErrCodeToolExecution,
-				"message": errMsg,
-				"details": gin.H{
-					"toolName":   req.ToolName,
-					"serverName": req.Server,
-				},
-			},
-		})
+		writeJSON(c, http.StatusInternalServerError, failure(mcpErrors.ErrCodeToolExecution, errMsg,
+			toolDetails{ToolName: req.ToolName, ServerName: req.Server}))
 		return
 	}
 
 	// Success case
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"result":  result,
+	if toolResult, ok := result.(*mcpSDK.CallToolResult); ok && h.largePayloads != nil {
+		h.streamToolResult(c, req, toolResult)
+		return
+	}
+	writeJSON(c, http.StatusOK, callResponse{Success: true, Result: result})
+}
+
+// respondInternalError responds with INTERNAL_ERROR without exposing the cause
+func respondInternalError(c *gin.Context) {
+	details := gin.H{}
+	if id := requestID(c); id != "" {
+		details["requestId"] = id
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    mcpErrors.ErrCodeInternal,
+			"message": "Internal server error",
+			"details": details,
+		},
 	})
 }
 
+// GetTools lists the cached tools of every server, sorted by server and name, as JSON or, with
+// ?format=csv|tsv, as a spreadsheet. ?view=compact reduces each tool to what fits in an LLM prompt,
+// and ?availableOnly=true leaves out the tools of servers that do not take calls.
 func (h *Handler) GetTools(c *gin.Context) {
+	format, err := exportFormat(c)
+	if err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	view := c.DefaultQuery("view", toolViewFull)
+	if view != toolViewFull && view != toolViewCompact {
+		respondValidationError(c, fmt.Sprintf("invalid view %q (must be full or compact)", view))
+		return
+	}
+	availableOnly, err := strconv.ParseBool(c.DefaultQuery("availableOnly", "false"))
+	if err != nil {
+		respondValidationError(c, "availableOnly must be true or false")
+		return
+	}
 	tools := h.clientManager.GetTools()
+	if availableOnly {
+		tools = slices.DeleteFunc(tools, func(tool mcp.ToolInfo) bool { return !tool.Available })
+	}
+	slices.SortFunc(tools, func(a, b mcp.ToolInfo) int {
+		return cmp.Or(cmp.Compare(a.Server, b.Server), cmp.Compare(a.Name, b.Name))
+	})
+
+	if view == toolViewCompact {
+		compact := make([]mcp.CompactToolInfo, len(tools))
+		for i, tool := range tools {
+			compact[i] = tool.Compact()
+		}
+		if format != formatJSON {
+			header, rows := compactToolRows(compact)
+			respondTable(c, format, "tools", header, rows)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"tools":   compact,
+		})
+		return
+	}
+	if format != formatJSON {
+		header, rows := toolRows(tools)
+		respondTable(c, format, "tools", header, rows)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"tools":   tools,
@@ -205,15 +269,51 @@ func (h *Handler) Health(c *gin.Context) {
 	statuses := h.processManager.GetAllStatuses()
 	status := "ok"
 	for _, s := range statuses {
-		if s != mcp.StatusAvailable {
+		// Idle servers are respawned on demand and inactive ones are stopped as scheduled, so they do not
+		// degrade the gateway
+		if s != mcp.StatusAvailable && s != mcp.StatusIdle && s != mcp.StatusInactive {
 			status = "degraded"
 			break
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"status":  status,
-		"uptime":  time.Since(h.startTime).Seconds(),
+		"uptime":  h.clock.Since(h.startTime).Seconds(),
 		"servers": statuses,
-	})
+		"details": h.processManager.GetAllDiagnostics(),
+		// CPU and memory of local server processes, to spot leaking servers before they are OOM-killed
+		"resources": h.clientManager.ResourceUsage(),
+	}
+	if h.selfDiagnose {
+		body["gateway"] = h.selfDiagnostics()
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// respondViolations responds with 400 VALIDATION_ERROR for a failed validator.ValidateRequest,
+// listing every violation in the details
+func respondViolations(c *gin.Context, err error) {
+	var details any
+	var invalid *validator.ValidationError
+	if errors.As(err, &invalid) {
+		details = violationDetails{Violations: invalid.Violations}
+	}
+	writeJSON(c, http.StatusBadRequest, failure(mcpErrors.ErrCodeValidation, err.Error(), details))
+}
+
+// appendViolations appends the violations of a failed validator.ValidateRequest for a part of a request,
+// e.g. steps[0], qualifying their fields and messages with it
+func appendViolations(violations []validator.Violation, part string, err error) []validator.Violation {
+	var invalid *validator.ValidationError
+	if !errors.As(err, &invalid) {
+		if err != nil {
+			violations = append(violations, validator.Violation{Field: part, Message: part + ": " + err.Error()})
+		}
+		return violations
+	}
+	for _, v := range invalid.Violations {
+		violations = append(violations, validator.Violation{Field: part + "." + v.Field, Message: part + ": " + v.Message})
+	}
+	return violations
 }
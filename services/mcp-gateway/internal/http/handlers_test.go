@@ -3,13 +3,17 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -133,6 +137,89 @@ func TestHandler_Health_OneCrashed(t *testing.T) {
 	assert.Equal(t, "degraded", resp["status"])
 }
 
+// TestHandler_Health_IdleIsOK tests that servers stopped for idleness do not degrade the gateway.
+func TestHandler_Health_IdleIsOK(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	pm.SetStatus("server-1", mcp.StatusAvailable)
+	pm.SetStatus("server-2", mcp.StatusIdle)
+
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Health(c)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp["status"])
+	assert.Equal(t, "idle", resp["servers"].(map[string]any)["server-2"])
+}
+
+// TestHandler_Health_InactiveIsOK tests that servers stopped outside their active hours do not degrade the gateway.
+func TestHandler_Health_InactiveIsOK(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	pm.SetStatus("server-1", mcp.StatusAvailable)
+	pm.SetStatus("server-2", mcp.StatusInactive)
+
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Health(c)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp["status"])
+	assert.Equal(t, "inactive", resp["servers"].(map[string]any)["server-2"])
+}
+
+// TestHandler_Health_Details tests that per-server failure details are exposed.
+func TestHandler_Health_Details(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "on-failure")
+	pm.SetStatus("server-1", mcp.StatusAvailable)
+	pm.SetStatus("server-2", mcp.StatusCrashed)
+	pm.RecordFailure("server-2", mcp.RestartReasonTransportClosed, errors.New("EOF"))
+	pm.RecordConnectError("server-2", errors.New("exec: not found"))
+
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Health(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	details := resp["details"].(map[string]any)
+	assert.Equal(t, map[string]any{"restartPolicy": "on-failure", "restartAttempts": 0.0, "maxRestartAttempts": 3.0}, details["server-1"])
+
+	crashed := details["server-2"].(map[string]any)
+	assert.Equal(t, "transport_closed", crashed["lastFailureReason"])
+	assert.Equal(t, "exec: not found", crashed["lastConnectError"])
+	assert.Equal(t, "exec: not found", crashed["lastError"])
+	assert.NotEmpty(t, crashed["lastFailureAt"])
+	assert.Equal(t, map[string]any{}, resp["resources"], "servers without a process report no resources")
+}
+
 // TestHandler_Health_NoServers tests health endpoint with no servers registered.
 func TestHandler_Health_NoServers(t *testing.T) {
 	pm := mcp.NewProcessManager(30000, "never")
@@ -155,6 +242,33 @@ func TestHandler_Health_NoServers(t *testing.T) {
 	assert.Equal(t, "ok", resp["status"])
 }
 
+// TestHandler_Health_SelfDiagnostics tests that gateway diagnostics are reported only when enabled.
+func TestHandler_Health_SelfDiagnostics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+
+	get := func(router *gin.Engine) map[string]any {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.NotContains(t, get(SetupRouter(NewHandler(cm, pm))), "gateway")
+
+	gateway := get(SetupRouter(NewHandler(cm, pm), WithSelfDiagnostics()))["gateway"].(map[string]any)
+	assert.Greater(t, gateway["goroutines"], float64(0))
+	assert.Greater(t, gateway["memory"].(map[string]any)["heapAllocBytes"], float64(0))
+	assert.Equal(t, map[string]any{}, gateway["inFlightCalls"])
+	assert.Equal(t, float64(0), gateway["caches"].(map[string]any)["tools"])
+	if _, err := os.Stat("/proc/self/fd"); err == nil {
+		assert.Greater(t, gateway["openFDs"], float64(0))
+	}
+}
+
 // TestHandler_GetTools_Empty tests GetTools with no tools.
 func TestHandler_GetTools_Empty(t *testing.T) {
 	pm := mcp.NewProcessManager(30000, "never")
@@ -205,6 +319,43 @@ func TestHandler_CallTool_InvalidJSON(t *testing.T) {
 	assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
 }
 
+// TestHandler_CallTool_Violations tests that CallTool lists every validation violation.
+func TestHandler_CallTool_Violations(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	body := `{"server": "bad server", "toolName": "", "input": {"constructor": {}}}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp/call", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CallTool(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Details struct {
+				Violations []map[string]string `json:"violations"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "VALIDATION_ERROR", resp.Error.Code)
+	assert.Equal(t, []map[string]string{
+		{"field": "server", "message": "server contains invalid characters"},
+		{"field": "toolName", "message": "toolName is required"},
+		{"field": "input", "message": "input contains forbidden key: constructor"},
+	}, resp.Error.Details.Violations)
+	assert.Equal(t, "server contains invalid characters; toolName is required; input contains forbidden key: constructor", resp.Error.Message)
+}
+
 // TestHandler_CallTool_EmptyServer tests CallTool with empty server name.
 func TestHandler_CallTool_EmptyServer(t *testing.T) {
 	pm := mcp.NewProcessManager(30000, "never")
@@ -552,3 +703,27 @@ func TestHandler_CallTool_ServerCrashed(t *testing.T) {
 	assert.False(t, resp["success"].(bool))
 	assert.Equal(t, "SERVER_NOT_FOUND", resp["error"].(map[string]any)["code"])
 }
+
+func TestCallErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   mcpErrors.ErrorCode
+	}{
+		{err: mcpErrors.ErrServerNotFound, status: http.StatusNotFound, code: mcpErrors.ErrCodeServerNotFound},
+		{err: fmt.Errorf("%w: input must be an object, got string", mcpErrors.ErrInvalidInput), status: http.StatusBadRequest, code: mcpErrors.ErrCodeValidation},
+		{err: fmt.Errorf("primary: %w", mcpErrors.ErrServerBusy), status: http.StatusTooManyRequests, code: mcpErrors.ErrCodeServerBusy},
+		{err: mcpErrors.ErrToolNotFound.Wrap(errors.New(`unknown tool "missing"`)), status: http.StatusNotFound, code: mcpErrors.ErrCodeToolNotFound},
+		{err: errors.New("connection reset"), status: http.StatusInternalServerError, code: mcpErrors.ErrCodeToolExecution},
+		// Only the client manager knows that a server answered a call of an unknown tool
+		{err: errors.New(`calling "tools/call": unknown tool "missing"`), status: http.StatusInternalServerError, code: mcpErrors.ErrCodeToolExecution},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			status, code := callErrorStatus(tt.err)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package http
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/assert"
 )
@@ -86,3 +89,23 @@ func TestExtractErrorMessage_EmptyText(t *testing.T) {
 	assert.True(t, isError)
 	assert.Equal(t, "Tool execution failed: unexpected content type: *mcp.TextContent", msg)
 }
+
+// panickyResult stands in for a pathological tool result whose rendering panics
+type panickyResult struct{}
+
+func (panickyResult) MarshalJSON() ([]byte, error) {
+	panic("unexpected content type")
+}
+
+func TestRespondToolResult_PanicReturnsInternalError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(nil, nil)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	h.respondToolResult(c, CallToolRequest{Server: "weather", ToolName: "forecast"}, panickyResult{})
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":{"code":"INTERNAL_ERROR","message":"Internal server error","details":{}}}`, w.Body.String())
+	assert.Equal(t, uint64(1), h.panics.Load())
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// responseHeadersMiddleware sets the configured headers before the handlers run,
+// so that they are also present on error responses and aborted requests
+func responseHeadersMiddleware(cfg config.ResponseHeadersConfig) gin.HandlerFunc {
+	global := make(http.Header, len(cfg.Headers))
+	for name, value := range cfg.Headers {
+		global.Set(name, value)
+	}
+	type pathHeaders struct {
+		path    string
+		prefix  bool
+		headers http.Header
+	}
+	paths := make([]pathHeaders, 0, len(cfg.Paths))
+	for _, p := range cfg.Paths {
+		ph := pathHeaders{path: strings.TrimSuffix(p.Path, "*"), prefix: strings.HasSuffix(p.Path, "*"), headers: make(http.Header, len(p.Headers))}
+		for name, value := range p.Headers {
+			ph.headers.Set(name, value)
+		}
+		paths = append(paths, ph)
+	}
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		for name, values := range global {
+			h[name] = values
+		}
+		// Later entries win when several paths match
+		path := routePath(c)
+		for _, p := range paths {
+			if path == p.path || (p.prefix && strings.HasPrefix(path, p.path)) {
+				for name, values := range p.headers {
+					h[name] = values
+				}
+			}
+		}
+		c.Next()
+	}
+}
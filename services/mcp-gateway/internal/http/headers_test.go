@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm), WithResponseHeaders(config.ResponseHeadersConfig{
+		Headers: map[string]string{
+			"strict-transport-security": "max-age=31536000; includeSubDomains",
+			"X-Content-Type-Options":    "nosniff",
+			"Cache-Control":             "max-age=5",
+		},
+		Paths: []config.PathHeadersConfig{
+			{Path: "/mcp/call", Headers: map[string]string{"Cache-Control": "no-store"}},
+			{Path: "/admin/*", Headers: map[string]string{"Cache-Control": "private"}},
+		},
+	}))
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		cacheControl string
+	}{
+		{name: "global headers", method: http.MethodGet, path: "/health", cacheControl: "max-age=5"},
+		{name: "path override on an error response", method: http.MethodPost, path: "/mcp/call", cacheControl: "no-store"},
+		{name: "prefix match", method: http.MethodPost, path: "/admin/servers/stop-all", cacheControl: "private"},
+		{name: "unknown route", method: http.MethodGet, path: "/missing", cacheControl: "max-age=5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}")))
+
+			assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, []string{tt.cacheControl}, w.Header().Values("Cache-Control"))
+		})
+	}
+}
+
+func TestResponseHeaders_DisabledByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+}
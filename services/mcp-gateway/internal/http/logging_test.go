@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStructuredRouter returns a router with structured logging and a route that panics
+func newStructuredRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm), WithStructuredLogging())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("unexpected content type")
+	})
+	return router
+}
+
+func TestStructuredLogging_PanicReturnsErrorEnvelope(t *testing.T) {
+	router := newStructuredRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(headerRequestID, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-123", w.Header().Get(headerRequestID))
+
+	var body struct {
+		Success bool `json:"success"`
+		Error   struct {
+			Code    string         `json:"code"`
+			Details map[string]any `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.False(t, body.Success)
+	assert.Equal(t, "INTERNAL_ERROR", body.Error.Code)
+	assert.Equal(t, "req-123", body.Error.Details["requestId"])
+
+	// The panic is counted
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), panicsMetric+" 1\n")
+}
+
+func TestStructuredLogging_GeneratesRequestID(t *testing.T) {
+	router := newStructuredRouter()
+
+	for _, supplied := range []string{"", "has space", string(make([]byte, maxRequestIDLength+1))} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		if supplied != "" {
+			req.Header.Set(headerRequestID, supplied)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		id := w.Header().Get(headerRequestID)
+		assert.Len(t, id, 32, "supplied %q", supplied)
+		assert.NotEqual(t, supplied, id)
+	}
+}
+
+func TestSetupRouter_DefaultMiddlewareHasNoRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Empty(t, w.Header().Get(headerRequestID))
+}
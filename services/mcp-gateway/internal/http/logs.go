@@ -0,0 +1,113 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+const (
+	// defaultLogLines is the number of lines returned by GET /mcp/servers/:server/logs without ?lines
+	defaultLogLines = 100
+	// streamKeepaliveInterval is how often an event stream sends a comment to keep proxies from closing it
+	streamKeepaliveInterval = 15 * time.Second
+)
+
+// ServerLogs returns the last lines a server's processes wrote to stderr, for diagnosing crashes
+func (h *Handler) ServerLogs(c *gin.Context) {
+	server := c.Param("server")
+	n := defaultLogLines
+	if raw := c.Query("lines"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > mcp.MaxLogLines {
+			respondValidationError(c, fmt.Sprintf("lines must be an integer between 1 and %d", mcp.MaxLogLines))
+			return
+		}
+		n = parsed
+	}
+
+	follow := false
+	if raw := c.Query("follow"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondValidationError(c, "follow must be true or false")
+			return
+		}
+		follow = parsed
+	}
+	if follow {
+		h.followServerLogs(c, server, n)
+		return
+	}
+
+	lines, err := h.clientManager.ServerLogs(server, n)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    mcpErrors.ErrCodeServerNotFound,
+				"message": "Server not found: " + server,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"server":  server,
+		"lines":   lines,
+	})
+}
+
+// followServerLogs streams the last lines and then every new line as Server-Sent Events, like docker logs -f.
+// The stream ends when the client disconnects, falls too far behind, or the gateway shuts down.
+func (h *Handler) followServerLogs(c *gin.Context, server string, n int) {
+	recent, lines, unfollow, err := h.clientManager.FollowServerLogs(server, n)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    mcpErrors.ErrCodeServerNotFound,
+				"message": "Server not found: " + server,
+			},
+		})
+		return
+	}
+	defer unfollow()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	for _, line := range recent {
+		c.SSEvent("log", line)
+	}
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(streamKeepaliveInterval)
+	defer keepalive.Stop()
+	shutdown := shutdownNotice(c.Request.Context())
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return false
+			}
+			c.SSEvent("log", line)
+			return true
+		case <-keepalive.C:
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-shutdown:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerLogs(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{{
+		Name:    "crasher",
+		Command: "sh",
+		Args:    []string{"-c", "echo starting >&2; echo 'fatal: cannot open database' >&2; exit 1"},
+		Timeout: 30000,
+	}}))
+	router := SetupRouter(NewHandler(cm, pm))
+
+	get := func(path string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return w.Code, resp
+	}
+
+	require.Eventually(t, func() bool {
+		_, resp := get("/mcp/servers/crasher/logs")
+		lines, _ := resp["lines"].([]any)
+		return len(lines) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	code, resp := get("/mcp/servers/crasher/logs?lines=1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "crasher", resp["server"])
+	lines := resp["lines"].([]any)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "fatal: cannot open database", lines[0].(map[string]any)["line"])
+	assert.NotEmpty(t, lines[0].(map[string]any)["time"])
+
+	code, resp = get("/mcp/servers/crasher/logs?lines=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+
+	code, resp = get("/mcp/servers/missing/logs")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "SERVER_NOT_FOUND", resp["error"].(map[string]any)["code"])
+}
+
+func TestServerLogs_Follow(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{{
+		Name:    "crasher",
+		Command: "sh",
+		Args:    []string{"-c", "echo 'fatal: cannot open database' >&2; exit 1"},
+		Timeout: 30000,
+	}}))
+	require.Eventually(t, func() bool {
+		lines, _ := cm.ServerLogs("crasher", 10)
+		return len(lines) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	sm := NewServerManagerAt(SetupRouter(NewHandler(cm, pm)), "127.0.0.1:0")
+	require.NoError(t, sm.Listen())
+	go func() { _ = sm.Start() }()
+
+	resp, err := http.Get("http://" + sm.listener.Addr().String() + "/mcp/servers/crasher/logs?follow=true")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "event:log", scanner.Text())
+	require.True(t, scanner.Scan())
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data:")), &line))
+	assert.Equal(t, "fatal: cannot open database", line["line"])
+
+	// Shutting down ends the stream instead of waiting out the shutdown timeout
+	done := make(chan error, 1)
+	go func() { done <- sm.Shutdown() }()
+	_, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown waited for the followed stream")
+	}
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+)
+
+// metricsContentType is the Prometheus text exposition format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricFamily describes a per-server metric derived from call statistics
+type metricFamily struct {
+	name  string
+	help  string
+	kind  string
+	value func(s mcp.ServerCallStats) float64
+}
+
+var callMetricFamilies = []metricFamily{
+	{
+		name:  "mcp_gateway_tool_calls_total",
+		help:  "Tool calls dispatched to the server.",
+		kind:  "counter",
+		value: func(s mcp.ServerCallStats) float64 { return float64(s.Calls) },
+	},
+	{
+		name:  "mcp_gateway_tool_call_errors_total",
+		help:  "Tool calls that failed or returned a tool error.",
+		kind:  "counter",
+		value: func(s mcp.ServerCallStats) float64 { return float64(s.Errors) },
+	},
+	{
+		name:  "mcp_gateway_tool_calls_in_flight",
+		help:  "Tool calls currently awaiting a response from the server.",
+		kind:  "gauge",
+		value: func(s mcp.ServerCallStats) float64 { return float64(s.InFlight) },
+	},
+	{
+		name:  "mcp_gateway_tool_call_latency_ewma_seconds",
+		help:  "Exponentially weighted moving average of tool call latency.",
+		kind:  "gauge",
+		value: func(s mcp.ServerCallStats) float64 { return s.EWMALatency.Seconds() },
+	},
+}
+
+// restartsMetric counts restarts and reconnections, which the process manager tracks
+const restartsMetric = "mcp_gateway_server_restarts_total"
+
+// panicsMetric counts handler panics turned into INTERNAL_ERROR responses
+const panicsMetric = "mcp_gateway_http_panics_total"
+
+// Metrics exposes per-server tool call statistics in the Prometheus text format
+func (h *Handler) Metrics(c *gin.Context) {
+	stats := h.clientManager.GetCallStats()
+	servers := make([]string, 0, len(stats))
+	for name := range stats {
+		servers = append(servers, name)
+	}
+	slices.Sort(servers)
+
+	var b strings.Builder
+	for _, f := range callMetricFamilies {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, name := range servers {
+			fmt.Fprintf(&b, "%s{server=%q} %g\n", f.name, name, f.value(stats[name]))
+		}
+	}
+
+	diagnostics := h.processManager.GetAllDiagnostics()
+	restarted := make([]string, 0, len(diagnostics))
+	for name, d := range diagnostics {
+		if d.Restarts > 0 {
+			restarted = append(restarted, name)
+		}
+	}
+	slices.Sort(restarted)
+	fmt.Fprintf(&b, "# HELP %s Restarts and reconnections of the server.\n# TYPE %s counter\n", restartsMetric, restartsMetric)
+	for _, name := range restarted {
+		fmt.Fprintf(&b, "%s{server=%q} %d\n", restartsMetric, name, diagnostics[name].Restarts)
+	}
+
+	fmt.Fprintf(&b, "# HELP %s Panics recovered while handling HTTP requests.\n# TYPE %s counter\n%s %d\n",
+		panicsMetric, panicsMetric, panicsMetric, h.panics.Load())
+
+	c.Data(http.StatusOK, metricsContentType, []byte(b.String()))
+}
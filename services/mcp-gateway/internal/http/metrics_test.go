@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Metrics_Empty(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+	for _, f := range callMetricFamilies {
+		assert.Contains(t, body, "# TYPE "+f.name+" "+f.kind)
+	}
+	assert.Contains(t, body, "# TYPE "+restartsMetric+" counter")
+	assert.Contains(t, body, panicsMetric+" 0\n")
+	assert.NotContains(t, body, "{server=")
+}
+
+func TestHandler_Metrics_Restarts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	router := SetupRouter(NewHandler(cm, pm))
+	pm.SetStatus("weather", mcp.StatusRestarting)
+	pm.RecordRestart("weather", mcp.RestartReasonManual)
+	pm.RecordRestart("weather", mcp.RestartReasonManual)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Contains(t, w.Body.String(), restartsMetric+`{server="weather"} 2`)
+}
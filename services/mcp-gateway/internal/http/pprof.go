@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pprofIndex lists the available profiles
+func pprofIndex(c *gin.Context) {
+	pprof.Index(c.Writer, c.Request)
+}
+
+// pprofProfile serves a profile by name, e.g. /debug/pprof/heap or /debug/pprof/profile?seconds=10.
+// It dispatches by the route parameter rather than the URL, which carries the base path behind a reverse proxy.
+func pprofProfile(c *gin.Context) {
+	switch name := c.Param("profile"); name {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
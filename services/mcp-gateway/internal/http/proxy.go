@@ -0,0 +1,36 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// basePathContextKey holds the base path the gateway's routes are served under
+const basePathContextKey = "basePath"
+
+// basePathMiddleware records the base path so that path-based settings keep matching routes
+// such as /mcp/call regardless of the prefix the gateway is deployed under
+func basePathMiddleware(basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(basePathContextKey, basePath)
+		c.Next()
+	}
+}
+
+// routePath returns the request path relative to the base path, e.g. /mcp/call for /gateway/mcp/call.
+// Paths outside the base path are returned unchanged.
+func routePath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	base := c.GetString(basePathContextKey)
+	if base == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, base); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		if rest == "" {
+			return "/"
+		}
+		return rest
+	}
+	return path
+}
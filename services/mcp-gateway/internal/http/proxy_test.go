@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProxyTestRouter(cfg config.ReverseProxyConfig, opts ...RouterOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	return SetupRouter(NewHandler(mcp.NewClientManager(pm), pm), append(opts, WithReverseProxy(cfg))...)
+}
+
+func TestReverseProxy_BasePath(t *testing.T) {
+	var seen *authz.Request
+	router := newProxyTestRouter(config.ReverseProxyConfig{BasePath: "/gateway"},
+		WithAuthorizer(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+			seen = req
+			return authz.Deny(http.StatusForbidden, "denied"), nil
+		})),
+		WithResponseHeaders(config.ResponseHeadersConfig{
+			Paths: []config.PathHeadersConfig{{Path: "/admin/*", Headers: map[string]string{"Cache-Control": "no-store"}}},
+		}),
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{name: "health under the base path", method: http.MethodGet, path: "/gateway/health", code: http.StatusOK},
+		{name: "metrics under the base path", method: http.MethodGet, path: "/gateway/metrics", code: http.StatusOK},
+		{name: "health without the base path", method: http.MethodGet, path: "/health", code: http.StatusNotFound},
+		{name: "similar prefix", method: http.MethodGet, path: "/gatewayx/health", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+
+	// Authorization and response header paths are relative to the base path
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/gateway/admin/servers/stop-all", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "/admin/servers/stop-all", seen.Path)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestReverseProxy_TrustedProxies(t *testing.T) {
+	var clientIP string
+	router := newProxyTestRouter(config.ReverseProxyConfig{
+		TrustedProxies:  []string{"10.0.0.0/8"},
+		ClientIPHeaders: []string{"X-Forwarded-For"},
+	}, WithAuthorizer(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+		clientIP = req.ClientIP
+		return authz.Allow, nil
+	})))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		value      string
+		want       string
+	}{
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:40000", header: "X-Forwarded-For", value: "203.0.113.7", want: "203.0.113.7"},
+		{name: "trusted proxy chain", remoteAddr: "10.1.2.3:40000", header: "X-Forwarded-For", value: "203.0.113.7, 10.9.9.9", want: "203.0.113.7"},
+		{name: "untrusted peer", remoteAddr: "198.51.100.1:40000", header: "X-Forwarded-For", value: "203.0.113.7", want: "198.51.100.1"},
+		{name: "header not configured", remoteAddr: "10.1.2.3:40000", header: "X-Real-IP", value: "203.0.113.7", want: "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(tt.header, tt.value)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.want, clientIP)
+		})
+	}
+}
+
+func TestReverseProxy_NoTrustedProxies(t *testing.T) {
+	var clientIP string
+	router := newProxyTestRouter(config.ReverseProxyConfig{ClientIPHeaders: config.DefaultClientIPHeaders},
+		WithAuthorizer(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+			clientIP = req.ClientIP
+			return authz.Allow, nil
+		})))
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/tools", nil)
+	req.RemoteAddr = "10.1.2.3:40000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "10.1.2.3", clientIP)
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// The /mcp/call hot path writes typed envelopes through pooled buffers rather than gin.H maps through
+// c.JSON, which allocates the map, sorts its keys and copies the encoded body; see BenchmarkCallTool.
+
+const jsonContentType = "application/json; charset=utf-8"
+
+// maxPooledBufferSize is the capacity above which a response buffer is dropped rather than pooled, so
+// that one huge tool result does not pin its memory
+const maxPooledBufferSize = 64 << 10
+
+var responseBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// callResponse is the envelope of /mcp/call responses
+type callResponse struct {
+	Success bool       `json:"success"`
+	Result  any        `json:"result,omitempty"`
+	Error   *errorBody `json:"error,omitempty"`
+}
+
+// errorBody is the error of a failed response
+type errorBody struct {
+	Code    mcpErrors.ErrorCode `json:"code"`
+	Message string              `json:"message"`
+	Details any                 `json:"details,omitempty"`
+}
+
+// toolDetails are the details of errors about a tool call
+type toolDetails struct {
+	ToolName   string `json:"toolName"`
+	ServerName string `json:"serverName"`
+	Timeout    int64  `json:"timeout,omitempty"` // ms, of calls that timed out
+}
+
+// violationDetails are the details of VALIDATION_ERRORs that list every violation of a request
+type violationDetails struct {
+	Violations []validator.Violation `json:"violations"`
+}
+
+// failure returns the envelope of a failed response
+func failure(code mcpErrors.ErrorCode, message string, details any) callResponse {
+	return callResponse{Error: &errorBody{Code: code, Message: message, Details: details}}
+}
+
+// writeJSON writes v as the JSON response body, like c.JSON but encoding into a pooled buffer. A value
+// that cannot be encoded is answered with INTERNAL_ERROR, since nothing has been written yet.
+func writeJSON(c *gin.Context, status int, v any) {
+	buf := responseBuffers.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			responseBuffers.Put(buf)
+		}
+	}()
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		slog.Error("Failed to encode response", "error", err, "requestId", requestID(c))
+		respondInternalError(c)
+		return
+	}
+	// Encode terminates the value with a newline, which c.JSON does not
+	c.Data(status, jsonContentType, bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
+
+// sentinelBodies are the pre-encoded responses to the sentinel errors that calls are rejected with
+// unchanged, e.g. SERVER_BUSY while shedding load, which should cost as little as possible
+var sentinelBodies = func() map[*mcpErrors.GatewayError][]byte {
+	bodies := make(map[*mcpErrors.GatewayError][]byte)
+	for _, sentinel := range []*mcpErrors.GatewayError{
+		mcpErrors.ErrServerNotFound,
+		mcpErrors.ErrServerNotRunning,
+		mcpErrors.ErrServerCrashed,
+		mcpErrors.ErrServerBusy,
+		mcpErrors.ErrToolNotFound,
+		mcpErrors.ErrInvalidInput,
+	} {
+		body, err := json.Marshal(failure(sentinel.Code, sentinel.Error(), nil))
+		if err != nil {
+			panic(err)
+		}
+		bodies[sentinel] = body
+	}
+	return bodies
+}()
+
+// respondCallError writes the response for an error returned by ClientManager.CallTool
+func respondCallError(c *gin.Context, err error) {
+	if sentinel, ok := err.(*mcpErrors.GatewayError); ok {
+		if body, ok := sentinelBodies[sentinel]; ok {
+			c.Data(sentinel.HTTPStatus, jsonContentType, body)
+			return
+		}
+	}
+
+	status, code := callErrorStatus(err)
+	var details any
+	if gatewayErr, ok := mcpErrors.As(err); ok && len(gatewayErr.Details) > 0 {
+		details = gatewayErr.Details
+	}
+	writeJSON(c, status, failure(code, err.Error(), details))
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func respondTo(f func(c *gin.Context)) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/mcp/call", nil)
+	f(c)
+	return w
+}
+
+func TestRespondCallError_SentinelBodiesMatchEncodedOnes(t *testing.T) {
+	for sentinel := range sentinelBodies {
+		t.Run(string(sentinel.Code), func(t *testing.T) {
+			pre := respondTo(func(c *gin.Context) { respondCallError(c, sentinel) })
+			// Wrapped in another error, the sentinel is encoded per response
+			encoded := respondTo(func(c *gin.Context) { respondCallError(c, fmt.Errorf("%w", sentinel)) })
+
+			require.Equal(t, sentinel.HTTPStatus, pre.Code)
+			assert.Equal(t, encoded.Code, pre.Code)
+			assert.Equal(t, encoded.Header().Get("Content-Type"), pre.Header().Get("Content-Type"))
+			assert.JSONEq(t, encoded.Body.String(), pre.Body.String())
+		})
+	}
+}
+
+func TestRespondCallError_Details(t *testing.T) {
+	err := mcpErrors.ErrToolNotFound.WithDetails(map[string]any{"toolName": "missing"})
+	w := respondTo(func(c *gin.Context) { respondCallError(c, err) })
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":{"code":"TOOL_NOT_FOUND","message":"tool not found","details":{"toolName":"missing"}}}`, w.Body.String())
+}
+
+func TestWriteJSON_UnencodableValue(t *testing.T) {
+	w := respondTo(func(c *gin.Context) { writeJSON(c, http.StatusOK, callResponse{Success: true, Result: make(chan int)}) })
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"INTERNAL_ERROR"`)
+}
@@ -1,29 +1,278 @@
 package http
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/toolsearch"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/usage"
 )
 
+// routerOptions holds optional router features
+type routerOptions struct {
+	authorizer        authz.Authorizer
+	apiKeys           *apiKeyAuthenticator
+	structuredLogging bool
+	selfDiagnostics   bool
+	usage             *usage.Recorder
+	responseHeaders   *config.ResponseHeadersConfig
+	reverseProxy      *config.ReverseProxyConfig
+	largePayloads     *config.LargePayloadsConfig
+	logLevel          *slog.LevelVar
+	transactions      bool
+	embedder          toolsearch.Embedder
+	fakeClock         *clock.Fake
+}
+
+// RouterOption customizes SetupRouter
+type RouterOption func(*routerOptions)
+
+// WithAuthorizer delegates authorization of /mcp and /admin requests to the given authorizer
+func WithAuthorizer(a authz.Authorizer) RouterOption {
+	return func(o *routerOptions) {
+		o.authorizer = a
+	}
+}
+
+// WithAPIKeys requires one of the given API keys on /mcp and /admin requests and
+// applies the limits of the profile assigned to each key
+func WithAPIKeys(keys []config.APIKeyConfig, profiles []config.ProfileConfig) RouterOption {
+	return func(o *routerOptions) {
+		if len(keys) > 0 {
+			o.apiKeys = newAPIKeyAuthenticator(keys, profiles)
+		}
+	}
+}
+
+// WithStructuredLogging replaces gin.Logger and gin.Recovery with slog access logs, request IDs and
+// a panic handler that responds with the standard error envelope
+func WithStructuredLogging() RouterOption {
+	return func(o *routerOptions) {
+		o.structuredLogging = true
+	}
+}
+
+// WithSelfDiagnostics adds gateway process diagnostics (goroutines, memory, open file descriptors,
+// in-flight calls and cache sizes) to /health
+func WithSelfDiagnostics() RouterOption {
+	return func(o *routerOptions) {
+		o.selfDiagnostics = true
+	}
+}
+
+// WithUsageRecorder records every tool call per tool and API key for usage analytics
+func WithUsageRecorder(r *usage.Recorder) RouterOption {
+	return func(o *routerOptions) {
+		o.usage = r
+	}
+}
+
+// WithResponseHeaders adds the configured headers to responses, e.g. HSTS or Cache-Control: no-store
+func WithResponseHeaders(cfg config.ResponseHeadersConfig) RouterOption {
+	return func(o *routerOptions) {
+		o.responseHeaders = &cfg
+	}
+}
+
+// WithReverseProxy serves every route under the configured base path and takes the client IP
+// from the configured headers only when the request comes from a trusted proxy
+func WithReverseProxy(cfg config.ReverseProxyConfig) RouterOption {
+	return func(o *routerOptions) {
+		o.reverseProxy = &cfg
+	}
+}
+
+// WithLargePayloads raises the body limit of POST /mcp/call to the configured ceiling, passes its inputs
+// on as sent and streams its results. The validator's input size limit must be raised alike, see
+// validator.SetMaxInputSize.
+func WithLargePayloads(cfg config.LargePayloadsConfig) RouterOption {
+	return func(o *routerOptions) {
+		o.largePayloads = &cfg
+	}
+}
+
+// WithLogLevel enables PUT /admin/loglevel, which changes the given level at runtime
+func WithLogLevel(level *slog.LevelVar) RouterOption {
+	return func(o *routerOptions) {
+		o.logLevel = level
+	}
+}
+
+// WithTransactions enables the experimental POST /mcp/transactions API
+func WithTransactions() RouterOption {
+	return func(o *routerOptions) {
+		o.transactions = true
+	}
+}
+
+// WithToolSearchEmbedder ranks the results of POST /mcp/tools/search by embedding similarity
+// instead of by keywords
+func WithToolSearchEmbedder(e toolsearch.Embedder) RouterOption {
+	return func(o *routerOptions) {
+		o.embedder = e
+	}
+}
+
+// WithFakeClock enables POST /admin/clock/advance, which moves the given clock, for a gateway running
+// in deterministic mode
+func WithFakeClock(c *clock.Fake) RouterOption {
+	return func(o *routerOptions) {
+		o.fakeClock = c
+	}
+}
+
 // SetupRouter configures the Gin engine and routes
-func SetupRouter(handler *Handler) *gin.Engine {
+func SetupRouter(handler *Handler, opts ...RouterOption) *gin.Engine {
+	return SetupListenerRouters(handler, []config.ListenerConfig{{Routes: config.ListenerRoutesAll}}, opts...)[0]
+}
+
+// SetupListenerRouters configures an engine for each listener, serving the route group of the listener
+// (all, api or admin). The engines share API keys, quotas and runtime settings, so that e.g. limits set
+// through an admin listener apply to the calls on an API listener. A listener with its own API keys
+// accepts those instead of the global ones.
+func SetupListenerRouters(handler *Handler, listeners []config.ListenerConfig, opts ...RouterOption) []*gin.Engine {
+	var options routerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	handler.selfDiagnose = options.selfDiagnostics
+	handler.largePayloads = options.largePayloads
+
+	shared := sharedEndpoints{
+		search: &toolSearch{handler: handler, searcher: toolsearch.New(options.embedder)},
+		// MCP messages over plain JSON-RPC; methods are authorized and quota-checked by the handler
+		rpc: &rpcEndpoint{calls: toolCaller{handler: handler, usage: options.usage}, authorizer: options.authorizer},
+		// Runtime settings for mid-incident tuning, which revert on restart or after an optional TTL
+		settings: &runtimeSettings{handler: handler, logLevel: options.logLevel, apiKeys: options.apiKeys},
+	}
+	if options.transactions {
+		// Steps are authorized and recorded one by one by the handler
+		shared.tx = &transactions{calls: toolCaller{handler: handler, usage: options.usage}, authorizer: options.authorizer}
+	}
+
+	engines := make([]*gin.Engine, len(listeners))
+	for i, listener := range listeners {
+		engines[i] = newRouter(handler, &options, shared, listener)
+	}
+	return engines
+}
+
+// sharedEndpoints are the handlers with state, shared by the engines of all listeners
+type sharedEndpoints struct {
+	search   *toolSearch
+	rpc      *rpcEndpoint
+	tx       *transactions // nil unless transactions are enabled
+	settings *runtimeSettings
+}
+
+// newRouter builds the engine of one listener
+func newRouter(handler *Handler, options *routerOptions, shared sharedEndpoints, listener config.ListenerConfig) *gin.Engine {
 	// Create Gin instance
 	r := gin.New()
 
+	basePath := ""
+	if proxy := options.reverseProxy; proxy != nil {
+		basePath = proxy.BasePath
+		r.RemoteIPHeaders = proxy.ClientIPHeaders
+		// Proxies are validated with the config; if Gin still rejects them, trust none rather than all
+		if err := r.SetTrustedProxies(proxy.TrustedProxies); err != nil {
+			slog.Error("Invalid trusted proxies, ignoring client IP headers", "error", err)
+			_ = r.SetTrustedProxies(nil)
+		}
+		r.Use(basePathMiddleware(basePath))
+	}
+
 	// Middleware
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	if options.structuredLogging {
+		r.Use(requestIDMiddleware)
+		r.Use(accessLogMiddleware)
+		r.Use(handler.recoveryMiddleware)
+	} else {
+		r.Use(gin.Logger())
+		r.Use(gin.Recovery())
+	}
+	if options.responseHeaders != nil {
+		r.Use(responseHeadersMiddleware(*options.responseHeaders))
+	}
 	r.Use(func(c *gin.Context) {
 		const maxBodySize = 100 * 1024 // 100KB
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+		limit := int64(maxBodySize)
+		if options.largePayloads != nil && c.FullPath() == basePath+"/mcp/call" {
+			limit = options.largePayloads.MaxRequestBytes
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 		c.Next()
 	})
 
-	// Routes
-	r.POST("/mcp/call", handler.CallTool)
-	r.GET("/mcp/tools", handler.GetTools)
-	r.GET("/health", handler.Health)
+	routes := r.Group(basePath)
+
+	// Health is served by every listener and metrics by every listener serving admin routes.
+	// Both are public so that probes and scrapers work regardless of authorization.
+	routes.GET("/health", handler.Health)
+	if listener.Routes != config.ListenerRoutesAPI {
+		routes.GET("/metrics", handler.Metrics)
+	}
+
+	// Routes that require authorization when an authorizer is configured
+	protected := routes.Group("")
+	if len(listener.APIKeys) > 0 {
+		protected.Use(apiKeyMiddleware(newAPIKeyAuthenticator(listener.APIKeys, nil)))
+	} else if options.apiKeys != nil {
+		protected.Use(apiKeyMiddleware(options.apiKeys))
+	}
+	if options.authorizer != nil {
+		protected.Use(authorizationMiddleware(options.authorizer))
+	}
+
+	if listener.Routes != config.ListenerRoutesAdmin {
+		callHandlers := []gin.HandlerFunc{callTracingMiddleware}
+		if options.usage != nil {
+			callHandlers = append(callHandlers, usageMiddleware(options.usage))
+		}
+		callHandlers = append(callHandlers, callQuotaMiddleware, handler.CallTool)
+		protected.POST("/mcp/call", callHandlers...)
+		if shared.tx != nil {
+			protected.POST("/mcp/transactions", callQuotaMiddleware, shared.tx.Run)
+		}
+		protected.GET("/mcp/tools", handler.GetTools)
+		protected.GET("/mcp/tools/events", handler.ToolEvents)
+		protected.GET("/mcp/tools/:server/:tool/config", handler.GetToolConfig)
+		protected.POST("/mcp/tools/search", shared.search.Search)
+		protected.POST("/mcp/feedback", handler.Feedback)
+		protected.GET("/mcp/servers", handler.ListServers)
+		protected.GET("/mcp/servers/:server", handler.GetServer)
+		protected.GET("/mcp/servers/:server/logs", handler.ServerLogs)
+		protected.POST("/rpc", shared.rpc.Serve)
+	}
+
+	if listener.Routes != config.ListenerRoutesAPI {
+		// Admin routes, and the lifecycle event stream for operators
+		protected.GET("/events", handler.Events)
+		admin := protected.Group("/admin")
+		admin.POST("/servers/restart-all", handler.RestartAll)
+		admin.POST("/servers/stop-all", handler.StopAll)
+		admin.POST("/servers/refresh-tools-all", handler.RefreshToolsAll)
+		admin.POST("/servers/:server/reset-restarts", handler.ResetRestarts)
+		admin.PUT("/limits", shared.settings.SetLimits)
+		if options.logLevel != nil {
+			admin.PUT("/loglevel", shared.settings.SetLogLevel)
+		}
+		if options.fakeClock != nil {
+			admin.POST("/clock/advance", (&fakeClockEndpoint{clock: options.fakeClock}).Advance)
+		}
+	}
+
+	// Profiling is too revealing for a port shared with agents, so only dedicated admin listeners serve it
+	if listener.Routes == config.ListenerRoutesAdmin {
+		protected.GET("/debug/pprof/", pprofIndex)
+		protected.GET("/debug/pprof/:profile", pprofProfile)
+		protected.POST("/debug/pprof/:profile", pprofProfile)
+	}
 
 	return r
 }
@@ -1,9 +1,12 @@
 package http
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,9 +26,20 @@ func TestSetupRouter(t *testing.T) {
 
 	// Expected routes: method + path
 	expectedRoutes := map[string]bool{
-		"POST /mcp/call": false,
-		"GET /mcp/tools": false,
-		"GET /health":    false,
+		"POST /mcp/call":                false,
+		"GET /mcp/tools":                false,
+		"POST /mcp/tools/search":        false,
+		"POST /mcp/feedback":            false,
+		"GET /mcp/servers":              false,
+		"GET /mcp/servers/:server":      false,
+		"GET /mcp/servers/:server/logs": false,
+		"POST /rpc":                     false,
+		"GET /health":                   false,
+		"GET /metrics":                  false,
+
+		"POST /admin/servers/restart-all":       false,
+		"POST /admin/servers/stop-all":          false,
+		"POST /admin/servers/refresh-tools-all": false,
 	}
 
 	// Check that all expected routes exist
@@ -110,3 +124,75 @@ func TestSetupRouter_HealthRoute(t *testing.T) {
 
 	require.True(t, found, "GET /health route should be registered")
 }
+
+// TestSetupListenerRouters verifies that each listener serves only its route group.
+func TestSetupListenerRouters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	routers := SetupListenerRouters(handler, []config.ListenerConfig{
+		{Routes: config.ListenerRoutesAPI},
+		{Routes: config.ListenerRoutesAdmin},
+	})
+	require.Len(t, routers, 2)
+
+	routeSet := func(r *gin.Engine) map[string]bool {
+		set := make(map[string]bool)
+		for _, route := range r.Routes() {
+			set[route.Method+" "+route.Path] = true
+		}
+		return set
+	}
+	api, admin := routeSet(routers[0]), routeSet(routers[1])
+
+	assert.True(t, api["POST /mcp/call"])
+	assert.True(t, api["POST /rpc"])
+	assert.False(t, api["POST /admin/servers/restart-all"], "the data plane does not expose admin routes")
+
+	assert.True(t, admin["POST /admin/servers/restart-all"])
+	assert.True(t, admin["PUT /admin/limits"])
+	assert.False(t, admin["POST /mcp/call"])
+
+	assert.True(t, admin["GET /metrics"])
+	assert.False(t, api["GET /metrics"], "operational endpoints stay off the data plane")
+	assert.True(t, admin["GET /debug/pprof/:profile"])
+	assert.False(t, api["GET /debug/pprof/:profile"])
+
+	for _, routes := range []map[string]bool{api, admin} {
+		assert.True(t, routes["GET /health"], "probes work on every listener")
+	}
+
+	all := routeSet(SetupRouter(handler))
+	assert.True(t, all["GET /metrics"])
+	assert.False(t, all["GET /debug/pprof/:profile"], "profiling needs a dedicated admin listener")
+}
+
+// TestSetupListenerRouters_APIKeys verifies that a listener with its own API keys accepts only those.
+func TestSetupListenerRouters_APIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	handler := NewHandler(cm, pm)
+
+	routers := SetupListenerRouters(handler, []config.ListenerConfig{
+		{Routes: config.ListenerRoutesAPI},
+		{Routes: config.ListenerRoutesAdmin, APIKeys: []config.APIKeyConfig{{Name: "ops", Key: "ops-key-0123456789"}}},
+	}, WithAPIKeys([]config.APIKeyConfig{{Name: "agent", Key: "agent-key-0123456789"}}, nil))
+
+	status := func(r *gin.Engine, path, key string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, status(routers[0], "/mcp/tools", "agent-key-0123456789"))
+	assert.Equal(t, http.StatusUnauthorized, status(routers[0], "/mcp/tools", "ops-key-0123456789"))
+
+	assert.Equal(t, http.StatusOK, status(routers[1], "/debug/pprof/cmdline", "ops-key-0123456789"))
+	assert.Equal(t, http.StatusUnauthorized, status(routers[1], "/debug/pprof/cmdline", "agent-key-0123456789"))
+	assert.Equal(t, http.StatusOK, status(routers[1], "/metrics", ""), "scrapers need no key")
+}
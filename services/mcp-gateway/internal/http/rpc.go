@@ -0,0 +1,239 @@
+package http
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// JSON-RPC 2.0 error codes. Gateway errors use rpcServerError with the gateway's error code in data.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// rpcProtocolVersion is the MCP version reported by initialize when the client does not ask for one
+const rpcProtocolVersion = "2025-06-18"
+
+// rpcToolSeparator joins the server and tool name into the MCP tool name, e.g. weather-server.fetch-weather.
+// Neither the server nor the tool name of a callable tool can contain it.
+const rpcToolSeparator = "."
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// gatewayRPCError wraps a gateway error code, e.g. SERVER_BUSY, in a JSON-RPC error
+func gatewayRPCError(code mcpErrors.ErrorCode, message string) *rpcError {
+	rpcCode := rpcServerError
+	switch code {
+	case mcpErrors.ErrCodeValidation, mcpErrors.ErrCodeServerNotFound, mcpErrors.ErrCodeToolNotFound:
+		rpcCode = rpcInvalidParams
+	}
+	return &rpcError{Code: rpcCode, Message: message, Data: gin.H{"code": code}}
+}
+
+// rpcEndpoint serves POST /rpc, which speaks the MCP messages initialize, ping, tools/list and
+// tools/call as plain JSON-RPC 2.0 over HTTP, for thin clients written against MCP message shapes.
+// Tools of all servers are listed under one namespace as <server>.<tool>. Each method is authorized
+// as its REST counterpart.
+type rpcEndpoint struct {
+	calls      toolCaller
+	authorizer authz.Authorizer
+}
+
+func (r *rpcEndpoint) Serve(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		r.respond(c, http.StatusBadRequest, nil, nil, &rpcError{Code: rpcParseError, Message: "Parse error: " + err.Error()})
+		return
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		r.respond(c, http.StatusBadRequest, nil, nil, &rpcError{Code: rpcInvalidRequest, Message: "Batch requests are not supported"})
+		return
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		r.respond(c, http.StatusBadRequest, nil, nil, &rpcError{Code: rpcParseError, Message: "Parse error: " + err.Error()})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		r.respond(c, http.StatusBadRequest, req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: `Invalid request: jsonrpc must be "2.0" and method is required`})
+		return
+	}
+	// Notifications such as notifications/initialized need no answer and change nothing here
+	if req.ID == nil {
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	var result any
+	var rpcErr *rpcError
+	switch req.Method {
+	case "initialize":
+		result, rpcErr = r.initialize(req.Params)
+	case "ping":
+		result = gin.H{}
+	case "tools/list":
+		result, rpcErr = r.listTools(c)
+	case "tools/call":
+		result, rpcErr = r.callTool(c, req.Params)
+	default:
+		rpcErr = &rpcError{Code: rpcMethodNotFound, Message: "Method not found: " + req.Method}
+	}
+	r.respond(c, http.StatusOK, req.ID, result, rpcErr)
+}
+
+func (r *rpcEndpoint) respond(c *gin.Context, status int, id json.RawMessage, result any, rpcErr *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	c.JSON(status, rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
+
+func (r *rpcEndpoint) initialize(params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "Invalid params: " + err.Error()}
+		}
+	}
+	return &mcpSDK.InitializeResult{
+		ProtocolVersion: cmp.Or(p.ProtocolVersion, rpcProtocolVersion),
+		Capabilities:    &mcpSDK.ServerCapabilities{Tools: &mcpSDK.ToolCapabilities{}},
+		ServerInfo:      &mcpSDK.Implementation{Name: "mcp-gateway", Version: "1.0.0"},
+	}, nil
+}
+
+func (r *rpcEndpoint) listTools(c *gin.Context) (any, *rpcError) {
+	if r.authorizer != nil {
+		req := buildAuthzRequest(c, r.authorizer)
+		req.Method, req.Path = http.MethodGet, "/mcp/tools"
+		req.Server, req.Tool, req.Input = "", "", nil
+		decision, err := r.authorizer.Authorize(c.Request.Context(), req)
+		if rpcErr := r.authorizationError(c, decision, err); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+
+	tools := r.calls.handler.clientManager.GetTools()
+	slices.SortFunc(tools, func(a, b mcp.ToolInfo) int {
+		return cmp.Or(cmp.Compare(a.Server, b.Server), cmp.Compare(a.Name, b.Name))
+	})
+	result := &mcpSDK.ListToolsResult{Tools: make([]*mcpSDK.Tool, 0, len(tools))}
+	for _, tool := range tools {
+		t := &mcpSDK.Tool{
+			Name:         tool.Server + rpcToolSeparator + tool.Name,
+			Description:  tool.Description,
+			InputSchema:  tool.InputSchema,
+			OutputSchema: tool.OutputSchema,
+		}
+		if tool.ReadOnly {
+			t.Annotations = &mcpSDK.ToolAnnotations{ReadOnlyHint: true}
+		}
+		result.Tools = append(result.Tools, t)
+	}
+	return result, nil
+}
+
+// callTool calls a tool like /mcp/call. A tool that fails returns its result with isError, as in MCP;
+// only gateway errors become JSON-RPC errors.
+func (r *rpcEndpoint) callTool(c *gin.Context, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string `json:"name"`
+		Arguments any    `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "Invalid params: " + err.Error()}
+	}
+	if p.Arguments == nil {
+		p.Arguments = map[string]any{}
+	}
+	server, tool, ok := strings.Cut(p.Name, rpcToolSeparator)
+	if !ok {
+		return nil, gatewayRPCError(mcpErrors.ErrCodeValidation, "name must be <server>"+rpcToolSeparator+"<tool>")
+	}
+	if err := validator.ValidateRequest(server, tool, p.Arguments); err != nil {
+		rpcErr := gatewayRPCError(mcpErrors.ErrCodeValidation, err.Error())
+		var invalid *validator.ValidationError
+		if errors.As(err, &invalid) {
+			rpcErr.Data = gin.H{"code": mcpErrors.ErrCodeValidation, "violations": invalid.Violations}
+		}
+		return nil, rpcErr
+	}
+
+	// The quota of /mcp/call, checked here so that it is reported as a JSON-RPC error
+	if caller := callerFrom(c); caller != nil {
+		if !caller.allow() {
+			return nil, gatewayRPCError(mcpErrors.ErrCodeQuotaExceeded, "Request rate limit exceeded for API key "+caller.name)
+		}
+		if !caller.acquire() {
+			return nil, gatewayRPCError(mcpErrors.ErrCodeQuotaExceeded, "Too many concurrent calls for API key "+caller.name)
+		}
+		defer caller.release()
+	}
+
+	if r.authorizer != nil {
+		base := buildAuthzRequest(c, r.authorizer)
+		decision, err := authorizeCall(c, r.authorizer, base, server, tool, &p.Arguments)
+		if rpcErr := r.authorizationError(c, decision, err); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+
+	out := r.calls.call(c, c.Request.Context(), server, tool, p.Arguments, false)
+	if out.err != nil && !out.toolError {
+		return nil, gatewayRPCError(out.err.Code, out.err.Message)
+	}
+	return out.result, nil
+}
+
+// authorizationError returns the JSON-RPC error for a failed or denying authorization, or nil if allowed
+func (r *rpcEndpoint) authorizationError(c *gin.Context, decision authz.Decision, err error) *rpcError {
+	if err != nil {
+		slog.Error("Authorization check failed", "path", routePath(c), "error", err)
+		return gatewayRPCError(mcpErrors.ErrCodeAuthorization, "Authorization service unavailable")
+	}
+	if decision.Allowed {
+		return nil
+	}
+	code := mcpErrors.ErrCodeForbidden
+	if decision.Status == http.StatusUnauthorized {
+		code = mcpErrors.ErrCodeUnauthorized
+	}
+	return gatewayRPCError(code, decision.Reason)
+}
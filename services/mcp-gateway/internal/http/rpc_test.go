@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRPCTestRouter serves an in-process "calc" server with a read-only add tool and a tool that always fails
+func newRPCTestRouter(t *testing.T, opts ...RouterOption) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "calc", Version: "test"}, nil)
+	type addInput struct {
+		A float64 `json:"a"`
+		B float64 `json:"b"`
+	}
+	type addOutput struct {
+		Sum float64 `json:"sum"`
+	}
+	mcpSDK.AddTool(server, &mcpSDK.Tool{Name: "add", Description: "Add two numbers", Annotations: &mcpSDK.ToolAnnotations{ReadOnlyHint: true}},
+		func(_ context.Context, _ *mcpSDK.CallToolRequest, in addInput) (*mcpSDK.CallToolResult, addOutput, error) {
+			return nil, addOutput{Sum: in.A + in.B}, nil
+		})
+	server.AddTool(&mcpSDK.Tool{Name: "fail", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+			return &mcpSDK.CallToolResult{IsError: true, Content: []mcpSDK.Content{&mcpSDK.TextContent{Text: "boom"}}}, nil
+		})
+
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	require.NoError(t, cm.RegisterInProcess("calc", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	t.Cleanup(func() { _ = cm.Close() })
+	return SetupRouter(NewHandler(cm, pm), opts...)
+}
+
+func postRPC(t *testing.T, router *gin.Engine, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.Len() == 0 {
+		return w.Code, nil
+	}
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestRPC_Initialize(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	code, resp := postRPC(t, router, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-03-26"}}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "2.0", resp["jsonrpc"])
+	assert.Equal(t, float64(1), resp["id"])
+	result := resp["result"].(map[string]any)
+	assert.Equal(t, "2025-03-26", result["protocolVersion"])
+	assert.Equal(t, map[string]any{"tools": map[string]any{}}, result["capabilities"])
+	assert.Equal(t, "mcp-gateway", result["serverInfo"].(map[string]any)["name"])
+
+	code, resp = postRPC(t, router, `{"jsonrpc": "2.0", "id": "p", "method": "ping"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"jsonrpc": "2.0", "id": "p", "result": map[string]any{}}, resp)
+}
+
+func TestRPC_ListTools(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	code, resp := postRPC(t, router, `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	tools := resp["result"].(map[string]any)["tools"].([]any)
+	require.Len(t, tools, 2)
+	add := tools[0].(map[string]any)
+	assert.Equal(t, "calc.add", add["name"])
+	assert.Equal(t, "Add two numbers", add["description"])
+	assert.Equal(t, map[string]any{"readOnlyHint": true}, add["annotations"])
+	assert.NotNil(t, add["inputSchema"])
+	assert.Equal(t, "calc.fail", tools[1].(map[string]any)["name"])
+}
+
+func TestRPC_CallTool(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	code, resp := postRPC(t, router, `{"jsonrpc": "2.0", "id": 7, "method": "tools/call", "params": {"name": "calc.add", "arguments": {"a": 1, "b": 2}}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, resp["error"])
+	assert.Equal(t, map[string]any{"sum": float64(3)}, resp["result"].(map[string]any)["structuredContent"])
+
+	// Tool errors are results, as in MCP
+	code, resp = postRPC(t, router, `{"jsonrpc": "2.0", "id": 8, "method": "tools/call", "params": {"name": "calc.fail"}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, resp["error"])
+	result := resp["result"].(map[string]any)
+	assert.Equal(t, true, result["isError"])
+	assert.Equal(t, "boom", result["content"].([]any)[0].(map[string]any)["text"])
+}
+
+func TestRPC_Errors(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		code     float64
+		dataCode string
+	}{
+		{name: "Parse error", body: `{"jsonrpc": "2.0", `, status: http.StatusBadRequest, code: -32700},
+		{name: "Batch", body: `[{"jsonrpc": "2.0", "id": 1, "method": "ping"}]`, status: http.StatusBadRequest, code: -32600},
+		{name: "Wrong version", body: `{"jsonrpc": "1.0", "id": 1, "method": "ping"}`, status: http.StatusBadRequest, code: -32600},
+		{name: "Unknown method", body: `{"jsonrpc": "2.0", "id": 1, "method": "resources/list"}`, status: http.StatusOK, code: -32601},
+		{
+			name:     "Name without server",
+			body:     `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "add"}}`,
+			status:   http.StatusOK,
+			code:     -32602,
+			dataCode: "VALIDATION_ERROR",
+		},
+		{
+			name:     "Unknown server",
+			body:     `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "missing.add"}}`,
+			status:   http.StatusOK,
+			code:     -32602,
+			dataCode: "SERVER_NOT_FOUND",
+		},
+		{
+			name:     "Unknown tool",
+			body:     `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "calc.missing"}}`,
+			status:   http.StatusOK,
+			code:     -32602,
+			dataCode: "TOOL_NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := postRPC(t, router, tt.body)
+			assert.Equal(t, tt.status, status)
+			assert.Nil(t, resp["result"])
+			rpcErr := resp["error"].(map[string]any)
+			assert.Equal(t, tt.code, rpcErr["code"])
+			if tt.dataCode != "" {
+				assert.Equal(t, tt.dataCode, rpcErr["data"].(map[string]any)["code"])
+			}
+		})
+	}
+}
+
+func TestRPC_NotificationIsAccepted(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	code, resp := postRPC(t, router, `{"jsonrpc": "2.0", "method": "notifications/initialized"}`)
+
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Nil(t, resp)
+}
+
+func TestRPC_AuthorizesMethodsAsRESTCalls(t *testing.T) {
+	var seen []string
+	router := newRPCTestRouter(t, WithAuthorizer(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+		seen = append(seen, req.Method+" "+req.Path+" "+req.Tool)
+		if req.Tool == "fail" {
+			return authz.Deny(http.StatusForbidden, "fail not permitted"), nil
+		}
+		return authz.Allow, nil
+	})))
+
+	code, resp := postRPC(t, router, `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, resp["result"])
+
+	code, resp = postRPC(t, router, `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "calc.fail"}}`)
+	assert.Equal(t, http.StatusOK, code)
+	rpcErr := resp["error"].(map[string]any)
+	assert.Equal(t, float64(-32000), rpcErr["code"])
+	assert.Equal(t, "fail not permitted", rpcErr["message"])
+	assert.Equal(t, "FORBIDDEN", rpcErr["data"].(map[string]any)["code"])
+
+	assert.Equal(t, []string{"POST /rpc ", "GET /mcp/tools ", "POST /rpc ", "POST /mcp/call fail"}, seen)
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// overrides tracks settings changed at runtime that revert when their TTL expires
+type overrides struct {
+	mu      sync.Mutex
+	pending map[string]*override
+}
+
+type override struct {
+	restore func()
+}
+
+// apply runs change for the setting key. With a TTL, the value in effect before the first pending
+// change to key is restored once the TTL expires; changing key again restarts the TTL.
+// Without a TTL the change is permanent and cancels a pending restore.
+func (o *overrides) apply(key string, ttl time.Duration, snapshot func() (restore func()), change func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.pending == nil {
+		o.pending = make(map[string]*override)
+	}
+
+	var restore func()
+	if p, ok := o.pending[key]; ok {
+		restore = p.restore
+		delete(o.pending, key)
+	} else {
+		restore = snapshot()
+	}
+	change()
+	if ttl <= 0 {
+		return
+	}
+
+	next := &override{restore: restore}
+	o.pending[key] = next
+	time.AfterFunc(ttl, func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		// A later change to the same setting owns the restore now
+		if o.pending[key] != next {
+			return
+		}
+		delete(o.pending, key)
+		next.restore()
+	})
+}
+
+// logLevels are the levels accepted by PUT /admin/loglevel
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// runtimeSettings changes the log level and limits of the running gateway
+type runtimeSettings struct {
+	handler   *Handler
+	logLevel  *slog.LevelVar       // nil when the log level cannot be changed
+	apiKeys   *apiKeyAuthenticator // nil when API keys are not configured
+	overrides overrides
+}
+
+// SetLogLevelRequest is the body of PUT /admin/loglevel
+type SetLogLevelRequest struct {
+	Level string `json:"level" binding:"required"`         // debug, info, warn or error
+	TTL   int    `json:"ttl" binding:"min=0,max=86400000"` // ms until the previous level is restored, 0 keeps the change
+}
+
+// SetLimitsRequest is the body of PUT /admin/limits. Omitted fields keep their current value.
+type SetLimitsRequest struct {
+	Servers  map[string]ServerLimits  `json:"servers" binding:"dive"`
+	Profiles map[string]ProfileLimits `json:"profiles" binding:"dive"`
+	TTL      int                      `json:"ttl" binding:"min=0,max=86400000"` // ms until the previous limits are restored, 0 keeps the change
+}
+
+// ServerLimits are the limits of an MCP server that can be changed at runtime
+type ServerLimits struct {
+	MaxConcurrentCalls *int `json:"maxConcurrentCalls" binding:"omitempty,min=0,max=10000"`
+}
+
+// ProfileLimits are the limits of an API key profile that can be changed at runtime
+type ProfileLimits struct {
+	RequestsPerMinute  *int `json:"requestsPerMinute" binding:"omitempty,min=0,max=1000000"`
+	Burst              *int `json:"burst" binding:"omitempty,min=0,max=1000000"`
+	MaxConcurrentCalls *int `json:"maxConcurrentCalls" binding:"omitempty,min=0,max=10000"`
+}
+
+// SetLogLevel changes the log level, optionally reverting after the TTL
+func (s *runtimeSettings) SetLogLevel(c *gin.Context) {
+	var req SetLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	level, ok := logLevels[strings.ToLower(req.Level)]
+	if !ok {
+		respondValidationError(c, "level must be one of debug, info, warn or error")
+		return
+	}
+
+	ttl := time.Duration(req.TTL) * time.Millisecond
+	previous := s.logLevel.Level()
+	s.overrides.apply("loglevel", ttl, func() func() {
+		return func() {
+			s.logLevel.Set(previous)
+			slog.Info("Runtime log level expired", "level", previous.String())
+		}
+	}, func() {
+		s.logLevel.Set(level)
+	})
+	slog.Info("Log level changed at runtime", "level", level.String(), "previous", previous.String(), "ttlMs", req.TTL)
+
+	result := gin.H{"level": level.String(), "previousLevel": previous.String()}
+	if ttl > 0 {
+		result["expiresAt"] = time.Now().Add(ttl).UTC()
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "result": result})
+}
+
+// SetLimits changes server concurrency caps and profile limits, optionally reverting after the TTL.
+// Every target is checked before anything is changed.
+func (s *runtimeSettings) SetLimits(c *gin.Context) {
+	var req SetLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	if len(req.Servers) == 0 && len(req.Profiles) == 0 {
+		respondValidationError(c, "servers or profiles is required")
+		return
+	}
+
+	cm := s.handler.clientManager
+	servers := slices.Sorted(maps.Keys(req.Servers))
+	for _, name := range servers {
+		if _, err := cm.MaxConcurrentCalls(name); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    mcpErrors.ErrCodeServerNotFound,
+					"message": "Server not found: " + name,
+				},
+			})
+			return
+		}
+	}
+	profiles := slices.Sorted(maps.Keys(req.Profiles))
+	if len(profiles) > 0 && s.apiKeys == nil {
+		respondValidationError(c, "profiles can only be changed when apiKeys are configured")
+		return
+	}
+	for _, name := range profiles {
+		if _, ok := s.apiKeys.profileLimits(name); !ok {
+			respondValidationError(c, "unknown profile: "+name)
+			return
+		}
+	}
+
+	ttl := time.Duration(req.TTL) * time.Millisecond
+	serverResults := make(map[string]gin.H, len(servers))
+	for _, name := range servers {
+		if limit := req.Servers[name].MaxConcurrentCalls; limit != nil {
+			s.overrides.apply("server/"+name, ttl, func() func() {
+				previous, _ := cm.MaxConcurrentCalls(name)
+				return func() {
+					_ = cm.SetMaxConcurrentCalls(name, previous)
+					slog.Info("Runtime server limits expired", "server", name, "maxConcurrentCalls", previous)
+				}
+			}, func() {
+				_ = cm.SetMaxConcurrentCalls(name, *limit)
+			})
+			slog.Info("Server limits changed at runtime", "server", name, "maxConcurrentCalls", *limit, "ttlMs", req.TTL)
+		}
+		current, _ := cm.MaxConcurrentCalls(name)
+		serverResults[name] = gin.H{"maxConcurrentCalls": current}
+	}
+
+	profileResults := make(map[string]profileLimits, len(profiles))
+	for _, name := range profiles {
+		requested := req.Profiles[name]
+		s.overrides.apply("profile/"+name, ttl, func() func() {
+			previous, _ := s.apiKeys.profileLimits(name)
+			return func() {
+				s.apiKeys.setProfileLimits(name, previous)
+				slog.Info("Runtime profile limits expired", "profile", name)
+			}
+		}, func() {
+			limits, _ := s.apiKeys.profileLimits(name)
+			if requested.RequestsPerMinute != nil {
+				limits.RequestsPerMinute = *requested.RequestsPerMinute
+			}
+			if requested.Burst != nil {
+				limits.Burst = *requested.Burst
+			}
+			if requested.MaxConcurrentCalls != nil {
+				limits.MaxConcurrentCalls = *requested.MaxConcurrentCalls
+			}
+			s.apiKeys.setProfileLimits(name, limits)
+		})
+		current, _ := s.apiKeys.profileLimits(name)
+		slog.Info("Profile limits changed at runtime", "profile", name,
+			"requestsPerMinute", current.RequestsPerMinute, "burst", current.Burst, "maxConcurrentCalls", current.MaxConcurrentCalls, "ttlMs", req.TTL)
+		profileResults[name] = current
+	}
+
+	result := gin.H{"servers": serverResults, "profiles": profileResults}
+	if ttl > 0 {
+		result["expiresAt"] = time.Now().Add(ttl).UTC()
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "result": result})
+}
+
+// respondValidationError responds with 400 VALIDATION_ERROR
+func respondValidationError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    mcpErrors.ErrCodeValidation,
+			"message": message,
+		},
+	})
+}
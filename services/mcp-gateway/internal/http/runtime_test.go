@@ -0,0 +1,167 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func putJSON(t *testing.T, router *gin.Engine, path string, body any, headers ...string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPut, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w, resp
+}
+
+func TestOverrides_RestoresAfterTTL(t *testing.T) {
+	var o overrides
+	var value atomic.Int64
+	value.Store(1)
+	set := func(v int64) func() { return func() { value.Store(v) } }
+	snapshot := func() func() { return set(value.Load()) }
+
+	o.apply("k", 30*time.Millisecond, snapshot, set(2))
+	assert.Equal(t, int64(2), value.Load())
+	// A second change restarts the TTL but restores the value from before the first one
+	o.apply("k", 60*time.Millisecond, snapshot, set(3))
+	assert.Equal(t, int64(3), value.Load())
+	assert.Eventually(t, func() bool { return value.Load() == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	// A permanent change cancels a pending restore
+	o.apply("k", 20*time.Millisecond, snapshot, set(4))
+	o.apply("k", 0, snapshot, set(5))
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, int64(5), value.Load())
+}
+
+func TestSetLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	level := new(slog.LevelVar)
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm), WithLogLevel(level))
+
+	w, resp := putJSON(t, router, "/admin/loglevel", map[string]any{"level": "DEBUG", "ttl": 50})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+	result := resp["result"].(map[string]any)
+	assert.Equal(t, "DEBUG", result["level"])
+	assert.Equal(t, "INFO", result["previousLevel"])
+	assert.NotEmpty(t, result["expiresAt"])
+	assert.Eventually(t, func() bool { return level.Level() == slog.LevelInfo }, 5*time.Second, 10*time.Millisecond)
+
+	w, resp = putJSON(t, router, "/admin/loglevel", map[string]any{"level": "warn"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, slog.LevelWarn, level.Level())
+	assert.NotContains(t, resp["result"], "expiresAt")
+
+	for _, body := range []map[string]any{{"level": "trace"}, {}, {"level": "info", "ttl": -1}} {
+		w, resp = putJSON(t, router, "/admin/loglevel", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+	}
+	assert.Equal(t, slog.LevelWarn, level.Level())
+}
+
+func TestSetLogLevel_NotEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm))
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/loglevel", bytes.NewReader([]byte(`{"level":"debug"}`)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSetLimits_Profiles(t *testing.T) {
+	router := newAPIKeyTestRouter()
+	auth := []string{headerAPIKey, "dashboard-key-0123456789"}
+	callAsNightly := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(headerAPIKey, "nightly-key-0123456789")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// batch allows one request per minute
+	assert.NotEqual(t, http.StatusTooManyRequests, callAsNightly())
+	assert.Equal(t, http.StatusTooManyRequests, callAsNightly())
+
+	w, resp := putJSON(t, router, "/admin/limits", map[string]any{
+		"profiles": map[string]any{"batch": map[string]any{"requestsPerMinute": 600, "burst": 5}},
+		"ttl":      100,
+	}, auth...)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, map[string]any{"requestsPerMinute": float64(600), "burst": float64(5), "maxConcurrentCalls": float64(0)},
+		resp["result"].(map[string]any)["profiles"].(map[string]any)["batch"])
+	assert.NotEqual(t, http.StatusTooManyRequests, callAsNightly())
+	assert.NotEqual(t, http.StatusTooManyRequests, callAsNightly())
+
+	// Back to one request per minute once the TTL expires
+	assert.Eventually(t, func() bool {
+		return callAsNightly() == http.StatusTooManyRequests && callAsNightly() == http.StatusTooManyRequests
+	}, 5*time.Second, 20*time.Millisecond)
+
+	w, resp = putJSON(t, router, "/admin/limits", map[string]any{"profiles": map[string]any{"missing": map[string]any{"burst": 1}}}, auth...)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+}
+
+func TestSetLimits_Servers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	require.NoError(t, cm.RegisterInProcess("embedded", mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	defer cm.Close()
+	router := SetupRouter(NewHandler(cm, pm))
+
+	w, resp := putJSON(t, router, "/admin/limits", map[string]any{"servers": map[string]any{"embedded": map[string]any{"maxConcurrentCalls": 2}}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, map[string]any{"maxConcurrentCalls": float64(2)}, resp["result"].(map[string]any)["servers"].(map[string]any)["embedded"])
+	n, err := cm.MaxConcurrentCalls("embedded")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	tests := []struct {
+		name string
+		body map[string]any
+		code int
+	}{
+		{name: "unknown server", body: map[string]any{"servers": map[string]any{"missing": map[string]any{"maxConcurrentCalls": 1}}}, code: http.StatusNotFound},
+		{name: "profiles without API keys", body: map[string]any{"profiles": map[string]any{"batch": map[string]any{"burst": 1}}}, code: http.StatusBadRequest},
+		{name: "nothing to change", body: map[string]any{"ttl": 1000}, code: http.StatusBadRequest},
+		{name: "negative limit", body: map[string]any{"servers": map[string]any{"embedded": map[string]any{"maxConcurrentCalls": -1}}}, code: http.StatusBadRequest},
+		{name: "TTL too long", body: map[string]any{"servers": map[string]any{"embedded": map[string]any{"maxConcurrentCalls": 1}}, "ttl": 86400001}, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := putJSON(t, router, "/admin/limits", tt.body)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+	n, _ = cm.MaxConcurrentCalls("embedded")
+	assert.Equal(t, 2, n, "rejected requests change nothing")
+}
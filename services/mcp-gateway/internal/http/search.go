@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/toolsearch"
+)
+
+// defaultSearchTopK is the number of tools returned when topK is omitted
+const defaultSearchTopK = 5
+
+// SearchToolsRequest is the body of POST /mcp/tools/search
+type SearchToolsRequest struct {
+	Query  string `json:"query" binding:"required,max=2000"` // natural-language description of the task
+	TopK   int    `json:"topK" binding:"min=0,max=100"`      // default 5
+	Server string `json:"server"`                            // only search the tools of this server
+}
+
+// toolSearch serves POST /mcp/tools/search, which returns the tools most relevant to a task
+type toolSearch struct {
+	handler  *Handler
+	searcher *toolsearch.Searcher
+}
+
+func (s *toolSearch) Search(c *gin.Context) {
+	var req SearchToolsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	if req.TopK == 0 {
+		req.TopK = defaultSearchTopK
+	}
+
+	tools := s.handler.clientManager.GetTools()
+	if req.Server != "" {
+		filtered := make([]mcp.ToolInfo, 0, len(tools))
+		for _, tool := range tools {
+			if tool.Server == req.Server {
+				filtered = append(filtered, tool)
+			}
+		}
+		tools = filtered
+	}
+
+	matches, ranking := s.searcher.Search(c.Request.Context(), tools, req.Query, req.TopK)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"ranking": ranking,
+		"tools":   matches,
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postSearch(t *testing.T, router *gin.Engine, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp/tools/search", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestSearchTools_Keywords(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	code, resp := postSearch(t, router, `{"query": "sum of two numbers: add them"}`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["success"])
+	assert.Equal(t, "keyword", resp["ranking"])
+	tools := resp["tools"].([]any)
+	require.Len(t, tools, 1)
+	add := tools[0].(map[string]any)
+	assert.Equal(t, "calc", add["server"])
+	assert.Equal(t, "add", add["name"])
+	assert.Equal(t, true, add["readOnly"])
+	assert.Positive(t, add["score"])
+}
+
+func TestSearchTools_ServerFilter(t *testing.T) {
+	router := newExportTestRouter(t)
+
+	code, resp := postSearch(t, router, `{"query": "look up details", "server": "weather"}`)
+
+	require.Equal(t, http.StatusOK, code)
+	tools := resp["tools"].([]any)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "weather", tools[0].(map[string]any)["server"])
+
+	code, resp = postSearch(t, router, `{"query": "look up details", "server": "missing"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{}, resp["tools"])
+}
+
+// fixedEmbedder embeds texts mentioning "fail" on one axis and everything else on the other
+type fixedEmbedder struct{}
+
+func (fixedEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "fail") {
+			vectors[i] = []float64{1, 0}
+		} else {
+			vectors[i] = []float64{0, 1}
+		}
+	}
+	return vectors, nil
+}
+
+func TestSearchTools_Embeddings(t *testing.T) {
+	router := newRPCTestRouter(t, WithToolSearchEmbedder(fixedEmbedder{}))
+
+	code, resp := postSearch(t, router, `{"query": "a tool that always fails", "topK": 1}`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "embedding", resp["ranking"])
+	tools := resp["tools"].([]any)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "fail", tools[0].(map[string]any)["name"])
+	assert.Equal(t, float64(1), tools[0].(map[string]any)["score"])
+}
+
+func TestSearchTools_Validation(t *testing.T) {
+	router := newRPCTestRouter(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "Missing query", body: `{"topK": 3}`},
+		{name: "topK too large", body: `{"query": "add", "topK": 101}`},
+		{name: "Negative topK", body: `{"query": "add", "topK": -1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := postSearch(t, router, tt.body)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+		})
+	}
+}
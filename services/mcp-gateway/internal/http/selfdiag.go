@@ -0,0 +1,61 @@
+package http
+
+import (
+	"os"
+	"runtime"
+)
+
+// gatewayDiagnostics describes the health of the gateway process itself
+type gatewayDiagnostics struct {
+	Goroutines    int               `json:"goroutines"`
+	Memory        memoryDiagnostics `json:"memory"`
+	OpenFDs       *int              `json:"openFDs,omitempty"` // omitted where /proc is unavailable
+	InFlightCalls map[string]int    `json:"inFlightCalls"`     // calls awaiting a response, per server
+	Caches        cacheDiagnostics  `json:"caches"`
+}
+
+type memoryDiagnostics struct {
+	HeapAllocBytes uint64 `json:"heapAllocBytes"`
+	HeapInuseBytes uint64 `json:"heapInuseBytes"`
+	SysBytes       uint64 `json:"sysBytes"`
+	NumGC          uint32 `json:"numGC"`
+}
+
+type cacheDiagnostics struct {
+	Tools int `json:"tools"` // cached tool definitions across all servers
+}
+
+// selfDiagnostics collects gateway process diagnostics for /health
+func (h *Handler) selfDiagnostics() gatewayDiagnostics {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	inFlight := make(map[string]int)
+	for name, s := range h.clientManager.GetCallStats() {
+		inFlight[name] = s.InFlight
+	}
+
+	return gatewayDiagnostics{
+		Goroutines: runtime.NumGoroutine(),
+		Memory: memoryDiagnostics{
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapInuseBytes: mem.HeapInuse,
+			SysBytes:       mem.Sys,
+			NumGC:          mem.NumGC,
+		},
+		OpenFDs:       openFDs(),
+		InFlightCalls: inFlight,
+		Caches:        cacheDiagnostics{Tools: len(h.clientManager.GetTools())},
+	}
+}
+
+// openFDs counts the process's open file descriptors, or returns nil if they cannot be listed
+func openFDs() *int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return nil
+	}
+	// Exclude the descriptor ReadDir used to list the directory
+	n := max(len(entries)-1, 0)
+	return &n
+}
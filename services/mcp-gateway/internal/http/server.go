@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
@@ -11,24 +12,70 @@ import (
 
 // ServerManager handles HTTP server lifecycle
 type ServerManager struct {
-	srv *http.Server
+	srv             *http.Server
+	listener        net.Listener
+	shutdownTimeout time.Duration
+	shuttingDown    chan struct{}
 }
 
-// NewServerManager creates a new server manager
+// shutdownKey is the context key of the channel closed when the server starts shutting down
+type shutdownKey struct{}
+
+// shutdownNotice returns a channel closed once the server serving the request starts shutting down,
+// so that long-lived responses can end instead of holding up the graceful shutdown. It is nil outside a ServerManager.
+func shutdownNotice(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(shutdownKey{}).(chan struct{})
+	return ch
+}
+
+// NewServerManager creates a new server manager listening on the port on all interfaces
 func NewServerManager(router *gin.Engine, port string) *ServerManager {
+	return NewServerManagerAt(router, ":"+port)
+}
+
+// NewServerManagerAt creates a new server manager listening on a host:port address
+func NewServerManagerAt(router *gin.Engine, address string) *ServerManager {
+	shuttingDown := make(chan struct{})
 	return &ServerManager{
 		srv: &http.Server{
-			Addr:    ":" + port,
+			Addr:    address,
 			Handler: router,
+			BaseContext: func(net.Listener) context.Context {
+				return context.WithValue(context.Background(), shutdownKey{}, shuttingDown)
+			},
 		},
+		shutdownTimeout: 5 * time.Second,
+		shuttingDown:    shuttingDown,
+	}
+}
+
+// SetShutdownTimeout sets how long Shutdown waits for in-flight requests before closing their connections
+func (sm *ServerManager) SetShutdownTimeout(timeout time.Duration) {
+	sm.shutdownTimeout = timeout
+}
+
+// Listen binds the listening socket without serving requests yet,
+// so that callers can report readiness only once the port is actually open
+func (sm *ServerManager) Listen() error {
+	if sm.listener != nil {
+		return nil
+	}
+	ln, err := net.Listen("tcp", sm.srv.Addr)
+	if err != nil {
+		return err
 	}
+	sm.listener = ln
+	return nil
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server, binding the socket first if Listen was not called
 func (sm *ServerManager) Start() error {
-	slog.Info("Starting server", "address", sm.srv.Addr)
+	if err := sm.Listen(); err != nil {
+		return err
+	}
+	slog.Info("Starting server", "address", sm.listener.Addr().String())
 
-	if err := sm.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := sm.srv.Serve(sm.listener); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
@@ -36,9 +83,14 @@ func (sm *ServerManager) Start() error {
 
 // Shutdown gracefully shuts down the HTTP server
 func (sm *ServerManager) Shutdown() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), sm.shutdownTimeout)
 	defer cancel()
 
+	select {
+	case <-sm.shuttingDown:
+	default:
+		close(sm.shuttingDown)
+	}
 	if err := sm.srv.Shutdown(ctx); err != nil {
 		slog.Error("Server shutdown error", "error", err)
 		return err
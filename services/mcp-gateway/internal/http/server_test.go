@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServerManager_ListenBeforeStart verifies that the port is bound by Listen and served by Start.
+func TestServerManager_ListenBeforeStart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	sm := NewServerManager(router, "0")
+	require.NoError(t, sm.Listen())
+	addr := sm.listener.Addr().String()
+
+	// A second Listen is a no-op
+	require.NoError(t, sm.Listen())
+	assert.Equal(t, addr, sm.listener.Addr().String())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- sm.Start() }()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/ping")
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, sm.Shutdown())
+	assert.NoError(t, <-errCh)
+}
+
+// TestServerManager_ShutdownTimeout verifies that Shutdown gives up on requests still running after the timeout.
+func TestServerManager_ShutdownTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	sm := NewServerManager(router, "0")
+	sm.SetShutdownTimeout(100 * time.Millisecond)
+	require.NoError(t, sm.Listen())
+	go func() { _ = sm.Start() }()
+	defer close(release)
+
+	go func() {
+		if resp, err := http.Get("http://" + sm.listener.Addr().String() + "/slow"); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+
+	start := time.Now()
+	err := sm.Shutdown()
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
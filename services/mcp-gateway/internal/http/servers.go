@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// ListServers returns the operational status of every server, with more detail than /health
+func (h *Handler) ListServers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"servers": h.clientManager.Servers(),
+	})
+}
+
+// GetServer returns everything known about one server: its process, transport, last error and tools
+func (h *Handler) GetServer(c *gin.Context) {
+	server := c.Param("server")
+	detail, err := h.clientManager.ServerDetail(server)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    mcpErrors.ErrCodeServerNotFound,
+				"message": "Server not found: " + server,
+			},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"server":  detail,
+	})
+}
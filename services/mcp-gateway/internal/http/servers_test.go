@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServersTestRouter serves a crashed stdio server and an in-process server with two tools
+func newServersTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+	t.Cleanup(func() { _ = cm.Close() })
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)
+	noop := func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+		return &mcpSDK.CallToolResult{}, nil
+	}
+	server.AddTool(&mcpSDK.Tool{Name: "first", InputSchema: map[string]any{"type": "object"}}, noop)
+	server.AddTool(&mcpSDK.Tool{Name: "second", InputSchema: map[string]any{"type": "object"}}, noop)
+	require.NoError(t, cm.RegisterInProcess("embedded", server))
+	require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{
+		{Name: "broken", Command: "/nonexistent/mcp-server", Args: []string{"--api-key=abc"}, Timeout: 5000},
+	}))
+	return SetupRouter(NewHandler(cm, pm))
+}
+
+func TestListServers(t *testing.T) {
+	router := newServersTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp/servers", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Success bool             `json:"success"`
+		Servers []map[string]any `json:"servers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Success)
+	require.Len(t, resp.Servers, 2)
+
+	broken := resp.Servers[0]
+	assert.Equal(t, "broken", broken["name"])
+	assert.Equal(t, "crashed", broken["status"])
+	assert.Equal(t, 0.0, broken["uptime"])
+	assert.Equal(t, 0.0, broken["toolCount"])
+	assert.Equal(t, 5000.0, broken["timeout"])
+	assert.NotContains(t, broken, "lastHealthCheckAt", "omitted until a health check ran")
+
+	embedded := resp.Servers[1]
+	assert.Equal(t, "embedded", embedded["name"])
+	assert.Equal(t, "available", embedded["status"])
+	assert.Equal(t, 2.0, embedded["toolCount"])
+	assert.Equal(t, 30000.0, embedded["timeout"])
+	assert.Equal(t, 0.0, embedded["restartAttempts"])
+	assert.GreaterOrEqual(t, embedded["uptime"], 0.0)
+}
+
+func TestGetServer(t *testing.T) {
+	router := newServersTestRouter(t)
+	get := func(path string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return w.Code, resp
+	}
+
+	code, resp := get("/mcp/servers/broken")
+	require.Equal(t, http.StatusOK, code)
+	broken := resp["server"].(map[string]any)
+	assert.Equal(t, "broken", broken["name"])
+	assert.Equal(t, "crashed", broken["status"])
+	assert.Equal(t, "stdio", broken["transport"])
+	assert.Equal(t, []any{"/nonexistent/mcp-server", "--api-key=xxxxx"}, broken["command"])
+	assert.Contains(t, broken["lastError"], "no such file or directory")
+	assert.NotContains(t, broken, "pid")
+	assert.Equal(t, []any{}, broken["tools"])
+
+	code, resp = get("/mcp/servers/embedded")
+	require.Equal(t, http.StatusOK, code)
+	embedded := resp["server"].(map[string]any)
+	assert.Equal(t, "in-process", embedded["transport"])
+	assert.NotEmpty(t, embedded["startedAt"])
+	tools := embedded["tools"].([]any)
+	require.Len(t, tools, 2)
+	assert.Equal(t, "first", tools[0].(map[string]any)["name"])
+	assert.Equal(t, "second", tools[1].(map[string]any)["name"])
+
+	code, resp = get("/mcp/servers/missing")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "SERVER_NOT_FOUND", resp["error"].(map[string]any)["code"])
+}
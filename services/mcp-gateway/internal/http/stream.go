@@ -0,0 +1,147 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// With large payloads, /mcp/call passes inputs on as sent and writes successful results one content item
+// at a time, so that a multi-MB result is not encoded into a second buffer of its full size. The MCP SDK
+// still reads and writes whole JSON-RPC frames, so a call holds its result once, as decoded by the SDK.
+
+// rawCallToolRequest is a CallToolRequest whose input is kept as sent
+type rawCallToolRequest struct {
+	Server   string          `json:"server"`
+	ToolName string          `json:"toolName"`
+	Input    json.RawMessage `json:"input"`
+}
+
+// bindCallToolRequest binds the body of a /mcp/call request, keeping the input raw with large payloads
+func (h *Handler) bindCallToolRequest(c *gin.Context) (CallToolRequest, error) {
+	if h.largePayloads == nil {
+		var req CallToolRequest
+		err := c.ShouldBindJSON(&req)
+		return req, err
+	}
+	var req rawCallToolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return CallToolRequest{}, err
+	}
+	return CallToolRequest{Server: req.Server, ToolName: req.ToolName, Input: req.Input}, nil
+}
+
+// streamToolResult writes a successful result with the envelope writeJSON would write, flushing each
+// content item as soon as it is encoded. A result whose content exceeds maxResponseBytes is answered
+// with RESPONSE_TOO_LARGE instead.
+func (h *Handler) streamToolResult(c *gin.Context, req CallToolRequest, result *mcpSDK.CallToolResult) {
+	// Everything that is not streamed is encoded first, so that failures can still be answered properly
+	var meta, structured []byte
+	var err error
+	if len(result.Meta) > 0 {
+		if meta, err = json.Marshal(result.Meta); err != nil {
+			h.failStream(c, req, err)
+			return
+		}
+	}
+	if result.StructuredContent != nil {
+		if structured, err = json.Marshal(result.StructuredContent); err != nil {
+			h.failStream(c, req, err)
+			return
+		}
+	}
+
+	if size := int64(len(structured)) + contentSize(result.Content); size > h.largePayloads.MaxResponseBytes {
+		slog.Warn("Tool result exceeds maximum size",
+			"server", req.Server,
+			"tool", req.ToolName,
+			"size", size,
+			"requestId", requestID(c),
+		)
+		writeJSON(c, http.StatusBadGateway, failure(mcpErrors.ErrCodeResponseTooLarge,
+			fmt.Sprintf("Tool result exceeds maximum size (%d bytes)", h.largePayloads.MaxResponseBytes),
+			toolDetails{ToolName: req.ToolName, ServerName: req.Server}))
+		return
+	}
+
+	c.Header("Content-Type", jsonContentType)
+	c.Status(http.StatusOK)
+	w := c.Writer
+	_, _ = w.WriteString(`{"success":true,"result":{`)
+	if meta != nil {
+		_, _ = w.WriteString(`"_meta":`)
+		_, _ = w.Write(meta)
+		_, _ = w.WriteString(`,`)
+	}
+	if result.Content == nil {
+		_, _ = w.WriteString(`"content":null`)
+	} else {
+		_, _ = w.WriteString(`"content":[`)
+		for i, item := range result.Content {
+			data, err := json.Marshal(item)
+			if err != nil {
+				// The status is sent already; cutting the body off makes it invalid JSON for the client
+				slog.Error("Failed to encode tool result content, response cut off",
+					"server", req.Server,
+					"tool", req.ToolName,
+					"error", err,
+					"requestId", requestID(c),
+				)
+				c.Abort()
+				return
+			}
+			if i > 0 {
+				_, _ = w.WriteString(`,`)
+			}
+			_, _ = w.Write(data)
+			w.Flush()
+		}
+		_, _ = w.WriteString(`]`)
+	}
+	if structured != nil {
+		_, _ = w.WriteString(`,"structuredContent":`)
+		_, _ = w.Write(structured)
+	}
+	if result.IsError {
+		_, _ = w.WriteString(`,"isError":true`)
+	}
+	_, _ = w.WriteString(`}}`)
+}
+
+// failStream answers a result that cannot be encoded with INTERNAL_ERROR
+func (h *Handler) failStream(c *gin.Context, req CallToolRequest, err error) {
+	slog.Error("Failed to encode tool result",
+		"server", req.Server,
+		"tool", req.ToolName,
+		"error", err,
+		"requestId", requestID(c),
+	)
+	respondInternalError(c)
+}
+
+// contentSize estimates the encoded size of content items by their payloads: text as is and binary data
+// as base64
+func contentSize(content []mcpSDK.Content) int64 {
+	var size int64
+	for _, item := range content {
+		switch item := item.(type) {
+		case *mcpSDK.TextContent:
+			size += int64(len(item.Text))
+		case *mcpSDK.ImageContent:
+			size += int64(base64.StdEncoding.EncodedLen(len(item.Data)))
+		case *mcpSDK.AudioContent:
+			size += int64(base64.StdEncoding.EncodedLen(len(item.Data)))
+		case *mcpSDK.EmbeddedResource:
+			if item.Resource != nil {
+				size += int64(len(item.Resource.Text) + base64.StdEncoding.EncodedLen(len(item.Resource.Blob)))
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoRouter serves an in-process "blob" server whose "echo" tool answers with the data of its input
+func newEchoRouter(t *testing.T, opts ...RouterOption) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "blob", Version: "test"}, nil)
+	server.AddTool(&mcpSDK.Tool{Name: "echo", InputSchema: map[string]any{"type": "object"}},
+		func(_ context.Context, req *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+			var input struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(req.Params.Arguments, &input); err != nil {
+				return nil, err
+			}
+			return &mcpSDK.CallToolResult{Content: []mcpSDK.Content{&mcpSDK.TextContent{Text: input.Data}}}, nil
+		})
+	require.NoError(t, cm.RegisterInProcess("blob", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	t.Cleanup(func() { _ = cm.Close() })
+	return SetupRouter(NewHandler(cm, pm), opts...)
+}
+
+// postEcho calls the echo tool with data
+func postEcho(router *gin.Engine, data string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]any{"server": "blob", "toolName": "echo", "input": map[string]any{"data": data}})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCallTool_LargePayloads(t *testing.T) {
+	t.Cleanup(func() { validator.SetMaxInputSize(0) })
+	validator.SetMaxInputSize(8 << 20)
+	data := strings.Repeat("0123456789abcdef", 256<<10) // 4 MiB
+
+	router := newEchoRouter(t, WithLargePayloads(config.LargePayloadsConfig{MaxRequestBytes: 8 << 20, MaxResponseBytes: 8 << 20}))
+	w := postEcho(router, data)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String()[:min(w.Body.Len(), 200)])
+
+	var response struct {
+		Success bool                  `json:"success"`
+		Result  mcpSDK.CallToolResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	require.Len(t, response.Result.Content, 1)
+	assert.Equal(t, data, response.Result.Content[0].(*mcpSDK.TextContent).Text)
+
+	// Other routes keep the default body limit
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/mcp/tools/search", strings.NewReader(`{"query":"`+data+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "request body too large")
+}
+
+func TestCallTool_LargePayloadsDisabled(t *testing.T) {
+	router := newEchoRouter(t)
+	w := postEcho(router, strings.Repeat("a", 1<<20))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "request body too large")
+}
+
+func TestCallTool_LargePayloadsRequestCeiling(t *testing.T) {
+	t.Cleanup(func() { validator.SetMaxInputSize(0) })
+	validator.SetMaxInputSize(1 << 20)
+
+	router := newEchoRouter(t, WithLargePayloads(config.LargePayloadsConfig{MaxRequestBytes: 1 << 20, MaxResponseBytes: 8 << 20}))
+	w := postEcho(router, strings.Repeat("a", 2<<20))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "request body too large")
+}
+
+func TestCallTool_LargePayloadsResponseCeiling(t *testing.T) {
+	router := newEchoRouter(t, WithLargePayloads(config.LargePayloadsConfig{MaxRequestBytes: 1 << 20, MaxResponseBytes: 1024}))
+
+	w := postEcho(router, strings.Repeat("a", 1024))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postEcho(router, strings.Repeat("a", 1025))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.JSONEq(t, `{
+		"success": false,
+		"error": {
+			"code": "RESPONSE_TOO_LARGE",
+			"message": "Tool result exceeds maximum size (1024 bytes)",
+			"details": {"toolName": "echo", "serverName": "blob"}
+		}
+	}`, w.Body.String())
+}
+
+func TestStreamToolResult_MatchesWriteJSON(t *testing.T) {
+	results := map[string]*mcpSDK.CallToolResult{
+		"text":          {Content: []mcpSDK.Content{&mcpSDK.TextContent{Text: "<b>22.9</b> &  "}}},
+		"no content":    {},
+		"empty content": {Content: []mcpSDK.Content{}},
+		"several items": {Content: []mcpSDK.Content{
+			&mcpSDK.TextContent{Text: "a"},
+			&mcpSDK.ImageContent{Data: []byte{0x89, 'P', 'N', 'G'}, MIMEType: "image/png"},
+			&mcpSDK.AudioContent{Data: []byte{0, 1}, MIMEType: "audio/wav"},
+			&mcpSDK.EmbeddedResource{Resource: &mcpSDK.ResourceContents{URI: "file:///a", Blob: []byte{0, 1}}},
+			&mcpSDK.ResourceLink{URI: "file:///a", Name: "a"},
+		}},
+		"meta and structured content": {
+			Meta:              mcpSDK.Meta{"progressToken": "t"},
+			Content:           []mcpSDK.Content{&mcpSDK.TextContent{Text: `{"result":22.9}`}},
+			StructuredContent: map[string]any{"result": 22.9},
+		},
+	}
+	handler := &Handler{largePayloads: &config.LargePayloadsConfig{MaxRequestBytes: 1 << 20, MaxResponseBytes: 1 << 20}}
+
+	for name, result := range results {
+		t.Run(name, func(t *testing.T) {
+			want := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(want)
+			writeJSON(c, http.StatusOK, callResponse{Success: true, Result: result})
+
+			got := httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(got)
+			handler.streamToolResult(c, CallToolRequest{Server: "weather", ToolName: "forecast"}, result)
+
+			assert.Equal(t, http.StatusOK, got.Code)
+			assert.Equal(t, want.Header().Get("Content-Type"), got.Header().Get("Content-Type"))
+			assert.Equal(t, want.Body.String(), got.Body.String())
+		})
+	}
+}
+
+func TestStreamToolResult_UnencodableStructuredContent(t *testing.T) {
+	handler := &Handler{largePayloads: &config.LargePayloadsConfig{MaxRequestBytes: 1 << 20, MaxResponseBytes: 1 << 20}}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/mcp/call", nil)
+
+	handler.streamToolResult(c, CallToolRequest{Server: "weather", ToolName: "forecast"}, &mcpSDK.CallToolResult{
+		Content:           []mcpSDK.Content{&mcpSDK.TextContent{Text: "ok"}},
+		StructuredContent: map[string]any{"f": func() {}},
+	})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), string(mcpErrors.ErrCodeInternal))
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ToolEvents streams a Server-Sent Event whenever the tools of a server change, e.g. when a crashed server
+// that is not restarted has its tools removed, so that clients know to fetch GET /mcp/tools again.
+// The stream ends when the client disconnects, falls too far behind, or the gateway shuts down.
+func (h *Handler) ToolEvents(c *gin.Context) {
+	events, unwatch := h.clientManager.WatchTools()
+	defer unwatch()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(streamKeepaliveInterval)
+	defer keepalive.Stop()
+	shutdown := shutdownNotice(c.Request.Context())
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("tools", ev)
+			return true
+		case <-keepalive.C:
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-shutdown:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolEvents(t *testing.T) {
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)
+	noop := func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+		return &mcpSDK.CallToolResult{}, nil
+	}
+	server.AddTool(&mcpSDK.Tool{Name: "get", InputSchema: map[string]any{"type": "object"}}, noop)
+	require.NoError(t, cm.RegisterInProcess("embedded", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	sm := NewServerManagerAt(SetupRouter(NewHandler(cm, pm)), "127.0.0.1:0")
+	require.NoError(t, sm.Listen())
+	go func() { _ = sm.Start() }()
+	t.Cleanup(func() { _ = sm.Shutdown() })
+
+	resp, err := http.Get("http://" + sm.listener.Addr().String() + "/mcp/tools/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	server.AddTool(&mcpSDK.Tool{Name: "put", InputSchema: map[string]any{"type": "object"}}, noop)
+	require.NoError(t, cm.RefreshTools(context.Background(), "embedded"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "event:tools", scanner.Text())
+	require.True(t, scanner.Scan())
+	var ev map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data:")), &ev))
+	assert.Equal(t, "embedded", ev["server"])
+	assert.Equal(t, "updated", ev["change"])
+	assert.Equal(t, float64(2), ev["tools"])
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/usage"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// toolCaller makes tool calls on behalf of endpoints other than /mcp/call, such as transactions,
+// with the timeout, priority and usage recording of a /mcp/call request
+type toolCaller struct {
+	handler *Handler
+	usage   *usage.Recorder
+}
+
+// callOutcome is the outcome of one tool call
+type callOutcome struct {
+	result    any
+	status    int
+	err       *StepError
+	toolError bool // the tool returned a result with isError, which is kept in result
+}
+
+// call makes one tool call. With ignoreDeadline the client's deadline headers are not applied, for
+// calls that run after the client may have given up.
+func (t toolCaller) call(c *gin.Context, ctx context.Context, server, tool string, input any, ignoreDeadline bool) callOutcome {
+	h := t.handler
+	start := time.Now()
+	out := t.invoke(c, ctx, server, tool, input, ignoreDeadline)
+	if t.usage != nil {
+		var name string
+		if caller := callerFrom(c); caller != nil {
+			name = caller.name
+		}
+		t.usage.Record(server, tool, name, time.Since(start), out.err != nil)
+	}
+	if out.err != nil && out.err.Code == mcpErrors.ErrCodeInternal {
+		h.panics.Add(1)
+	}
+	return out
+}
+
+func (t toolCaller) invoke(c *gin.Context, ctx context.Context, server, tool string, input any, ignoreDeadline bool) callOutcome {
+	resolved, err := t.handler.resolveTimeout(c, server, tool)
+	if err != nil {
+		return callOutcome{status: http.StatusBadRequest, err: &StepError{Code: mcpErrors.ErrCodeValidation, Message: err.Error()}}
+	}
+	timeout := resolved.timeout
+	if ignoreDeadline {
+		for _, layer := range resolved.layers {
+			if layer.Applied && layer.Source != timeoutSourceRequest {
+				timeout = time.Duration(layer.Timeout) * time.Millisecond
+			}
+		}
+	} else if resolved.source == timeoutSourceRequest && timeout <= 0 {
+		return callOutcome{status: http.StatusGatewayTimeout, err: &StepError{
+			Code:    mcpErrors.ErrCodeTimeout,
+			Message: "Client deadline exceeded before the tool was called",
+		}}
+	}
+
+	if caller := callerFrom(c); caller != nil && caller.profile.Priority != "" {
+		ctx = mcp.WithPriority(ctx, caller.profile.Priority)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := t.handler.clientManager.CallTool(ctx, server, tool, input)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return callOutcome{status: http.StatusGatewayTimeout, err: &StepError{
+				Code:    mcpErrors.ErrCodeTimeout,
+				Message: fmt.Sprintf("Tool execution timed out after %dms", timeout.Milliseconds()),
+			}}
+		}
+		var panicErr *mcp.PanicError
+		if errors.As(err, &panicErr) {
+			// Already logged with its stack by the client manager
+			return callOutcome{status: http.StatusInternalServerError, err: &StepError{
+				Code:    mcpErrors.ErrCodeInternal,
+				Message: "Internal server error",
+			}}
+		}
+		status, code := callErrorStatus(err)
+		return callOutcome{status: status, err: &StepError{Code: code, Message: err.Error()}}
+	}
+
+	if errMsg, isToolError := extractErrorMessage(result); isToolError {
+		return callOutcome{status: http.StatusInternalServerError, result: result, toolError: true, err: &StepError{
+			Code:    mcpErrors.ErrCodeToolExecution,
+			Message: errMsg,
+		}}
+	}
+	return callOutcome{status: http.StatusOK, result: result}
+}
+
+// authorizeCall asks the authorizer about a tool call as if it were a /mcp/call request. base carries
+// the caller, headers and client IP of the actual request. An input transform of an allowing decision
+// is applied to input.
+func authorizeCall(c *gin.Context, a authz.Authorizer, base *authz.Request, server, tool string, input *any) (authz.Decision, error) {
+	req := *base
+	req.Method = http.MethodPost
+	req.Path = "/mcp/call"
+	req.Server, req.Tool = server, tool
+	req.Input, _ = (*input).(map[string]any)
+
+	decision, err := a.Authorize(c.Request.Context(), &req)
+	if err == nil && decision.Allowed && decision.Input != nil {
+		*input = decision.Input
+	}
+	return decision, err
+}
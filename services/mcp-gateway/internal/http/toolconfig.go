@@ -0,0 +1,140 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// defaultToolTimeout applies to tools missing from the cache
+const defaultToolTimeout = 30 * time.Second
+
+// Layers a tool call's timeout can come from, from the least to the most specific
+const (
+	timeoutSourceDefault = "default" // the tool is not in the cache
+	timeoutSourceServer  = "server"  // servers[].timeout
+	timeoutSourceProfile = "profile" // profiles[].timeout of the caller's API key
+	timeoutSourceRequest = "request" // the X-Request-Deadline or grpc-timeout header
+)
+
+// TimeoutLayer is a setting that contributed to a tool call's timeout
+type TimeoutLayer struct {
+	Source  string `json:"source"`
+	Timeout int64  `json:"timeout"` // ms; may be negative for a deadline that has passed
+	Applied bool   `json:"applied"` // false when a shorter timeout was already in effect
+	Profile string `json:"profile,omitempty"`
+}
+
+// timeoutResolution is the timeout of a tool call together with how it was reached
+type timeoutResolution struct {
+	timeout time.Duration
+	source  string
+	layers  []TimeoutLayer
+}
+
+// resolveTimeout applies the timeout layers of a tool call in order. The server's timeout sets it;
+// the caller's profile and deadline headers can only shorten it.
+func (h *Handler) resolveTimeout(c *gin.Context, server, tool string) (timeoutResolution, error) {
+	var r timeoutResolution
+	r.timeout, r.source = h.toolTimeout(server, tool)
+	r.layers = append(r.layers, TimeoutLayer{Source: r.source, Timeout: r.timeout.Milliseconds(), Applied: true})
+
+	if caller := callerFrom(c); caller != nil && caller.profile.Timeout > 0 {
+		limit := time.Duration(caller.profile.Timeout) * time.Millisecond
+		layer := TimeoutLayer{Source: timeoutSourceProfile, Timeout: limit.Milliseconds(), Profile: caller.profile.Name}
+		if limit < r.timeout {
+			r.timeout, r.source, layer.Applied = limit, timeoutSourceProfile, true
+		}
+		r.layers = append(r.layers, layer)
+	}
+
+	limit, ok, err := clientTimeout(c.Request.Header, time.Now())
+	if err != nil {
+		return timeoutResolution{}, err
+	}
+	if ok {
+		layer := TimeoutLayer{Source: timeoutSourceRequest, Timeout: limit.Milliseconds()}
+		if limit < r.timeout {
+			r.timeout, r.source, layer.Applied = limit, timeoutSourceRequest, true
+		}
+		r.layers = append(r.layers, layer)
+	}
+	return r, nil
+}
+
+// toolTimeout returns the timeout configured for the tool's server, or the default for unknown tools
+func (h *Handler) toolTimeout(server, tool string) (time.Duration, string) {
+	toolInfo, found := h.clientManager.GetToolInfo(server, tool)
+	if found && toolInfo.Timeout > 0 {
+		return time.Duration(toolInfo.Timeout) * time.Millisecond, timeoutSourceServer
+	}
+	if !found {
+		slog.Warn("Tool not found in cache, using default timeout", "toolName", tool, "server", server)
+	}
+	return defaultToolTimeout, timeoutSourceDefault
+}
+
+// ToolConfig is the configuration in effect for calls to a tool
+type ToolConfig struct {
+	Server             string         `json:"server"`
+	Tool               string         `json:"tool"`
+	Timeout            int64          `json:"timeout"` // ms
+	TimeoutSource      string         `json:"timeoutSource"`
+	TimeoutLayers      []TimeoutLayer `json:"timeoutLayers"`
+	Priority           string         `json:"priority"`
+	MaxConcurrentCalls int            `json:"maxConcurrentCalls"` // of the server, 0 means unlimited
+}
+
+// GetToolConfig shows the timeout, priority and concurrency cap that a call to the tool would get
+// from the caller's API key and headers, and which layer each came from
+func (h *Handler) GetToolConfig(c *gin.Context) {
+	server, tool := c.Param("server"), c.Param("tool")
+	maxConcurrentCalls, err := h.clientManager.MaxConcurrentCalls(server)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    mcpErrors.ErrCodeServerNotFound,
+				"message": "Server not found: " + server,
+			},
+		})
+		return
+	}
+	if _, found := h.clientManager.GetToolInfo(server, tool); !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    mcpErrors.ErrCodeToolNotFound,
+				"message": "Tool not found: " + tool,
+			},
+		})
+		return
+	}
+
+	resolved, err := h.resolveTimeout(c, server, tool)
+	if err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	priority := config.PriorityNormal
+	if caller := callerFrom(c); caller != nil && caller.profile.Priority != "" {
+		priority = caller.profile.Priority
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result": ToolConfig{
+			Server:             server,
+			Tool:               tool,
+			Timeout:            resolved.timeout.Milliseconds(),
+			TimeoutSource:      resolved.source,
+			TimeoutLayers:      resolved.layers,
+			Priority:           priority,
+			MaxConcurrentCalls: maxConcurrentCalls,
+		},
+	})
+}
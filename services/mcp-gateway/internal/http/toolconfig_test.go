@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newToolConfigTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "embedded", Version: "test"}, nil)
+	server.AddTool(&mcpSDK.Tool{Name: "echo", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+			return &mcpSDK.CallToolResult{}, nil
+		})
+
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	require.NoError(t, cm.RegisterInProcess("embedded", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.SetMaxConcurrentCalls("embedded", 4))
+	return SetupRouter(NewHandler(cm, pm), WithAPIKeys(testAPIKeys, testProfiles))
+}
+
+func getToolConfig(t *testing.T, router *gin.Engine, path string, headers map[string]string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestGetToolConfig(t *testing.T) {
+	router := newToolConfigTestRouter(t)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		timeout  float64
+		source   string
+		priority string
+		layers   []any
+	}{
+		{
+			name:     "server timeout",
+			headers:  map[string]string{headerAPIKey: "nightly-key-0123456789"},
+			timeout:  30000,
+			source:   "server",
+			priority: "low",
+			layers: []any{
+				map[string]any{"source": "server", "timeout": float64(30000), "applied": true},
+			},
+		},
+		{
+			name:     "profile shortens the timeout",
+			headers:  map[string]string{headerAPIKey: "dashboard-key-0123456789"},
+			timeout:  5000,
+			source:   "profile",
+			priority: "normal",
+			layers: []any{
+				map[string]any{"source": "server", "timeout": float64(30000), "applied": true},
+				map[string]any{"source": "profile", "timeout": float64(5000), "applied": true, "profile": "interactive"},
+			},
+		},
+		{
+			name:     "longer request timeout does not apply",
+			headers:  map[string]string{headerAPIKey: "dashboard-key-0123456789", "Grpc-Timeout": "10S"},
+			timeout:  5000,
+			source:   "profile",
+			priority: "normal",
+			layers: []any{
+				map[string]any{"source": "server", "timeout": float64(30000), "applied": true},
+				map[string]any{"source": "profile", "timeout": float64(5000), "applied": true, "profile": "interactive"},
+				map[string]any{"source": "request", "timeout": float64(10000), "applied": false},
+			},
+		},
+		{
+			name:     "request shortens the timeout",
+			headers:  map[string]string{headerAPIKey: "dashboard-key-0123456789", "Grpc-Timeout": "2S"},
+			timeout:  2000,
+			source:   "request",
+			priority: "normal",
+			layers: []any{
+				map[string]any{"source": "server", "timeout": float64(30000), "applied": true},
+				map[string]any{"source": "profile", "timeout": float64(5000), "applied": true, "profile": "interactive"},
+				map[string]any{"source": "request", "timeout": float64(2000), "applied": true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := getToolConfig(t, router, "/mcp/tools/embedded/echo/config", tt.headers)
+			require.Equal(t, http.StatusOK, code, resp)
+			result := resp["result"].(map[string]any)
+			assert.Equal(t, "embedded", result["server"])
+			assert.Equal(t, "echo", result["tool"])
+			assert.Equal(t, tt.timeout, result["timeout"])
+			assert.Equal(t, tt.source, result["timeoutSource"])
+			assert.Equal(t, tt.layers, result["timeoutLayers"])
+			assert.Equal(t, tt.priority, result["priority"])
+			assert.Equal(t, float64(4), result["maxConcurrentCalls"])
+		})
+	}
+}
+
+func TestGetToolConfig_Errors(t *testing.T) {
+	router := newToolConfigTestRouter(t)
+	auth := map[string]string{headerAPIKey: "dashboard-key-0123456789"}
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		code    int
+		errCode string
+	}{
+		{name: "unknown server", path: "/mcp/tools/missing/echo/config", headers: auth, code: http.StatusNotFound, errCode: "SERVER_NOT_FOUND"},
+		{name: "unknown tool", path: "/mcp/tools/embedded/missing/config", headers: auth, code: http.StatusNotFound, errCode: "TOOL_NOT_FOUND"},
+		{name: "invalid deadline header", path: "/mcp/tools/embedded/echo/config", headers: map[string]string{headerAPIKey: "dashboard-key-0123456789", "X-Request-Deadline": "soon"}, code: http.StatusBadRequest, errCode: "VALIDATION_ERROR"},
+		{name: "API key required", path: "/mcp/tools/embedded/echo/config", code: http.StatusUnauthorized, errCode: "UNAUTHORIZED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := getToolConfig(t, router, tt.path, tt.headers)
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.errCode, resp["error"].(map[string]any)["code"])
+		})
+	}
+}
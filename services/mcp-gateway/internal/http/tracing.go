@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/khirotaka/restexec/services/mcp-gateway/internal/http"
+
+// callTracingMiddleware records a server span for each tool call, continuing the caller's trace
+// when a traceparent header is present. Calls that fail on the gateway or server side (5xx)
+// are marked as errors so that the sampler always retains them.
+// Without a configured tracer provider the global no-op provider makes this free.
+func callTracingMiddleware(c *gin.Context) {
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "POST /mcp/call", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// annotateCallSpan adds the target of a tool call to the active span
+func annotateCallSpan(c *gin.Context, server, toolName string) {
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(
+		attribute.String("mcp.server", server),
+		attribute.String("mcp.tool", toolName),
+	)
+}
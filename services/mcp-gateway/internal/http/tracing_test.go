@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// tracedCalls serves one request per status through callTracingMiddleware with nothing head-sampled,
+// and returns the spans that were exported
+func tracedCalls(t *testing.T, header http.Header, statuses ...int) tracetest.SpanStubs {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewTracerProvider(exporter, tracing.Options{SampleRatio: 0})
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	gin.SetMode(gin.TestMode)
+	for _, status := range statuses {
+		r := gin.New()
+		r.POST("/mcp/call", callTracingMiddleware, func(c *gin.Context) {
+			annotateCallSpan(c, "weather", "get_forecast")
+			c.Status(status)
+		})
+		req := httptest.NewRequest(http.MethodPost, "/mcp/call", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	return exporter.GetSpans()
+}
+
+func TestCallTracingMiddleware_RetainsFailedCalls(t *testing.T) {
+	spans := tracedCalls(t, nil, http.StatusOK, http.StatusNotFound, http.StatusBadGateway)
+
+	require.Len(t, spans, 1, "only the server-side failure is retained when nothing is head-sampled")
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Contains(t, spans[0].Attributes, attribute.String("mcp.server", "weather"))
+	assert.Contains(t, spans[0].Attributes, attribute.String("mcp.tool", "get_forecast"))
+	assert.Contains(t, spans[0].Attributes, attribute.Int("http.response.status_code", http.StatusBadGateway))
+}
+
+func TestCallTracingMiddleware_ContinuesCallerTrace(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	spans := tracedCalls(t, header, http.StatusOK)
+
+	require.Len(t, spans, 1, "a sampled parent keeps the call sampled")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+}
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// maxTransactionSteps bounds the number of tool calls in a transaction
+const maxTransactionSteps = 20
+
+// Statuses of a transaction step
+const (
+	stepSucceeded = "succeeded"
+	stepFailed    = "failed"
+	stepSkipped   = "skipped" // not called because an earlier step failed
+)
+
+// TransactionRequest is a list of tool calls made in order
+type TransactionRequest struct {
+	Steps []TransactionStep `json:"steps"`
+}
+
+// TransactionStep is a tool call with an optional call that undoes it
+type TransactionStep struct {
+	Server     string            `json:"server"`
+	ToolName   string            `json:"toolName"`
+	Input      any               `json:"input"`
+	Compensate *CompensationCall `json:"compensate,omitempty"`
+}
+
+// CompensationCall undoes a step. The server defaults to the step's server.
+type CompensationCall struct {
+	Server   string `json:"server"`
+	ToolName string `json:"toolName"`
+	Input    any    `json:"input"`
+}
+
+// StepError is why a step or a compensation failed
+type StepError struct {
+	Code    mcpErrors.ErrorCode `json:"code"`
+	Message string              `json:"message"`
+}
+
+// StepResult is the outcome of a transaction step
+type StepResult struct {
+	Server       string              `json:"server"`
+	ToolName     string              `json:"toolName"`
+	Status       string              `json:"status"`
+	Result       any                 `json:"result,omitempty"`
+	Error        *StepError          `json:"error,omitempty"`
+	Compensation *CompensationResult `json:"compensation,omitempty"`
+}
+
+// CompensationResult is the outcome of a compensation call
+type CompensationResult struct {
+	Server   string     `json:"server"`
+	ToolName string     `json:"toolName"`
+	Success  bool       `json:"success"`
+	Result   any        `json:"result,omitempty"`
+	Error    *StepError `json:"error,omitempty"`
+}
+
+// transactions serves POST /mcp/transactions, which calls tools in order and, when one fails, calls
+// the compensations of the steps that succeeded in reverse order. This is not atomic: other clients
+// can observe the intermediate states and a compensation can fail.
+// Every call is authorized and recorded as if it were a separate /mcp/call request.
+type transactions struct {
+	calls      toolCaller
+	authorizer authz.Authorizer
+}
+
+func (t *transactions) Run(c *gin.Context) {
+	var req TransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	if len(req.Steps) == 0 || len(req.Steps) > maxTransactionSteps {
+		respondValidationError(c, fmt.Sprintf("steps must contain 1 to %d tool calls", maxTransactionSteps))
+		return
+	}
+	// Report the violations of all steps at once
+	var violations []validator.Violation
+	for i := range req.Steps {
+		step := &req.Steps[i]
+		part := fmt.Sprintf("steps[%d]", i)
+		violations = appendViolations(violations, part, validator.ValidateRequest(step.Server, step.ToolName, step.Input))
+		if comp := step.Compensate; comp != nil {
+			if comp.Server == "" {
+				comp.Server = step.Server
+			}
+			violations = appendViolations(violations, part+".compensate", validator.ValidateRequest(comp.Server, comp.ToolName, comp.Input))
+		}
+	}
+	if len(violations) > 0 {
+		respondViolations(c, &validator.ValidationError{Violations: violations})
+		return
+	}
+	// Reject invalid deadline headers before any tool is called
+	if _, _, err := clientTimeout(c.Request.Header, time.Now()); err != nil {
+		respondValidationError(c, err.Error())
+		return
+	}
+	if !t.authorize(c, req.Steps) {
+		return
+	}
+
+	results := make([]StepResult, len(req.Steps))
+	for i, step := range req.Steps {
+		results[i] = StepResult{Server: step.Server, ToolName: step.ToolName, Status: stepSkipped}
+	}
+	for i, step := range req.Steps {
+		out := t.calls.call(c, c.Request.Context(), step.Server, step.ToolName, step.Input, false)
+		if out.err == nil {
+			results[i].Status, results[i].Result = stepSucceeded, out.result
+			continue
+		}
+
+		results[i].Status, results[i].Error = stepFailed, out.err
+		compensated := t.compensate(c, req.Steps[:i], results)
+		c.JSON(out.status, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    out.err.Code,
+				"message": fmt.Sprintf("Step %d (%s/%s) failed: %s", i, step.Server, step.ToolName, out.err.Message),
+				"details": gin.H{
+					"failedStep":  i,
+					"compensated": compensated,
+				},
+			},
+			"steps": results,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"steps":   results,
+	})
+}
+
+// compensate calls the compensations of the given steps, last first, and reports whether every step
+// was undone. A failed compensation does not stop the others.
+func (t *transactions) compensate(c *gin.Context, steps []TransactionStep, results []StepResult) bool {
+	// Undo the steps even if the client has gone away
+	ctx := context.WithoutCancel(c.Request.Context())
+	all := true
+	for i := len(steps) - 1; i >= 0; i-- {
+		comp := steps[i].Compensate
+		if comp == nil {
+			all = false
+			continue
+		}
+		out := t.calls.call(c, ctx, comp.Server, comp.ToolName, comp.Input, true)
+		results[i].Compensation = &CompensationResult{
+			Server:   comp.Server,
+			ToolName: comp.ToolName,
+			Success:  out.err == nil,
+			Error:    out.err,
+		}
+		if out.err == nil {
+			results[i].Compensation.Result = out.result
+		} else {
+			all = false
+			slog.Error("Transaction compensation failed",
+				"step", i,
+				"server", comp.Server,
+				"tool", comp.ToolName,
+				"error", out.err.Message,
+				"requestId", requestID(c),
+			)
+		}
+	}
+	return all
+}
+
+// authorize asks the authorizer about every call of the transaction, compensations included, as if
+// each were a /mcp/call request, before any tool is called. Input transforms of the authorizer are applied.
+func (t *transactions) authorize(c *gin.Context, steps []TransactionStep) bool {
+	if t.authorizer == nil {
+		return true
+	}
+	base := buildAuthzRequest(c, t.authorizer)
+
+	check := func(step int, compensation bool, server, tool string, input *any) bool {
+		decision, err := authorizeCall(c, t.authorizer, base, server, tool, input)
+		if err != nil {
+			slog.Error("Authorization check failed", "path", routePath(c), "step", step, "error", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    mcpErrors.ErrCodeAuthorization,
+					"message": "Authorization service unavailable",
+				},
+			})
+			return false
+		}
+		if !decision.Allowed {
+			code := mcpErrors.ErrCodeForbidden
+			if decision.Status == http.StatusUnauthorized {
+				code = mcpErrors.ErrCodeUnauthorized
+			}
+			slog.Info("Transaction step denied by authorizer",
+				"step", step,
+				"compensation", compensation,
+				"server", server,
+				"tool", tool,
+				"status", decision.Status,
+			)
+			c.JSON(decision.Status, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    code,
+					"message": decision.Reason,
+					"details": gin.H{
+						"step":         step,
+						"compensation": compensation,
+					},
+				},
+			})
+			return false
+		}
+		return true
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		if !check(i, false, step.Server, step.ToolName, &step.Input) {
+			return false
+		}
+		if comp := step.Compensate; comp != nil && !check(i, true, comp.Server, comp.ToolName, &comp.Input) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/authz"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	mcpSDK "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callLog records the tool calls of an in-process server
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+// newTransactionTestRouter serves an in-process "inventory" server whose tools log "<tool> <id>"
+// and fail when the input has "fail": true
+func newTransactionTestRouter(t *testing.T, opts ...RouterOption) (*gin.Engine, *callLog) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	log := &callLog{}
+	server := mcpSDK.NewServer(&mcpSDK.Implementation{Name: "inventory", Version: "test"}, nil)
+	for _, name := range []string{"create", "configure", "delete"} {
+		server.AddTool(&mcpSDK.Tool{Name: name, InputSchema: map[string]any{"type": "object"}},
+			func(_ context.Context, req *mcpSDK.CallToolRequest) (*mcpSDK.CallToolResult, error) {
+				var args struct {
+					ID   string `json:"id"`
+					Fail bool   `json:"fail"`
+				}
+				_ = json.Unmarshal(req.Params.Arguments, &args)
+				log.add(name + " " + args.ID)
+				if args.Fail {
+					return &mcpSDK.CallToolResult{IsError: true, Content: []mcpSDK.Content{&mcpSDK.TextContent{Text: name + " failed"}}}, nil
+				}
+				return &mcpSDK.CallToolResult{Content: []mcpSDK.Content{&mcpSDK.TextContent{Text: "ok"}}}, nil
+			})
+	}
+
+	pm := mcp.NewProcessManager(30000, "never")
+	cm := mcp.NewClientManager(pm)
+	require.NoError(t, cm.RegisterInProcess("inventory", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	t.Cleanup(func() { _ = cm.Close() })
+	return SetupRouter(NewHandler(cm, pm), append([]RouterOption{WithTransactions()}, opts...)...), log
+}
+
+func postTransaction(t *testing.T, router *gin.Engine, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp/transactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func stepStatuses(resp map[string]any) []string {
+	var statuses []string
+	for _, step := range resp["steps"].([]any) {
+		statuses = append(statuses, step.(map[string]any)["status"].(string))
+	}
+	return statuses
+}
+
+func TestTransactions_AllStepsSucceed(t *testing.T) {
+	router, log := newTransactionTestRouter(t)
+
+	code, resp := postTransaction(t, router, `{"steps": [
+		{"server": "inventory", "toolName": "create", "input": {"id": "a"}, "compensate": {"toolName": "delete", "input": {"id": "a"}}},
+		{"server": "inventory", "toolName": "configure", "input": {"id": "a"}}
+	]}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, resp["success"].(bool))
+	assert.Equal(t, []string{"succeeded", "succeeded"}, stepStatuses(resp))
+	assert.Equal(t, []string{"create a", "configure a"}, log.get())
+}
+
+func TestTransactions_CompensatesInReverseOrder(t *testing.T) {
+	router, log := newTransactionTestRouter(t)
+
+	code, resp := postTransaction(t, router, `{"steps": [
+		{"server": "inventory", "toolName": "create", "input": {"id": "a"}, "compensate": {"toolName": "delete", "input": {"id": "a"}}},
+		{"server": "inventory", "toolName": "create", "input": {"id": "b"}, "compensate": {"toolName": "delete", "input": {"id": "b"}}},
+		{"server": "inventory", "toolName": "configure", "input": {"id": "b", "fail": true}},
+		{"server": "inventory", "toolName": "configure", "input": {"id": "a"}}
+	]}`)
+
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.False(t, resp["success"].(bool))
+	errBody := resp["error"].(map[string]any)
+	assert.Equal(t, "TOOL_EXECUTION_ERROR", errBody["code"])
+	assert.Contains(t, errBody["message"], "configure failed")
+	assert.Equal(t, map[string]any{"failedStep": float64(2), "compensated": true}, errBody["details"])
+	assert.Equal(t, []string{"succeeded", "succeeded", "failed", "skipped"}, stepStatuses(resp))
+	assert.Equal(t, []string{"create a", "create b", "configure b", "delete b", "delete a"}, log.get())
+
+	compensation := resp["steps"].([]any)[0].(map[string]any)["compensation"].(map[string]any)
+	assert.Equal(t, "inventory", compensation["server"], "defaults to the step's server")
+	assert.True(t, compensation["success"].(bool))
+}
+
+func TestTransactions_ReportsFailedCompensation(t *testing.T) {
+	router, log := newTransactionTestRouter(t)
+
+	code, resp := postTransaction(t, router, `{"steps": [
+		{"server": "inventory", "toolName": "create", "input": {"id": "a"}},
+		{"server": "inventory", "toolName": "create", "input": {"id": "b"}, "compensate": {"toolName": "delete", "input": {"id": "b", "fail": true}}},
+		{"server": "missing", "toolName": "configure", "input": {}}
+	]}`)
+
+	assert.Equal(t, http.StatusNotFound, code)
+	errBody := resp["error"].(map[string]any)
+	assert.Equal(t, "SERVER_NOT_FOUND", errBody["code"])
+	assert.Equal(t, false, errBody["details"].(map[string]any)["compensated"])
+	compensation := resp["steps"].([]any)[1].(map[string]any)["compensation"].(map[string]any)
+	assert.False(t, compensation["success"].(bool))
+	assert.Equal(t, "TOOL_EXECUTION_ERROR", compensation["error"].(map[string]any)["code"])
+	assert.Equal(t, []string{"create a", "create b", "delete b"}, log.get())
+}
+
+func TestTransactions_Validation(t *testing.T) {
+	router, log := newTransactionTestRouter(t)
+
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{name: "No steps", body: `{"steps": []}`, message: "steps must contain 1 to 20 tool calls"},
+		{name: "Invalid step", body: `{"steps": [{"server": "inventory", "input": {}}]}`, message: "steps[0]:"},
+		{
+			name:    "Invalid compensation",
+			body:    `{"steps": [{"server": "inventory", "toolName": "create", "input": {}, "compensate": {"toolName": "bad name!", "input": {}}}]}`,
+			message: "steps[0].compensate:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := postTransaction(t, router, tt.body)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Equal(t, "VALIDATION_ERROR", resp["error"].(map[string]any)["code"])
+			assert.Contains(t, resp["error"].(map[string]any)["message"], tt.message)
+		})
+	}
+
+	// Violations of every step are reported together
+	code, resp := postTransaction(t, router, `{"steps": [
+		{"server": "inventory", "input": {}},
+		{"server": "inventory", "toolName": "create", "input": {}, "compensate": {"toolName": "bad name!", "input": []}}
+	]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	violations := resp["error"].(map[string]any)["details"].(map[string]any)["violations"].([]any)
+	fields := make([]any, len(violations))
+	for i, v := range violations {
+		fields[i] = v.(map[string]any)["field"]
+	}
+	assert.Equal(t, []any{"steps[0].toolName", "steps[1].compensate.toolName", "steps[1].compensate.input"}, fields)
+	assert.Empty(t, log.get())
+}
+
+func TestTransactions_AuthorizesEveryCallFirst(t *testing.T) {
+	var seen []string
+	router, log := newTransactionTestRouter(t, WithAuthorizer(authorizerFunc(func(_ context.Context, req *authz.Request) (authz.Decision, error) {
+		if req.Path == "/mcp/transactions" {
+			return authz.Allow, nil
+		}
+		seen = append(seen, req.Path+" "+req.Tool)
+		if req.Tool == "delete" {
+			return authz.Deny(http.StatusForbidden, "delete not permitted"), nil
+		}
+		return authz.Allow, nil
+	})))
+
+	code, resp := postTransaction(t, router, `{"steps": [
+		{"server": "inventory", "toolName": "create", "input": {"id": "a"}, "compensate": {"toolName": "delete", "input": {"id": "a"}}}
+	]}`)
+
+	assert.Equal(t, http.StatusForbidden, code)
+	errBody := resp["error"].(map[string]any)
+	assert.Equal(t, "FORBIDDEN", errBody["code"])
+	assert.Equal(t, map[string]any{"step": float64(0), "compensation": true}, errBody["details"])
+	assert.Equal(t, []string{"/mcp/call create", "/mcp/call delete"}, seen)
+	assert.Empty(t, log.get(), "no tool is called when a call is denied")
+}
+
+func TestTransactions_DisabledByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm := mcp.NewProcessManager(30000, "never")
+	router := SetupRouter(NewHandler(mcp.NewClientManager(pm), pm))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp/transactions", strings.NewReader(`{"steps": []}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
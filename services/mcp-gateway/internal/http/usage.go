@@ -0,0 +1,36 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/usage"
+)
+
+// callTargetContextKey stores the callTarget of a validated tool call in the Gin context
+const callTargetContextKey = "callTarget"
+
+// callTarget is the server and tool a call was made to
+type callTarget struct {
+	server, tool string
+}
+
+// usageMiddleware records tool calls with the name of the caller's API key.
+// Requests rejected before they name a valid tool (malformed bodies, quota) are not counted.
+func usageMiddleware(r *usage.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		v, ok := c.Get(callTargetContextKey)
+		if !ok {
+			return
+		}
+		target := v.(callTarget)
+		var name string
+		if caller := callerFrom(c); caller != nil {
+			name = caller.name
+		}
+		r.Record(target.server, target.tool, name, time.Since(start), c.Writer.Status() >= 400)
+	}
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageSinkFunc func(records []usage.Record)
+
+func (f usageSinkFunc) Write(_ context.Context, records []usage.Record) error {
+	f(records)
+	return nil
+}
+
+func TestUsageMiddleware_RecordsCallsPerCaller(t *testing.T) {
+	recorder := usage.NewRecorder()
+	router := newAPIKeyTestRouter(WithUsageRecorder(recorder))
+
+	call := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp/call", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "dashboard-key-0123456789")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	// No server is connected, so the call fails after validation
+	assert.GreaterOrEqual(t, call(`{"server": "weather", "toolName": "forecast", "input": {}}`), 400)
+	// Invalid requests name no tool and are not counted
+	assert.Equal(t, http.StatusBadRequest, call(`{"server": "weather"}`))
+
+	var records []usage.Record
+	require.NoError(t, recorder.Flush(context.Background(), usageSinkFunc(func(r []usage.Record) { records = r })))
+	require.Len(t, records, 1)
+	assert.Equal(t, "weather", records[0].Server)
+	assert.Equal(t, "forecast", records[0].Tool)
+	assert.Equal(t, "dashboard", records[0].Caller)
+	assert.Equal(t, int64(1), records[0].Calls)
+	assert.Equal(t, int64(1), records[0].Errors)
+}
@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// startCanary calls the canary tool of a server every canary interval until ctx is done, and returns
+// a channel closed once it stopped. The channel is closed right away for servers without a canary.
+func (m *ClientManager) startCanary(ctx context.Context, serverName string, state *HealthCheckState) <-chan struct{} {
+	done := make(chan struct{})
+	cfg, ok := m.getConfig(serverName)
+	if !ok || cfg.Canary == nil {
+		close(done)
+		return done
+	}
+	canary := *cfg.Canary
+	timeout := time.Duration(cmp.Or(canary.Timeout, cfg.Timeout)) * time.Millisecond
+	interval := time.Duration(canary.Interval) * time.Millisecond
+
+	// A new session is judged by its own canary calls
+	state.mu.Lock()
+	state.canaryFailing = false
+	state.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		timer := m.processManager.Clock().NewTimer(m.healthCheckDelay(interval))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C():
+			}
+
+			m.mu.RLock()
+			session, ok := m.sessions[serverName]
+			m.mu.RUnlock()
+			if !ok {
+				return
+			}
+
+			callCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := callCanary(callCtx, session, canary)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			m.recordCanary(serverName, canary.Tool, state, err)
+			// Unlike health checks, calls never overlap: a slow tool delays the next call
+			timer.Reset(m.healthCheckDelay(interval))
+		}
+	}()
+	return done
+}
+
+// recordCanary marks a server unhealthy when its canary call failed and available again once the call
+// passes, unless its health checks are failing
+func (m *ClientManager) recordCanary(serverName, tool string, state *HealthCheckState, err error) {
+	failures := m.processManager.RecordCanary(serverName, err)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	wasFailing := state.canaryFailing
+	state.canaryFailing = err != nil
+
+	if err != nil {
+		slog.Warn("Canary call failed", "server", serverName, "tool", tool, "consecutive_failures", failures, "error", err)
+		m.events.Publish(events.Event{Type: events.CanaryFailed, Server: serverName, Tool: tool, Failures: failures, Error: err.Error()})
+		m.transition(serverName, StatusUnhealthy)
+		return
+	}
+	if wasFailing {
+		slog.Info("Canary call recovered", "server", serverName, "tool", tool)
+		if state.consecutiveFailures == 0 {
+			m.transition(serverName, StatusAvailable)
+		}
+	}
+}
+
+// callCanary calls the canary tool once and checks its result against the expectation
+func callCanary(ctx context.Context, session MCPSession, canary config.CanaryConfig) error {
+	input := canary.Input
+	if input == nil {
+		input = map[string]any{}
+	}
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: canary.Tool, Arguments: input})
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	for _, content := range result.Content {
+		if t, ok := content.(*mcp.TextContent); ok {
+			text.WriteString(t.Text)
+		}
+	}
+	if result.IsError {
+		if text.Len() > 0 {
+			return fmt.Errorf("tool %s returned an error: %s", canary.Tool, text.String())
+		}
+		return fmt.Errorf("tool %s returned an error", canary.Tool)
+	}
+	if canary.Expect == nil {
+		return nil
+	}
+
+	if canary.Expect.Contains != "" && !strings.Contains(text.String(), canary.Expect.Contains) {
+		return fmt.Errorf("tool %s returned text without %q", canary.Tool, canary.Expect.Contains)
+	}
+	if len(canary.Expect.StructuredContent) > 0 {
+		var actual map[string]any
+		if err := roundTripJSON(result.StructuredContent, &actual); err != nil {
+			return fmt.Errorf("tool %s returned unexpected structured content: %w", canary.Tool, err)
+		}
+		for field, want := range canary.Expect.StructuredContent {
+			// Compare as JSON, so that e.g. an expected YAML integer matches a JSON number
+			var expected any
+			if err := roundTripJSON(want, &expected); err != nil {
+				return fmt.Errorf("canary expectation of %s: %w", field, err)
+			}
+			got, ok := actual[field]
+			if !ok {
+				return fmt.Errorf("tool %s returned structured content without %s", canary.Tool, field)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				return fmt.Errorf("tool %s returned %s = %v, expected %v", canary.Tool, field, got, expected)
+			}
+		}
+	}
+	return nil
+}
+
+// roundTripJSON converts v to dst through JSON
+func roundTripJSON(v, dst any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallCanary_Expectations(t *testing.T) {
+	bmi := &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: `{"result":22.857142857142858}`}},
+		StructuredContent: map[string]any{"result": 22.857142857142858, "unit": "kg/m2", "count": float64(1)},
+	}
+	tests := []struct {
+		name    string
+		expect  *config.CanaryExpectConfig
+		result  *mcp.CallToolResult
+		callErr error
+		wantErr string
+	}{
+		{name: "any result", result: bmi},
+		{name: "contains", expect: &config.CanaryExpectConfig{Contains: "22.85"}, result: bmi},
+		{name: "structured fields", expect: &config.CanaryExpectConfig{StructuredContent: map[string]any{"unit": "kg/m2", "count": 1}}, result: bmi},
+		{
+			name:    "missing text",
+			expect:  &config.CanaryExpectConfig{Contains: "30.1"},
+			result:  bmi,
+			wantErr: `tool calculate-bmi returned text without "30.1"`,
+		},
+		{
+			name:    "different field",
+			expect:  &config.CanaryExpectConfig{StructuredContent: map[string]any{"unit": "lb/in2"}},
+			result:  bmi,
+			wantErr: "tool calculate-bmi returned unit = kg/m2, expected lb/in2",
+		},
+		{
+			name:    "missing field",
+			expect:  &config.CanaryExpectConfig{StructuredContent: map[string]any{"category": "normal"}},
+			result:  bmi,
+			wantErr: "tool calculate-bmi returned structured content without category",
+		},
+		{
+			name:    "tool error",
+			result:  &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "database unreachable"}}},
+			wantErr: "tool calculate-bmi returned an error: database unreachable",
+		},
+		{name: "call error", callErr: errors.New("connection closed"), wantErr: "connection closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := new(MockMCPSession)
+			session.On("CallTool", mock.Anything, &mcp.CallToolParams{Name: "calculate-bmi", Arguments: map[string]any{"height_m": 1.75}}).
+				Return(tt.result, tt.callErr).Once()
+
+			err := callCanary(context.Background(), session, config.CanaryConfig{
+				Tool:   "calculate-bmi",
+				Input:  map[string]any{"height_m": 1.75},
+				Expect: tt.expect,
+			})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			session.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartHealthCheck_CanaryMarksUnhealthyUntilItPasses(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.SetHealthCheckJitter(0)
+	cm.configs = []config.ServerConfig{{
+		Name:    "test-server",
+		Timeout: 1000,
+		Canary:  &config.CanaryConfig{Tool: "status", Interval: 20},
+	}}
+
+	session := new(MockMCPSession)
+	session.On("CallTool", mock.Anything, mock.Anything).
+		Return(&mcp.CallToolResult{IsError: true}, nil).Twice()
+	session.On("CallTool", mock.Anything, mock.Anything).
+		Return(&mcp.CallToolResult{}, nil)
+	cm.sessions["test-server"] = session
+	pm.SetStatus("test-server", StatusAvailable)
+
+	failed := make(chan events.Event, 4)
+	unsubscribe := cm.Events().Subscribe(events.SubscriberFunc(func(ev events.Event) { failed <- ev }), events.CanaryFailed)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cm.StartHealthCheck(ctx, "test-server")
+
+	select {
+	case ev := <-failed:
+		assert.Equal(t, "status", ev.Tool)
+		assert.Equal(t, 1, ev.Failures)
+		assert.Equal(t, "tool status returned an error", ev.Error)
+	case <-time.After(time.Second):
+		t.Fatal("Canary failure was not published")
+	}
+	assert.Equal(t, StatusUnhealthy, pm.GetStatus("test-server"))
+
+	require.Eventually(t, func() bool {
+		return pm.GetStatus("test-server") == StatusAvailable
+	}, time.Second, 5*time.Millisecond)
+	d := pm.GetDiagnostics("test-server")
+	assert.False(t, d.LastCanaryAt.IsZero())
+	assert.Empty(t, d.LastCanaryError)
+	assert.Zero(t, d.CanaryFailures)
+	session.AssertNotCalled(t, "Ping", mock.Anything, mock.Anything)
+}
+
+func TestRecordCanary_FailingHealthChecksKeepServerUnhealthy(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	state := &HealthCheckState{consecutiveFailures: 1}
+	pm.SetStatus("test-server", StatusAvailable)
+
+	cm.recordCanary("test-server", "status", state, errors.New("broken"))
+	assert.Equal(t, StatusUnhealthy, pm.GetStatus("test-server"))
+
+	// A passing canary leaves a server with failing health checks unhealthy
+	cm.recordCanary("test-server", "status", state, nil)
+	assert.Equal(t, StatusUnhealthy, pm.GetStatus("test-server"))
+	assert.False(t, state.canaryFailing)
+}
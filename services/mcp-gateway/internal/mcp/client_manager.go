@@ -4,14 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
 	"os/exec"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
-	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -24,17 +24,40 @@ type MCPSession interface {
 	Wait() error
 }
 
+// cleanExitResetUptime is how long a server must have run for a clean exit under restartPolicy always
+// to reset its restart attempts
+const cleanExitResetUptime = time.Minute
+
 // ClientManager manages multiple MCP clients
 type ClientManager struct {
-	sessions           map[string]MCPSession
-	processes          map[string]*exec.Cmd
-	processManager     *ProcessManager
-	toolsCache         map[string]ToolInfo
-	configs            []config.ServerConfig         // Store configs for restart capability
-	healthCheckCancels map[string]context.CancelFunc // Cancel functions for health checks
-	healthCheckDone    map[string]chan struct{}      // Channels to signal health check termination
-	healthCheckStates  map[string]*HealthCheckState  // Track consecutive failures
-	mu                 sync.RWMutex
+	sessions             map[string]MCPSession
+	processes            map[string]*exec.Cmd
+	processManager       *ProcessManager
+	toolsCache           map[string]ToolInfo
+	configs              []config.ServerConfig         // Store configs for restart capability
+	inProcess            map[string]*mcp.Server        // Servers registered with RegisterInProcess
+	healthCheckCancels   map[string]context.CancelFunc // Cancel functions for health checks
+	healthCheckDone      map[string]chan struct{}      // Channels to signal health check termination
+	healthCheckStates    map[string]*HealthCheckState  // Track consecutive failures
+	callStats            map[string]*callStats         // Load and latency of tool calls per server
+	feedback             map[string]*feedbackCounts    // Reports from agents per tool, keyed by toolCacheKey
+	logs                 map[string]*serverLog         // Recent stderr of server processes, kept across restarts
+	cpuSamples           map[string]cpuSample          // CPU time at the last ResourceUsage call, per server
+	connectedAt          map[string]time.Time          // When the current session of each server connected, for uptime
+	toolWatchers         map[chan ToolsEvent]struct{}  // Clients notified of tool changes, see WatchTools
+	events               *events.Bus                   // Lifecycle and tool call events, see Events
+	supervisors          map[string]*supervisor        // Run the restarts of each server one at a time
+	concurrencyOverrides map[string]int                // maxConcurrentCalls changed at runtime
+	suspending           map[string]bool               // Servers being stopped for idleness; calls wait for the respawn
+	wakeLocks            map[string]*sync.Mutex        // Serialize stopping and respawning idle servers
+	startupFailurePolicy string                        // abort or continue, see SetStartupFailurePolicy
+	restoredRestarts     map[string]ServerRestartState // applied by Initialize, see RestoreRestartState
+	gracePeriod          time.Duration                 // how long Close waits for processes to exit after SIGTERM
+	secretStore          *secrets.Store                // fetches env values from secrets managers, see SetSecretStore
+	healthCheckJitter    int                           // percent by which each health check interval varies
+	workers              *workerGroup                  // Health checks and restarts, cancelled on Close
+	monitors             *workerGroup                  // Connection monitors, which exit once their session is closed
+	mu                   sync.RWMutex
 }
 
 // HealthCheckState tracks health check failures for a server
@@ -42,99 +65,173 @@ type HealthCheckState struct {
 	mu                  sync.Mutex
 	consecutiveFailures int
 	lastCheckTime       time.Time
+	canaryFailing       bool // the last canary call failed, so health checks do not mark the server available
 }
 
 // ToolInfo represents cached tool information
 type ToolInfo struct {
-	Timeout      int    `json:"timeout"`
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	Server       string `json:"server"`
-	InputSchema  any    `json:"inputSchema"`
-	OutputSchema any    `json:"outputSchema"`
+	Timeout      int           `json:"timeout"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Server       string        `json:"server"`
+	InputSchema  any           `json:"inputSchema"`
+	OutputSchema any           `json:"outputSchema"`
+	ReadOnly     bool          `json:"readOnly"`           // from the readOnlyHint annotation
+	Available    bool          `json:"available"`          // whether the server takes calls, set by GetTools
+	Feedback     *ToolFeedback `json:"feedback,omitempty"` // reports from agents, set by GetTools
 }
 
 // NewClientManager creates a new ClientManager
 func NewClientManager(pm *ProcessManager) *ClientManager {
-	return &ClientManager{
+	m := &ClientManager{
+		workers:            newWorkerGroup(),
+		monitors:           newWorkerGroup(),
 		sessions:           make(map[string]MCPSession),
 		processes:          make(map[string]*exec.Cmd),
+		cpuSamples:         make(map[string]cpuSample),
+		connectedAt:        make(map[string]time.Time),
 		processManager:     pm,
 		toolsCache:         make(map[string]ToolInfo),
+		toolWatchers:       make(map[chan ToolsEvent]struct{}),
+		events:             events.NewBus(),
+		supervisors:        make(map[string]*supervisor),
 		healthCheckCancels: make(map[string]context.CancelFunc),
 		healthCheckDone:    make(map[string]chan struct{}),
 		healthCheckStates:  make(map[string]*HealthCheckState),
+		callStats:          make(map[string]*callStats),
+		feedback:           make(map[string]*feedbackCounts),
+		logs:               make(map[string]*serverLog),
+		suspending:         make(map[string]bool),
+		wakeLocks:          make(map[string]*sync.Mutex),
+		inProcess:          make(map[string]*mcp.Server),
+		gracePeriod:        5 * time.Second,
 	}
+	pm.OnStatusChange(m.publishStatusEvents)
+	return m
+}
+
+// SetStartupFailurePolicy sets what Initialize does when a server fails to connect:
+// abort (the default) closes everything and returns the error, continue leaves the server
+// crashed and restarts it per its restart policy. Must be called before Initialize.
+func (m *ClientManager) SetStartupFailurePolicy(policy string) {
+	m.startupFailurePolicy = policy
+}
+
+// SetSecretStore sets the store fetching the env values of servers from secrets managers.
+// Without one, servers with such values fail to start. Must be called before Initialize.
+func (m *ClientManager) SetSecretStore(store *secrets.Store) {
+	m.secretStore = store
+}
+
+// SetProcessGracePeriod sets how long Close waits for servers to exit after asking them to
+// before killing them. Must be called before Close.
+func (m *ClientManager) SetProcessGracePeriod(grace time.Duration) {
+	m.gracePeriod = grace
 }
 
-// Initialize connects to all configured MCP servers
+// SetHealthCheckJitter makes each health check interval vary randomly by up to percent of it in either
+// direction, so that the probes of servers started together do not all fire at the same moment.
+// Must be called before Initialize.
+func (m *ClientManager) SetHealthCheckJitter(percent int) {
+	m.healthCheckJitter = percent
+}
+
+// Initialize connects to all configured MCP servers.
+// ctx only bounds the initial connections; health checks and restarts live until Close.
 func (m *ClientManager) Initialize(ctx context.Context, configs []config.ServerConfig) error {
+	configs, err := m.withInProcessConfigs(configs)
+	if err != nil {
+		return err
+	}
+
 	// Store configs for restart capability
+	m.mu.Lock()
 	m.configs = configs
+	restored := m.restoredRestarts
+	m.mu.Unlock()
 
-	// Set up restart handler
-	m.processManager.SetOnServerCrashed(func(serverName string) {
-		// Find config for this server
-		for _, cfg := range m.configs {
-			if cfg.Name == serverName {
-				if err := m.RestartServer(ctx, cfg); err != nil {
-					slog.Error("Failed to restart server", "server", serverName, "error", err)
-				}
-				return
-			}
-		}
-	})
+	// Crashed servers are restarted by their supervisors
+	m.processManager.SetOnServerCrashed(m.notifyCrashed)
 
-	m.mu.Lock()
+	for _, cfg := range configs {
+		if s, ok := restored[cfg.Name]; ok {
+			m.processManager.restoreRestartState(cfg.Name, s)
+		}
+	}
 
+	connected := make([]string, 0, len(configs))
 	for _, cfg := range configs {
+		if m.processManager.GetStatus(cfg.Name) == StatusQuarantined {
+			slog.Warn("Server was quarantined before the gateway restarted, not connecting until restarted manually",
+				"server", cfg.Name)
+			continue
+		}
+		if m.deferStart(cfg) {
+			continue
+		}
 		if err := m.connectClient(ctx, cfg); err != nil {
+			if m.startupFailurePolicy == config.StartupFailurePolicyContinue {
+				slog.Error("Failed to connect to server, continuing without it", "server", cfg.Name, "error", err)
+				// Retried in the background like a crash, if the restart policy allows
+				if m.processManager.GetStatus(cfg.Name) == StatusCrashed {
+					m.processManager.onServerCrashed(cfg.Name)
+				}
+				continue
+			}
 			// Cleanup already connected servers before returning error
-			// Unlock before calling Close() to avoid deadlock
-			m.mu.Unlock()
 			if closeErr := m.Close(); closeErr != nil {
 				slog.Warn("Failed to cleanup clients during initialization failure", "error", closeErr)
 			}
 			return fmt.Errorf("failed to connect to server %s: %w", cfg.Name, err)
 		}
+		connected = append(connected, cfg.Name)
 	}
 
-	m.mu.Unlock()
-
-	// Start health checks AFTER releasing lock to prevent deadlock
+	// Start health checks after all servers are connected; a successful restart starts its own
+	for _, name := range connected {
+		m.StartHealthCheck(m.workers.ctx, name)
+	}
 	for _, cfg := range configs {
-		m.StartHealthCheck(ctx, cfg.Name)
+		if cfg.ActiveHours != nil {
+			m.watchActiveHours(cfg)
+		}
 	}
 
 	return nil
 }
 
+// connectClient starts and connects to a single MCP server.
+// It acquires m.mu only while mutating shared maps, so callers must NOT hold the lock.
 func (m *ClientManager) connectClient(ctx context.Context, cfg config.ServerConfig) error {
-	// Prepare environment variables
-	var safeEnvVars = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ", "TMPDIR"}
-	// ホワイトリストの環境変数のみ継承
-	env := make([]string, 0)
-	for _, key := range safeEnvVars {
-		if val := os.Getenv(key); val != "" {
-			env = append(env, fmt.Sprintf("%s=%s", key, val))
-		}
+	policy := m.restartPolicy(cfg)
+	if cfg.IsRemote() && policy == config.RestartPolicyNever {
+		// Remote servers are reconnected after failures whatever their policy
+		policy = config.RestartPolicyOnFailure
 	}
+	m.processManager.setRestartPolicy(cfg.Name, policy)
 
-	for _, e := range cfg.Envs {
-		env = append(env, fmt.Sprintf("%s=%s", e.Name, e.Value))
+	// Fails if the server was stopped concurrently, e.g. while a restart was backing off
+	if err := m.processManager.Transition(cfg.Name, StatusConnecting); err != nil {
+		return err
 	}
 
-	// Create command
-	cmd := exec.Command(cfg.Command, cfg.Args...)
-	cmd.Env = env
+	transport, cmd, err := m.transportFor(ctx, cfg)
+	if err != nil {
+		m.processManager.RecordConnectError(cfg.Name, err)
+		m.transition(cfg.Name, StatusCrashed)
+		return err
+	}
 
 	// Store process reference for shutdown
 	// Note: cmd.Process will be non-nil only after Connect() starts the process
-	m.processes[cfg.Name] = cmd
-
-	// Create transport
-	transport := &mcp.CommandTransport{
-		Command: cmd,
+	if cmd != nil {
+		m.mu.Lock()
+		m.processes[cfg.Name] = cmd
+		if cmd.Stderr == nil {
+			cmd.Stderr = m.serverLogLocked(cfg.Name)
+			cmd.WaitDelay = stderrWaitDelay
+		}
+		m.mu.Unlock()
 	}
 
 	// Create client
@@ -148,19 +245,35 @@ func (m *ClientManager) connectClient(ctx context.Context, cfg config.ServerConf
 	if err != nil {
 		// Clean up process if Connect failed
 		// The process may have been started by CommandTransport
-		if cmd.Process != nil {
-			if err := cmd.Process.Kill(); err != nil {
+		if cmd != nil && cmd.Process != nil {
+			if err := signalProcessGroup(cmd, syscall.SIGKILL); err != nil {
 				slog.Warn("Failed to kill process during cleanup", "server", cfg.Name, "error", err)
 			}
 		}
 		// Remove from process map to prevent resource leak
+		m.mu.Lock()
 		delete(m.processes, cfg.Name)
-		return fmt.Errorf("failed to connect: %w", err)
+		m.mu.Unlock()
+		if cmd != nil && !cfg.IsContainer() {
+			err = runAsError(cfg.RunAs, err)
+		}
+		err = fmt.Errorf("failed to connect: %w", err)
+		m.processManager.RecordConnectError(cfg.Name, err)
+		m.transition(cfg.Name, StatusCrashed)
+		return err
 	}
 
-	// Store session
+	// Store session; the idle timeout starts over with every connection
+	m.mu.Lock()
 	m.sessions[cfg.Name] = session
-	m.processManager.SetStatus(cfg.Name, StatusAvailable)
+	m.connectedAt[cfg.Name] = m.processManager.Clock().Now()
+	m.statsLocked(cfg.Name).lastActive = m.connectedAt[cfg.Name]
+	m.mu.Unlock()
+	if !m.transition(cfg.Name, StatusAvailable) {
+		// Stopped while connecting; the stop owns the status
+		m.teardownServer(cfg.Name)
+		return fmt.Errorf("server %s was stopped while connecting", cfg.Name)
+	}
 
 	// Cache tools
 	if err := m.cacheTools(ctx, cfg.Name, session, cfg.Timeout); err != nil {
@@ -168,34 +281,75 @@ func (m *ClientManager) connectClient(ctx context.Context, cfg config.ServerConf
 		if err := session.Close(); err != nil {
 			slog.Warn("Failed to close session during cleanup", "server", cfg.Name, "error", err)
 		}
-		if cmd.Process != nil {
-			if err := cmd.Process.Kill(); err != nil {
+		if cmd != nil && cmd.Process != nil {
+			if err := signalProcessGroup(cmd, syscall.SIGKILL); err != nil {
 				slog.Warn("Failed to kill process during cleanup", "server", cfg.Name, "error", err)
 			}
 		}
+		m.mu.Lock()
 		delete(m.sessions, cfg.Name)
+		delete(m.connectedAt, cfg.Name)
 		delete(m.processes, cfg.Name)
-		m.processManager.SetStatus(cfg.Name, StatusUnavailable)
-		return fmt.Errorf("failed to cache tools: %w", err)
+		m.mu.Unlock()
+		err = fmt.Errorf("failed to cache tools: %w", err)
+		m.processManager.RecordConnectError(cfg.Name, err)
+		m.transition(cfg.Name, StatusCrashed)
+		return err
 	}
 
 	// Monitor connection
-	go func() {
+	connectedAt := m.processManager.Clock().Now()
+	monitored := m.monitors.Go("monitor:"+cfg.Name, func(context.Context) {
 		// Wait blocks until the session is closed
 		err := session.Wait()
+
+		// Ignore sessions that were intentionally replaced or torn down
+		// (restart, stop); their status is owned by whoever removed them.
+		m.mu.RLock()
+		current, ok := m.sessions[cfg.Name]
+		m.mu.RUnlock()
+		if !ok || current != MCPSession(session) {
+			slog.Debug("MCP Client session closed", "server", cfg.Name)
+			return
+		}
+
 		if err != nil {
 			slog.Error("MCP Client disconnected", "server", cfg.Name, "error", err)
-			m.processManager.SetStatus(cfg.Name, StatusCrashed)
+			if !m.transition(cfg.Name, StatusCrashed) {
+				return
+			}
+			m.processManager.RecordFailure(cfg.Name, RestartReasonTransportClosed, err)
 
 			// Trigger restart handler if configured
 			if m.processManager.onServerCrashed != nil {
 				m.processManager.onServerCrashed(cfg.Name)
 			}
+		} else if m.restartPolicy(cfg) == config.RestartPolicyAlways {
+			slog.Info("MCP Client disconnected, restarting due to policy", "server", cfg.Name, "policy", config.RestartPolicyAlways)
+			if !m.transition(cfg.Name, StatusCrashed) {
+				return
+			}
+			m.processManager.RecordFailure(cfg.Name, RestartReasonExited, nil)
+			// A server that ran for a while before exiting is not failing, so it starts over with
+			// the full number of attempts; one that exits right away still gives up after them
+			if m.processManager.Clock().Since(connectedAt) >= cleanExitResetUptime {
+				m.processManager.ResetRestartAttempts(cfg.Name)
+			}
+			if m.processManager.onServerCrashed != nil {
+				m.processManager.onServerCrashed(cfg.Name)
+			}
 		} else {
 			slog.Info("MCP Client disconnected", "server", cfg.Name)
-			m.processManager.SetStatus(cfg.Name, StatusUnavailable)
+			m.transition(cfg.Name, StatusUnavailable)
 		}
-	}()
+	})
+	if !monitored {
+		// The manager was closed while connecting; nothing would own this session
+		m.teardownServer(cfg.Name)
+		m.transition(cfg.Name, StatusUnavailable)
+		return fmt.Errorf("client manager is closed")
+	}
+	m.watchProcess(cfg.Name, cmd)
 
 	return nil
 }
@@ -211,7 +365,21 @@ func (m *ClientManager) cacheTools(ctx context.Context, serverName string, sessi
 		return err
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop stale entries so tools removed upstream disappear from the cache
+	previous := make(map[string]bool)
+	for key, tool := range m.toolsCache {
+		if tool.Server == serverName {
+			previous[tool.Name] = true
+			delete(m.toolsCache, key)
+		}
+	}
+
+	changed := len(previous) != len(result.Tools)
 	for _, tool := range result.Tools {
+		changed = changed || !previous[tool.Name]
 		// Use "server:toolName" as cache key to avoid collisions
 		cacheKey := toolCacheKey(serverName, tool.Name)
 		m.toolsCache[cacheKey] = ToolInfo{
@@ -221,56 +389,25 @@ func (m *ClientManager) cacheTools(ctx context.Context, serverName string, sessi
 			Server:       serverName,
 			InputSchema:  tool.InputSchema,
 			OutputSchema: tool.OutputSchema,
+			ReadOnly:     tool.Annotations != nil && tool.Annotations.ReadOnlyHint,
 		}
 	}
-	return nil
-}
-
-// CallTool calls a tool on the specified server
-func (m *ClientManager) CallTool(ctx context.Context, server, toolName string, input any) (any, error) {
-	m.mu.RLock()
-	session, ok := m.sessions[server]
-	m.mu.RUnlock()
-
-	if !ok {
-		return nil, mcpErrors.ErrServerNotFound
-	}
-
-	// Check status
-	status := m.processManager.GetStatus(server)
-	if status == StatusRestarting {
-		return nil, fmt.Errorf("server %s is currently restarting, please retry shortly", server)
-	} else if status == StatusCrashed {
-		return nil, mcpErrors.ErrServerCrashed
-	} else if status != StatusAvailable {
-		return nil, mcpErrors.ErrServerNotRunning
-	}
-
-	// Convert input to map[string]any
-	inputMap, ok := input.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("input must be a map, got %T", input)
+	if changed {
+		m.emitToolsEventLocked(serverName, ToolsChangeUpdated, len(result.Tools))
 	}
-
-	// Call tool
-	result, err := session.CallTool(ctx, &mcp.CallToolParams{
-		Name:      toolName,
-		Arguments: inputMap,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return nil
 }
 
-// GetTools returns the list of all available tools
+// GetTools returns the list of all cached tools. Tools of servers that are crashed, restarting or
+// otherwise not taking calls stay listed, with Available unset.
 func (m *ClientManager) GetTools() []ToolInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	tools := make([]ToolInfo, 0, len(m.toolsCache))
 	for _, tool := range m.toolsCache {
+		tool.Available = m.processManager.GetStatus(tool.Server).Callable()
+		tool.Feedback = m.feedbackLocked(tool.Server, tool.Name)
 		tools = append(tools, tool)
 	}
 	return tools
@@ -287,19 +424,15 @@ func (m *ClientManager) GetToolInfo(server, toolName string) (ToolInfo, bool) {
 	return tool, found
 }
 
-// Close closes all sessions
+// Close stops all background workers, then closes all sessions and processes
 func (m *ClientManager) Close() error {
 	m.mu.Lock()
 
-	// Collect cancels and done channels
+	// Collect cancels
 	cancels := make([]context.CancelFunc, 0, len(m.healthCheckCancels))
 	for _, cancel := range m.healthCheckCancels {
 		cancels = append(cancels, cancel)
 	}
-	dones := make([]chan struct{}, 0, len(m.healthCheckDone))
-	for _, done := range m.healthCheckDone {
-		dones = append(dones, done)
-	}
 
 	// Clear maps
 	m.healthCheckCancels = make(map[string]context.CancelFunc)
@@ -307,31 +440,35 @@ func (m *ClientManager) Close() error {
 
 	m.mu.Unlock()
 
-	// Cancel all health checks
+	// Cancel health checks and pending restarts, and wait for them to exit so that
+	// none of them can reconnect a server after its session has been closed below
+	m.workers.Shutdown()
 	for _, cancel := range cancels {
 		cancel()
 	}
+	if m.workers.Wait(5 * time.Second) {
+		slog.Debug("All background workers stopped")
+	} else {
+		slog.Warn("Timeout waiting for background workers to stop")
+	}
 
-	// Wait for health check goroutines to exit
-	done := make(chan struct{})
-	go func() {
-		// Wait for all done channels to be closed
-		for _, d := range dones {
-			<-d
-		}
-		close(done)
-	}()
-
-	select {
-	case <-done:
-		slog.Debug("All health checks stopped")
-	case <-time.After(5 * time.Second):
-		slog.Warn("Timeout waiting for health checks to stop")
+	errs := m.closeConnections()
+
+	// Connection monitors exit once their session is closed
+	m.monitors.Shutdown()
+	if !m.monitors.Wait(5 * time.Second) {
+		slog.Warn("Timeout waiting for connection monitors to stop")
 	}
 
-	// Give goroutines time to exit (extra buffer if needed, though we waited on done)
-	// time.Sleep(100 * time.Millisecond) // Not strictly needed if we waited on done
+	if len(errs) > 0 {
+		return fmt.Errorf("errors closing sessions or processes: %v", errs)
+	}
+	return nil
+}
 
+// closeConnections closes every session and terminates every process.
+// The maps are cleared so that connection monitors treat the closures as intentional.
+func (m *ClientManager) closeConnections() []error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -346,17 +483,29 @@ func (m *ClientManager) Close() error {
 			errs = append(errs, fmt.Errorf("failed to close session %s: %w", name, err))
 		}
 	}
-
 	// 2. Terminate processes gracefully
 	errCh := make(chan error, len(m.processes))
 	for name, cmd := range m.processes {
 		if cmd.Process != nil {
 			wg.Add(1)
-			go func(n string, c *exec.Cmd) {
+			cfg, _ := m.getConfig(name)
+			go func(n string, c *exec.Cmd, cfg config.ServerConfig) {
 				defer wg.Done()
-				// First, send SIGTERM
-				if err := c.Process.Signal(syscall.SIGTERM); err != nil {
-					slog.Warn("Failed to send SIGTERM", "server", n, "error", err)
+				// First, ask the server to exit: SIGTERM for processes, docker stop for containers,
+				// and a remote SIGTERM over a second session for ssh servers
+				switch {
+				case cfg.IsContainer():
+					if err := stopContainer(n, m.gracePeriod); err != nil {
+						slog.Warn("Failed to stop container", "server", n, "error", err)
+					}
+				case cfg.IsSSH():
+					if err := stopRemoteProcess(cfg, m.gracePeriod); err != nil {
+						slog.Warn("Failed to stop remote process", "server", n, "error", err)
+					}
+				default:
+					if err := signalProcessGroup(c, syscall.SIGTERM); err != nil {
+						slog.Warn("Failed to send SIGTERM", "server", n, "error", err)
+					}
 				}
 
 				// Wait for process to exit with timeout
@@ -366,9 +515,18 @@ func (m *ClientManager) Close() error {
 				}()
 
 				select {
-				case <-time.After(5 * time.Second):
-					// If process doesn't exit after 5 seconds, kill it
-					if err := c.Process.Kill(); err != nil {
+				case <-time.After(m.gracePeriod):
+					// If process doesn't exit within the grace period, kill it
+					if cfg.IsContainer() {
+						if err := removeContainer(n); err != nil {
+							slog.Warn("Failed to remove container", "server", n, "error", err)
+						}
+					} else if cfg.IsSSH() {
+						if err := killRemoteProcess(cfg); err != nil {
+							slog.Warn("Failed to kill remote process", "server", n, "error", err)
+						}
+					}
+					if err := signalProcessGroup(c, syscall.SIGKILL); err != nil {
 						errCh <- fmt.Errorf("failed to kill process %s: %w", n, err)
 						slog.Warn("Failed to kill process", "server", n, "error", err)
 					} else {
@@ -382,8 +540,10 @@ func (m *ClientManager) Close() error {
 					} else {
 						slog.Info("Process exited gracefully", "server", n)
 					}
+					// Children that ignored SIGTERM would outlive the server
+					_ = signalProcessGroup(c, syscall.SIGKILL)
 				}
-			}(name, cmd)
+			}(name, cmd, cfg)
 		}
 	}
 
@@ -395,8 +555,7 @@ func (m *ClientManager) Close() error {
 		errs = append(errs, err)
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("errors closing sessions or processes: %v", errs)
-	}
-	return nil
+	m.sessions = make(map[string]MCPSession)
+	m.processes = make(map[string]*exec.Cmd)
+	return errs
 }
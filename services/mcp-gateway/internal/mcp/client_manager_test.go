@@ -3,8 +3,11 @@ package mcp
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewClientManager(t *testing.T) {
@@ -193,3 +196,48 @@ func TestClientManager_CallTool_ServerCheckBeforeInputValidation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "server not found", err.Error())
 }
+
+func TestInitialize_StartupFailurePolicy(t *testing.T) {
+	broken := config.ServerConfig{Name: "broken", Command: "/nonexistent/mcp-server", Timeout: 30000}
+
+	t.Run("Abort", func(t *testing.T) {
+		pm := NewProcessManager(30000, "never")
+		cm := NewClientManager(pm)
+		t.Cleanup(func() { _ = cm.Close() })
+		require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+
+		err := cm.Initialize(context.Background(), []config.ServerConfig{broken})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "broken")
+	})
+
+	t.Run("Continue", func(t *testing.T) {
+		pm := NewProcessManager(30000, "never")
+		cm := NewClientManager(pm)
+		cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+		t.Cleanup(func() { _ = cm.Close() })
+		require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+
+		require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{broken}))
+
+		assert.Equal(t, StatusCrashed, pm.GetStatus("broken"))
+		assert.Equal(t, StatusAvailable, pm.GetStatus("embedded"))
+		_, found := cm.GetToolInfo("embedded", "echo")
+		assert.True(t, found, "healthy servers serve traffic")
+		assert.Zero(t, pm.GetRestartAttempts("broken"), "the restart policy never does not retry")
+	})
+
+	t.Run("Continue retries per restart policy", func(t *testing.T) {
+		pm := NewProcessManager(30000, "on-failure")
+		cm := NewClientManager(pm)
+		cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+		t.Cleanup(func() { _ = cm.Close() })
+
+		require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{broken}))
+
+		assert.Eventually(t, func() bool {
+			return pm.GetRestartAttempts("broken") >= 1
+		}, 3*time.Second, 50*time.Millisecond)
+	})
+}
@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// coerceToolInput converts arguments of the tool if coerceInput is enabled for it.
+// The input is returned unchanged if coercion is disabled or the tool schema is unknown.
+func (m *ClientManager) coerceToolInput(server, toolName string, input any) any {
+	cfg, ok := m.getConfig(server)
+	if !ok || !cfg.CoercesInput(toolName) {
+		return input
+	}
+	tool, ok := m.GetToolInfo(server, toolName)
+	if !ok {
+		return input
+	}
+	schema := schemaObject(tool.InputSchema)
+	if schema == nil {
+		return input
+	}
+
+	var coerced []string
+	out := coerceValue(schema, input, "", &coerced)
+	if len(coerced) > 0 {
+		slog.Debug("Coerced tool arguments", "server", server, "tool", toolName, "paths", coerced)
+	}
+	return out
+}
+
+// schemaObject returns a JSON Schema as a map, or nil if it is not an object
+func schemaObject(schema any) map[string]any {
+	if m, ok := schema.(map[string]any); ok {
+		return m
+	}
+	if schema == nil {
+		return nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// coerceValue converts strings that the schema does not allow to the number, integer or boolean the
+// schema requires, descending into object properties and array items. Objects and arrays are copied
+// rather than modified. The paths of converted values are appended to coerced.
+func coerceValue(schema map[string]any, v any, path string, coerced *[]string) any {
+	switch val := v.(type) {
+	case string:
+		types := schemaTypes(schema)
+		if len(types) == 0 || slices.Contains(types, "string") {
+			return v
+		}
+		for _, t := range types {
+			if out, ok := coerceString(val, t); ok {
+				*coerced = append(*coerced, path)
+				return out
+			}
+		}
+		return v
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			if prop, ok := properties[k].(map[string]any); ok {
+				out[k] = coerceValue(prop, item, path+"/"+k, coerced)
+			} else {
+				out[k] = item
+			}
+		}
+		return out
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return v
+		}
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = coerceValue(items, item, path+"/"+strconv.Itoa(i), coerced)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// schemaTypes returns the types allowed by a schema's "type", which is either a string or a list
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// coerceString converts s to a value of the JSON Schema type if s is an unambiguous literal of it
+func coerceString(s, schemaType string) (any, bool) {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, true
+		}
+	case "number":
+		// Hex floats, "Inf" and "NaN" are not JSON numbers
+		if strings.ContainsAny(s, "xXnN") {
+			return nil, false
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) {
+			return f, true
+		}
+	case "boolean":
+		switch strings.ToLower(s) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var coerceSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"lat":     map[string]any{"type": "number"},
+		"days":    map[string]any{"type": "integer"},
+		"metric":  map[string]any{"type": "boolean"},
+		"city":    map[string]any{"type": "string"},
+		"zip":     map[string]any{"type": []any{"string", "integer"}},
+		"limit":   map[string]any{"type": []any{"integer", "null"}},
+		"weights": map[string]any{"type": "array", "items": map[string]any{"type": "number"}},
+		"options": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"verbose": map[string]any{"type": "boolean"}},
+		},
+	},
+}
+
+func TestCoerceValue(t *testing.T) {
+	input := map[string]any{
+		"lat":     "1.75",
+		"days":    "3",
+		"metric":  "TRUE",
+		"city":    "42",
+		"zip":     "01234",
+		"limit":   "10",
+		"weights": []any{"0.5", 2.0, "heavy"},
+		"options": map[string]any{"verbose": "false"},
+		"extra":   "7",
+	}
+
+	var coerced []string
+	out := coerceValue(coerceSchema, input, "", &coerced)
+
+	assert.Equal(t, map[string]any{
+		"lat":     1.75,
+		"days":    int64(3),
+		"metric":  true,
+		"city":    "42",
+		"zip":     "01234",
+		"limit":   int64(10),
+		"weights": []any{0.5, 2.0, "heavy"},
+		"options": map[string]any{"verbose": false},
+		"extra":   "7",
+	}, out)
+	assert.ElementsMatch(t, []string{"/lat", "/days", "/metric", "/limit", "/weights/0", "/options/verbose"}, coerced)
+	assert.Equal(t, "1.75", input["lat"], "the input is not modified")
+}
+
+func TestCoerceString_RejectsAmbiguousLiterals(t *testing.T) {
+	for _, tc := range []struct{ s, schemaType string }{
+		{"1.5", "integer"},
+		{" 3", "integer"},
+		{"NaN", "number"},
+		{"Inf", "number"},
+		{"0x1p-2", "number"},
+		{"1e400", "number"},
+		{"yes", "boolean"},
+		{"1", "boolean"},
+		{"", "number"},
+	} {
+		_, ok := coerceString(tc.s, tc.schemaType)
+		assert.False(t, ok, "%q as %s", tc.s, tc.schemaType)
+	}
+}
+
+func newCoerceManager(serverCfg config.ServerConfig) (*ClientManager, *MockMCPSession) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{serverCfg}
+	session := new(MockMCPSession)
+	cm.sessions["weather"] = session
+	cm.toolsCache[toolCacheKey("weather", "forecast")] = ToolInfo{Name: "forecast", Server: "weather", InputSchema: coerceSchema}
+	pm.SetStatus("weather", StatusAvailable)
+	return cm, session
+}
+
+func TestCallTool_CoercesInputWhenEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name string
+		cfg  config.ServerConfig
+		want any
+	}{
+		{"server enabled", config.ServerConfig{Name: "weather", CoerceInput: true}, 1.75},
+		{"tool enabled", config.ServerConfig{Name: "weather", Tools: []config.ToolConfig{{Name: "forecast", CoerceInput: &enabled}}}, 1.75},
+		{"disabled by default", config.ServerConfig{Name: "weather"}, "1.75"},
+		{"tool disabled", config.ServerConfig{Name: "weather", CoerceInput: true, Tools: []config.ToolConfig{{Name: "forecast", CoerceInput: &disabled}}}, "1.75"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm, session := newCoerceManager(tt.cfg)
+			session.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+			_, err := cm.CallTool(context.Background(), "weather", "forecast", map[string]any{"lat": "1.75"})
+			require.NoError(t, err)
+
+			params := session.Calls[0].Arguments.Get(1).(*mcp.CallToolParams)
+			assert.Equal(t, tt.want, params.Arguments.(map[string]any)["lat"])
+		})
+	}
+}
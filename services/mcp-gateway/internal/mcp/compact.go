@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCompactDescription is the number of characters a compact description is cut to
+const maxCompactDescription = 160
+
+// CompactToolInfo summarizes a tool for listings that must stay small, e.g. hundreds of tools in an
+// LLM prompt: the schemas are reduced to the required parameters and their types
+type CompactToolInfo struct {
+	Server      string          `json:"server"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Required    []RequiredParam `json:"required"`
+	Available   bool            `json:"available"`
+	Feedback    *ToolFeedback   `json:"feedback,omitempty"`
+}
+
+// RequiredParam is a required top-level parameter of a tool
+type RequiredParam struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // JSON Schema type; alternatives are joined with |
+}
+
+// Compact returns the compact summary of the tool. The description is cut to its first line and
+// to maxCompactDescription characters.
+func (t ToolInfo) Compact() CompactToolInfo {
+	return CompactToolInfo{
+		Server:      t.Server,
+		Name:        t.Name,
+		Description: oneLine(t.Description, maxCompactDescription),
+		Required:    requiredParams(t.InputSchema),
+		Available:   t.Available,
+		Feedback:    t.Feedback,
+	}
+}
+
+// oneLine returns the first non-empty line of s, cut to max characters with an ellipsis
+func oneLine(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+// requiredParams lists the required properties of an object schema in the schema's order
+func requiredParams(inputSchema any) []RequiredParam {
+	params := []RequiredParam{}
+	schema := schemaObject(inputSchema)
+	required, _ := schema["required"].([]any)
+	properties, _ := schema["properties"].(map[string]any)
+	for _, item := range required {
+		name, ok := item.(string)
+		if !ok {
+			continue
+		}
+		param := RequiredParam{Name: name}
+		if prop, ok := properties[name].(map[string]any); ok {
+			param.Type = strings.Join(schemaTypes(prop), "|")
+		}
+		params = append(params, param)
+	}
+	return params
+}
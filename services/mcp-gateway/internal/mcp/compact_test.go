@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolInfo_Compact(t *testing.T) {
+	tool := ToolInfo{
+		Server:      "weather",
+		Name:        "forecast",
+		Description: "  Fetch the forecast for a city.\nReturns one entry per day with temperatures in °C.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city":  map[string]any{"type": "string", "description": "City name"},
+				"days":  map[string]any{"type": []any{"integer", "null"}},
+				"units": map[string]any{"type": "string", "enum": []any{"metric", "imperial"}},
+			},
+			"required": []any{"days", "city", "extra"},
+		},
+	}
+
+	assert.Equal(t, CompactToolInfo{
+		Server:      "weather",
+		Name:        "forecast",
+		Description: "Fetch the forecast for a city.",
+		Required:    []RequiredParam{{Name: "days", Type: "integer|null"}, {Name: "city", Type: "string"}, {Name: "extra"}},
+	}, tool.Compact())
+}
+
+func TestToolInfo_CompactTypedSchema(t *testing.T) {
+	// Schemas of in-process tools are typed values rather than maps
+	type property struct {
+		Type string `json:"type"`
+	}
+	schema := struct {
+		Type       string              `json:"type"`
+		Properties map[string]property `json:"properties"`
+		Required   []string            `json:"required"`
+	}{Type: "object", Properties: map[string]property{"a": {Type: "number"}}, Required: []string{"a"}}
+
+	assert.Equal(t, []RequiredParam{{Name: "a", Type: "number"}}, ToolInfo{Name: "add", InputSchema: schema}.Compact().Required)
+	assert.Equal(t, []RequiredParam{}, ToolInfo{Name: "noargs"}.Compact().Required)
+}
+
+func TestOneLine(t *testing.T) {
+	long := strings.Repeat("あ", 200)
+	got := oneLine(long, 160)
+	assert.Equal(t, 160, len([]rune(got)))
+	assert.True(t, strings.HasSuffix(got, "…"))
+	assert.Equal(t, "first", oneLine("\n first \r\nsecond", 160))
+	assert.Equal(t, "", oneLine("", 160))
+}
@@ -0,0 +1,330 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Conformance check outcomes
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+// ConformanceOptions controls which checks CheckConformance runs
+type ConformanceOptions struct {
+	// CallAllTools also calls tools without the readOnlyHint annotation.
+	// Generated inputs are arbitrary, so this may have side effects on the backend.
+	CallAllTools bool
+}
+
+// ConformanceCheck is the outcome of a single check
+type ConformanceCheck struct {
+	Name   string `json:"name"`
+	Tool   string `json:"tool,omitempty"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ConformanceReport lists the checks run against a server
+type ConformanceReport struct {
+	Server  string             `json:"server"`
+	Checks  []ConformanceCheck `json:"checks"`
+	Passed  int                `json:"passed"`
+	Failed  int                `json:"failed"`
+	Skipped int                `json:"skipped"`
+}
+
+// OK reports whether no check failed
+func (r *ConformanceReport) OK() bool {
+	return r.Failed == 0
+}
+
+func (r *ConformanceReport) add(check ConformanceCheck) {
+	switch check.Status {
+	case CheckPass:
+		r.Passed++
+	case CheckFail:
+		r.Failed++
+	case CheckSkip:
+		r.Skipped++
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// CheckConformance exercises a connected server the way the gateway uses it:
+// ping, tools/list, input schema sanity, and tool calls with generated valid and invalid inputs.
+// It returns an error only if the server is not connected; failed checks are part of the report.
+func (m *ClientManager) CheckConformance(ctx context.Context, server string, opts ConformanceOptions) (*ConformanceReport, error) {
+	m.mu.RLock()
+	session, ok := m.sessions[server]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("server %s is not connected", server)
+	}
+	timeout := 30 * time.Second
+	if cfg, ok := m.getConfig(server); ok && cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Millisecond
+	}
+
+	report := &ConformanceReport{Server: server, Checks: []ConformanceCheck{}}
+
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := session.Ping(pingCtx, nil)
+	cancel()
+	report.add(checkResult("ping", "", err))
+
+	listCtx, cancel := context.WithTimeout(ctx, timeout)
+	listed, err := session.ListTools(listCtx, &mcp.ListToolsParams{})
+	cancel()
+	report.add(checkResult("tools/list", "", err))
+	if err != nil {
+		return report, nil
+	}
+
+	seen := make(map[string]bool, len(listed.Tools))
+	for _, tool := range listed.Tools {
+		if seen[tool.Name] {
+			report.add(ConformanceCheck{Name: "tool name", Tool: tool.Name, Status: CheckFail, Detail: "listed more than once"})
+			continue
+		}
+		seen[tool.Name] = true
+		report.add(checkResult("tool name", tool.Name, validator.ValidateRequest(server, tool.Name, map[string]any{})))
+
+		schema, err := inputSchemaOf(tool)
+		report.add(checkResult("input schema", tool.Name, err))
+		if err != nil {
+			continue
+		}
+
+		if !opts.CallAllTools && (tool.Annotations == nil || !tool.Annotations.ReadOnlyHint) {
+			detail := "not annotated readOnlyHint; calls may have side effects"
+			report.add(ConformanceCheck{Name: "call valid input", Tool: tool.Name, Status: CheckSkip, Detail: detail})
+			report.add(ConformanceCheck{Name: "call invalid input", Tool: tool.Name, Status: CheckSkip, Detail: detail})
+			continue
+		}
+		report.add(checkValidCall(ctx, session, timeout, tool, schema))
+		report.add(checkInvalidCall(ctx, session, timeout, tool.Name, schema))
+	}
+
+	return report, nil
+}
+
+func checkResult(name, tool string, err error) ConformanceCheck {
+	if err != nil {
+		return ConformanceCheck{Name: name, Tool: tool, Status: CheckFail, Detail: err.Error()}
+	}
+	return ConformanceCheck{Name: name, Tool: tool, Status: CheckPass}
+}
+
+// checkValidCall calls a tool with an input generated from its schema.
+// A tool-level error is tolerated because generated values need not make sense to the tool,
+// but the call itself must succeed and honour the declared output schema.
+func checkValidCall(ctx context.Context, session MCPSession, timeout time.Duration, tool *mcp.Tool, schema map[string]any) ConformanceCheck {
+	check := ConformanceCheck{Name: "call valid input", Tool: tool.Name}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := session.CallTool(callCtx, &mcp.CallToolParams{Name: tool.Name, Arguments: validValue(schema)})
+	switch {
+	case err != nil:
+		check.Status, check.Detail = CheckFail, err.Error()
+	case result.IsError:
+		check.Status, check.Detail = CheckPass, "tool reported an error for the generated input"
+	case tool.OutputSchema != nil && result.StructuredContent == nil:
+		check.Status, check.Detail = CheckFail, "outputSchema is declared but structuredContent is missing"
+	default:
+		check.Status = CheckPass
+	}
+	return check
+}
+
+// checkInvalidCall calls a tool with an input that violates its schema and expects it to be rejected,
+// either as a protocol error or as a tool result with isError set
+func checkInvalidCall(ctx context.Context, session MCPSession, timeout time.Duration, toolName string, schema map[string]any) ConformanceCheck {
+	check := ConformanceCheck{Name: "call invalid input", Tool: toolName}
+
+	input, violation := invalidInput(schema)
+	if input == nil {
+		check.Status, check.Detail = CheckSkip, "schema accepts any object"
+		return check
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := session.CallTool(callCtx, &mcp.CallToolParams{Name: toolName, Arguments: input})
+	if err != nil || result.IsError {
+		check.Status = CheckPass
+		return check
+	}
+	check.Status, check.Detail = CheckFail, "accepted input with "+violation
+	return check
+}
+
+// inputSchemaOf decodes a tool's input schema and checks that it describes an object
+func inputSchemaOf(tool *mcp.Tool) (map[string]any, error) {
+	if tool.InputSchema == nil {
+		return nil, fmt.Errorf("inputSchema is missing")
+	}
+	data, err := json.Marshal(tool.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("inputSchema is not JSON: %w", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("inputSchema is not a JSON object")
+	}
+
+	if schema["type"] != "object" {
+		return nil, fmt.Errorf("inputSchema type is %v, want object", schema["type"])
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	if _, ok := schema["properties"]; ok && properties == nil {
+		return nil, fmt.Errorf("inputSchema properties is not an object")
+	}
+	for name, prop := range properties {
+		if _, ok := prop.(map[string]any); !ok {
+			return nil, fmt.Errorf("property %q is not a schema object", name)
+		}
+	}
+	for _, name := range requiredOf(schema) {
+		if _, ok := properties[name]; !ok {
+			return nil, fmt.Errorf("required property %q is not declared in properties", name)
+		}
+	}
+	return schema, nil
+}
+
+// requiredOf returns the required property names of an object schema
+func requiredOf(schema map[string]any) []string {
+	raw, _ := schema["required"].([]any)
+	names := make([]string, 0, len(raw))
+	for _, name := range raw {
+		if s, ok := name.(string); ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// schemaType returns the first non-null type of a schema, or "" if none is declared
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// validValue generates a value that satisfies the common JSON Schema keywords of schema
+func validValue(schema map[string]any) any {
+	if v, ok := schema["const"]; ok {
+		return v
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		properties, _ := schema["properties"].(map[string]any)
+		obj := make(map[string]any)
+		for _, name := range requiredOf(schema) {
+			prop, _ := properties[name].(map[string]any)
+			obj[name] = validValue(prop)
+		}
+		return obj
+	case "array":
+		n, _ := schema["minItems"].(float64)
+		items, _ := schema["items"].(map[string]any)
+		arr := make([]any, int(n))
+		for i := range arr {
+			arr[i] = validValue(items)
+		}
+		return arr
+	case "integer", "number":
+		v := 1.0
+		if lo, ok := schema["minimum"].(float64); ok {
+			v = lo
+		}
+		if lo, ok := schema["exclusiveMinimum"].(float64); ok {
+			v = lo + 1
+		}
+		if hi, ok := schema["maximum"].(float64); ok && v > hi {
+			v = hi
+		}
+		return v
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	default:
+		s := "conformance"
+		if n, ok := schema["minLength"].(float64); ok && int(n) > len(s) {
+			s += strings.Repeat("x", int(n)-len(s))
+		}
+		if n, ok := schema["maxLength"].(float64); ok && int(n) < len(s) {
+			s = s[:int(n)]
+		}
+		return s
+	}
+}
+
+// invalidInput generates an object that violates schema, and describes the violation.
+// It returns nil when the schema constrains nothing that can be violated.
+func invalidInput(schema map[string]any) (map[string]any, string) {
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	// A property of the wrong type
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]any)
+		wrong, ok := wrongTypeValue(schemaType(prop))
+		if !ok {
+			continue
+		}
+		input, _ := validValue(schema).(map[string]any)
+		input[name] = wrong
+		return input, fmt.Sprintf("property %q of the wrong type", name)
+	}
+	// A missing required property
+	if required := requiredOf(schema); len(required) > 0 {
+		input, _ := validValue(schema).(map[string]any)
+		delete(input, required[0])
+		return input, fmt.Sprintf("required property %q missing", required[0])
+	}
+	return nil, ""
+}
+
+// wrongTypeValue returns a value that is not of the given JSON Schema type
+func wrongTypeValue(typ string) (any, bool) {
+	switch typ {
+	case "string":
+		return 12345, true
+	case "integer", "number", "boolean", "array", "object", "null":
+		return "not-a-" + typ, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lookupInput struct {
+	City  string `json:"city" jsonschema:"city to look up"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type lookupOutput struct {
+	Found bool `json:"found"`
+}
+
+// newConformanceServer serves a well-behaved read-only tool, a read-only tool that ignores its input,
+// and a tool with side effects that records whether it was called
+func newConformanceServer(deleted *atomic.Bool) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "conformance", Version: "test"}, nil)
+	readOnly := &mcp.ToolAnnotations{ReadOnlyHint: true}
+
+	mcp.AddTool(server, &mcp.Tool{Name: "lookup", Annotations: readOnly},
+		func(_ context.Context, _ *mcp.CallToolRequest, in lookupInput) (*mcp.CallToolResult, lookupOutput, error) {
+			return nil, lookupOutput{Found: in.City != ""}, nil
+		})
+	server.AddTool(&mcp.Tool{
+		Name:        "lenient",
+		Annotations: readOnly,
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"count": map[string]any{"type": "integer", "minimum": 3}},
+			"required":   []any{"count"},
+		},
+	}, func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "delete"},
+		func(context.Context, *mcp.CallToolRequest, lookupInput) (*mcp.CallToolResult, any, error) {
+			deleted.Store(true)
+			return nil, nil, nil
+		})
+	return server
+}
+
+func checkConformance(t *testing.T, opts ConformanceOptions, deleted *atomic.Bool) map[string]ConformanceCheck {
+	t.Helper()
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("backend", newConformanceServer(deleted)))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	report, err := cm.CheckConformance(context.Background(), "backend", opts)
+	require.NoError(t, err)
+
+	checks := make(map[string]ConformanceCheck)
+	for _, check := range report.Checks {
+		checks[check.Name+"/"+check.Tool] = check
+	}
+	assert.Equal(t, len(report.Checks), report.Passed+report.Failed+report.Skipped)
+	assert.Equal(t, report.Failed == 0, report.OK())
+	return checks
+}
+
+func TestCheckConformance(t *testing.T) {
+	var deleted atomic.Bool
+	checks := checkConformance(t, ConformanceOptions{}, &deleted)
+
+	assert.Equal(t, CheckPass, checks["ping/"].Status)
+	assert.Equal(t, CheckPass, checks["tools/list/"].Status)
+	for _, tool := range []string{"lookup", "lenient", "delete"} {
+		assert.Equal(t, CheckPass, checks["tool name/"+tool].Status, tool)
+		assert.Equal(t, CheckPass, checks["input schema/"+tool].Status, tool)
+	}
+
+	assert.Equal(t, CheckPass, checks["call valid input/lookup"].Status, checks["call valid input/lookup"].Detail)
+	assert.Equal(t, CheckPass, checks["call invalid input/lookup"].Status, checks["call invalid input/lookup"].Detail)
+
+	assert.Equal(t, CheckPass, checks["call valid input/lenient"].Status)
+	invalid := checks["call invalid input/lenient"]
+	assert.Equal(t, CheckFail, invalid.Status)
+	assert.Contains(t, invalid.Detail, `"count"`)
+
+	assert.Equal(t, CheckSkip, checks["call valid input/delete"].Status)
+	assert.Equal(t, CheckSkip, checks["call invalid input/delete"].Status)
+	assert.False(t, deleted.Load(), "tools with side effects are not called by default")
+}
+
+func TestCheckConformance_CallAllTools(t *testing.T) {
+	var deleted atomic.Bool
+	checks := checkConformance(t, ConformanceOptions{CallAllTools: true}, &deleted)
+
+	assert.Equal(t, CheckPass, checks["call valid input/delete"].Status)
+	assert.Equal(t, CheckPass, checks["call invalid input/delete"].Status)
+	assert.True(t, deleted.Load())
+}
+
+func TestCheckConformance_NotConnected(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+
+	_, err := cm.CheckConformance(context.Background(), "missing", ConformanceOptions{})
+
+	assert.Error(t, err)
+}
+
+func TestInputSchemaOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  any
+		wantErr string
+	}{
+		{name: "object", schema: map[string]any{"type": "object"}},
+		{name: "missing", schema: nil, wantErr: "missing"},
+		{name: "not an object", schema: map[string]any{"type": "string"}, wantErr: "want object"},
+		{name: "bad property", schema: map[string]any{"type": "object", "properties": map[string]any{"a": true}}, wantErr: `"a"`},
+		{name: "undeclared required", schema: map[string]any{"type": "object", "required": []any{"b"}}, wantErr: `"b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inputSchemaOf(&mcp.Tool{Name: "t", InputSchema: tt.schema})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidValue(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "maxLength": float64(4)},
+			"unit":  map[string]any{"type": "string", "enum": []any{"c", "f"}},
+			"count": map[string]any{"type": "integer", "minimum": float64(5)},
+			"tags":  map[string]any{"type": "array", "minItems": float64(2), "items": map[string]any{"type": "boolean"}},
+			"extra": map[string]any{"type": "string"},
+		},
+		"required": []any{"name", "unit", "count", "tags"},
+	}
+
+	assert.Equal(t, map[string]any{
+		"name":  "conf",
+		"unit":  "c",
+		"count": float64(5),
+		"tags":  []any{true, true},
+	}, validValue(schema))
+}
+
+func TestInvalidInput(t *testing.T) {
+	input, violation := invalidInput(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"n": map[string]any{"type": "number"}},
+		"required":   []any{"n"},
+	})
+	assert.Equal(t, map[string]any{"n": "not-a-number"}, input)
+	assert.Contains(t, violation, "wrong type")
+
+	input, _ = invalidInput(map[string]any{"type": "object"})
+	assert.Nil(t, input, "nothing to violate")
+}
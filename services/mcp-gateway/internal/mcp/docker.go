@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// dockerCommand is the Docker CLI used to run containerized servers
+var dockerCommand = "docker"
+
+// dockerClientEnvVars are inherited by the Docker CLI in addition to safeEnvVars
+var dockerClientEnvVars = []string{"DOCKER_HOST", "DOCKER_CONTEXT", "DOCKER_CONFIG", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"}
+
+// dockerCLITimeout bounds docker stop/rm invocations
+const dockerCLITimeout = 15 * time.Second
+
+// containerName returns the name of the container for a server. It includes the gateway PID
+// so that several gateways on one host do not collide.
+func containerName(serverName string) string {
+	return fmt.Sprintf("mcp-gateway-%d-%s", os.Getpid(), serverName)
+}
+
+// newDockerCommand creates a docker run command that attaches stdio to the server's container.
+// Env values are passed through the CLI's environment rather than its arguments so that they
+// do not show up in the process list.
+func newDockerCommand(cfg config.ServerConfig) *exec.Cmd {
+	env := inheritedEnv(slices.Concat(safeEnvVars, dockerClientEnvVars))
+
+	args := []string{"run", "-i", "--rm",
+		"--name", containerName(cfg.Name),
+		"--label", "mcp-gateway.server=" + cfg.Name,
+	}
+	if cfg.WorkDir != "" {
+		args = append(args, "-w", cfg.WorkDir)
+	}
+	if cfg.RunAs != nil {
+		args = append(args, "--user", fmt.Sprintf("%d:%d", *cfg.RunAs.UID, *cfg.RunAs.GID))
+	}
+	for _, v := range cfg.Docker.Volumes {
+		args = append(args, "-v", v)
+	}
+	for _, e := range forwardedEnvs(cfg) {
+		args = append(args, "-e", e.Name)
+		env = append(env, fmt.Sprintf("%s=%s", e.Name, e.Value))
+	}
+	args = append(args, cfg.Docker.Image)
+	if cfg.Command != "" {
+		args = append(args, cfg.Command)
+	}
+	args = append(args, cfg.Args...)
+
+	cmd := exec.Command(dockerCommand, args...)
+	cmd.Env = env
+	return cmd
+}
+
+// stopContainer stops a server's container, giving it grace to exit before Docker kills it.
+// The container is removed by --rm once it stops.
+func stopContainer(serverName string, grace time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grace+dockerCLITimeout)
+	defer cancel()
+	seconds := strconv.Itoa(int(grace / time.Second))
+	if out, err := exec.CommandContext(ctx, dockerCommand, "stop", "--time", seconds, containerName(serverName)).CombinedOutput(); err != nil {
+		return fmt.Errorf("docker stop: %w: %s", err, out)
+	}
+	return nil
+}
+
+// removeContainer kills and removes a server's container
+func removeContainer(serverName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerCLITimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, dockerCommand, "rm", "--force", containerName(serverName)).CombinedOutput(); err != nil {
+		return fmt.Errorf("docker rm: %w: %s", err, out)
+	}
+	return nil
+}
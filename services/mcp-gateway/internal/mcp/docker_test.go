@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker replaces the Docker CLI with a script that appends its arguments to a log file
+// and, on stop, terminates the process whose PID is in FAKE_DOCKER_PID
+func fakeDocker(t *testing.T) (logPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath = filepath.Join(dir, "docker.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\nif [ \"$1\" = stop ] && [ -n \"$FAKE_DOCKER_PID\" ]; then kill \"$FAKE_DOCKER_PID\"; fi\n"
+	path := filepath.Join(dir, "docker")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+
+	orig := dockerCommand
+	dockerCommand = path
+	t.Cleanup(func() { dockerCommand = orig })
+	return logPath
+}
+
+func dockerServerConfig() config.ServerConfig {
+	return config.ServerConfig{
+		Name:    "files",
+		Command: "mcp-files",
+		Args:    []string{"--root", "/data"},
+		Runtime: config.RuntimeDocker,
+		Docker: &config.DockerConfig{
+			Image:   "example/mcp-files:1.0",
+			Volumes: []string{"/srv/data:/data:ro"},
+		},
+		Envs: []config.EnvVar{{Name: "API_TOKEN", Value: "s3cret"}},
+	}
+}
+
+func TestNewServerCommand_Docker(t *testing.T) {
+	cmd := newServerCommand(dockerServerConfig())
+
+	assert.Equal(t, []string{
+		dockerCommand, "run", "-i", "--rm",
+		"--name", containerName("files"),
+		"--label", "mcp-gateway.server=files",
+		"-v", "/srv/data:/data:ro",
+		"-e", "API_TOKEN",
+		"example/mcp-files:1.0", "mcp-files", "--root", "/data",
+	}, cmd.Args)
+	// The value reaches the container through the CLI's environment, not its arguments
+	assert.Contains(t, cmd.Env, "API_TOKEN=s3cret")
+	assert.NotContains(t, strings.Join(cmd.Args, " "), "s3cret")
+}
+
+func TestNewServerCommand_DockerWorkDir(t *testing.T) {
+	cfg := dockerServerConfig()
+	cfg.WorkDir = "/data"
+
+	cmd := newServerCommand(cfg)
+
+	assert.Contains(t, strings.Join(cmd.Args, " "), "--label mcp-gateway.server=files -w /data -v ")
+	assert.Empty(t, cmd.Dir, "the directory is inside the container")
+}
+
+func TestNewServerCommand_DockerRunAs(t *testing.T) {
+	cfg := dockerServerConfig()
+	uid, gid := uint32(1000), uint32(100)
+	cfg.RunAs = &config.RunAsConfig{UID: &uid, GID: &gid}
+
+	cmd, err := stdioDialer{}.Dial(context.Background(), cfg)
+	require.NoError(t, err)
+
+	command := cmd.(*mcp.CommandTransport).Command
+	assert.Contains(t, strings.Join(command.Args, " "), "--label mcp-gateway.server=files --user 1000:100 ")
+}
+
+func TestTeardownServer_RemovesContainer(t *testing.T) {
+	logPath := fakeDocker(t)
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{dockerServerConfig()}
+
+	cmd := exec.Command("sleep", "30")
+	require.NoError(t, cmd.Start())
+	cm.processes["files"] = cmd
+
+	cm.teardownServer("files")
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "rm --force "+containerName("files")+"\n", string(log))
+	_ = cmd.Wait()
+}
+
+func TestClose_StopsContainer(t *testing.T) {
+	logPath := fakeDocker(t)
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{dockerServerConfig()}
+
+	cmd := exec.Command("sleep", "30")
+	require.NoError(t, cmd.Start())
+	cm.processes["files"] = cmd
+	t.Setenv("FAKE_DOCKER_PID", strconv.Itoa(cmd.Process.Pid))
+
+	require.NoError(t, cm.Close())
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "stop --time 5 "+containerName("files")+"\n", string(log))
+	assert.NotNil(t, cmd.ProcessState, "the docker CLI process should have been reaped")
+}
+
+func TestNewServerCommand_DockerInheritEnv(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("API_TOKEN", "from-gateway")
+	cfg := dockerServerConfig()
+	cfg.InheritEnv = []string{"AWS_REGION", "API_TOKEN"}
+
+	cmd := newServerCommand(cfg)
+
+	assert.Contains(t, strings.Join(cmd.Args, " "), "-e AWS_REGION -e API_TOKEN example/")
+	assert.Contains(t, cmd.Env, "AWS_REGION=eu-west-1")
+	// envs win over inherited variables of the same name
+	assert.Contains(t, cmd.Env, "API_TOKEN=s3cret")
+	assert.NotContains(t, cmd.Env, "API_TOKEN=from-gateway")
+}
@@ -0,0 +1,19 @@
+package mcp
+
+import (
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+)
+
+// Events returns the bus on which the manager publishes the lifecycle events of the servers and the
+// tool calls made to them
+func (m *ClientManager) Events() *events.Bus {
+	return m.events
+}
+
+// publishStatusEvents turns status changes into lifecycle events. Registered by NewClientManager.
+func (m *ClientManager) publishStatusEvents(ev StatusEvent) {
+	m.events.Publish(events.Event{Type: events.StatusChanged, Server: ev.Server, At: ev.At, From: string(ev.From), To: string(ev.To)})
+	if ev.From == StatusConnecting && ev.To == StatusAvailable {
+		m.events.Publish(events.Event{Type: events.ServerStarted, Server: ev.Server, At: ev.At})
+	}
+}
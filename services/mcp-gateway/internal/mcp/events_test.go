@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiveEvent waits for the next event of the given type on a channel of Bus.Watch
+func receiveEvent(t *testing.T, stream <-chan events.Event, typ events.Type) events.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-stream:
+			require.True(t, ok, "watch channel closed")
+			if ev.Type == typ {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", typ)
+			return events.Event{}
+		}
+	}
+}
+
+func TestEvents_RestartAndQuarantine(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 10, Multiplier: 2, MaxDelay: 10, MaxAttempts: 1})
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	stream, unwatch := cm.Events().Watch(64)
+	defer unwatch()
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	ev := receiveEvent(t, stream, events.ServerStarted)
+	assert.Equal(t, "embedded", ev.Server)
+
+	require.NoError(t, pm.Transition("embedded", StatusCrashed))
+	pm.RecordFailure("embedded", RestartReasonTransportClosed, nil)
+	pm.onServerCrashed("embedded")
+	ev = receiveEvent(t, stream, events.RestartAttempted)
+	assert.Equal(t, 1, ev.Attempt)
+	assert.Equal(t, 1, ev.MaxAttempts)
+	assert.Equal(t, int64(10), ev.BackoffMs)
+	assert.Equal(t, string(RestartReasonTransportClosed), ev.Reason)
+	receiveEvent(t, stream, events.ServerStarted)
+
+	// The second crash finds the attempts used up
+	require.NoError(t, pm.Transition("embedded", StatusCrashed))
+	pm.onServerCrashed("embedded")
+	ev = receiveEvent(t, stream, events.RestartExhausted)
+	assert.Equal(t, 1, ev.Attempt)
+	assert.Equal(t, StatusQuarantined, pm.GetStatus("embedded"))
+}
+
+func TestEvents_ToolCalls(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	stream, unwatch := cm.Events().Watch(8, events.ToolCallStarted, events.ToolCallFinished)
+	defer unwatch()
+
+	_, err := cm.CallTool(context.Background(), "embedded", "echo", map[string]any{"text": "hi"})
+	require.NoError(t, err)
+
+	ev := receiveEvent(t, stream, events.ToolCallStarted)
+	assert.Equal(t, "embedded", ev.Server)
+	assert.Equal(t, "echo", ev.Tool)
+	ev = receiveEvent(t, stream, events.ToolCallFinished)
+	assert.Equal(t, "echo", ev.Tool)
+	assert.False(t, ev.Failed)
+	assert.Empty(t, ev.Error)
+}
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// feedbackCounts tallies reports on a tool. Guarded by ClientManager.mu.
+type feedbackCounts struct {
+	server      string
+	tool        string
+	satisfied   uint64
+	unsatisfied uint64
+}
+
+// ToolFeedback aggregates reports from agents on whether a tool satisfied the task it was selected for
+type ToolFeedback struct {
+	Satisfied   uint64  `json:"satisfied"`
+	Unsatisfied uint64  `json:"unsatisfied"`
+	Score       float64 `json:"score"` // success rate smoothed towards 0.5 while there are few reports
+}
+
+// summary converts the counts into ToolFeedback. The score is the Laplace-smoothed success rate
+// (satisfied+1)/(total+2), so that a single report does not rank a tool at 0 or 1.
+func (f feedbackCounts) summary() ToolFeedback {
+	return ToolFeedback{
+		Satisfied:   f.satisfied,
+		Unsatisfied: f.unsatisfied,
+		Score:       float64(f.satisfied+1) / float64(f.satisfied+f.unsatisfied+2),
+	}
+}
+
+// RecordFeedback adds a report on whether a tool satisfied a task and returns the tool's updated feedback.
+// Only tools currently listed can receive feedback.
+func (m *ClientManager) RecordFeedback(server, toolName string, satisfied bool) (ToolFeedback, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.getConfig(server); !ok {
+		return ToolFeedback{}, mcpErrors.ErrServerNotFound
+	}
+	if _, ok := m.toolsCache[toolCacheKey(server, toolName)]; !ok {
+		return ToolFeedback{}, mcpErrors.ErrToolNotFound
+	}
+	counts := m.feedbackCountsLocked(server, toolName)
+	if satisfied {
+		counts.satisfied++
+	} else {
+		counts.unsatisfied++
+	}
+	return counts.summary(), nil
+}
+
+// feedbackCountsLocked returns the feedback counts of a tool, creating them if needed. Callers must hold m.mu.
+func (m *ClientManager) feedbackCountsLocked(server, toolName string) *feedbackCounts {
+	key := toolCacheKey(server, toolName)
+	counts, ok := m.feedback[key]
+	if !ok {
+		counts = &feedbackCounts{server: server, tool: toolName}
+		m.feedback[key] = counts
+	}
+	return counts
+}
+
+// feedbackLocked returns the feedback of a tool, or nil if it has none. Callers must hold m.mu.
+func (m *ClientManager) feedbackLocked(server, toolName string) *ToolFeedback {
+	counts, ok := m.feedback[toolCacheKey(server, toolName)]
+	if !ok {
+		return nil
+	}
+	summary := counts.summary()
+	return &summary
+}
@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeedbackTestManager() *ClientManager {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cm.configs = []config.ServerConfig{{Name: "weather"}}
+	cm.toolsCache[toolCacheKey("weather", "forecast")] = ToolInfo{Name: "forecast", Server: "weather"}
+	cm.toolsCache[toolCacheKey("weather", "alerts")] = ToolInfo{Name: "alerts", Server: "weather"}
+	return cm
+}
+
+func TestRecordFeedback_SmoothedScore(t *testing.T) {
+	cm := newFeedbackTestManager()
+
+	feedback, err := cm.RecordFeedback("weather", "forecast", true)
+	require.NoError(t, err)
+	assert.Equal(t, ToolFeedback{Satisfied: 1, Score: 2.0 / 3}, feedback)
+
+	for range 3 {
+		_, err = cm.RecordFeedback("weather", "forecast", true)
+		require.NoError(t, err)
+	}
+	feedback, err = cm.RecordFeedback("weather", "forecast", false)
+	require.NoError(t, err)
+	assert.Equal(t, ToolFeedback{Satisfied: 4, Unsatisfied: 1, Score: 5.0 / 7}, feedback)
+}
+
+func TestRecordFeedback_UnknownTool(t *testing.T) {
+	cm := newFeedbackTestManager()
+
+	_, err := cm.RecordFeedback("missing", "forecast", true)
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotFound)
+
+	_, err = cm.RecordFeedback("weather", "missing", true)
+	assert.ErrorIs(t, err, mcpErrors.ErrToolNotFound)
+}
+
+func TestGetTools_IncludesFeedback(t *testing.T) {
+	cm := newFeedbackTestManager()
+	_, err := cm.RecordFeedback("weather", "forecast", false)
+	require.NoError(t, err)
+
+	for _, tool := range cm.GetTools() {
+		switch tool.Name {
+		case "forecast":
+			assert.Equal(t, &ToolFeedback{Unsatisfied: 1, Score: 1.0 / 3}, tool.Feedback)
+		case "alerts":
+			assert.Nil(t, tool.Feedback, "tools without reports have no feedback")
+		}
+	}
+}
+
+func TestCounterSnapshot_Feedback(t *testing.T) {
+	cm := newFeedbackTestManager()
+	_, err := cm.RecordFeedback("weather", "forecast", true)
+	require.NoError(t, err)
+	_, err = cm.RecordFeedback("weather", "forecast", false)
+	require.NoError(t, err)
+
+	snap := cm.SnapshotCounters()
+	assert.Equal(t, map[string]FeedbackCounters{"forecast": {Satisfied: 1, Unsatisfied: 1}}, snap.Servers["weather"].Feedback)
+
+	cm2 := newFeedbackTestManager()
+	cm2.RestoreCounters(snap)
+	feedback, err := cm2.RecordFeedback("weather", "forecast", true)
+	require.NoError(t, err)
+	assert.Equal(t, ToolFeedback{Satisfied: 2, Unsatisfied: 1, Score: 3.0 / 5}, feedback)
+}
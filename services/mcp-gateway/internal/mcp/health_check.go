@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// StartHealthCheck starts MCP ping-based health monitoring for a server
+// StartHealthCheck starts health monitoring for a server, probing it with MCP ping or its healthCheck method
+// and calling its canary tool, if it has one
 // TODO: Make consecutive failure threshold configurable (currently hardcoded to 3)
 func (m *ClientManager) StartHealthCheck(ctx context.Context, serverName string) {
 	interval := time.Duration(m.processManager.healthCheckInterval) * time.Millisecond
@@ -44,25 +46,45 @@ func (m *ClientManager) StartHealthCheck(ctx context.Context, serverName string)
 	m.healthCheckDone[serverName] = done
 	m.mu.Unlock()
 
-	go func() {
+	exit := func() {
+		cancel()
+		m.mu.Lock()
+		delete(m.healthCheckCancels, serverName)
+		delete(m.healthCheckDone, serverName)
+		m.mu.Unlock()
+		close(done)
+		slog.Debug("Health check goroutine exited", "server", serverName)
+	}
+
+	started := m.workers.Go("health-check:"+serverName, func(workerCtx context.Context) {
+		defer exit()
+		// Also stop when the manager shuts down
+		stop := context.AfterFunc(workerCtx, cancel)
+		defer stop()
+
+		canaryDone := m.startCanary(healthCtx, serverName, state)
 		defer func() {
-			m.mu.Lock()
-			delete(m.healthCheckCancels, serverName)
-			delete(m.healthCheckDone, serverName)
-			m.mu.Unlock()
-			close(done)
-			slog.Debug("Health check goroutine exited", "server", serverName)
+			cancel()
+			<-canaryDone
 		}()
 
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
+		timer := m.processManager.Clock().NewTimer(m.healthCheckDelay(interval))
+		defer timer.Stop()
 
 		for {
 			select {
 			case <-healthCtx.Done():
 				slog.Debug("Health check stopped", "server", serverName)
 				return
-			case <-ticker.C:
+			case <-timer.C():
+				// Like a ticker, the next check does not wait for this one to finish
+				timer.Reset(m.healthCheckDelay(interval))
+
+				// An idle server has no process to check until a call respawns it
+				if m.suspendIfIdle(serverName) {
+					return
+				}
+
 				m.mu.RLock()
 				session, ok := m.sessions[serverName]
 				m.mu.RUnlock()
@@ -72,6 +94,10 @@ func (m *ClientManager) StartHealthCheck(ctx context.Context, serverName string)
 					return
 				}
 
+				if m.enforceMemoryLimit(serverName) {
+					continue
+				}
+
 				// Calculate ping timeout: interval/2, min 3s, max 10s
 				// Rationale: Timeout should be shorter than interval to allow multiple retries,
 				// but long enough to handle network latency. 3s min protects against too-short intervals,
@@ -84,13 +110,13 @@ func (m *ClientManager) StartHealthCheck(ctx context.Context, serverName string)
 					pingTimeout = 10 * time.Second
 				}
 
-				// MCP ping with timeout
+				// Probe with timeout
 				pingCtx, pingCancel := context.WithTimeout(healthCtx, pingTimeout)
-				err := session.Ping(pingCtx, &mcp.PingParams{})
+				err := m.probe(pingCtx, serverName, session)
 				pingCancel()
 
 				state.mu.Lock()
-				state.lastCheckTime = time.Now()
+				state.lastCheckTime = m.processManager.Clock().Now()
 
 				if err != nil {
 					// Check if restarting before incrementing failures
@@ -102,25 +128,27 @@ func (m *ClientManager) StartHealthCheck(ctx context.Context, serverName string)
 					failures := state.consecutiveFailures
 					state.mu.Unlock()
 
-					slog.Warn("Health check failed - MCP ping failed",
+					slog.Warn("Health check failed",
 						"server", serverName,
+						"method", m.healthCheckMethod(serverName),
 						"consecutive_failures", failures,
 						"error", err)
+					m.events.Publish(events.Event{Type: events.HealthCheckFailed, Server: serverName, Failures: failures, Error: err.Error()})
 
-					// 3-strike rule: Only mark as crashed after 3 consecutive failures
+					// 3-strike rule: Only mark as crashed after 3 consecutive failures.
+					// The transitions fail while the server is restarting, crashed or stopped,
+					// which prevents duplicate restart triggers.
 					if failures >= 3 {
-						// Check if already restarting to prevent duplicate triggers
-						// Reuse isRestarting from above check
-						isRestarting = m.processManager.GetStatus(serverName) == StatusRestarting
-
-						if !isRestarting {
-							m.processManager.SetStatus(serverName, StatusCrashed)
+						if m.transition(serverName, StatusCrashed) {
+							m.processManager.RecordFailure(serverName, RestartReasonHealthCheck, err)
 
 							// Trigger restart if policy allows
 							if m.processManager.onServerCrashed != nil {
 								m.processManager.onServerCrashed(serverName)
 							}
 						}
+					} else if failures > 0 {
+						m.transition(serverName, StatusUnhealthy)
 					}
 					// Continue checking instead of returning - allows recovery detection
 					continue
@@ -132,29 +160,98 @@ func (m *ClientManager) StartHealthCheck(ctx context.Context, serverName string)
 							"previous_failures", state.consecutiveFailures)
 						state.consecutiveFailures = 0
 						m.processManager.ResetRestartAttempts(serverName)
+						// A failing canary keeps the server unhealthy until a canary call passes
+						if !state.canaryFailing {
+							m.transition(serverName, StatusAvailable)
+						}
 					}
 					state.mu.Unlock()
 				}
 			}
 		}
-	}()
+	})
+	if !started {
+		exit()
+	}
+}
+
+// healthCheckDelay returns the interval until the next health check, varied by the jitter
+func (m *ClientManager) healthCheckDelay(interval time.Duration) time.Duration {
+	spread := interval * time.Duration(m.healthCheckJitter) / 100
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + m.processManager.randN(2*spread+1)
+}
+
+// healthCheckMethod returns how a server is probed, ping unless its healthCheck says otherwise
+func (m *ClientManager) healthCheckMethod(serverName string) string {
+	if cfg, ok := m.getConfig(serverName); ok && cfg.HealthCheck != nil && cfg.HealthCheck.Method != "" {
+		return cfg.HealthCheck.Method
+	}
+	return config.HealthCheckPing
 }
 
-// RestartServer attempts to restart a crashed server
+// probe checks once that a server responds, with the method of its healthCheck
+func (m *ClientManager) probe(ctx context.Context, serverName string, session MCPSession) error {
+	switch m.healthCheckMethod(serverName) {
+	case config.HealthCheckListTools:
+		_, err := session.ListTools(ctx, &mcp.ListToolsParams{})
+		return err
+	case config.HealthCheckTool:
+		cfg, _ := m.getConfig(serverName)
+		input := cfg.HealthCheck.Input
+		if input == nil {
+			input = map[string]any{}
+		}
+		result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: cfg.HealthCheck.ToolName, Arguments: input})
+		if err != nil {
+			return err
+		}
+		if result.IsError {
+			for _, content := range result.Content {
+				if text, ok := content.(*mcp.TextContent); ok && text.Text != "" {
+					return fmt.Errorf("tool %s returned an error: %s", cfg.HealthCheck.ToolName, text.Text)
+				}
+			}
+			return fmt.Errorf("tool %s returned an error", cfg.HealthCheck.ToolName)
+		}
+		return nil
+	default:
+		return session.Ping(ctx, &mcp.PingParams{})
+	}
+}
+
+// RestartServer restarts a crashed server after its backoff, if its restart policy and remaining
+// attempts allow. The restart runs on the server's supervisor; RestartServer returns once it is scheduled.
 func (m *ClientManager) RestartServer(ctx context.Context, cfg config.ServerConfig) error {
-	// Check restart policy before attempting restart
-	if m.processManager.restartPolicy != "on-failure" {
-		slog.Info("Restart skipped due to policy", "server", cfg.Name, "policy", m.processManager.restartPolicy)
+	if err := m.beginRestart(cfg); err != nil {
+		return err
+	}
+	if err := m.supervise(cfg.Name, func(workerCtx context.Context) { m.performRestart(ctx, workerCtx, cfg) }); err != nil {
+		m.transition(cfg.Name, StatusCrashed)
+		return fmt.Errorf("restart of server %s aborted: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// beginRestart checks that a crashed server may be restarted and moves it to restarting
+func (m *ClientManager) beginRestart(cfg config.ServerConfig) error {
+	// Check restart policy before attempting restart.
+	// Remote servers have no process to restart, so they are always reconnected.
+	policy := m.restartPolicy(cfg)
+	if policy != config.RestartPolicyOnFailure && policy != config.RestartPolicyAlways && !cfg.IsRemote() {
+		slog.Info("Restart skipped due to policy", "server", cfg.Name, "policy", policy)
 		// Status remains as set by caller (should be StatusCrashed)
-		return fmt.Errorf("restart policy does not allow restart")
+		return errRestartNotAllowed
 	}
 
 	// Check max attempts before attempting restart
 	currentAttempts := m.processManager.GetRestartAttempts(cfg.Name)
-	if currentAttempts >= 3 {
+	if currentAttempts >= m.processManager.MaxRestartAttempts() {
 		slog.Error("Max restart attempts reached", "server", cfg.Name, "attempts", currentAttempts)
 		// Status remains as set by caller (should be StatusCrashed)
-		return fmt.Errorf("max restart attempts reached")
+		return errRestartsExhausted
 	}
 
 	// Use atomic CAS to prevent TOCTOU race condition
@@ -166,76 +263,98 @@ func (m *ClientManager) RestartServer(ctx context.Context, cfg config.ServerConf
 		}
 		return fmt.Errorf("server %s is not in crashed state (current: %s)", cfg.Name, currentStatus)
 	}
+	return nil
+}
 
-	// Perform restart asynchronously to avoid blocking
-	go func() {
-		attempts := m.processManager.IncrementRestartAttempts(cfg.Name)
+// performRestart waits for the backoff of a server moved to restarting and reconnects it. A failed
+// reconnection counts as another crash, which is retried or quarantines the server.
+// Runs on the server's supervisor; workerCtx is cancelled on Close.
+func (m *ClientManager) performRestart(ctx, workerCtx context.Context, cfg config.ServerConfig) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(workerCtx, cancel)
+	defer stop()
+
+	attempts := m.processManager.IncrementRestartAttempts(cfg.Name)
+	m.processManager.RecordRestart(cfg.Name, m.processManager.GetDiagnostics(cfg.Name).LastFailureReason)
+
+	// Calculate backoff
+	backoff := m.processManager.CalculateBackoff(attempts)
+	if cfg.IsRemote() {
+		slog.Info("Reconnecting server", "server", cfg.Name, "transport", cfg.Transport, "url", cfg.URL, "attempt", attempts, "backoff", backoff)
+	} else {
+		slog.Info("Restarting server", "server", cfg.Name, "transport", cfg.Transport, "attempt", attempts, "backoff", backoff)
+	}
 
-		// Calculate backoff
-		backoff := m.processManager.CalculateBackoff(attempts)
-		slog.Info("Restarting server", "server", cfg.Name, "attempt", attempts, "backoff", backoff)
+	m.events.Publish(events.Event{
+		Type:        events.RestartAttempted,
+		Server:      cfg.Name,
+		Attempt:     attempts,
+		MaxAttempts: m.processManager.MaxRestartAttempts(),
+		BackoffMs:   backoff.Milliseconds(),
+		Reason:      string(m.processManager.GetDiagnostics(cfg.Name).LastFailureReason),
+	})
+
+	// Wait for backoff, giving up early on shutdown
+	clk := m.processManager.Clock()
+	m.processManager.setNextRestart(cfg.Name, clk.Now().Add(backoff))
+	select {
+	case <-runCtx.Done():
+	case <-clk.After(backoff):
+	}
+	m.processManager.setNextRestart(cfg.Name, time.Time{})
 
-		// Wait for backoff
-		time.Sleep(backoff)
+	// Check if the context is already cancelled (e.g., during shutdown)
+	// Early exit to avoid tearing down and reconnecting a server that Close is about to clean up
+	if runCtx.Err() != nil {
+		slog.Info("Restart aborted due to context cancellation", "server", cfg.Name)
 
-		// Clean up old session and process
+		// Cancel any existing health check to prevent unnecessary operations during shutdown
 		m.mu.Lock()
-		if oldSession, ok := m.sessions[cfg.Name]; ok {
-			if err := oldSession.Close(); err != nil {
-				slog.Warn("Failed to close old session during restart", "server", cfg.Name, "error", err)
-			}
-			delete(m.sessions, cfg.Name)
-		}
-		if oldCmd, ok := m.processes[cfg.Name]; ok {
-			if oldCmd.Process != nil {
-				if err := oldCmd.Process.Kill(); err != nil {
-					slog.Warn("Failed to kill old process during restart", "server", cfg.Name, "error", err)
-				}
-			}
-			delete(m.processes, cfg.Name)
+		if cancel, ok := m.healthCheckCancels[cfg.Name]; ok {
+			cancel()
 		}
 		m.mu.Unlock()
 
-		// Check if parent context is already cancelled (e.g., during shutdown)
-		// Early exit to avoid unnecessary resource cleanup (session close, process kill) that occurred above
-		// and the subsequent reconnection attempt during graceful shutdown
-		if ctx.Err() != nil {
-			slog.Info("Restart aborted due to context cancellation", "server", cfg.Name)
-
-			// Cancel any existing health check to prevent unnecessary operations during shutdown
-			m.mu.Lock()
-			if cancel, ok := m.healthCheckCancels[cfg.Name]; ok {
-				cancel()
-			}
-			m.mu.Unlock()
+		m.transition(cfg.Name, StatusCrashed)
+		return
+	}
 
-			m.processManager.SetStatus(cfg.Name, StatusCrashed)
-			return
-		}
+	// Clean up old session and process
+	m.teardownServer(cfg.Name)
 
-		// Attempt reconnection with timeout
-		// Use context.WithTimeout to ensure reconnection respects parent context cancellation
-		// This allows proper shutdown handling during application termination
-		connCtx, connCancel := context.WithTimeout(ctx, 30*time.Second)
-		defer connCancel()
-		if err := m.connectClient(connCtx, cfg); err != nil {
-			slog.Error("Failed to reconnect server", "server", cfg.Name, "error", err)
+	// Attempt reconnection with timeout
+	// Use context.WithTimeout to ensure reconnection respects parent context cancellation
+	// This allows proper shutdown handling during application termination
+	connCtx, connCancel := context.WithTimeout(runCtx, 30*time.Second)
+	defer connCancel()
+	if err := m.connectClient(connCtx, cfg); err != nil {
+		slog.Error("Failed to reconnect server", "server", cfg.Name, "error", err)
 
-			// Cancel any existing health check
-			m.mu.Lock()
-			if cancel, ok := m.healthCheckCancels[cfg.Name]; ok {
-				cancel()
-			}
-			m.mu.Unlock()
+		// Cancel any existing health check
+		m.mu.Lock()
+		if cancel, ok := m.healthCheckCancels[cfg.Name]; ok {
+			cancel()
+		}
+		m.mu.Unlock()
 
-			m.processManager.SetStatus(cfg.Name, StatusCrashed)
-			return
+		m.transition(cfg.Name, StatusCrashed)
+		// Unless the server was stopped meanwhile or the manager is closing
+		if m.processManager.GetStatus(cfg.Name) == StatusCrashed && runCtx.Err() == nil {
+			m.notifyCrashed(cfg.Name)
 		}
+		return
+	}
 
-		// Restart health check after successful reconnection
-		m.StartHealthCheck(ctx, cfg.Name)
-		slog.Info("Server restarted successfully", "server", cfg.Name, "attempt", attempts)
-	}()
+	// Restart health check after successful reconnection
+	m.StartHealthCheck(ctx, cfg.Name)
+	slog.Info("Server restarted successfully", "server", cfg.Name, "attempt", attempts)
+}
 
-	return nil
+// restartPolicy returns the server's restart policy, or the global one when it has none
+func (m *ClientManager) restartPolicy(cfg config.ServerConfig) string {
+	if cfg.RestartPolicy != "" {
+		return cfg.RestartPolicy
+	}
+	return m.processManager.restartPolicy
 }
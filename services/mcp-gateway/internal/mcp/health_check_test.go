@@ -10,6 +10,7 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockMCPSession is a mock implementation of MCPSession
@@ -106,6 +107,11 @@ func TestStartHealthCheck_ThreeFailuresTriggerRestart(t *testing.T) {
 
 	// Verify status is crashed
 	assert.Equal(t, StatusCrashed, pm.GetStatus("test-server"))
+
+	// Verify the failure reason was recorded
+	d := pm.GetDiagnostics("test-server")
+	assert.Equal(t, RestartReasonHealthCheck, d.LastFailureReason)
+	assert.Equal(t, "ping failed", d.LastError)
 }
 
 func TestStartHealthCheck_RecoveryResetsCounter(t *testing.T) {
@@ -168,6 +174,53 @@ func TestStartHealthCheck_CancellationStopsGoroutine(t *testing.T) {
 	assert.False(t, ok, "Health check cancel function should be removed from map")
 }
 
+func TestHealthCheckDelay(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	interval := 30 * time.Second
+	assert.Equal(t, interval, cm.healthCheckDelay(interval), "no jitter by default")
+
+	cm.SetHealthCheckJitter(10)
+	seen := make(map[time.Duration]bool)
+	for range 100 {
+		delay := cm.healthCheckDelay(interval)
+		assert.GreaterOrEqual(t, delay, 27*time.Second)
+		assert.LessOrEqual(t, delay, 33*time.Second)
+		seen[delay] = true
+	}
+	assert.Greater(t, len(seen), 1, "delays should vary")
+}
+
+func TestProbe_HealthCheckMethods(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cm.configs = []config.ServerConfig{
+		{Name: "default"},
+		{Name: "list", HealthCheck: &config.HealthCheckConfig{Method: config.HealthCheckListTools}},
+		{Name: "tool", HealthCheck: &config.HealthCheckConfig{Method: config.HealthCheckTool, ToolName: "status", Input: map[string]any{"quick": true}}},
+		{Name: "no-input", HealthCheck: &config.HealthCheckConfig{Method: config.HealthCheckTool, ToolName: "status"}},
+	}
+	ctx := context.Background()
+
+	session := new(MockMCPSession)
+	session.On("Ping", mock.Anything, mock.Anything).Return(nil).Once()
+	assert.NoError(t, cm.probe(ctx, "default", session))
+
+	session = new(MockMCPSession)
+	session.On("ListTools", mock.Anything, mock.Anything).Return(nil, errors.New("connection reset")).Once()
+	assert.EqualError(t, cm.probe(ctx, "list", session), "connection reset")
+
+	session = new(MockMCPSession)
+	session.On("CallTool", mock.Anything, &mcp.CallToolParams{Name: "status", Arguments: map[string]any{"quick": true}}).
+		Return(&mcp.CallToolResult{}, nil).Once()
+	assert.NoError(t, cm.probe(ctx, "tool", session))
+
+	// A tool that reports an error means the server is not healthy
+	session = new(MockMCPSession)
+	session.On("CallTool", mock.Anything, &mcp.CallToolParams{Name: "status", Arguments: map[string]any{}}).
+		Return(&mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "database unreachable"}}}, nil).Once()
+	assert.EqualError(t, cm.probe(ctx, "no-input", session), "tool status returned an error: database unreachable")
+	session.AssertNotCalled(t, "Ping", mock.Anything, mock.Anything)
+}
+
 func TestRestartServer_BackoffCalculation(t *testing.T) {
 	pm := NewProcessManager(100, "on-failure")
 
@@ -178,6 +231,66 @@ func TestRestartServer_BackoffCalculation(t *testing.T) {
 	assert.Equal(t, 4*time.Second, pm.CalculateBackoff(10))
 }
 
+func TestRestartServer_ConfiguredBackoff(t *testing.T) {
+	pm := NewProcessManager(100, "on-failure")
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 500, Multiplier: 3, MaxDelay: 10000, MaxAttempts: 5})
+
+	assert.Equal(t, 5, pm.MaxRestartAttempts())
+	assert.Equal(t, 500*time.Millisecond, pm.CalculateBackoff(1))
+	assert.Equal(t, 1500*time.Millisecond, pm.CalculateBackoff(2))
+	assert.Equal(t, 4500*time.Millisecond, pm.CalculateBackoff(3))
+	assert.Equal(t, 10*time.Second, pm.CalculateBackoff(4))
+	assert.Equal(t, 10*time.Second, pm.CalculateBackoff(1000))
+
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 1000, Multiplier: 2, MaxDelay: 4000, Jitter: 20, MaxAttempts: 3})
+	for range 100 {
+		delay := pm.CalculateBackoff(2)
+		assert.GreaterOrEqual(t, delay, 1600*time.Millisecond)
+		assert.LessOrEqual(t, delay, 2400*time.Millisecond)
+	}
+}
+
+func TestRestartServer_SeededJitterIsReproducible(t *testing.T) {
+	backoff := config.RestartBackoffConfig{InitialDelay: 1000, Multiplier: 2, MaxDelay: 60000, Jitter: 50, MaxAttempts: 10}
+	delays := func(seed uint64) []time.Duration {
+		pm := NewProcessManager(100, "on-failure")
+		pm.SetRestartBackoff(backoff)
+		pm.SetRandSeed(seed)
+		var out []time.Duration
+		for attempt := 1; attempt <= 6; attempt++ {
+			out = append(out, pm.CalculateBackoff(attempt))
+		}
+		return out
+	}
+
+	assert.Equal(t, delays(42), delays(42))
+	assert.NotEqual(t, delays(42), delays(43))
+}
+
+func TestRestartServer_ReportsPendingRestart(t *testing.T) {
+	pm := NewProcessManager(100, "on-failure")
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 60000, Multiplier: 2, MaxDelay: 60000, MaxAttempts: 5})
+	cm := NewClientManager(pm)
+
+	cfg := config.ServerConfig{Name: "test-server", RestartPolicy: "always"}
+	pm.setRestartPolicy(cfg.Name, cm.restartPolicy(cfg))
+	pm.SetStatus("test-server", StatusCrashed)
+	pm.RecordFailure("test-server", RestartReasonTransportClosed, errors.New("EOF"))
+
+	require.NoError(t, cm.RestartServer(context.Background(), cfg))
+	require.Eventually(t, func() bool { return !pm.GetDiagnostics("test-server").NextRestartAt.IsZero() }, 2*time.Second, 10*time.Millisecond)
+
+	d := pm.GetDiagnostics("test-server")
+	assert.Equal(t, "always", d.RestartPolicy)
+	assert.Equal(t, 1, d.RestartAttempts)
+	assert.Equal(t, 5, d.MaxRestartAttempts)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), d.NextRestartAt, 5*time.Second)
+
+	// Closing the manager aborts the backoff, so no restart is pending anymore
+	require.NoError(t, cm.Close())
+	assert.True(t, pm.GetDiagnostics("test-server").NextRestartAt.IsZero())
+}
+
 func TestRestartServer_MaxAttemptsExceeded(t *testing.T) {
 	pm := NewProcessManager(100, "on-failure")
 	cm := NewClientManager(pm)
@@ -230,3 +343,60 @@ func TestRestartServer_PolicyNever(t *testing.T) {
 	// Verify attempts didn't increase
 	assert.Equal(t, 0, pm.GetRestartAttempts("test-server"))
 }
+
+func TestRestartServer_ServerPolicyOverridesGlobal(t *testing.T) {
+	pm := NewProcessManager(100, "on-failure")
+	cm := NewClientManager(pm)
+
+	cfg := config.ServerConfig{Name: "test-server", Command: "/bin/true", RestartPolicy: "never"}
+	pm.SetStatus("test-server", StatusCrashed)
+
+	err := cm.RestartServer(context.Background(), cfg)
+	assert.ErrorContains(t, err, "restart policy does not allow restart")
+	assert.Equal(t, StatusCrashed, pm.GetStatus("test-server"))
+
+	// And the other way round
+	pm = NewProcessManager(100, "never")
+	cm = NewClientManager(pm)
+	cfg.RestartPolicy = "on-failure"
+	pm.SetStatus("test-server", StatusCrashed)
+
+	assert.NoError(t, cm.RestartServer(context.Background(), cfg))
+	assert.Equal(t, StatusRestarting, pm.GetStatus("test-server"))
+	assert.NoError(t, cm.Close())
+}
+
+func TestRestartServer_CloseCancelsPendingRestart(t *testing.T) {
+	pm := NewProcessManager(100, "on-failure")
+	cm := NewClientManager(pm)
+
+	cfg := config.ServerConfig{Name: "test-server", Command: "/bin/true"}
+	pm.SetStatus("test-server", StatusCrashed)
+
+	// The first attempt waits 1s before reconnecting
+	assert.NoError(t, cm.RestartServer(context.Background(), cfg))
+	assert.Equal(t, StatusRestarting, pm.GetStatus("test-server"))
+
+	start := time.Now()
+	assert.NoError(t, cm.Close())
+
+	// Close returns only after the restart worker has exited, without waiting out the backoff
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusCrashed, pm.GetStatus("test-server"))
+	assert.Empty(t, cm.sessions)
+}
+
+func TestRestartServer_AfterClose(t *testing.T) {
+	pm := NewProcessManager(100, "on-failure")
+	cm := NewClientManager(pm)
+	assert.NoError(t, cm.Close())
+
+	cfg := config.ServerConfig{Name: "test-server", Command: "/bin/true"}
+	pm.SetStatus("test-server", StatusCrashed)
+
+	err := cm.RestartServer(context.Background(), cfg)
+
+	assert.Error(t, err)
+	assert.Equal(t, StatusCrashed, pm.GetStatus("test-server"))
+	assert.Equal(t, 0, pm.GetRestartAttempts("test-server"), "attempts are counted only when the worker runs")
+}
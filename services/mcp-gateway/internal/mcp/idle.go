@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// errServerIdle reports that a server was stopped for idleness and must be respawned before a call
+var errServerIdle = errors.New("server is idle")
+
+// wakeLock returns the lock that serializes stopping and respawning an idle server
+func (m *ClientManager) wakeLock(serverName string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.wakeLocks[serverName]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.wakeLocks[serverName] = lock
+	}
+	return lock
+}
+
+// suspendIfIdle stops an available server that has taken no call for its idleTimeout and marks it idle.
+// Its tools stay cached so that they are still listed. It reports whether the server was stopped.
+func (m *ClientManager) suspendIfIdle(serverName string) bool {
+	cfg, ok := m.getConfig(serverName)
+	if !ok || cfg.IdleTimeout == 0 {
+		return false
+	}
+	lock := m.wakeLock(serverName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Checked and flagged under m.mu, so that no call can acquire the session once it is condemned
+	m.mu.Lock()
+	stats := m.statsLocked(serverName)
+	if stats.inFlight > 0 || m.processManager.Clock().Since(stats.lastActive) < time.Duration(cfg.IdleTimeout)*time.Millisecond {
+		m.mu.Unlock()
+		return false
+	}
+	m.suspending[serverName] = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.suspending, serverName)
+		m.mu.Unlock()
+	}()
+
+	if !m.transition(serverName, StatusIdle) {
+		return false
+	}
+	m.teardownServer(serverName)
+	slog.Info("Server stopped after idle timeout", "server", serverName, "idle_timeout_ms", cfg.IdleTimeout)
+	return true
+}
+
+// wakeServer respawns an idle server for a call. Concurrent callers wait for the same respawn.
+// A server that fails to come back is handled like a crash, so that its restart policy applies.
+func (m *ClientManager) wakeServer(ctx context.Context, serverName string) error {
+	cfg, ok := m.getConfig(serverName)
+	if !ok {
+		return errServerIdle
+	}
+	lock := m.wakeLock(serverName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Another call already respawned it, or it was stopped or restarted meanwhile
+	if m.processManager.GetStatus(serverName) != StatusIdle {
+		return nil
+	}
+
+	slog.Info("Respawning idle server", "server", serverName)
+	if err := m.connectClient(ctx, cfg); err != nil {
+		if m.processManager.GetStatus(serverName) == StatusCrashed && m.processManager.onServerCrashed != nil {
+			m.processManager.onServerCrashed(serverName)
+		}
+		return fmt.Errorf("failed to respawn idle server %s: %w", serverName, err)
+	}
+	m.resetHealthCheckState(serverName)
+	m.StartHealthCheck(m.workers.ctx, serverName)
+	return nil
+}
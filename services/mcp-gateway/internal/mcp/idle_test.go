@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIdleTestManager connects an in-process "calc" server with the given idle timeout, without health checks
+func newIdleTestManager(t *testing.T, pm *ProcessManager, idleTimeout int) *ClientManager {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "calc", Version: "test"}, nil)
+	server.AddTool(&mcp.Tool{Name: "echo", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+		})
+
+	cm := NewClientManager(pm)
+	require.NoError(t, cm.RegisterInProcess("calc", server))
+	cfg := config.ServerConfig{Name: "calc", Transport: TransportInProcess, Timeout: 30000, IdleTimeout: idleTimeout}
+	cm.configs = []config.ServerConfig{cfg}
+	require.NoError(t, cm.connectClient(context.Background(), cfg))
+	t.Cleanup(func() { _ = cm.Close() })
+	return cm
+}
+
+// backdate pretends that the last call on the server ended d ago
+func backdate(cm *ClientManager, name string, d time.Duration) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.statsLocked(name).lastActive = time.Now().Add(-d)
+}
+
+func TestSuspendIfIdle_StopsServerAfterTimeout(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := newIdleTestManager(t, pm, 60000)
+
+	assert.False(t, cm.suspendIfIdle("calc"), "a freshly connected server is not idle")
+	assert.Equal(t, StatusAvailable, pm.GetStatus("calc"))
+
+	backdate(cm, "calc", time.Minute)
+	require.True(t, cm.suspendIfIdle("calc"))
+
+	assert.Equal(t, StatusIdle, pm.GetStatus("calc"))
+	_, hasSession := cm.sessions["calc"]
+	assert.False(t, hasSession)
+	_, cached := cm.GetToolInfo("calc", "echo")
+	assert.True(t, cached, "tools of idle servers are still listed")
+}
+
+func TestSuspendIfIdle_KeepsServerWithCallInFlight(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := newIdleTestManager(t, pm, 60000)
+
+	_, err := cm.acquireSession(context.Background(), "calc", "echo", false)
+	require.NoError(t, err)
+	backdate(cm, "calc", time.Minute)
+
+	assert.False(t, cm.suspendIfIdle("calc"))
+	assert.Equal(t, StatusAvailable, pm.GetStatus("calc"))
+}
+
+func TestSuspendIfIdle_Disabled(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := newIdleTestManager(t, pm, 0)
+	backdate(cm, "calc", 24*time.Hour)
+
+	assert.False(t, cm.suspendIfIdle("calc"))
+	assert.Equal(t, StatusAvailable, pm.GetStatus("calc"))
+}
+
+func TestCallTool_RespawnsIdleServer(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := newIdleTestManager(t, pm, 60000)
+	backdate(cm, "calc", time.Minute)
+	require.True(t, cm.suspendIfIdle("calc"))
+
+	result, err := cm.CallTool(context.Background(), "calc", "echo", map[string]any{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.(*mcp.CallToolResult).Content[0].(*mcp.TextContent).Text)
+	assert.Equal(t, StatusAvailable, pm.GetStatus("calc"))
+	assert.False(t, cm.suspendIfIdle("calc"), "the respawned server starts a new idle window")
+}
+
+func TestStopServer_FromIdle(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := newIdleTestManager(t, pm, 60000)
+	backdate(cm, "calc", time.Minute)
+	require.True(t, cm.suspendIfIdle("calc"))
+
+	require.NoError(t, cm.StopServer("calc"))
+
+	assert.Equal(t, StatusStopped, pm.GetStatus("calc"))
+	_, err := cm.CallTool(context.Background(), "calc", "echo", map[string]any{})
+	assert.Error(t, err, "stopped servers are not respawned by calls")
+}
+
+func TestStartHealthCheck_SuspendsIdleServer(t *testing.T) {
+	pm := NewProcessManager(20, "never") // 20ms interval
+	cm := newIdleTestManager(t, pm, 50)
+
+	cm.StartHealthCheck(cm.workers.ctx, "calc")
+
+	assert.Eventually(t, func() bool { return pm.GetStatus("calc") == StatusIdle }, 2*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		cm.mu.RLock()
+		defer cm.mu.RUnlock()
+		_, running := cm.healthCheckCancels["calc"]
+		return !running
+	}, 2*time.Second, 10*time.Millisecond, "the health check ends with the process")
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TransportInProcess identifies servers registered with RegisterInProcess. It cannot be configured.
+const TransportInProcess = "in-process"
+
+// RegisterInProcess serves the tools of a Go MCP server running inside the gateway's process,
+// without spawning a subprocess. It must be called before Initialize, which connects
+// in-process servers together with the configured ones; the name must not collide with them.
+func (m *ClientManager) RegisterInProcess(name string, server *mcp.Server) error {
+	// Same rules as servers[].name in config.yaml
+	if err := validator.New().Var(name, "required,hostname_rfc1123,max=50"); err != nil {
+		return fmt.Errorf("invalid in-process server name %q: %w", name, err)
+	}
+	if server == nil {
+		return fmt.Errorf("in-process server %s: server is nil", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.configs != nil {
+		return fmt.Errorf("in-process server %s: servers must be registered before Initialize", name)
+	}
+	if _, dup := m.inProcess[name]; dup {
+		return fmt.Errorf("duplicate in-process server name: %s", name)
+	}
+	m.inProcess[name] = server
+	return nil
+}
+
+// withInProcessConfigs appends a config for every in-process server to the configured servers
+func (m *ClientManager) withInProcessConfigs(configs []config.ServerConfig) ([]config.ServerConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.inProcess))
+	for name := range m.inProcess {
+		if slices.ContainsFunc(configs, func(c config.ServerConfig) bool { return c.Name == name }) {
+			return nil, fmt.Errorf("in-process server %s collides with a configured server", name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	// Never nil, so that RegisterInProcess can tell that Initialize has run
+	all := make([]config.ServerConfig, 0, len(configs)+len(names))
+	all = append(all, configs...)
+	for _, name := range names {
+		all = append(all, config.ServerConfig{Name: name, Transport: TransportInProcess, Timeout: 30000})
+	}
+	return all, nil
+}
+
+// transportFor builds the transport for a server with its env values resolved, connecting
+// in-process servers in memory
+func (m *ClientManager) transportFor(ctx context.Context, cfg config.ServerConfig) (mcp.Transport, *exec.Cmd, error) {
+	if cfg.Transport != TransportInProcess {
+		envs, err := resolveEnvs(cfg.Envs, m.secretStore)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Envs = envs
+		return newTransport(ctx, cfg)
+	}
+
+	m.mu.RLock()
+	server, ok := m.inProcess[cfg.Name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("in-process server %s is not registered", cfg.Name)
+	}
+
+	// Each connection, including reconnects after a restart, gets its own server session
+	clientT, err := connectInMemory(ctx, server)
+	if err != nil {
+		return nil, nil, fmt.Errorf("in-process server %s: %w", cfg.Name, err)
+	}
+	return clientT, nil, nil
+}
+
+// connectInMemory starts a session of server over an in-memory pipe and returns the client's end
+func connectInMemory(ctx context.Context, server *mcp.Server) (mcp.Transport, error) {
+	clientT, serverT := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverT, nil); err != nil {
+		return nil, err
+	}
+	return clientT, nil
+}
@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterInProcess_Validation(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+
+	assert.Error(t, cm.RegisterInProcess("", newRemoteMCPServer()))
+	assert.Error(t, cm.RegisterInProcess("not a hostname", newRemoteMCPServer()))
+	assert.Error(t, cm.RegisterInProcess("embedded", nil))
+
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	assert.Error(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()), "names are unique")
+
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	assert.Error(t, cm.RegisterInProcess("late", newRemoteMCPServer()), "registration closes at Initialize")
+}
+
+func TestRegisterInProcess_CollidesWithConfiguredServer(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("tools", newRemoteMCPServer()))
+
+	err := cm.Initialize(context.Background(), []config.ServerConfig{{Name: "tools", Command: "/bin/true"}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "collides")
+}
+
+func TestRegisterInProcess_ServesAndReconnects(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("embedded"))
+	assert.Empty(t, cm.processes, "no subprocess is spawned")
+	_, found := cm.GetToolInfo("embedded", "echo")
+	assert.True(t, found)
+
+	// Like remote servers, in-process servers are reconnected regardless of the restart policy
+	cfg, ok := cm.getConfig("embedded")
+	require.True(t, ok)
+	pm.SetStatus("embedded", StatusCrashed)
+	require.NoError(t, cm.RestartServer(context.Background(), cfg))
+	assert.Eventually(t, func() bool {
+		return pm.GetStatus("embedded") == StatusAvailable
+	}, 10*time.Second, 50*time.Millisecond)
+
+	result, err := cm.CallTool(context.Background(), "embedded", "echo", map[string]any{"text": "again"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "again"}, result.(*mcp.CallToolResult).StructuredContent)
+}
+
+func TestRestartPolicyAlways_RestartsCleanExit(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		wantRestarted bool
+	}{
+		{name: "always", policy: config.RestartPolicyAlways, wantRestarted: true},
+		{name: "on-failure", policy: config.RestartPolicyOnFailure, wantRestarted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := NewProcessManager(30000, tt.policy)
+			cm := NewClientManager(pm)
+			t.Cleanup(func() { _ = cm.Close() })
+			server := newRemoteMCPServer()
+			require.NoError(t, cm.RegisterInProcess("embedded", server))
+			require.NoError(t, cm.Initialize(context.Background(), nil))
+
+			// The server ends its session, as servers that self-terminate after idling do
+			for session := range server.Sessions() {
+				require.NoError(t, session.Close())
+			}
+
+			if !tt.wantRestarted {
+				assert.Eventually(t, func() bool {
+					return pm.GetStatus("embedded") == StatusUnavailable
+				}, 5*time.Second, 20*time.Millisecond)
+				assert.Zero(t, pm.GetDiagnostics("embedded").Restarts)
+				return
+			}
+			assert.Eventually(t, func() bool {
+				return pm.GetDiagnostics("embedded").Restarts == 1 && pm.GetStatus("embedded") == StatusAvailable
+			}, 10*time.Second, 50*time.Millisecond)
+			assert.Equal(t, RestartReasonExited, pm.GetDiagnostics("embedded").LastRestartReason)
+		})
+	}
+}
+
+func TestCallTool_UnknownTool(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	_, err := cm.CallTool(context.Background(), "embedded", "missing", map[string]any{})
+	require.ErrorIs(t, err, mcpErrors.ErrToolNotFound)
+	gatewayErr, ok := mcpErrors.As(err)
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"toolName": "missing", "serverName": "embedded"}, gatewayErr.Details)
+	assert.Contains(t, err.Error(), `unknown tool "missing"`)
+}
+
+// TestIsUnknownToolError_MatchesSDK pins the match against the error the MCP SDK really returns,
+// so that an SDK upgrade changing its message fails here instead of turning TOOL_NOT_FOUND into a 500
+func TestIsUnknownToolError_MatchesSDK(t *testing.T) {
+	ctx := context.Background()
+	clientT, serverT := mcp.NewInMemoryTransports()
+	_, err := newRemoteMCPServer().Connect(ctx, serverT, nil)
+	require.NoError(t, err)
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientT, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = session.Close() })
+
+	_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "missing", Arguments: map[string]any{}})
+
+	require.Error(t, err)
+	assert.True(t, isUnknownToolError(err, "missing"))
+	assert.True(t, isUnknownToolError(fmt.Errorf("call failed: %w", err), "missing"))
+	assert.False(t, isUnknownToolError(err, "miss"), "the tool name must match")
+	assert.False(t, isUnknownToolError(errors.New(`upstream: unknown tool "missing" in catalog`), "missing"))
+}
+
+func TestCallTool_InvalidInputFromEmbeddedCaller(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	for _, input := range []any{"not-a-map", []any{"a"}, 42, nil} {
+		_, err := cm.CallTool(context.Background(), "embedded", "echo", input)
+		assert.ErrorIs(t, err, mcpErrors.ErrInvalidInput, "input %#v", input)
+	}
+	// The rejected calls did not hold on to a call slot
+	cm.mu.RLock()
+	assert.Zero(t, cm.statsLocked("embedded").inFlight)
+	cm.mu.RUnlock()
+}
@@ -0,0 +1,220 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"syscall"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// getConfig returns the stored configuration for a server
+func (m *ClientManager) getConfig(serverName string) (config.ServerConfig, bool) {
+	for _, cfg := range m.configs {
+		if cfg.Name == serverName {
+			return cfg, true
+		}
+	}
+	return config.ServerConfig{}, false
+}
+
+// stopHealthCheck cancels the health check for a server and waits for it to exit
+func (m *ClientManager) stopHealthCheck(serverName string) {
+	m.mu.Lock()
+	cancel, ok := m.healthCheckCancels[serverName]
+	done := m.healthCheckDone[serverName]
+	m.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	cancel()
+	if done != nil {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			slog.Warn("Timed out waiting for health check to exit", "server", serverName)
+		}
+	}
+}
+
+// transition moves a server to the given status and reports whether it did. A concurrent change
+// (e.g. a stop) may already have moved the server somewhere the transition is not allowed from,
+// in which case that change wins and this one is dropped.
+func (m *ClientManager) transition(serverName string, to ServerStatus) bool {
+	if err := m.processManager.Transition(serverName, to); err != nil {
+		slog.Debug("Status change skipped", "error", err)
+		return false
+	}
+	return true
+}
+
+// resetHealthCheckState clears the consecutive failure counter for a server
+func (m *ClientManager) resetHealthCheckState(serverName string) {
+	m.mu.RLock()
+	state := m.healthCheckStates[serverName]
+	m.mu.RUnlock()
+
+	if state != nil {
+		state.mu.Lock()
+		state.consecutiveFailures = 0
+		state.mu.Unlock()
+	}
+}
+
+// teardownServer closes the session and kills the process (or removes the container) of a server.
+// Entries are removed from the maps before closing so that the connection
+// monitor does not report the intentional shutdown as a crash.
+func (m *ClientManager) teardownServer(serverName string) {
+	m.mu.Lock()
+	session, hasSession := m.sessions[serverName]
+	cmd, hasCmd := m.processes[serverName]
+	delete(m.sessions, serverName)
+	delete(m.connectedAt, serverName)
+	delete(m.processes, serverName)
+	m.mu.Unlock()
+
+	if hasSession {
+		if err := session.Close(); err != nil {
+			slog.Warn("Failed to close session", "server", serverName, "error", err)
+		}
+	}
+	if hasCmd && cmd.Process != nil {
+		// Killing the docker or ssh client alone would leave the server running
+		if cfg, ok := m.getConfig(serverName); ok && cfg.IsContainer() {
+			if err := removeContainer(serverName); err != nil {
+				slog.Warn("Failed to remove container", "server", serverName, "error", err)
+			}
+		} else if ok && cfg.IsSSH() {
+			if err := killRemoteProcess(cfg); err != nil {
+				slog.Warn("Failed to kill remote process", "server", serverName, "error", err)
+			}
+		}
+		// The whole group, so that children of a crashed or wrapped server do not survive it
+		if err := signalProcessGroup(cmd, syscall.SIGKILL); err != nil {
+			slog.Debug("Failed to kill process", "server", serverName, "error", err)
+		}
+	}
+}
+
+// StopServer stops a server's health check, session and process, and marks it as stopped.
+// A stopped server is not restarted automatically.
+func (m *ClientManager) StopServer(serverName string) error {
+	if _, ok := m.getConfig(serverName); !ok {
+		return mcpErrors.ErrServerNotFound
+	}
+
+	// Mark the server stopped first so that a pending restart or an in-flight
+	// connection cannot bring it back
+	m.transition(serverName, StatusStopped)
+	m.stopHealthCheck(serverName)
+	m.teardownServer(serverName)
+
+	slog.Info("Server stopped", "server", serverName)
+	return nil
+}
+
+// ForceRestartServer restarts a server regardless of restart policy and attempt count.
+// Unlike RestartServer, it waits for the restart and works from any status except restarting,
+// e.g. to bring back a quarantined server.
+func (m *ClientManager) ForceRestartServer(ctx context.Context, serverName string) error {
+	cfg, ok := m.getConfig(serverName)
+	if !ok {
+		return mcpErrors.ErrServerNotFound
+	}
+	// Rather than waiting for an automatic restart to finish its backoff
+	if m.processManager.GetStatus(serverName) == StatusRestarting {
+		return fmt.Errorf("server %s is already restarting", serverName)
+	}
+
+	return m.superviseAndWait(ctx, serverName, func() error {
+		// Given up by the caller while waiting for the supervisor
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		current := m.processManager.GetStatus(serverName)
+		if current == StatusRestarting {
+			return fmt.Errorf("server %s is already restarting", serverName)
+		}
+		if !CanTransition(current, StatusRestarting) {
+			return fmt.Errorf("server %s cannot be restarted while %s", serverName, current)
+		}
+		if !m.processManager.CompareAndSwapStatus(serverName, current, StatusRestarting) {
+			return fmt.Errorf("server %s changed status concurrently, please retry", serverName)
+		}
+
+		slog.Info("Manually restarting server", "server", serverName, "previous_status", current)
+		m.processManager.RecordRestart(serverName, RestartReasonManual)
+
+		m.stopHealthCheck(serverName)
+		m.teardownServer(serverName)
+
+		if err := m.connectClient(ctx, cfg); err != nil {
+			m.transition(serverName, StatusCrashed)
+			if m.processManager.GetStatus(serverName) == StatusCrashed {
+				m.removeTools(serverName)
+			}
+			return fmt.Errorf("failed to restart server %s: %w", serverName, err)
+		}
+
+		m.processManager.ResetRestartAttempts(serverName)
+		m.resetHealthCheckState(serverName)
+		m.StartHealthCheck(m.workers.ctx, serverName)
+
+		slog.Info("Server restarted manually", "server", serverName)
+		return nil
+	})
+}
+
+// ResetRestarts clears the restart attempts of a server, e.g. after the cause of its crashes was fixed.
+// A crashed or quarantined server is then restarted as its restart policy allows, starting over with the
+// full number of attempts; restarted reports whether it was.
+func (m *ClientManager) ResetRestarts(ctx context.Context, serverName string) (restarted bool, err error) {
+	if _, ok := m.getConfig(serverName); !ok {
+		return false, mcpErrors.ErrServerNotFound
+	}
+
+	// On the supervisor, so that a crash handled meanwhile cannot quarantine the server again
+	err = m.superviseAndWait(ctx, serverName, func() error {
+		m.processManager.ResetRestartAttempts(serverName)
+		slog.Info("Restart attempts reset", "server", serverName)
+		switch m.processManager.GetStatus(serverName) {
+		case StatusQuarantined:
+			restarted = m.transition(serverName, StatusCrashed)
+		case StatusCrashed:
+			restarted = true
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if restarted {
+		m.notifyCrashed(serverName)
+	}
+	return restarted, nil
+}
+
+// RefreshTools re-fetches the tool list of a running server and replaces its cache entries
+func (m *ClientManager) RefreshTools(ctx context.Context, serverName string) error {
+	cfg, ok := m.getConfig(serverName)
+	if !ok {
+		return mcpErrors.ErrServerNotFound
+	}
+
+	m.mu.RLock()
+	session, ok := m.sessions[serverName]
+	m.mu.RUnlock()
+
+	if !ok || !m.processManager.GetStatus(serverName).Serving() {
+		return mcpErrors.ErrServerNotRunning
+	}
+
+	if err := m.cacheTools(ctx, serverName, session, cfg.Timeout); err != nil {
+		return fmt.Errorf("failed to refresh tools for server %s: %w", serverName, err)
+	}
+	return nil
+}
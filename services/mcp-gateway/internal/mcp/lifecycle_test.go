@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopServer_UnknownServer(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+
+	err := cm.StopServer("missing")
+
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotFound)
+}
+
+func TestStopServer_ClosesSessionAndMarksStopped(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "test-server"}}
+
+	mockSession := new(MockMCPSession)
+	mockSession.On("Close").Return(nil)
+	cm.sessions["test-server"] = mockSession
+	pm.SetStatus("test-server", StatusAvailable)
+
+	require.NoError(t, cm.StopServer("test-server"))
+
+	mockSession.AssertCalled(t, "Close")
+	assert.Equal(t, StatusStopped, pm.GetStatus("test-server"))
+	_, ok := cm.sessions["test-server"]
+	assert.False(t, ok)
+}
+
+func TestForceRestartServer_UnknownServer(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+
+	err := cm.ForceRestartServer(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotFound)
+}
+
+func TestForceRestartServer_AlreadyRestarting(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "test-server"}}
+	pm.SetStatus("test-server", StatusRestarting)
+
+	err := cm.ForceRestartServer(context.Background(), "test-server")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already restarting")
+}
+
+func TestForceRestartServer_ConnectFailureMarksCrashed(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "test-server", Command: "/nonexistent/mcp-server"}}
+	pm.SetStatus("test-server", StatusStopped)
+
+	err := cm.ForceRestartServer(context.Background(), "test-server")
+
+	assert.Error(t, err)
+	assert.Equal(t, StatusCrashed, pm.GetStatus("test-server"))
+}
+
+func TestRefreshTools_ReplacesCacheEntries(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "test-server", Timeout: 1000}}
+	cm.toolsCache[toolCacheKey("test-server", "old-tool")] = ToolInfo{Name: "old-tool", Server: "test-server"}
+	cm.toolsCache[toolCacheKey("other-server", "other-tool")] = ToolInfo{Name: "other-tool", Server: "other-server"}
+
+	mockSession := new(MockMCPSession)
+	mockSession.On("ListTools", mock.Anything, mock.Anything).Return(&mcp.ListToolsResult{
+		Tools: []*mcp.Tool{{Name: "new-tool", Description: "fresh"}},
+	}, nil)
+	cm.sessions["test-server"] = mockSession
+	pm.SetStatus("test-server", StatusAvailable)
+
+	require.NoError(t, cm.RefreshTools(context.Background(), "test-server"))
+
+	_, hasOld := cm.GetToolInfo("test-server", "old-tool")
+	assert.False(t, hasOld)
+	tool, hasNew := cm.GetToolInfo("test-server", "new-tool")
+	assert.True(t, hasNew)
+	assert.Equal(t, 1000, tool.Timeout)
+	_, hasOther := cm.GetToolInfo("other-server", "other-tool")
+	assert.True(t, hasOther)
+}
+
+func TestRefreshTools_ServerNotRunning(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "test-server"}}
+	pm.SetStatus("test-server", StatusCrashed)
+
+	err := cm.RefreshTools(context.Background(), "test-server")
+
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotRunning)
+}
+
+func TestRefreshTools_ListToolsError(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "test-server"}}
+
+	mockSession := new(MockMCPSession)
+	mockSession.On("ListTools", mock.Anything, mock.Anything).Return(nil, errors.New("boom"))
+	cm.sessions["test-server"] = mockSession
+	pm.SetStatus("test-server", StatusAvailable)
+
+	err := cm.RefreshTools(context.Background(), "test-server")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestParseServerStatus(t *testing.T) {
+	status, err := ParseServerStatus("crashed")
+	require.NoError(t, err)
+	assert.Equal(t, StatusCrashed, status)
+
+	_, err = ParseServerStatus("exploded")
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package mcp
+
+import (
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// SetMaxConcurrentCalls changes the concurrency cap of a server at runtime, overriding
+// maxConcurrentCalls from the config until the gateway restarts. 0 means unlimited.
+// Calls already in flight are not affected.
+func (m *ClientManager) SetMaxConcurrentCalls(server string, n int) error {
+	if _, ok := m.getConfig(server); !ok {
+		return mcpErrors.ErrServerNotFound
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.concurrencyOverrides == nil {
+		m.concurrencyOverrides = make(map[string]int)
+	}
+	m.concurrencyOverrides[server] = n
+	return nil
+}
+
+// MaxConcurrentCalls returns the concurrency cap currently in effect for a server
+func (m *ClientManager) MaxConcurrentCalls(server string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, ok := m.maxConcurrentCallsLocked(server)
+	if !ok {
+		return 0, mcpErrors.ErrServerNotFound
+	}
+	return n, nil
+}
+
+// maxConcurrentCallsLocked returns the runtime override or the configured cap. m.mu must be held.
+func (m *ClientManager) maxConcurrentCallsLocked(server string) (int, bool) {
+	cfg, ok := m.getConfig(server)
+	if !ok {
+		return 0, false
+	}
+	if n, ok := m.concurrencyOverrides[server]; ok {
+		return n, true
+	}
+	return cfg.MaxConcurrentCalls, true
+}
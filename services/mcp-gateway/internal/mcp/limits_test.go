@@ -0,0 +1,36 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMaxConcurrentCalls(t *testing.T) {
+	cm, _, _, _ := newSpilloverManager(t, 0)
+
+	n, err := cm.MaxConcurrentCalls("primary")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Lowering the cap to the calls in flight makes the server busy
+	_, err = cm.acquireSession(context.Background(), "primary", "search", false)
+	require.NoError(t, err)
+	require.NoError(t, cm.SetMaxConcurrentCalls("primary", 1))
+	_, err = cm.acquireSession(context.Background(), "primary", "search", false)
+	assert.ErrorIs(t, err, mcpErrors.ErrServerBusy)
+
+	// Raising it admits the call
+	require.NoError(t, cm.SetMaxConcurrentCalls("primary", 2))
+	_, err = cm.acquireSession(context.Background(), "primary", "search", false)
+	require.NoError(t, err)
+	cm.releaseSession("primary")
+	cm.releaseSession("primary")
+
+	assert.ErrorIs(t, cm.SetMaxConcurrentCalls("missing", 1), mcpErrors.ErrServerNotFound)
+	_, err = cm.MaxConcurrentCalls("missing")
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotFound)
+}
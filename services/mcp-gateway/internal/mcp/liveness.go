@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// processWatchInterval is how often a server's process is checked for having exited
+const processWatchInterval = 500 * time.Millisecond
+
+// procAvailable reports whether /proc can be read, without which processes are assumed to be running
+var procAvailable = sync.OnceValue(func() bool {
+	_, err := os.Stat("/proc/self/stat")
+	return err == nil
+})
+
+// processExited reports whether a process has exited. A server process is only reaped when its
+// session is closed, so until then an exited one remains as a zombie.
+func processExited(pid int) bool {
+	s, err := readProcStat(pid)
+	if err != nil {
+		return errors.Is(err, fs.ErrNotExist) && procAvailable()
+	}
+	return s.state == 'Z' || s.state == 'X'
+}
+
+// watchProcess marks a server crashed once its process has exited, without waiting for a ping to fail.
+// The session itself only ends when every process holding the server's stdout has exited, which a child
+// the server spawned may never do. An exit the connection monitor notices first is left to it.
+func (m *ClientManager) watchProcess(serverName string, cmd *exec.Cmd) {
+	if cmd == nil || cmd.Process == nil || !procAvailable() {
+		return
+	}
+	m.monitors.Go("process:"+serverName, func(ctx context.Context) {
+		ticker := time.NewTicker(processWatchInterval)
+		defer ticker.Stop()
+
+		exited := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			m.mu.RLock()
+			current := m.processes[serverName]
+			m.mu.RUnlock()
+			// Torn down, restarted or stopped; whoever removed the process owns the status
+			if current != cmd {
+				return
+			}
+			if !processExited(cmd.Process.Pid) {
+				continue
+			}
+			// Give the connection monitor one interval to handle an exit that also ended the session
+			if !exited {
+				exited = true
+				continue
+			}
+			m.markProcessExited(serverName)
+			return
+		}
+	})
+}
+
+// markProcessExited crashes a server whose process has exited and restarts it through the crash flow.
+// A server that is not restarted is torn down, so that children keeping its session open are killed.
+func (m *ClientManager) markProcessExited(serverName string) {
+	if !m.processManager.GetStatus(serverName).Serving() || !m.transition(serverName, StatusCrashed) {
+		return
+	}
+	slog.Error("MCP Server process exited", "server", serverName)
+	m.processManager.RecordFailure(serverName, RestartReasonProcessExited, errors.New("process exited while its session was open"))
+	if m.processManager.onServerCrashed != nil {
+		m.processManager.onServerCrashed(serverName)
+	}
+	if m.processManager.GetStatus(serverName) == StatusCrashed {
+		m.teardownServer(serverName)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build unix
+
+package mcp
+
+import (
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessExited(t *testing.T) {
+	if !procAvailable() {
+		t.Skip("requires /proc")
+	}
+	assert.False(t, processExited(os.Getpid()))
+
+	cmd := exec.Command("sh", "-c", "exit 0")
+	require.NoError(t, cmd.Start())
+	// Not reaped yet, so the process remains as a zombie
+	assert.Eventually(t, func() bool { return processExited(cmd.Process.Pid) }, 2*time.Second, 10*time.Millisecond)
+
+	_ = cmd.Wait()
+	assert.True(t, processExited(cmd.Process.Pid))
+}
+
+func TestWatchProcess_CrashesServerWhoseProcessExited(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	var crashed atomic.Int32
+	pm.SetOnServerCrashed(func(string) { crashed.Add(1) })
+
+	// The wrapper exits while its child keeps stdout, and with it the session, open
+	cmd, child := startWrapped(t, cm, "wrapped", "sleep 60 & echo $!; exit 3")
+	pm.SetStatus("wrapped", StatusAvailable)
+	cm.watchProcess("wrapped", cmd)
+
+	require.Eventually(t, func() bool { return pm.GetStatus("wrapped") == StatusCrashed }, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, RestartReasonProcessExited, pm.GetDiagnostics("wrapped").LastFailureReason)
+	assert.Equal(t, int32(1), crashed.Load())
+
+	// Not restarted, so the server is torn down along with the child
+	assert.Eventually(t, func() bool { return !processRunning(child) }, 2*time.Second, 10*time.Millisecond)
+	cm.mu.RLock()
+	_, ok := cm.processes["wrapped"]
+	cm.mu.RUnlock()
+	assert.False(t, ok)
+}
+
+func TestWatchProcess_LeavesRunningAndRemovedProcesses(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+
+	running, _ := startWrapped(t, cm, "running", "sleep 60 & echo $!; wait")
+	pm.SetStatus("running", StatusAvailable)
+	cm.watchProcess("running", running)
+
+	removed, _ := startWrapped(t, cm, "removed", "sleep 60 & echo $!; exit 0")
+	pm.SetStatus("removed", StatusAvailable)
+	// Stopped intentionally, e.g. for a restart; the stop owns the status
+	cm.mu.Lock()
+	delete(cm.processes, "removed")
+	cm.mu.Unlock()
+	cm.watchProcess("removed", removed)
+
+	time.Sleep(4 * processWatchInterval)
+	assert.Equal(t, StatusAvailable, pm.GetStatus("running"))
+	assert.Equal(t, StatusAvailable, pm.GetStatus("removed"))
+
+	cm.monitors.Shutdown()
+	assert.True(t, cm.monitors.Wait(2*time.Second), "watchers should exit on shutdown")
+}
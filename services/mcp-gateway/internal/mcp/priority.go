@@ -0,0 +1,23 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+type priorityKey struct{}
+
+// WithPriority attaches the caller priority to ctx. Low-priority calls may only use
+// half of a server's maxConcurrentCalls, keeping the rest free for normal callers.
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// slotLimit returns how many concurrent calls the caller in ctx may have on a server
+func slotLimit(ctx context.Context, maxConcurrentCalls int) int {
+	if p, _ := ctx.Value(priorityKey{}).(string); p == config.PriorityLow {
+		return (maxConcurrentCalls + 1) / 2
+	}
+	return maxConcurrentCalls
+}
@@ -1,27 +1,119 @@
 package mcp
 
 import (
+	"fmt"
 	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
 )
 
 // ServerStatus represents the status of an MCP server
 type ServerStatus string
 
 const (
+	StatusConnecting  ServerStatus = "connecting"
 	StatusAvailable   ServerStatus = "available"
+	StatusUnhealthy   ServerStatus = "unhealthy" // health checks are failing but the server is not yet considered crashed
 	StatusUnavailable ServerStatus = "unavailable"
 	StatusCrashed     ServerStatus = "crashed"
 	StatusRestarting  ServerStatus = "restarting"
+	StatusStopped     ServerStatus = "stopped"
+	StatusIdle        ServerStatus = "idle"        // stopped after idleTimeout without calls; the next call respawns it
+	StatusQuarantined ServerStatus = "quarantined" // crashed after using up its restart attempts; only restarted manually
+	StatusInactive    ServerStatus = "inactive"    // stopped outside its activeHours; started again when a window opens
 )
 
+// knownStatuses lists every valid ServerStatus value
+var knownStatuses = []ServerStatus{
+	StatusConnecting,
+	StatusAvailable,
+	StatusUnhealthy,
+	StatusUnavailable,
+	StatusCrashed,
+	StatusRestarting,
+	StatusStopped,
+	StatusIdle,
+	StatusQuarantined,
+	StatusInactive,
+}
+
+// Serving reports whether a server in this status accepts tool calls
+func (s ServerStatus) Serving() bool {
+	return s == StatusAvailable || s == StatusUnhealthy
+}
+
+// Callable reports whether a call to a server in this status is attempted, respawning an idle server,
+// rather than failing right away
+func (s ServerStatus) Callable() bool {
+	return s.Serving() || s == StatusIdle
+}
+
+// ParseServerStatus converts a string into a ServerStatus, rejecting unknown values
+func ParseServerStatus(s string) (ServerStatus, error) {
+	for _, status := range knownStatuses {
+		if string(status) == s {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("unknown server status: %q", s)
+}
+
+// RestartReason classifies why a server was (or would be) restarted
+type RestartReason string
+
+const (
+	RestartReasonHealthCheck     RestartReason = "health_check_failed" // consecutive failed health checks
+	RestartReasonTransportClosed RestartReason = "transport_closed"    // session ended unexpectedly (e.g. EOF on stdio)
+	RestartReasonManual          RestartReason = "manual"              // operator-initiated via admin API
+	RestartReasonResourceLimit   RestartReason = "resource_limit"      // process exceeded a configured resource limit
+	RestartReasonExited          RestartReason = "exited"              // session ended cleanly under restartPolicy always
+	RestartReasonProcessExited   RestartReason = "process_exited"      // process exited while its session was still open
+)
+
+// ServerDiagnostics records the most recent failure and restart information for a server
+type ServerDiagnostics struct {
+	LastFailureReason  RestartReason `json:"lastFailureReason,omitempty"`
+	LastFailureAt      time.Time     `json:"lastFailureAt,omitzero"`
+	LastError          string        `json:"lastError,omitempty"`
+	LastRestartReason  RestartReason `json:"lastRestartReason,omitempty"`
+	LastRestartAt      time.Time     `json:"lastRestartAt,omitzero"`
+	Restarts           uint64        `json:"restarts,omitempty"` // cumulative, restored from metrics snapshots
+	LastConnectError   string        `json:"lastConnectError,omitempty"`
+	LastConnectErrorAt time.Time     `json:"lastConnectErrorAt,omitzero"`
+
+	// Canary calls, reported once a server has a canary
+	LastCanaryAt    time.Time `json:"lastCanaryAt,omitzero"`
+	LastCanaryError string    `json:"lastCanaryError,omitempty"` // of the last call, empty when it passed
+	CanaryFailures  int       `json:"canaryFailures,omitempty"`  // consecutive
+
+	// Restart state: whether and when the server is restarted if it fails
+	RestartPolicy      string    `json:"restartPolicy"`
+	RestartAttempts    int       `json:"restartAttempts"` // since the server last recovered
+	MaxRestartAttempts int       `json:"maxRestartAttempts"`
+	NextRestartAt      time.Time `json:"nextRestartAt,omitzero"` // while a restart waits for its backoff
+}
+
 // ProcessManager manages the status of MCP server processes
 type ProcessManager struct {
 	statuses            map[string]ServerStatus
 	healthCheckInterval int
 	restartPolicy       string
 	restartAttempts     map[string]int
+	restartBackoff      config.RestartBackoffConfig
+	restartPolicies     map[string]string    // effective per-server policies, where they differ from restartPolicy
+	nextRestarts        map[string]time.Time // when restarts waiting for their backoff are due
+	diagnostics         map[string]*ServerDiagnostics
+	listeners           []func(StatusEvent)
+	restartStateChanged chan struct{} // signalled when the state saved by SnapshotRestartState changes
+	clock               clock.Clock
+	rand                *rand.Rand // seeded source of jitter, see SetRandSeed; nil uses the global source
+	randMu              sync.Mutex // guards rand, which is not safe for concurrent use
 	mu                  sync.RWMutex
 
 	// Callback for restart notification
@@ -35,6 +127,17 @@ func NewProcessManager(healthCheckInterval int, restartPolicy string) *ProcessMa
 		healthCheckInterval: healthCheckInterval,
 		restartPolicy:       restartPolicy,
 		restartAttempts:     make(map[string]int),
+		restartBackoff: config.RestartBackoffConfig{
+			InitialDelay: config.DefaultRestartInitialDelayMs,
+			Multiplier:   config.DefaultRestartMultiplier,
+			MaxDelay:     config.DefaultRestartMaxDelayMs,
+			MaxAttempts:  config.DefaultRestartMaxAttempts,
+		},
+		restartPolicies:     make(map[string]string),
+		nextRestarts:        make(map[string]time.Time),
+		diagnostics:         make(map[string]*ServerDiagnostics),
+		restartStateChanged: make(chan struct{}, 1),
+		clock:               clock.Real,
 	}
 }
 
@@ -50,29 +153,74 @@ func (p *ProcessManager) GetStatus(serverName string) ServerStatus {
 	return status
 }
 
-// SetStatus updates the status of a server
+// SetStatus overwrites the status of a server without validating the transition.
+// Lifecycle code uses Transition or CompareAndSwapStatus; this is meant for seeding state in tests.
 func (p *ProcessManager) SetStatus(serverName string, status ServerStatus) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	from := p.statusLocked(serverName)
 	p.statuses[serverName] = status
+	listeners := p.listeners
+	p.mu.Unlock()
+
+	if from != status {
+		emitStatusEvent(listeners, StatusEvent{Server: serverName, From: from, To: status, At: p.Clock().Now()})
+	}
+}
+
+// Transition moves a server to the given status if the lifecycle allows it from the current one.
+// Moving to the current status is a no-op. Otherwise a *TransitionError is returned.
+func (p *ProcessManager) Transition(serverName string, to ServerStatus) error {
+	p.mu.Lock()
+	from := p.statusLocked(serverName)
+	if from == to {
+		p.mu.Unlock()
+		return nil
+	}
+	if !CanTransition(from, to) {
+		p.mu.Unlock()
+		return &TransitionError{Server: serverName, From: from, To: to}
+	}
+	p.statuses[serverName] = to
+	listeners := p.listeners
+	p.mu.Unlock()
+
+	emitStatusEvent(listeners, StatusEvent{Server: serverName, From: from, To: to, At: p.Clock().Now()})
+	return nil
 }
 
 // CompareAndSwapStatus atomically updates the status only if the current status matches expected
-// Returns true if the swap was successful, false otherwise
+// and the lifecycle allows the transition. Returns true if the swap was successful, false otherwise
 func (p *ProcessManager) CompareAndSwapStatus(serverName string, expected, new ServerStatus) bool {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	current := p.statusLocked(serverName)
+	if current != expected || !CanTransition(current, new) {
+		p.mu.Unlock()
+		return false
+	}
+	p.statuses[serverName] = new
+	listeners := p.listeners
+	p.mu.Unlock()
 
-	current, ok := p.statuses[serverName]
+	emitStatusEvent(listeners, StatusEvent{Server: serverName, From: current, To: new, At: p.Clock().Now()})
+	return true
+}
+
+// statusLocked returns the current status of a server. Caller must hold p.mu.
+func (p *ProcessManager) statusLocked(serverName string) ServerStatus {
+	status, ok := p.statuses[serverName]
 	if !ok {
-		current = StatusUnavailable
+		return StatusUnavailable
 	}
+	return status
+}
 
-	if current == expected {
-		p.statuses[serverName] = new
-		return true
-	}
-	return false
+// OnStatusChange registers a listener that is called after every status change.
+// Listeners run synchronously on the goroutine that changed the status and must not block.
+func (p *ProcessManager) OnStatusChange(listener func(StatusEvent)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Copy on write so that emitters can iterate their snapshot without holding the lock
+	p.listeners = append(slices.Clip(p.listeners), listener)
 }
 
 // GetAllStatuses returns a map of all server statuses
@@ -85,11 +233,81 @@ func (p *ProcessManager) GetAllStatuses() map[string]ServerStatus {
 	return statuses
 }
 
+// SetClock replaces the clock of the manager and of the ClientManager using it, e.g. with a fake one in
+// tests. Must be called before the managers start.
+func (p *ProcessManager) SetClock(c clock.Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = c
+}
+
+// SetRandSeed makes the jitter of health checks and restart backoffs reproducible, e.g. for
+// integration tests of restart timing. Must be called before the managers start.
+func (p *ProcessManager) SetRandSeed(seed uint64) {
+	p.randMu.Lock()
+	defer p.randMu.Unlock()
+	p.rand = rand.New(rand.NewPCG(seed, seed))
+}
+
+// randN returns a random duration in [0, n) from the seeded source if there is one
+func (p *ProcessManager) randN(n time.Duration) time.Duration {
+	p.randMu.Lock()
+	defer p.randMu.Unlock()
+	if p.rand == nil {
+		return rand.N(n)
+	}
+	return time.Duration(p.rand.Int64N(int64(n)))
+}
+
+// Clock returns the clock that health checks, restart backoffs and idle timeouts are measured with
+func (p *ProcessManager) Clock() clock.Clock {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.clock
+}
+
 // SetOnServerCrashed sets the callback for when a server crashes
 func (p *ProcessManager) SetOnServerCrashed(callback func(serverName string)) {
 	p.onServerCrashed = callback
 }
 
+// SetRestartBackoff sets the delays between restarts and how many are attempted.
+// Must be called before any server is restarted.
+func (p *ProcessManager) SetRestartBackoff(backoff config.RestartBackoffConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.restartBackoff = backoff
+}
+
+// setRestartPolicy records the restart policy that applies to a server, for its diagnostics
+func (p *ProcessManager) setRestartPolicy(serverName, policy string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if policy == p.restartPolicy {
+		delete(p.restartPolicies, serverName)
+	} else {
+		p.restartPolicies[serverName] = policy
+	}
+}
+
+// setNextRestart records when the pending restart of a server is due, or clears it for a zero time
+func (p *ProcessManager) setNextRestart(serverName string, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if at.IsZero() {
+		delete(p.nextRestarts, serverName)
+	} else {
+		p.nextRestarts[serverName] = at
+	}
+}
+
+// MaxRestartAttempts returns how many restarts are attempted before a crashed server is given up
+func (p *ProcessManager) MaxRestartAttempts() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.restartBackoff.MaxAttempts
+}
+
 // GetRestartAttempts returns the number of restart attempts for a server
 func (p *ProcessManager) GetRestartAttempts(serverName string) int {
 	p.mu.RLock()
@@ -102,6 +320,7 @@ func (p *ProcessManager) IncrementRestartAttempts(serverName string) int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.restartAttempts[serverName]++
+	p.notifyRestartState()
 	return p.restartAttempts[serverName]
 }
 
@@ -109,18 +328,139 @@ func (p *ProcessManager) IncrementRestartAttempts(serverName string) int {
 func (p *ProcessManager) ResetRestartAttempts(serverName string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.restartAttempts[serverName] = 0
+	if p.restartAttempts[serverName] != 0 {
+		p.restartAttempts[serverName] = 0
+		p.notifyRestartState()
+	}
 }
 
-// CalculateBackoff returns exponential backoff duration
-// attempt 1: 1s, attempt 2: 2s, attempt 3: 4s, attempt 4+: 4s (max)
+// CalculateBackoff returns exponential backoff duration: initialDelay * multiplier^(attempt-1), capped at
+// maxDelay and varied by up to jitter percent. With the defaults, attempt 1: 1s, attempt 2: 2s, attempt 3+: 4s (max)
 func (p *ProcessManager) CalculateBackoff(attempt int) time.Duration {
-	if attempt <= 0 {
-		return time.Second
+	p.mu.RLock()
+	backoff := p.restartBackoff
+	p.mu.RUnlock()
+
+	initial := time.Duration(backoff.InitialDelay) * time.Millisecond
+	maxDelay := time.Duration(backoff.MaxDelay) * time.Millisecond
+	delay := initial
+	if attempt > 1 {
+		// Computed in float64, so that large attempts saturate at maxDelay instead of overflowing
+		delay = time.Duration(min(float64(initial)*math.Pow(backoff.Multiplier, float64(attempt-1)), float64(maxDelay)))
+	}
+	if backoff.Jitter > 0 && delay > 0 {
+		spread := delay * time.Duration(backoff.Jitter) / 100
+		delay += p.randN(2*spread+1) - spread
+	}
+	return delay
+}
+
+// diagnosticsLocked returns the diagnostics entry for a server, creating it if needed.
+// Caller must hold p.mu for writing.
+func (p *ProcessManager) diagnosticsLocked(serverName string) *ServerDiagnostics {
+	d, ok := p.diagnostics[serverName]
+	if !ok {
+		d = &ServerDiagnostics{}
+		p.diagnostics[serverName] = d
+	}
+	return d
+}
+
+// RecordFailure records why a server was considered failed, along with the triggering error
+func (p *ProcessManager) RecordFailure(serverName string, reason RestartReason, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := p.diagnosticsLocked(serverName)
+	d.LastFailureReason = reason
+	d.LastFailureAt = p.clock.Now()
+	if err != nil {
+		d.LastError = err.Error()
+	}
+	p.notifyRestartState()
+}
+
+// RecordRestart records that a restart was started for the given reason
+func (p *ProcessManager) RecordRestart(serverName string, reason RestartReason) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := p.diagnosticsLocked(serverName)
+	d.LastRestartReason = reason
+	d.LastRestartAt = p.clock.Now()
+	d.Restarts++
+}
+
+// addRestarts adds restarts counted by a previous gateway run to a server's cumulative total
+func (p *ProcessManager) addRestarts(serverName string, n uint64) {
+	if n == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.diagnosticsLocked(serverName).Restarts += n
+}
+
+// RecordCanary records the outcome of a canary call and returns the consecutive failures
+func (p *ProcessManager) RecordCanary(serverName string, err error) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := p.diagnosticsLocked(serverName)
+	d.LastCanaryAt = p.clock.Now()
+	if err == nil {
+		d.LastCanaryError = ""
+		d.CanaryFailures = 0
+		return 0
+	}
+	d.LastCanaryError = err.Error()
+	d.CanaryFailures++
+	return d.CanaryFailures
+}
+
+// RecordConnectError records the most recent error encountered while connecting to a server
+func (p *ProcessManager) RecordConnectError(serverName string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := p.diagnosticsLocked(serverName)
+	d.LastConnectError = err.Error()
+	d.LastConnectErrorAt = p.clock.Now()
+	d.LastError = err.Error()
+}
+
+// GetDiagnostics returns a copy of the diagnostics for a server
+func (p *ProcessManager) GetDiagnostics(serverName string) ServerDiagnostics {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.diagnosticsSnapshotLocked(serverName)
+}
+
+// GetAllDiagnostics returns diagnostics for every server with a known status
+func (p *ProcessManager) GetAllDiagnostics() map[string]ServerDiagnostics {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string]ServerDiagnostics, len(p.statuses))
+	for name := range p.statuses {
+		result[name] = p.diagnosticsSnapshotLocked(name)
+	}
+	return result
+}
+
+// diagnosticsSnapshotLocked copies the diagnostics of a server and adds its restart state.
+// Caller must hold p.mu.
+func (p *ProcessManager) diagnosticsSnapshotLocked(serverName string) ServerDiagnostics {
+	var d ServerDiagnostics
+	if recorded, ok := p.diagnostics[serverName]; ok {
+		d = *recorded
 	}
-	// Cap at 3 to ensure max backoff is 4s
-	if attempt > 3 {
-		attempt = 3
+	d.RestartPolicy = p.restartPolicy
+	if policy, ok := p.restartPolicies[serverName]; ok {
+		d.RestartPolicy = policy
 	}
-	return time.Duration(1<<uint(attempt-1)) * time.Second
+	d.RestartAttempts = p.restartAttempts[serverName]
+	d.MaxRestartAttempts = p.restartBackoff.MaxAttempts
+	d.NextRestartAt = p.nextRestarts[serverName]
+	return d
 }
@@ -1,9 +1,11 @@
 package mcp
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -331,3 +333,63 @@ func TestProcessManager_MultipleServers(t *testing.T) {
 		assert.Equal(t, expectedStatus, allStatuses[server])
 	}
 }
+
+func TestProcessManager_Diagnostics(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetStatus("server", StatusCrashed)
+
+	// Servers without failures only report their restart state
+	assert.Equal(t, ServerDiagnostics{RestartPolicy: "on-failure", MaxRestartAttempts: 3}, pm.GetDiagnostics("server"))
+
+	pm.RecordConnectError("server", errors.New("connection refused"))
+	pm.RecordFailure("server", RestartReasonTransportClosed, errors.New("EOF"))
+	pm.RecordRestart("server", RestartReasonTransportClosed)
+
+	d := pm.GetDiagnostics("server")
+	assert.Equal(t, RestartReasonTransportClosed, d.LastFailureReason)
+	assert.Equal(t, RestartReasonTransportClosed, d.LastRestartReason)
+	assert.Equal(t, "EOF", d.LastError)
+	assert.Equal(t, "connection refused", d.LastConnectError)
+	assert.False(t, d.LastFailureAt.IsZero())
+	assert.False(t, d.LastRestartAt.IsZero())
+	assert.False(t, d.LastConnectErrorAt.IsZero())
+}
+
+func TestProcessManager_DiagnosticsRestartState(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetStatus("server", StatusAvailable)
+	pm.setRestartPolicy("server", "never")
+
+	// Servers that never failed report their restart state too
+	assert.Equal(t, ServerDiagnostics{RestartPolicy: "never", MaxRestartAttempts: 3}, pm.GetDiagnostics("server"))
+
+	pm.RecordFailure("server", RestartReasonHealthCheck, errors.New("ping timeout"))
+	pm.IncrementRestartAttempts("server")
+	d := pm.GetDiagnostics("server")
+	assert.Equal(t, "never", d.RestartPolicy)
+	assert.Equal(t, 1, d.RestartAttempts)
+	assert.Equal(t, 3, d.MaxRestartAttempts)
+	assert.True(t, d.NextRestartAt.IsZero())
+
+	next := time.Now().Add(2 * time.Second)
+	pm.setNextRestart("server", next)
+	assert.Equal(t, next, pm.GetAllDiagnostics()["server"].NextRestartAt)
+
+	// Back to the global policy
+	pm.setRestartPolicy("server", "on-failure")
+	assert.Equal(t, "on-failure", pm.GetDiagnostics("server").RestartPolicy)
+}
+
+func TestProcessManager_GetAllDiagnostics(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	pm.SetStatus("server-1", StatusAvailable)
+	pm.SetStatus("server-2", StatusCrashed)
+	pm.RecordFailure("server-2", RestartReasonHealthCheck, errors.New("ping timeout"))
+
+	all := pm.GetAllDiagnostics()
+
+	require.Len(t, all, 2)
+	assert.Equal(t, ServerDiagnostics{RestartPolicy: "never", MaxRestartAttempts: 3}, all["server-1"])
+	assert.Equal(t, RestartReasonHealthCheck, all["server-2"].LastFailureReason)
+	assert.Equal(t, "ping timeout", all["server-2"].LastError)
+}
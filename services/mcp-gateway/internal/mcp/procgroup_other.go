@@ -0,0 +1,18 @@
+//go:build !unix
+
+package mcp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Process groups are unix only; elsewhere only the server process itself is signalled
+func setProcessGroup(*exec.Cmd) {}
+
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if sig == syscall.SIGKILL {
+		return cmd.Process.Kill()
+	}
+	return cmd.Process.Signal(sig)
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package mcp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in a new process group led by the server, so that processes it
+// spawns, e.g. the real server behind a shell wrapper, can be signalled together with it
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcessGroup sends sig to every process in the group led by cmd's process. The group
+// outlives its leader as long as any member is running, so this also reaches children orphaned by
+// a crash. Commands not started in their own group, e.g. by custom dialers, are signalled alone.
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return cmd.Process.Signal(sig)
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
@@ -0,0 +1,95 @@
+//go:build unix
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startWrapped starts a shell wrapper the way the stdio dialer would and returns the PID of the
+// process it spawned in the background
+func startWrapped(t *testing.T, cm *ClientManager, name, script string) (*exec.Cmd, int) {
+	t.Helper()
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("requires /proc")
+	}
+	cfg := config.ServerConfig{Name: name, Command: "sh", Args: []string{"-c", script}, Transport: config.TransportStdio}
+	transport, err := stdioDialer{}.Dial(context.Background(), cfg)
+	require.NoError(t, err)
+	cmd := transport.(*mcp.CommandTransport).Command
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = signalProcessGroup(cmd, syscall.SIGKILL) })
+
+	cm.configs = append(cm.configs, cfg)
+	cm.processes[name] = cmd
+	return cmd, pid
+}
+
+// processRunning reports whether pid is alive and not a zombie waiting to be reaped
+func processRunning(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// The state follows the parenthesized command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func TestTeardownServer_KillsProcessGroup(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cmd, child := startWrapped(t, cm, "wrapped", "sleep 60 & echo $!; wait")
+	require.True(t, processRunning(child))
+
+	cm.teardownServer("wrapped")
+	_ = cmd.Wait()
+
+	assert.Eventually(t, func() bool { return !processRunning(child) }, 2*time.Second, 10*time.Millisecond,
+		"the wrapper's child should not survive it")
+}
+
+func TestClose_KillsChildrenIgnoringSIGTERM(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	_, child := startWrapped(t, cm, "wrapped", `(trap "" TERM; exec sleep 60) & echo $!; wait`)
+	require.True(t, processRunning(child))
+
+	start := time.Now()
+	require.NoError(t, cm.Close())
+
+	assert.Less(t, time.Since(start), 5*time.Second, "the wrapper exits on SIGTERM without waiting for the kill timeout")
+	assert.Eventually(t, func() bool { return !processRunning(child) }, 2*time.Second, 10*time.Millisecond,
+		"the child ignoring SIGTERM should be killed with the group")
+}
+
+func TestClose_KillsAfterGracePeriod(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cm.SetProcessGracePeriod(200 * time.Millisecond)
+	_, pid := startWrapped(t, cm, "stubborn", `trap "" TERM; echo $$; while :; do sleep 1; done`)
+	require.True(t, processRunning(pid))
+
+	start := time.Now()
+	require.NoError(t, cm.Close())
+
+	assert.Less(t, time.Since(start), 2*time.Second, "a server ignoring SIGTERM is killed after the grace period")
+	assert.False(t, processRunning(pid))
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc, which is 100 on all common Linux platforms
+const clockTicks = 100
+
+// ResourceUsage is the CPU and memory used by a server's processes
+type ResourceUsage struct {
+	PID        int      `json:"pid"`
+	Processes  int      `json:"processes"`            // the server and the processes it spawned in its process group
+	RSSBytes   uint64   `json:"rssBytes"`             // resident memory of all processes
+	CPUSeconds float64  `json:"cpuSeconds"`           // user and system time of the running processes
+	CPUPercent *float64 `json:"cpuPercent,omitempty"` // since the previous sample, 100 per busy core; omitted on the first sample
+}
+
+// cpuSample is the CPU time of a server at a point in time, to derive utilization from the next sample
+type cpuSample struct {
+	pid        int
+	cpuSeconds float64
+	at         time.Time
+}
+
+// procStat holds the fields of /proc/<pid>/stat needed for resource usage
+type procStat struct {
+	state    byte // R, S, D, Z for an exited process not yet reaped, ...
+	pgrp     int
+	cpuTicks uint64
+	rssPages uint64
+}
+
+// readProcStat reads the state, process group, CPU time and resident set size of a process
+func readProcStat(pid int) (procStat, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return procStat{}, err
+	}
+	// The fields follow the parenthesized command name, which may contain spaces; fields[0] is field 3 of proc(5)
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return procStat{}, errors.New("malformed stat")
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return procStat{}, errors.New("malformed stat")
+	}
+	pgrp, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return procStat{}, err
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+	rss, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+	return procStat{state: fields[0][0], pgrp: pgrp, cpuTicks: utime + stime, rssPages: uint64(max(rss, 0))}, nil
+}
+
+// sampleProcessGroup sums the usage of the process group led by pid, or of pid alone when it does not lead
+// a group. It reports false where /proc is unavailable or the process is gone.
+func sampleProcessGroup(pid int) (ResourceUsage, bool) {
+	leader, err := readProcStat(pid)
+	if err != nil {
+		return ResourceUsage{}, false
+	}
+
+	usage := ResourceUsage{PID: pid}
+	var ticks uint64
+	add := func(s procStat) {
+		usage.Processes++
+		usage.RSSBytes += s.rssPages * uint64(os.Getpagesize())
+		ticks += s.cpuTicks
+	}
+	if leader.pgrp != pid {
+		add(leader)
+	} else {
+		entries, err := os.ReadDir("/proc")
+		if err != nil {
+			return ResourceUsage{}, false
+		}
+		for _, e := range entries {
+			member, err := strconv.Atoi(e.Name())
+			if err != nil {
+				continue
+			}
+			// Processes may exit while the group is scanned
+			if s, err := readProcStat(member); err == nil && s.pgrp == pid {
+				add(s)
+			}
+		}
+	}
+	usage.CPUSeconds = float64(ticks) / clockTicks
+	return usage, true
+}
+
+// ResourceUsage samples the CPU and memory usage of the running server processes the gateway started on its
+// own host. Servers in Docker containers, on SSH hosts, remote, WASM and in-process servers are not included,
+// nor are servers without a running process.
+func (m *ClientManager) ResourceUsage() map[string]ResourceUsage {
+	m.mu.Lock()
+	pids := make(map[string]int, len(m.processes))
+	for name, cmd := range m.processes {
+		cfg, ok := m.getConfig(name)
+		if !ok || cfg.IsContainer() || cfg.IsSSH() || cmd.Process == nil {
+			continue
+		}
+		pids[name] = cmd.Process.Pid
+	}
+	m.mu.Unlock()
+
+	// Scanning /proc takes a while, so sample without holding the lock
+	now := time.Now()
+	result := make(map[string]ResourceUsage, len(pids))
+	for name, pid := range pids {
+		if usage, ok := sampleProcessGroup(pid); ok {
+			result[name] = usage
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, usage := range result {
+		// A restarted server's new process starts over
+		if prev, ok := m.cpuSamples[name]; ok && prev.pid == usage.PID && now.After(prev.at) {
+			percent := max(usage.CPUSeconds-prev.cpuSeconds, 0) / now.Sub(prev.at).Seconds() * 100
+			usage.CPUPercent = &percent
+			result[name] = usage
+		}
+		m.cpuSamples[name] = cpuSample{pid: usage.PID, cpuSeconds: usage.CPUSeconds, at: now}
+	}
+	for name := range m.cpuSamples {
+		if _, ok := result[name]; !ok {
+			delete(m.cpuSamples, name)
+		}
+	}
+	return result
+}
+
+// enforceMemoryLimit restarts a server whose processes use more resident memory than its maxMemoryMB.
+// The restart goes through the crash flow, so that the restart policy and backoff apply; a server that is
+// not restarted is stopped rather than left to keep growing. It reports whether the limit was exceeded.
+func (m *ClientManager) enforceMemoryLimit(serverName string) bool {
+	m.mu.RLock()
+	cfg, ok := m.getConfig(serverName)
+	cmd := m.processes[serverName]
+	m.mu.RUnlock()
+	if !ok || cfg.MaxMemoryMB == 0 || cmd == nil || cmd.Process == nil {
+		return false
+	}
+
+	usage, ok := sampleProcessGroup(cmd.Process.Pid)
+	if !ok || usage.RSSBytes <= uint64(cfg.MaxMemoryMB)<<20 {
+		return false
+	}
+	slog.Warn("Server exceeded its memory limit", "server", serverName, "rssBytes", usage.RSSBytes, "maxMemoryMB", cfg.MaxMemoryMB)
+
+	if !m.transition(serverName, StatusCrashed) {
+		return true
+	}
+	m.processManager.RecordFailure(serverName, RestartReasonResourceLimit,
+		fmt.Errorf("memory usage %d MB exceeds maxMemoryMB %d", usage.RSSBytes>>20, cfg.MaxMemoryMB))
+	if m.processManager.onServerCrashed != nil {
+		m.processManager.onServerCrashed(serverName)
+	}
+	if m.processManager.GetStatus(serverName) == StatusCrashed {
+		m.teardownServer(serverName)
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceUsage_SumsProcessGroup(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cmd, _ := startWrapped(t, cm, "wrapped", "sleep 60 & echo $!; wait")
+	startWrapped(t, cm, "in-container", "echo $$; sleep 60")
+	cm.configs[len(cm.configs)-1].Runtime = config.RuntimeDocker
+
+	usage := cm.ResourceUsage()
+	require.Contains(t, usage, "wrapped")
+	assert.NotContains(t, usage, "in-container", "the docker client's usage is not the container's")
+
+	wrapped := usage["wrapped"]
+	assert.Equal(t, cmd.Process.Pid, wrapped.PID)
+	assert.Equal(t, 2, wrapped.Processes, "the wrapper and its child")
+	assert.Positive(t, wrapped.RSSBytes)
+	assert.Nil(t, wrapped.CPUPercent, "utilization needs a previous sample")
+
+	time.Sleep(20 * time.Millisecond)
+	wrapped = cm.ResourceUsage()["wrapped"]
+	require.NotNil(t, wrapped.CPUPercent)
+	assert.GreaterOrEqual(t, *wrapped.CPUPercent, 0.0)
+}
+
+func TestReadProcStat(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cmd, _ := startWrapped(t, cm, "wrapped", "echo $$; sleep 60")
+
+	stat, err := readProcStat(cmd.Process.Pid)
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, stat.pgrp, "servers lead their own process group")
+
+	_, err = readProcStat(-1)
+	assert.Error(t, err)
+}
+
+func TestEnforceMemoryLimit(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cmd, child := startWrapped(t, cm, "leaky", "sleep 60 & echo $!; wait")
+	pm.SetStatus("leaky", StatusAvailable)
+
+	cm.configs[0].MaxMemoryMB = 1024 * 1024
+	assert.False(t, cm.enforceMemoryLimit("leaky"), "within the limit")
+	assert.Equal(t, StatusAvailable, pm.GetStatus("leaky"))
+
+	// A shell and sleep together use more than 1 MB
+	cm.configs[0].MaxMemoryMB = 1
+	assert.True(t, cm.enforceMemoryLimit("leaky"))
+	assert.Equal(t, StatusCrashed, pm.GetStatus("leaky"))
+	diagnostics := pm.GetDiagnostics("leaky")
+	assert.Equal(t, RestartReasonResourceLimit, diagnostics.LastFailureReason)
+	assert.Contains(t, diagnostics.LastError, "exceeds maxMemoryMB 1")
+
+	_ = cmd.Wait()
+	assert.Eventually(t, func() bool { return !processRunning(child) }, 2*time.Second, 10*time.Millisecond,
+		"a server that is not restarted is stopped")
+}
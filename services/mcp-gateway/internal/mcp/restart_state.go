@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// RestartState is the persisted restart history of the servers, so that restarting the gateway does not
+// give a crash-looping server a fresh set of restart attempts
+type RestartState struct {
+	SavedAt time.Time                     `json:"savedAt"`
+	Servers map[string]ServerRestartState `json:"servers"`
+}
+
+// ServerRestartState is the restart history of a server that survives gateway restarts
+type ServerRestartState struct {
+	RestartAttempts   int           `json:"restartAttempts,omitempty"`
+	Quarantined       bool          `json:"quarantined,omitempty"`
+	LastFailureReason RestartReason `json:"lastFailureReason,omitempty"`
+	LastFailureAt     time.Time     `json:"lastFailureAt,omitzero"`
+	LastError         string        `json:"lastError,omitempty"`
+}
+
+// SnapshotRestartState captures the restart history of every server that has failed or is quarantined
+func (p *ProcessManager) SnapshotRestartState() RestartState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	state := RestartState{SavedAt: time.Now().UTC(), Servers: make(map[string]ServerRestartState)}
+	for name := range p.statuses {
+		s := ServerRestartState{
+			RestartAttempts: p.restartAttempts[name],
+			Quarantined:     p.statuses[name] == StatusQuarantined,
+		}
+		if d, ok := p.diagnostics[name]; ok {
+			s.LastFailureReason = d.LastFailureReason
+			s.LastFailureAt = d.LastFailureAt
+			s.LastError = d.LastError
+		}
+		if s.RestartAttempts > 0 || s.Quarantined || !s.LastFailureAt.IsZero() {
+			state.Servers[name] = s
+		}
+	}
+	return state
+}
+
+// restoreRestartState puts back the restart history of a server saved by a previous gateway run.
+// A quarantined server stays quarantined until it is restarted manually.
+func (p *ProcessManager) restoreRestartState(serverName string, s ServerRestartState) {
+	p.mu.Lock()
+	p.restartAttempts[serverName] = s.RestartAttempts
+	d := p.diagnosticsLocked(serverName)
+	d.LastFailureReason = s.LastFailureReason
+	d.LastFailureAt = s.LastFailureAt
+	d.LastError = s.LastError
+	p.mu.Unlock()
+
+	if s.Quarantined {
+		p.SetStatus(serverName, StatusQuarantined)
+	}
+}
+
+// notifyRestartState signals that the state saved by SnapshotRestartState has changed
+func (p *ProcessManager) notifyRestartState() {
+	select {
+	case p.restartStateChanged <- struct{}{}:
+	default:
+		// A save is already pending and will include this change
+	}
+}
+
+// RestoreRestartState sets the restart history that Initialize applies to the configured servers. Restart
+// attempts are forgotten once the last failure of a server is older than expireAfter; quarantines are kept
+// until the server is restarted manually. Must be called before Initialize.
+func (m *ClientManager) RestoreRestartState(state RestartState, expireAfter time.Duration) {
+	restored := make(map[string]ServerRestartState, len(state.Servers))
+	for name, s := range state.Servers {
+		if !s.Quarantined && m.processManager.Clock().Since(s.LastFailureAt) > expireAfter {
+			continue
+		}
+		restored[name] = s
+	}
+	m.mu.Lock()
+	m.restoredRestarts = restored
+	m.mu.Unlock()
+}
+
+// LoadRestartState reads a state written by SaveRestartState. A missing file yields an empty state.
+func LoadRestartState(path string) (RestartState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return RestartState{}, nil
+	}
+	if err != nil {
+		return RestartState{}, fmt.Errorf("failed to read restart state: %w", err)
+	}
+
+	var state RestartState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return RestartState{}, fmt.Errorf("failed to parse restart state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveRestartState writes a state atomically so that a crash never leaves a partial file
+func SaveRestartState(path string, state RestartState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode restart state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write restart state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write restart state: %w", err)
+	}
+	return nil
+}
+
+// StartRestartStatePersistence saves the restart history to path whenever it changes, and a final time on Close
+func (m *ClientManager) StartRestartStatePersistence(path string) {
+	m.processManager.OnStatusChange(func(ev StatusEvent) {
+		if ev.From == StatusQuarantined || ev.To == StatusQuarantined {
+			m.processManager.notifyRestartState()
+		}
+	})
+	m.workers.Go("restart-state", func(ctx context.Context) {
+		save := func() {
+			if err := SaveRestartState(path, m.processManager.SnapshotRestartState()); err != nil {
+				slog.Warn("Failed to save restart state", "path", path, "error", err)
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				save()
+				return
+			case <-m.processManager.restartStateChanged:
+				save()
+			}
+		}
+	})
+}
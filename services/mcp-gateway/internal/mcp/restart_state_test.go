@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartState_QuarantineSurvivesGatewayRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restarts.json")
+
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetStatus("embedded", StatusQuarantined)
+	for range 3 {
+		pm.IncrementRestartAttempts("embedded")
+	}
+	pm.RecordFailure("embedded", RestartReasonTransportClosed, errors.New("EOF"))
+	require.NoError(t, SaveRestartState(path, pm.SnapshotRestartState()))
+
+	// A new gateway run does not connect the quarantined server
+	pm2 := NewProcessManager(30000, "on-failure")
+	cm2 := NewClientManager(pm2)
+	t.Cleanup(func() { _ = cm2.Close() })
+	state, err := LoadRestartState(path)
+	require.NoError(t, err)
+	cm2.RestoreRestartState(state, time.Hour)
+	require.NoError(t, cm2.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm2.Initialize(context.Background(), nil))
+
+	assert.Equal(t, StatusQuarantined, pm2.GetStatus("embedded"))
+	assert.Empty(t, cm2.GetTools())
+	d := pm2.GetDiagnostics("embedded")
+	assert.Equal(t, 3, d.RestartAttempts)
+	assert.Equal(t, RestartReasonTransportClosed, d.LastFailureReason)
+	assert.Equal(t, "EOF", d.LastError)
+
+	// Until its restarts are reset
+	restarted, err := cm2.ResetRestarts(context.Background(), "embedded")
+	require.NoError(t, err)
+	assert.True(t, restarted)
+	require.Eventually(t, func() bool { return pm2.GetStatus("embedded") == StatusAvailable }, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, cm2.GetTools(), 1)
+}
+
+func TestRestoreRestartState_ForgetsExpiredAttempts(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+
+	cm.RestoreRestartState(RestartState{Servers: map[string]ServerRestartState{
+		"embedded": {RestartAttempts: 2, LastFailureAt: time.Now().Add(-2 * time.Hour)},
+	}}, time.Hour)
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("embedded"))
+	assert.Zero(t, pm.GetRestartAttempts("embedded"))
+}
+
+func TestStartRestartStatePersistence_SavesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restarts.json")
+	pm := NewProcessManager(30000, "on-failure")
+	cm := NewClientManager(pm)
+	pm.SetStatus("weather", StatusCrashed)
+
+	cm.StartRestartStatePersistence(path)
+	pm.IncrementRestartAttempts("weather")
+	require.Eventually(t, func() bool {
+		state, err := LoadRestartState(path)
+		return err == nil && state.Servers["weather"].RestartAttempts == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pm.SetStatus("weather", StatusQuarantined)
+	require.NoError(t, cm.Close())
+	state, err := LoadRestartState(path)
+	require.NoError(t, err)
+	assert.True(t, state.Servers["weather"].Quarantined)
+}
+
+func TestLoadRestartState_Missing(t *testing.T) {
+	state, err := LoadRestartState(filepath.Join(t.TempDir(), "missing.json"))
+
+	require.NoError(t, err)
+	assert.Empty(t, state.Servers)
+}
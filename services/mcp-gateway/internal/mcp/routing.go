@@ -0,0 +1,351 @@
+package mcp
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CallTool calls a tool on the specified server.
+// If the server cannot take the call (not available, or at its maxConcurrentCalls quota),
+// the call spills over to the servers declared equivalentTo it. With loadBalancing set to
+// least-latency, all servers of the group are tried in order of expected latency instead.
+// Spillover only happens before dispatch; a call that fails on a server is never retried elsewhere.
+// Read-only tools may additionally be hedged to a second server, see callHedged.
+// The input is either decoded JSON or a json.RawMessage, which is passed on as sent unless the tool's
+// input is sanitized or coerced.
+func (m *ClientManager) CallTool(ctx context.Context, server, toolName string, input any) (any, error) {
+	input, err := m.decodeRewrittenInput(server, toolName, input)
+	if err != nil {
+		return nil, err
+	}
+	input = m.sanitizeToolInput(server, toolName, input)
+	input = m.coerceToolInput(server, toolName, input)
+	candidates := m.routeCandidates(server)
+	next, name, session, err := m.acquireNext(ctx, server, toolName, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	if delay := m.hedgeDelay(server, toolName); delay > 0 && next < len(candidates) {
+		return m.callHedged(ctx, server, toolName, input, delay, candidates[next:], name, session)
+	}
+
+	defer m.releaseSession(name)
+	return m.callSession(ctx, name, session, toolName, input)
+}
+
+// decodeRewrittenInput decodes a raw input if the gateway rewrites the input of the tool
+func (m *ClientManager) decodeRewrittenInput(server, toolName string, input any) (any, error) {
+	raw, ok := input.(json.RawMessage)
+	if !ok {
+		return input, nil
+	}
+	cfg, ok := m.getConfig(server)
+	if !ok {
+		return input, nil
+	}
+	if tool, _ := cfg.Tool(toolName); len(tool.Sanitize) == 0 && !cfg.CoercesInput(toolName) {
+		return input, nil
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, mcpErrors.ErrInvalidInput.Wrap(err)
+	}
+	return decoded, nil
+}
+
+// acquireNext acquires the first candidate that can take the call. It returns the index of the
+// candidate after the acquired one, or the error of the requested server if none could be acquired.
+func (m *ClientManager) acquireNext(ctx context.Context, server, toolName string, candidates []string) (int, string, MCPSession, error) {
+	var requestedErr error
+	for i, name := range candidates {
+		session, err := m.acquireSession(ctx, name, toolName, name != server)
+		// Only the requested server is respawned; idle alternates are skipped like stopped ones
+		if errors.Is(err, errServerIdle) && name == server {
+			if err = m.wakeServer(ctx, name); err == nil {
+				session, err = m.acquireSession(ctx, name, toolName, false)
+			}
+		}
+		if err != nil {
+			if name == server {
+				requestedErr = err
+			}
+			continue
+		}
+		if name != server {
+			slog.Debug("Routing tool call to equivalent server",
+				"server", server,
+				"target", name,
+				"tool", toolName,
+				"reason", requestedErr,
+			)
+		}
+		return i + 1, name, session, nil
+	}
+	if requestedErr == nil {
+		// The requested server was never tried because it is not part of the group
+		requestedErr = mcpErrors.ErrServerNotFound
+	}
+	return len(candidates), "", nil, requestedErr
+}
+
+// hedgeDelay returns the hedge delay configured for server if the tool is read-only, or zero
+func (m *ClientManager) hedgeDelay(server, toolName string) time.Duration {
+	cfg, ok := m.getConfig(server)
+	if !ok || cfg.HedgeDelay == 0 {
+		return 0
+	}
+	tool, ok := m.GetToolInfo(server, toolName)
+	if !ok || !tool.ReadOnly {
+		return 0
+	}
+	return time.Duration(cfg.HedgeDelay) * time.Millisecond
+}
+
+// callOutcome is the result of a single dispatched tool call
+type callOutcome struct {
+	server string
+	result any
+	err    error
+}
+
+// callHedged dispatches the call to the acquired session and, if no response arrives within delay,
+// dispatches a second call to the next server in rest that can take it. The first successful
+// response wins and the other call is cancelled. Tool-level errors count as responses; only
+// transport errors make the gateway wait for the other call.
+func (m *ClientManager) callHedged(ctx context.Context, server, toolName string, input any, delay time.Duration, rest []string, name string, session MCPSession) (any, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so that the losing call never blocks after we return. The calls run as workers,
+	// so that Close cancels a losing call that is still running and waits for it to exit.
+	outcomes := make(chan callOutcome, 2)
+	dispatch := func(name string, session MCPSession) {
+		started := m.workers.Go("hedged-call:"+name, func(workerCtx context.Context) {
+			defer m.releaseSession(name)
+			stop := context.AfterFunc(workerCtx, cancel)
+			defer stop()
+			result, err := m.callSession(ctx, name, session, toolName, input)
+			outcomes <- callOutcome{server: name, result: result, err: err}
+		})
+		if !started {
+			m.releaseSession(name)
+			outcomes <- callOutcome{server: name, err: mcpErrors.ErrServerNotRunning}
+		}
+	}
+	dispatch(name, session)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case out := <-outcomes:
+		return out.result, out.err
+	case <-timer.C:
+	}
+
+	_, hedgeName, hedgeSession, err := m.acquireNext(ctx, server, toolName, rest)
+	if err != nil {
+		// No replica can take the hedge; keep waiting for the original call
+		out := <-outcomes
+		return out.result, out.err
+	}
+	slog.Debug("Hedging tool call", "server", server, "tool", toolName, "first", name, "hedge", hedgeName)
+	dispatch(hedgeName, hedgeSession)
+
+	first := <-outcomes
+	if first.err == nil {
+		return first.result, nil
+	}
+	if second := <-outcomes; second.err == nil {
+		return second.result, nil
+	}
+	return first.result, first.err
+}
+
+// routeCandidates returns the servers that may take a call addressed to server, in the order
+// they should be tried: the server followed by its equivalents in configuration order, or,
+// for least-latency groups, all members ordered by their load-adjusted EWMA latency.
+func (m *ClientManager) routeCandidates(server string) []string {
+	candidates := []string{server}
+	for _, cfg := range m.configs {
+		if cfg.EquivalentTo == server {
+			candidates = append(candidates, cfg.Name)
+		}
+	}
+
+	cfg, ok := m.getConfig(server)
+	if !ok || cfg.LoadBalancing != config.LoadBalancingLeastLatency || len(candidates) == 1 {
+		return candidates
+	}
+
+	m.mu.RLock()
+	scores := make(map[string]float64, len(candidates))
+	for _, name := range candidates {
+		scores[name] = m.callStats[name].score()
+	}
+	m.mu.RUnlock()
+
+	slices.SortStableFunc(candidates, func(a, b string) int {
+		return cmp.Compare(scores[a], scores[b])
+	})
+	return candidates
+}
+
+// acquireSession reserves a call slot on a server that is ready to take a call.
+// For alternates, the tool must also be known to be provided by that server.
+func (m *ClientManager) acquireSession(ctx context.Context, name, toolName string, alternate bool) (MCPSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Idle servers have no session until a call respawns them
+	if m.suspending[name] || m.processManager.GetStatus(name) == StatusIdle {
+		return nil, errServerIdle
+	}
+	if m.processManager.GetStatus(name) == StatusInactive {
+		return nil, errServerInactive
+	}
+
+	session, ok := m.sessions[name]
+	if !ok {
+		return nil, mcpErrors.ErrServerNotFound
+	}
+
+	// Check status
+	status := m.processManager.GetStatus(name)
+	if status == StatusRestarting {
+		return nil, fmt.Errorf("server %s is currently restarting, please retry shortly", name)
+	} else if status == StatusCrashed || status == StatusQuarantined {
+		return nil, mcpErrors.ErrServerCrashed
+	} else if !status.Serving() {
+		return nil, mcpErrors.ErrServerNotRunning
+	}
+
+	if alternate {
+		if _, ok := m.toolsCache[toolCacheKey(name, toolName)]; !ok {
+			return nil, mcpErrors.ErrToolNotFound
+		}
+	}
+
+	if limit, _ := m.maxConcurrentCallsLocked(name); limit > 0 && m.statsLocked(name).inFlight >= slotLimit(ctx, limit) {
+		return nil, mcpErrors.ErrServerBusy
+	}
+
+	stats := m.statsLocked(name)
+	stats.inFlight++
+	stats.lastActive = m.processManager.Clock().Now()
+	return session, nil
+}
+
+// releaseSession frees the call slot taken by acquireSession
+func (m *ClientManager) releaseSession(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.statsLocked(name)
+	stats.inFlight--
+	stats.lastActive = m.processManager.Clock().Now()
+}
+
+// PanicError reports a panic recovered while calling a tool, e.g. while the SDK decoded a
+// pathological result. Only the call that panicked fails.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic during tool call: %v", e.Value)
+}
+
+// callSession invokes a tool on an acquired session and records its latency.
+// A panic during the call is returned as a *PanicError so that it cannot take down the
+// gateway, even when the call runs on its own goroutine as a hedge.
+func (m *ClientManager) callSession(ctx context.Context, name string, session MCPSession, toolName string, input any) (result any, err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			slog.Error("Panic during tool call", "server", name, "tool", toolName, "panic", r, "stack", string(stack))
+			m.recordCall(name, time.Since(start), true)
+			result, err = nil, &PanicError{Value: r, Stack: stack}
+			m.publishCallFinished(name, toolName, start, true, err)
+		}
+	}()
+
+	// The input must be an object, as a map[string]any or as raw JSON. Calls from /mcp/call are
+	// validated already, but embedded callers of CallTool are not.
+	var arguments any
+	switch input := input.(type) {
+	case map[string]any:
+		arguments = input
+	case json.RawMessage:
+		if !bytes.HasPrefix(bytes.TrimLeft(input, " \t\r\n"), []byte("{")) {
+			return nil, mcpErrors.ErrInvalidInput.Wrap(errors.New("input must be an object"))
+		}
+		arguments = input
+	default:
+		return nil, mcpErrors.ErrInvalidInput.Wrap(fmt.Errorf("input must be an object, got %T", input))
+	}
+
+	// Call tool
+	m.events.Publish(events.Event{Type: events.ToolCallStarted, Server: name, Tool: toolName})
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      toolName,
+		Arguments: arguments,
+	})
+	failed := err != nil || (res != nil && res.IsError)
+	// Calls abandoned by the caller (or lost hedges) say nothing about the server's latency
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		m.recordCall(name, time.Since(start), failed)
+	}
+	m.publishCallFinished(name, toolName, start, failed, err)
+	if err != nil {
+		if isUnknownToolError(err, toolName) {
+			return nil, mcpErrors.ErrToolNotFound.Wrap(err).WithDetails(map[string]any{"toolName": toolName, "serverName": name})
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// publishCallFinished publishes the outcome of a call started at start
+func (m *ClientManager) publishCallFinished(name, toolName string, start time.Time, failed bool, err error) {
+	ev := events.Event{
+		Type:       events.ToolCallFinished,
+		Server:     name,
+		Tool:       toolName,
+		DurationMs: time.Since(start).Milliseconds(),
+		Failed:     failed,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	m.events.Publish(ev)
+}
+
+// isUnknownToolError checks if the error is the server's answer to a call of toolName, which it does
+// not have. The MCP SDK reports it as an invalid params error, whose type it does not export, with
+// exactly the message fmt.Sprintf("unknown tool %q", name), wrapped as "calling "tools/call": ...".
+func isUnknownToolError(err error, toolName string) bool {
+	want := fmt.Sprintf("unknown tool %q", toolName)
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,327 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newSpilloverManager sets up a primary and a secondary server that both provide "search"
+func newSpilloverManager(t *testing.T, primaryMax int) (*ClientManager, *ProcessManager, *MockMCPSession, *MockMCPSession) {
+	t.Helper()
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{
+		{Name: "primary", MaxConcurrentCalls: primaryMax},
+		{Name: "secondary", EquivalentTo: "primary"},
+	}
+
+	primary := new(MockMCPSession)
+	secondary := new(MockMCPSession)
+	cm.sessions["primary"] = primary
+	cm.sessions["secondary"] = secondary
+	cm.toolsCache[toolCacheKey("primary", "search")] = ToolInfo{Name: "search", Server: "primary"}
+	cm.toolsCache[toolCacheKey("secondary", "search")] = ToolInfo{Name: "search", Server: "secondary"}
+	pm.SetStatus("primary", StatusAvailable)
+	pm.SetStatus("secondary", StatusAvailable)
+	return cm, pm, primary, secondary
+}
+
+func TestCallTool_PrefersPrimary(t *testing.T) {
+	cm, _, primary, secondary := newSpilloverManager(t, 0)
+	primary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	primary.AssertNumberOfCalls(t, "CallTool", 1)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
+
+func TestCallTool_SpillsOverWhenPrimaryUnavailable(t *testing.T) {
+	cm, pm, primary, secondary := newSpilloverManager(t, 0)
+	pm.SetStatus("primary", StatusCrashed)
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	primary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+}
+
+func TestCallTool_SpillsOverWhenQuotaExhausted(t *testing.T) {
+	cm, _, primary, secondary := newSpilloverManager(t, 1)
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	// Occupy the primary's only slot
+	_, err := cm.acquireSession(context.Background(), "primary", "search", false)
+	require.NoError(t, err)
+
+	_, err = cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	primary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+
+	cm.releaseSession("primary")
+	assert.Zero(t, cm.GetCallStats()["primary"].InFlight)
+}
+
+func TestCallTool_PrimaryErrorWhenNoAlternateCanTakeCall(t *testing.T) {
+	cm, pm, _, secondary := newSpilloverManager(t, 1)
+	pm.SetStatus("secondary", StatusUnavailable)
+
+	_, err := cm.acquireSession(context.Background(), "primary", "search", false)
+	require.NoError(t, err)
+	defer cm.releaseSession("primary")
+
+	_, err = cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	assert.ErrorIs(t, err, mcpErrors.ErrServerBusy)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
+
+func TestCallTool_SkipsAlternateWithoutTool(t *testing.T) {
+	cm, pm, _, secondary := newSpilloverManager(t, 0)
+	pm.SetStatus("primary", StatusCrashed)
+
+	_, err := cm.CallTool(context.Background(), "primary", "other-tool", map[string]any{})
+
+	assert.ErrorIs(t, err, mcpErrors.ErrServerCrashed)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
+
+func TestCallTool_DoesNotRetryFailedCall(t *testing.T) {
+	cm, _, primary, secondary := newSpilloverManager(t, 0)
+	primary.On("CallTool", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+	stats := cm.GetCallStats()["primary"]
+	assert.Zero(t, stats.InFlight, "slot must be released after a failed call")
+	assert.Equal(t, uint64(1), stats.Calls)
+	assert.Equal(t, uint64(1), stats.Errors)
+}
+
+func TestCallTool_LeastLatencyPrefersFasterServer(t *testing.T) {
+	cm, _, primary, secondary := newSpilloverManager(t, 0)
+	cm.configs[0].LoadBalancing = config.LoadBalancingLeastLatency
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	cm.recordCall("primary", 200*time.Millisecond, false)
+	cm.recordCall("secondary", 20*time.Millisecond, false)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	primary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+}
+
+func TestCallTool_LeastLatencyAccountsForInFlightCalls(t *testing.T) {
+	cm, _, primary, _ := newSpilloverManager(t, 0)
+	cm.configs[0].LoadBalancing = config.LoadBalancingLeastLatency
+	primary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	cm.recordCall("primary", 30*time.Millisecond, false)
+	cm.recordCall("secondary", 20*time.Millisecond, false)
+
+	// Two calls already queued on the faster secondary make the primary the better choice
+	for range 2 {
+		_, err := cm.acquireSession(context.Background(), "secondary", "search", true)
+		require.NoError(t, err)
+	}
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	primary.AssertNumberOfCalls(t, "CallTool", 1)
+}
+
+func TestCallTool_LeastLatencyTriesUnmeasuredServersFirst(t *testing.T) {
+	cm, _, primary, secondary := newSpilloverManager(t, 0)
+	cm.configs[0].LoadBalancing = config.LoadBalancingLeastLatency
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	cm.recordCall("primary", 10*time.Millisecond, false)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	primary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
+
+func TestCallStats_EWMA(t *testing.T) {
+	var s callStats
+
+	s.observe(100*time.Millisecond, false)
+	assert.Equal(t, 100*time.Millisecond, s.ewma, "first sample seeds the average")
+
+	s.observe(200*time.Millisecond, true)
+	assert.Equal(t, 130*time.Millisecond, s.ewma)
+	assert.Equal(t, uint64(2), s.calls)
+	assert.Equal(t, uint64(1), s.errors)
+}
+
+// newHedgingManager sets up a spillover group whose "search" tool is read-only and hedged after 20ms
+func newHedgingManager(t *testing.T, readOnly bool) (*ClientManager, *MockMCPSession, *MockMCPSession) {
+	t.Helper()
+	cm, _, primary, secondary := newSpilloverManager(t, 0)
+	cm.configs[0].HedgeDelay = 20
+	cm.toolsCache[toolCacheKey("primary", "search")] = ToolInfo{Name: "search", Server: "primary", ReadOnly: readOnly}
+	return cm, primary, secondary
+}
+
+// blockUntilCancelled makes a mocked CallTool hang until its context is cancelled
+func blockUntilCancelled(args mock.Arguments) {
+	<-args.Get(0).(context.Context).Done()
+}
+
+func TestCallTool_HedgesSlowReadOnlyCall(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, true)
+	primary.On("CallTool", mock.Anything, mock.Anything).Run(blockUntilCancelled).Return(nil, context.Canceled)
+	hedged := &mcp.CallToolResult{StructuredContent: map[string]any{"from": "secondary"}}
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(hedged, nil)
+
+	result, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	assert.Same(t, hedged, result)
+	primary.AssertNumberOfCalls(t, "CallTool", 1)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+
+	// The cancelled primary call releases its slot without skewing its latency average
+	assert.Eventually(t, func() bool { return cm.GetCallStats()["primary"].InFlight == 0 }, time.Second, 5*time.Millisecond)
+	assert.Zero(t, cm.GetCallStats()["primary"].Calls)
+}
+
+func TestCallTool_ShutdownWaitsForLosingHedgedCall(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, true)
+	release := make(chan struct{})
+	// The losing call keeps running after the hedge wins, even though it is cancelled
+	primary.On("CallTool", mock.Anything, mock.Anything).Run(func(mock.Arguments) { <-release }).Return(nil, context.Canceled)
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+	require.NoError(t, err)
+
+	cm.workers.Shutdown()
+	assert.False(t, cm.workers.Wait(20*time.Millisecond), "shutdown waits for the losing call")
+	close(release)
+	assert.True(t, cm.workers.Wait(time.Second))
+	assert.Zero(t, cm.GetCallStats()["primary"].InFlight)
+}
+
+func TestCallTool_HedgeNotStartedDuringShutdown(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, true)
+	cm.workers.Shutdown()
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotRunning)
+	primary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+	assert.Zero(t, cm.GetCallStats()["primary"].InFlight, "the call slot is released")
+}
+
+func TestCallTool_NoHedgeWhenFirstResponseIsFast(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, true)
+	primary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	time.Sleep(40 * time.Millisecond)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
+
+func TestCallTool_NoHedgeForWriteTools(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, false)
+	primary.On("CallTool", mock.Anything, mock.Anything).After(50*time.Millisecond).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	secondary.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
+
+func TestCallTool_HedgeFallsBackToOriginalOnHedgeFailure(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, true)
+	original := &mcp.CallToolResult{}
+	primary.On("CallTool", mock.Anything, mock.Anything).After(50*time.Millisecond).Return(original, nil)
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+	result, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	assert.Same(t, original, result)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+}
+
+func TestCallTool_PanicFailsOnlyThatCall(t *testing.T) {
+	cm, primary, _ := newHedgingManager(t, false)
+	primary.On("CallTool", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		panic("unexpected content type")
+	}).Once()
+	primary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "unexpected content type", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+	stats := cm.GetCallStats()["primary"]
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Zero(t, stats.InFlight, "the call slot is released")
+
+	// The server keeps serving other calls
+	_, err = cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+	assert.NoError(t, err)
+}
+
+func TestCallTool_HedgePanicIsContained(t *testing.T) {
+	cm, primary, secondary := newHedgingManager(t, true)
+	original := &mcp.CallToolResult{}
+	primary.On("CallTool", mock.Anything, mock.Anything).After(50*time.Millisecond).Return(original, nil)
+	secondary.On("CallTool", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		panic("unexpected content type")
+	})
+
+	// A panic on the hedge goroutine would crash the test binary if it escaped
+	result, err := cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+
+	require.NoError(t, err)
+	assert.Same(t, original, result)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+}
+
+func TestCallTool_LowPriorityUsesHalfOfQuota(t *testing.T) {
+	cm, _, primary, secondary := newSpilloverManager(t, 4)
+	primary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+	secondary.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	for range 2 {
+		_, err := cm.acquireSession(context.Background(), "primary", "search", false)
+		require.NoError(t, err)
+	}
+
+	// Half of the primary's slots are taken: low priority spills over, normal priority does not
+	_, err := cm.CallTool(WithPriority(context.Background(), config.PriorityLow), "primary", "search", map[string]any{})
+	require.NoError(t, err)
+	secondary.AssertNumberOfCalls(t, "CallTool", 1)
+
+	_, err = cm.CallTool(context.Background(), "primary", "search", map[string]any{})
+	require.NoError(t, err)
+	primary.AssertNumberOfCalls(t, "CallTool", 1)
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package mcp
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// Process credentials can only be set on unix
+func setRunAs(*exec.Cmd, *config.RunAsConfig) error {
+	return errors.New("runAs is only supported on unix")
+}
+
+func runAsError(_ *config.RunAsConfig, err error) error {
+	return err
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// setRunAs makes cmd start as the configured user and group. Supplementary groups are cleared so
+// that the server keeps none of the gateway's.
+func setRunAs(cmd *exec.Cmd, runAs *config.RunAsConfig) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: *runAs.UID, Gid: *runAs.GID}
+	return nil
+}
+
+// runAsError explains a spawn that failed because the gateway may not switch users
+func runAsError(runAs *config.RunAsConfig, err error) error {
+	if runAs == nil || !errors.Is(err, syscall.EPERM) {
+		return err
+	}
+	return fmt.Errorf("cannot run as uid %d gid %d: the gateway must run as root or with CAP_SETUID and CAP_SETGID: %w",
+		*runAs.UID, *runAs.GID, err)
+}
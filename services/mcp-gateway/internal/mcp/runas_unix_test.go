@@ -0,0 +1,85 @@
+//go:build unix
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdioDialer_RunAs(t *testing.T) {
+	uid, gid := uint32(1000), uint32(100)
+	cfg := config.ServerConfig{Name: "stdio", Command: "/bin/true", RunAs: &config.RunAsConfig{UID: &uid, GID: &gid}}
+
+	transport, err := stdioDialer{}.Dial(context.Background(), cfg)
+	require.NoError(t, err)
+
+	attr := transport.(*mcp.CommandTransport).Command.SysProcAttr
+	require.NotNil(t, attr)
+	assert.Equal(t, &syscall.Credential{Uid: 1000, Gid: 100}, attr.Credential)
+}
+
+func TestStdioDialer_DockerRunAsKeepsCLIUser(t *testing.T) {
+	uid, gid := uint32(1000), uint32(100)
+	cfg := dockerServerConfig()
+	cfg.RunAs = &config.RunAsConfig{UID: &uid, GID: &gid}
+
+	transport, err := stdioDialer{}.Dial(context.Background(), cfg)
+	require.NoError(t, err)
+
+	attr := transport.(*mcp.CommandTransport).Command.SysProcAttr
+	assert.Nil(t, attr.Credential, "the docker CLI keeps the gateway's user; the container gets --user")
+}
+
+func TestStdioDialer_RunAsSwitchesUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+	uid, gid := uint32(65534), uint32(65534)
+	cfg := config.ServerConfig{Name: "stdio", Command: "id", Args: []string{"-u"}, RunAs: &config.RunAsConfig{UID: &uid, GID: &gid}}
+
+	transport, err := stdioDialer{}.Dial(context.Background(), cfg)
+	require.NoError(t, err)
+
+	out, err := transport.(*mcp.CommandTransport).Command.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "65534\n", string(out))
+}
+
+func TestRunAsError(t *testing.T) {
+	uid, gid := uint32(1000), uint32(100)
+	runAs := &config.RunAsConfig{UID: &uid, GID: &gid}
+	spawnErr := &os.PathError{Op: "fork/exec", Path: "/bin/true", Err: syscall.EPERM}
+
+	err := runAsError(runAs, spawnErr)
+	assert.ErrorIs(t, err, syscall.EPERM)
+	assert.Contains(t, err.Error(), "cannot run as uid 1000 gid 100: the gateway must run as root or with CAP_SETUID and CAP_SETGID")
+
+	assert.Equal(t, spawnErr, runAsError(nil, spawnErr), "servers without runAs keep the error")
+	other := errors.New("exec: not found")
+	assert.Equal(t, other, runAsError(runAs, other))
+}
+
+func TestInitialize_RunAsWithoutPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root may switch to any user")
+	}
+	// Supplementary groups cannot be cleared without CAP_SETGID, even for the gateway's own user
+	uid, gid := uint32(os.Geteuid()), uint32(os.Getegid())
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "stdio", Command: "cat", Transport: config.TransportStdio, RunAs: &config.RunAsConfig{UID: &uid, GID: &gid}}
+
+	err := cm.Initialize(context.Background(), []config.ServerConfig{cfg})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to server stdio: failed to connect: cannot run as uid")
+	assert.ErrorIs(t, err, syscall.EPERM)
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// sanitizeToolInput applies the sanitizers configured for the tool to its arguments.
+// Objects on the way to a sanitized field are copied rather than modified.
+func (m *ClientManager) sanitizeToolInput(server, toolName string, input any) any {
+	cfg, ok := m.getConfig(server)
+	if !ok {
+		return input
+	}
+	tool, ok := cfg.Tool(toolName)
+	if !ok {
+		return input
+	}
+	for _, s := range tool.Sanitize {
+		input = sanitizeField(input, splitField(s.Field), s)
+	}
+	return input
+}
+
+// splitField splits a dot-separated field path
+func splitField(field string) []string {
+	return strings.Split(field, ".")
+}
+
+// sanitizeField applies s to the value at path. Arrays along the path, and an array at the end of it,
+// are sanitized element by element. Missing fields are left alone.
+func sanitizeField(v any, path []string, s config.SanitizerConfig) any {
+	if items, ok := v.([]any); ok {
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = sanitizeField(item, path, s)
+		}
+		return out
+	}
+	if len(path) == 0 {
+		return sanitizeValue(v, s)
+	}
+
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	field, ok := obj[path[0]]
+	if !ok {
+		return v
+	}
+	out := make(map[string]any, len(obj))
+	for k, item := range obj {
+		out[k] = item
+	}
+	out[path[0]] = sanitizeField(field, path[1:], s)
+	return out
+}
+
+// sanitizeValue applies the string filters to strings and the clamp to numbers
+func sanitizeValue(v any, s config.SanitizerConfig) any {
+	switch val := v.(type) {
+	case string:
+		if s.StripControl {
+			val = strings.Map(func(r rune) rune {
+				if unicode.IsControl(r) {
+					return -1
+				}
+				return r
+			}, val)
+		}
+		if s.Trim {
+			val = strings.TrimSpace(val)
+		}
+		if s.Lowercase {
+			val = strings.ToLower(val)
+		}
+		return val
+	case float64:
+		return clamp(val, s.Clamp)
+	default:
+		return v
+	}
+}
+
+func clamp(f float64, c *config.ClampConfig) float64 {
+	if c == nil {
+		return f
+	}
+	if c.Min != nil && f < *c.Min {
+		return *c.Min
+	}
+	if c.Max != nil && f > *c.Max {
+		return *c.Max
+	}
+	return f
+}
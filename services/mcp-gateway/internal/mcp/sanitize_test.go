@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func float(f float64) *float64 { return &f }
+
+func TestSanitizeField(t *testing.T) {
+	tests := []struct {
+		name      string
+		sanitizer config.SanitizerConfig
+		input     any
+		want      any
+	}{
+		{
+			name:      "Trim and lowercase",
+			sanitizer: config.SanitizerConfig{Field: "city", Trim: true, Lowercase: true},
+			input:     map[string]any{"city": "  Tokyo\n", "other": " X "},
+			want:      map[string]any{"city": "tokyo", "other": " X "},
+		},
+		{
+			name:      "Strip control characters",
+			sanitizer: config.SanitizerConfig{Field: "query", StripControl: true},
+			input:     map[string]any{"query": "rm\x00 -rf\x1b[31m\tx\u200b"},
+			want:      map[string]any{"query": "rm -rf[31mx\u200b"},
+		},
+		{
+			name:      "Clamp",
+			sanitizer: config.SanitizerConfig{Field: "limit", Clamp: &config.ClampConfig{Min: float(1), Max: float(100)}},
+			input:     map[string]any{"limit": 500.0},
+			want:      map[string]any{"limit": 100.0},
+		},
+		{
+			name:      "Clamp lower bound only",
+			sanitizer: config.SanitizerConfig{Field: "limit", Clamp: &config.ClampConfig{Min: float(0)}},
+			input:     map[string]any{"limit": -3.0},
+			want:      map[string]any{"limit": 0.0},
+		},
+		{
+			name:      "Nested field",
+			sanitizer: config.SanitizerConfig{Field: "options.tag", Trim: true},
+			input:     map[string]any{"options": map[string]any{"tag": " a ", "keep": " b "}},
+			want:      map[string]any{"options": map[string]any{"tag": "a", "keep": " b "}},
+		},
+		{
+			name:      "Array elements",
+			sanitizer: config.SanitizerConfig{Field: "items.tag", Lowercase: true},
+			input:     map[string]any{"items": []any{map[string]any{"tag": "A"}, map[string]any{"tag": "B"}, "C"}},
+			want:      map[string]any{"items": []any{map[string]any{"tag": "a"}, map[string]any{"tag": "b"}, "C"}},
+		},
+		{
+			name:      "Array field",
+			sanitizer: config.SanitizerConfig{Field: "tags", Trim: true},
+			input:     map[string]any{"tags": []any{" a", "b "}},
+			want:      map[string]any{"tags": []any{"a", "b"}},
+		},
+		{
+			name:      "Mismatched types are left alone",
+			sanitizer: config.SanitizerConfig{Field: "limit", Trim: true, Clamp: &config.ClampConfig{Max: float(10)}},
+			input:     map[string]any{"limit": " 500 ", "other": 500.0},
+			want:      map[string]any{"limit": "500", "other": 500.0},
+		},
+		{
+			name:      "Missing field",
+			sanitizer: config.SanitizerConfig{Field: "options.tag", Trim: true},
+			input:     map[string]any{"options": "none"},
+			want:      map[string]any{"options": "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeField(tt.input, splitField(tt.sanitizer.Field), tt.sanitizer)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizeField_DoesNotModifyInput(t *testing.T) {
+	input := map[string]any{"options": map[string]any{"tag": " a "}}
+	sanitizeField(input, splitField("options.tag"), config.SanitizerConfig{Trim: true})
+	assert.Equal(t, " a ", input["options"].(map[string]any)["tag"])
+}
+
+func TestCallTool_SanitizesBeforeCoercing(t *testing.T) {
+	cm, session := newCoerceManager(config.ServerConfig{
+		Name:        "weather",
+		CoerceInput: true,
+		Tools: []config.ToolConfig{{
+			Name:     "forecast",
+			Sanitize: []config.SanitizerConfig{{Field: "days", Trim: true}, {Field: "city", Lowercase: true}},
+		}},
+	})
+	session.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+	_, err := cm.CallTool(context.Background(), "weather", "forecast", map[string]any{"days": " 3 ", "city": "Tokyo"})
+	require.NoError(t, err)
+
+	params := session.Calls[0].Arguments.Get(1).(*mcp.CallToolParams)
+	assert.Equal(t, map[string]any{"days": int64(3), "city": "tokyo"}, params.Arguments)
+}
+
+func TestCallTool_RawInput(t *testing.T) {
+	enabled := true
+	tests := []struct {
+		name  string
+		tools []config.ToolConfig
+		input json.RawMessage
+		want  any
+	}{
+		{
+			name:  "Passed on as sent",
+			input: json.RawMessage(`{"days": " 3 ", "city": "Tokyo"}`),
+			want:  json.RawMessage(`{"days": " 3 ", "city": "Tokyo"}`),
+		},
+		{
+			name:  "Decoded for sanitizers",
+			tools: []config.ToolConfig{{Name: "forecast", Sanitize: []config.SanitizerConfig{{Field: "city", Lowercase: true}}}},
+			input: json.RawMessage(`{"days": " 3 ", "city": "Tokyo"}`),
+			want:  map[string]any{"days": " 3 ", "city": "tokyo"},
+		},
+		{
+			name:  "Decoded for coercion",
+			tools: []config.ToolConfig{{Name: "forecast", CoerceInput: &enabled}},
+			input: json.RawMessage(`{"days": "3", "city": "Tokyo"}`),
+			want:  map[string]any{"days": int64(3), "city": "Tokyo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm, session := newCoerceManager(config.ServerConfig{Name: "weather", Tools: tt.tools})
+			session.On("CallTool", mock.Anything, mock.Anything).Return(&mcp.CallToolResult{}, nil)
+
+			_, err := cm.CallTool(context.Background(), "weather", "forecast", tt.input)
+			require.NoError(t, err)
+
+			params := session.Calls[0].Arguments.Get(1).(*mcp.CallToolParams)
+			assert.Equal(t, tt.want, params.Arguments)
+		})
+	}
+}
+
+func TestCallTool_RawInputMustBeObject(t *testing.T) {
+	cm, session := newCoerceManager(config.ServerConfig{Name: "weather"})
+
+	_, err := cm.CallTool(context.Background(), "weather", "forecast", json.RawMessage(` [1, 2]`))
+	assert.ErrorIs(t, err, mcpErrors.ErrInvalidInput)
+	session.AssertNotCalled(t, "CallTool", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// errServerInactive reports a call to a server outside its activeHours
+var errServerInactive = mcpErrors.ErrServerNotRunning.Wrap(errors.New("server is outside its active hours"))
+
+// deferStart holds back a server at gateway startup while it is outside its active hours, or until its
+// startDelayMs has passed, and reports whether it did. A server outside its active hours is started when
+// its next window opens, without a delay.
+func (m *ClientManager) deferStart(cfg config.ServerConfig) bool {
+	clk := m.processManager.Clock()
+	if hours := cfg.ActiveHours; hours != nil && !hours.Active(clk.Now()) {
+		if m.transition(cfg.Name, StatusInactive) {
+			slog.Info("Server outside its active hours, not starting", "server", cfg.Name, "until", hours.NextChange(clk.Now()))
+		}
+		return true
+	}
+	if cfg.StartDelayMs == 0 {
+		return false
+	}
+
+	delay := time.Duration(cfg.StartDelayMs) * time.Millisecond
+	slog.Info("Delaying server start", "server", cfg.Name, "delay_ms", cfg.StartDelayMs)
+	m.workers.Go("start-delay:"+cfg.Name, func(ctx context.Context) {
+		timer := clk.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+		}
+		if err := m.supervise(cfg.Name, func(ctx context.Context) { m.startHeldBack(ctx, cfg, StatusUnavailable) }); err != nil {
+			slog.Warn("Delayed server start not queued", "server", cfg.Name, "error", err)
+		}
+	})
+	return true
+}
+
+// startHeldBack starts a server held back in the given status by deferStart or deactivate, unless it was
+// stopped meanwhile. A server that fails to start is handled like a crash, so that its restart policy
+// applies. Runs on the server's supervisor.
+func (m *ClientManager) startHeldBack(ctx context.Context, cfg config.ServerConfig, from ServerStatus) {
+	if m.processManager.GetStatus(cfg.Name) != from {
+		return
+	}
+	slog.Info("Starting server", "server", cfg.Name, "from", from)
+	if err := m.connectClient(ctx, cfg); err != nil {
+		slog.Error("Failed to start server", "server", cfg.Name, "error", err)
+		m.handleCrash(ctx, cfg.Name)
+		return
+	}
+	m.resetHealthCheckState(cfg.Name)
+	m.StartHealthCheck(m.workers.ctx, cfg.Name)
+}
+
+// watchActiveHours starts and stops a server as the windows of its active hours open and close
+func (m *ClientManager) watchActiveHours(cfg config.ServerConfig) {
+	hours := *cfg.ActiveHours
+	clk := m.processManager.Clock()
+	m.workers.Go("active-hours:"+cfg.Name, func(ctx context.Context) {
+		for {
+			next := hours.NextChange(clk.Now())
+			if next.IsZero() {
+				return
+			}
+			timer := clk.NewTimer(next.Sub(clk.Now()))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+			if err := m.supervise(cfg.Name, func(ctx context.Context) { m.applyActiveHours(ctx, cfg) }); err != nil {
+				slog.Warn("Active hours change not queued", "server", cfg.Name, "error", err)
+			}
+		}
+	})
+}
+
+// applyActiveHours starts an inactive server whose window has opened, or stops a server whose window has
+// closed. Runs on the server's supervisor.
+func (m *ClientManager) applyActiveHours(ctx context.Context, cfg config.ServerConfig) {
+	if cfg.ActiveHours.Active(m.processManager.Clock().Now()) {
+		m.startHeldBack(ctx, cfg, StatusInactive)
+		return
+	}
+	m.deactivate(cfg.Name)
+}
+
+// deactivate stops a server at the end of its active hours and hides its tools. Calls in flight fail.
+// A stopped, quarantined or restarting server is left alone.
+func (m *ClientManager) deactivate(serverName string) {
+	// Under the wake lock, so that a call cannot respawn an idle server that is being deactivated
+	lock := m.wakeLock(serverName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if !m.transition(serverName, StatusInactive) {
+		return
+	}
+	m.stopHealthCheck(serverName)
+	m.teardownServer(serverName)
+	m.removeTools(serverName)
+	slog.Info("Server stopped outside its active hours", "server", serverName)
+}
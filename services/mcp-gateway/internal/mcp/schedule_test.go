@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// monday8am is a Monday, before the 09:00-17:00 business hours of the schedule tests
+var monday8am = time.Date(2000, 1, 3, 8, 0, 0, 0, time.UTC)
+
+// businessHours is open 09:00-17:00 UTC on weekdays
+var businessHours = &config.ActiveHoursConfig{
+	Timezone: "UTC",
+	Windows:  []config.ActiveWindowConfig{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"}},
+}
+
+// newScheduleTestManager prepares an in-process "calc" server with cfg on a fake clock, without starting it
+func newScheduleTestManager(t *testing.T, clk *clock.Fake, cfg config.ServerConfig) *ClientManager {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "calc", Version: "test"}, nil)
+	server.AddTool(&mcp.Tool{Name: "echo", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+		})
+
+	pm := NewProcessManager(30000, "never")
+	pm.SetClock(clk)
+	cm := NewClientManager(pm)
+	require.NoError(t, cm.RegisterInProcess("calc", server))
+	cfg.Name, cfg.Transport, cfg.Timeout = "calc", TransportInProcess, 30000
+	cm.configs = []config.ServerConfig{cfg}
+	t.Cleanup(func() { _ = cm.Close() })
+	return cm
+}
+
+func TestActiveHours_StartsAndStopsServer(t *testing.T) {
+	clk := clock.NewFake(monday8am)
+	cm := newScheduleTestManager(t, clk, config.ServerConfig{ActiveHours: businessHours})
+	cfg := cm.configs[0]
+
+	require.True(t, cm.deferStart(cfg))
+	assert.Equal(t, StatusInactive, cm.processManager.GetStatus("calc"))
+	_, err := cm.CallTool(context.Background(), "calc", "echo", map[string]any{})
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotRunning)
+	assert.ErrorContains(t, err, "outside its active hours")
+
+	cm.watchActiveHours(cfg)
+	require.Eventually(t, func() bool { return clk.Waiters() == 1 }, 2*time.Second, 10*time.Millisecond)
+	clk.Advance(time.Hour)
+	require.Eventually(t, func() bool { return cm.processManager.GetStatus("calc") == StatusAvailable },
+		2*time.Second, 10*time.Millisecond, "started when the window opens")
+	_, err = cm.CallTool(context.Background(), "calc", "echo", map[string]any{})
+	require.NoError(t, err)
+	_, listed := cm.GetToolInfo("calc", "echo")
+	assert.True(t, listed)
+
+	// The watcher and the health check wait on the clock
+	require.Eventually(t, func() bool { return clk.Waiters() == 2 }, 2*time.Second, 10*time.Millisecond)
+	clk.Advance(8 * time.Hour)
+	require.Eventually(t, func() bool { return cm.processManager.GetStatus("calc") == StatusInactive },
+		2*time.Second, 10*time.Millisecond, "stopped when the window closes")
+	// The status changes first, then the server is torn down and its tools are removed
+	assert.Eventually(t, func() bool {
+		_, listed := cm.GetToolInfo("calc", "echo")
+		return !listed
+	}, 2*time.Second, 10*time.Millisecond, "tools of inactive servers are hidden")
+	cm.mu.RLock()
+	_, hasSession := cm.sessions["calc"]
+	cm.mu.RUnlock()
+	assert.False(t, hasSession)
+}
+
+func TestDeactivate_LeavesStoppedServerAlone(t *testing.T) {
+	clk := clock.NewFake(monday8am)
+	cm := newScheduleTestManager(t, clk, config.ServerConfig{ActiveHours: businessHours})
+	cm.processManager.SetStatus("calc", StatusStopped)
+
+	cm.deactivate("calc")
+
+	assert.Equal(t, StatusStopped, cm.processManager.GetStatus("calc"))
+}
+
+func TestDeferStart_StartDelay(t *testing.T) {
+	clk := clock.NewFake(monday8am)
+	cm := newScheduleTestManager(t, clk, config.ServerConfig{StartDelayMs: 5000})
+
+	require.True(t, cm.deferStart(cm.configs[0]))
+	require.Eventually(t, func() bool { return clk.Waiters() == 1 }, 2*time.Second, 10*time.Millisecond)
+	clk.Advance(4 * time.Second)
+	assert.Equal(t, StatusUnavailable, cm.processManager.GetStatus("calc"))
+
+	clk.Advance(time.Second)
+	assert.Eventually(t, func() bool { return cm.processManager.GetStatus("calc") == StatusAvailable },
+		2*time.Second, 10*time.Millisecond)
+}
+
+func TestDeferStart_NotDeferred(t *testing.T) {
+	clk := clock.NewFake(monday8am.Add(2 * time.Hour))
+	cm := newScheduleTestManager(t, clk, config.ServerConfig{ActiveHours: businessHours})
+
+	assert.False(t, cm.deferStart(cm.configs[0]), "servers within their active hours start right away")
+	assert.Equal(t, StatusUnavailable, cm.processManager.GetStatus("calc"))
+}
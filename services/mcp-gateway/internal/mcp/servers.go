@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"cmp"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+// ServerInfo is the operational status of a server, for dashboards
+type ServerInfo struct {
+	Name              string       `json:"name"`
+	Status            ServerStatus `json:"status"`
+	Uptime            float64      `json:"uptime"`          // seconds since the current session connected, 0 while not serving
+	RestartAttempts   int          `json:"restartAttempts"` // since the server last recovered
+	LastHealthCheckAt time.Time    `json:"lastHealthCheckAt,omitzero"`
+	ToolCount         int          `json:"toolCount"`
+	Timeout           int          `json:"timeout"` // configured tool call timeout in ms
+}
+
+// ServerDetail is everything known about one server, for debugging it without the gateway's logs
+type ServerDetail struct {
+	ServerInfo
+	PID         int        `json:"pid,omitempty"`       // of the local process; the docker or ssh client for those runtimes
+	StartedAt   time.Time  `json:"startedAt,omitzero"`  // when the current session connected
+	Transport   string     `json:"transport"`           // stdio, sse, streamable-http, tcp, unix, wasm or in-process
+	Runtime     string     `json:"runtime,omitempty"`   // process, docker or ssh, for stdio
+	Command     []string   `json:"command,omitempty"`   // with secrets redacted, for stdio
+	Endpoint    string     `json:"endpoint,omitempty"`  // URL with secrets redacted, address or socket path, for remote servers
+	LastError   string     `json:"lastError,omitempty"` // the most recent of the failure, connection and canary errors
+	LastErrorAt time.Time  `json:"lastErrorAt,omitzero"`
+	Tools       []ToolInfo `json:"tools"`
+}
+
+// Servers returns the status of every server, in the order they are configured
+func (m *ClientManager) Servers() []ServerInfo {
+	now := m.processManager.Clock().Now()
+
+	m.mu.RLock()
+	toolCounts := make(map[string]int, len(m.configs))
+	for _, tool := range m.toolsCache {
+		toolCounts[tool.Server]++
+	}
+	servers := make([]ServerInfo, len(m.configs))
+	states := make([]*HealthCheckState, len(m.configs))
+	for i, cfg := range m.configs {
+		servers[i] = m.serverInfoLocked(cfg, now, toolCounts[cfg.Name])
+		states[i] = m.healthCheckStates[cfg.Name]
+	}
+	m.mu.RUnlock()
+
+	for i, state := range states {
+		servers[i].LastHealthCheckAt = lastHealthCheck(state)
+	}
+	return servers
+}
+
+// ServerDetail returns everything known about a server
+func (m *ClientManager) ServerDetail(serverName string) (ServerDetail, error) {
+	now := m.processManager.Clock().Now()
+
+	m.mu.RLock()
+	cfg, ok := m.getConfig(serverName)
+	if !ok {
+		m.mu.RUnlock()
+		return ServerDetail{}, mcpErrors.ErrServerNotFound
+	}
+	available := m.processManager.GetStatus(serverName).Callable()
+	tools := make([]ToolInfo, 0)
+	for _, tool := range m.toolsCache {
+		if tool.Server == serverName {
+			tool.Available = available
+			tool.Feedback = m.feedbackLocked(serverName, tool.Name)
+			tools = append(tools, tool)
+		}
+	}
+	detail := ServerDetail{
+		ServerInfo: m.serverInfoLocked(cfg, now, len(tools)),
+		Transport:  cmp.Or(cfg.Transport, config.TransportStdio),
+		Tools:      tools,
+	}
+	if detail.Uptime > 0 {
+		detail.StartedAt = m.connectedAt[serverName]
+	}
+	if cmd, ok := m.processes[serverName]; ok && cmd.Process != nil {
+		detail.PID = cmd.Process.Pid
+	}
+	state := m.healthCheckStates[serverName]
+	m.mu.RUnlock()
+
+	detail.LastHealthCheckAt = lastHealthCheck(state)
+	slices.SortFunc(detail.Tools, func(a, b ToolInfo) int { return cmp.Compare(a.Name, b.Name) })
+
+	switch detail.Transport {
+	case config.TransportStdio:
+		detail.Runtime = cmp.Or(cfg.Runtime, config.RuntimeProcess)
+		detail.Command = sanitizeCommand(cfg)
+	case config.TransportSSE, config.TransportStreamableHTTP:
+		detail.Endpoint = redactURL(cfg.URL)
+	case config.TransportTCP:
+		detail.Endpoint = cfg.Address
+	case config.TransportUnix:
+		detail.Endpoint = cfg.SocketPath
+	}
+
+	diag := m.processManager.GetDiagnostics(serverName)
+	for _, e := range []struct {
+		message string
+		at      time.Time
+	}{
+		{diag.LastError, diag.LastFailureAt},
+		{diag.LastConnectError, diag.LastConnectErrorAt},
+		{diag.LastCanaryError, diag.LastCanaryAt},
+	} {
+		if e.message != "" && !e.at.Before(detail.LastErrorAt) {
+			detail.LastError, detail.LastErrorAt = e.message, e.at
+		}
+	}
+	return detail, nil
+}
+
+// serverInfoLocked returns the status of a server, except for its last health check
+func (m *ClientManager) serverInfoLocked(cfg config.ServerConfig, now time.Time, toolCount int) ServerInfo {
+	info := ServerInfo{
+		Name:            cfg.Name,
+		Status:          m.processManager.GetStatus(cfg.Name),
+		RestartAttempts: m.processManager.GetRestartAttempts(cfg.Name),
+		ToolCount:       toolCount,
+		Timeout:         cfg.Timeout,
+	}
+	// A crashed server's session lingers until its restart replaces it
+	if connected, ok := m.connectedAt[cfg.Name]; ok && info.Status.Serving() {
+		info.Uptime = now.Sub(connected).Seconds()
+	}
+	return info
+}
+
+// lastHealthCheck returns when a health check state was last updated. Health check states have their
+// own locks, taken after m.mu is released as in resetHealthCheckState.
+func lastHealthCheck(state *HealthCheckState) time.Time {
+	if state == nil {
+		return time.Time{}
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.lastCheckTime
+}
+
+// redactedValue replaces secrets, as in url.URL.Redacted
+const redactedValue = "xxxxx"
+
+// secretName matches the names of flags, variables and query parameters whose values are secrets
+var secretName = regexp.MustCompile(`(?i)token|secret|passw(or)?d|pwd|(^|[-_])key$|api[-_]?key|auth|credential|signature`)
+
+// sanitizeCommand returns a server's command line with secrets redacted: the values of flags such as
+// --api-key, whether given as --api-key=value or --api-key value, NAME=value arguments with such names,
+// credentials in URLs, and the values of the server's secret environment variables wherever they appear
+func sanitizeCommand(cfg config.ServerConfig) []string {
+	if cfg.Command == "" {
+		return nil
+	}
+	var secrets []string
+	for _, env := range cfg.Envs {
+		if env.Value != "" && secretName.MatchString(env.Name) {
+			secrets = append(secrets, env.Value)
+		}
+	}
+
+	command := make([]string, 0, 1+len(cfg.Args))
+	secretFlag := false
+	for _, arg := range append([]string{cfg.Command}, cfg.Args...) {
+		for _, secret := range secrets {
+			arg = strings.ReplaceAll(arg, secret, redactedValue)
+		}
+		switch name, _, hasValue := strings.Cut(arg, "="); {
+		case secretFlag && !strings.HasPrefix(arg, "-"):
+			arg = redactedValue
+		case strings.Contains(arg, "://"):
+			arg = redactURL(arg)
+		case hasValue && secretName.MatchString(name):
+			arg = name + "=" + redactedValue
+		}
+		secretFlag = strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && secretName.MatchString(arg)
+		command = append(command, arg)
+	}
+	return command
+}
+
+// redactURL redacts the credentials and secret query parameters of a URL. Text that is not a URL is
+// returned as is.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redactedValue)
+		} else {
+			// A lone user name is often a token, as in https://TOKEN@github.com
+			u.User = url.User(redactedValue)
+		}
+	}
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		if secretName.MatchString(name) {
+			query[name] = []string{redactedValue}
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
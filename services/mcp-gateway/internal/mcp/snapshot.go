@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// CounterSnapshot is the persisted form of the cumulative per-server counters
+type CounterSnapshot struct {
+	SavedAt time.Time                 `json:"savedAt"`
+	Servers map[string]ServerCounters `json:"servers"`
+}
+
+// ServerCounters are the counters of a server that survive gateway restarts
+type ServerCounters struct {
+	Calls    uint64                      `json:"calls"`
+	Errors   uint64                      `json:"errors"`
+	Restarts uint64                      `json:"restarts"`
+	Feedback map[string]FeedbackCounters `json:"feedback,omitempty"` // by tool name
+}
+
+// FeedbackCounters are the feedback reports on a tool that survive gateway restarts
+type FeedbackCounters struct {
+	Satisfied   uint64 `json:"satisfied"`
+	Unsatisfied uint64 `json:"unsatisfied"`
+}
+
+// SnapshotCounters captures the current cumulative counters of every server
+func (m *ClientManager) SnapshotCounters() CounterSnapshot {
+	snap := CounterSnapshot{SavedAt: time.Now().UTC(), Servers: make(map[string]ServerCounters)}
+	for name, s := range m.GetCallStats() {
+		snap.Servers[name] = ServerCounters{Calls: s.Calls, Errors: s.Errors}
+	}
+	for name, d := range m.processManager.GetAllDiagnostics() {
+		c := snap.Servers[name]
+		c.Restarts = d.Restarts
+		snap.Servers[name] = c
+	}
+	m.mu.RLock()
+	for _, f := range m.feedback {
+		c := snap.Servers[f.server]
+		if c.Feedback == nil {
+			c.Feedback = make(map[string]FeedbackCounters)
+		}
+		c.Feedback[f.tool] = FeedbackCounters{Satisfied: f.satisfied, Unsatisfied: f.unsatisfied}
+		snap.Servers[f.server] = c
+	}
+	m.mu.RUnlock()
+	return snap
+}
+
+// RestoreCounters adds the counters of a snapshot to the current ones.
+// Servers that are no longer configured are ignored.
+func (m *ClientManager) RestoreCounters(snap CounterSnapshot) {
+	m.mu.Lock()
+	for name, c := range snap.Servers {
+		if _, ok := m.getConfig(name); !ok {
+			continue
+		}
+		s := m.statsLocked(name)
+		s.calls += c.Calls
+		s.errors += c.Errors
+		// Kept even for tools the server no longer lists, in case they come back
+		for tool, f := range c.Feedback {
+			counts := m.feedbackCountsLocked(name, tool)
+			counts.satisfied += f.Satisfied
+			counts.unsatisfied += f.Unsatisfied
+		}
+	}
+	m.mu.Unlock()
+
+	for name, c := range snap.Servers {
+		if _, ok := m.getConfig(name); ok {
+			m.processManager.addRestarts(name, c.Restarts)
+		}
+	}
+}
+
+// LoadCounterSnapshot reads a snapshot written by SaveCounterSnapshot. A missing file yields an empty snapshot.
+func LoadCounterSnapshot(path string) (CounterSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return CounterSnapshot{}, nil
+	}
+	if err != nil {
+		return CounterSnapshot{}, fmt.Errorf("failed to read metrics snapshot: %w", err)
+	}
+
+	var snap CounterSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return CounterSnapshot{}, fmt.Errorf("failed to parse metrics snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// SaveCounterSnapshot writes a snapshot atomically so that a crash never leaves a partial file
+func SaveCounterSnapshot(path string, snap CounterSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics snapshot: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write metrics snapshot: %w", err)
+	}
+	return nil
+}
+
+// StartCounterSnapshots periodically saves the counters to path until Close, which triggers a final save
+func (m *ClientManager) StartCounterSnapshots(path string, interval time.Duration) {
+	m.workers.Go("metrics-snapshot", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		save := func() {
+			if err := SaveCounterSnapshot(path, m.SnapshotCounters()); err != nil {
+				slog.Warn("Failed to save metrics snapshot", "path", path, "error", err)
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				save()
+				return
+			case <-ticker.C:
+				save()
+			}
+		}
+	})
+}
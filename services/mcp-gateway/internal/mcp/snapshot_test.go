@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterSnapshot_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "weather"}}
+	cm.recordCall("weather", 10*time.Millisecond, false)
+	cm.recordCall("weather", 20*time.Millisecond, true)
+	pm.SetStatus("weather", StatusCrashed)
+	pm.RecordRestart("weather", RestartReasonHealthCheck)
+
+	require.NoError(t, SaveCounterSnapshot(path, cm.SnapshotCounters()))
+
+	// A new gateway run starts from the saved counters
+	pm2 := NewProcessManager(30000, "never")
+	cm2 := NewClientManager(pm2)
+	cm2.configs = []config.ServerConfig{{Name: "weather"}}
+	snap, err := LoadCounterSnapshot(path)
+	require.NoError(t, err)
+	cm2.RestoreCounters(snap)
+	cm2.recordCall("weather", 40*time.Millisecond, false)
+
+	stats := cm2.GetCallStats()["weather"]
+	assert.Equal(t, uint64(3), stats.Calls)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, 40*time.Millisecond, stats.EWMALatency, "restored counters carry no latency")
+	assert.Equal(t, uint64(1), pm2.GetDiagnostics("weather").Restarts)
+}
+
+func TestRestoreCounters_IgnoresUnknownServers(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "weather"}}
+
+	cm.RestoreCounters(CounterSnapshot{Servers: map[string]ServerCounters{
+		"removed": {Calls: 5, Restarts: 1},
+	}})
+
+	assert.Empty(t, cm.GetCallStats())
+	assert.Zero(t, pm.GetDiagnostics("removed").Restarts)
+}
+
+func TestLoadCounterSnapshot_Missing(t *testing.T) {
+	snap, err := LoadCounterSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+
+	require.NoError(t, err)
+	assert.Empty(t, snap.Servers)
+}
+
+func TestLoadCounterSnapshot_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	_, err := LoadCounterSnapshot(path)
+
+	assert.Error(t, err)
+}
+
+func TestStartCounterSnapshots_SavesOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{{Name: "weather"}}
+
+	cm.StartCounterSnapshots(path, time.Hour)
+	cm.recordCall("weather", time.Millisecond, false)
+	require.NoError(t, cm.Close())
+
+	snap, err := LoadCounterSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), snap.Servers["weather"].Calls)
+}
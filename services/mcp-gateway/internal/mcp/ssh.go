@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+)
+
+// sshCommand is the OpenSSH client used to run servers on remote hosts
+var sshCommand = "ssh"
+
+// sshClientEnvVars are inherited by the ssh client in addition to safeEnvVars so that keys
+// held by an agent can be used
+var sshClientEnvVars = []string{"SSH_AUTH_SOCK"}
+
+// sshCLITimeout bounds the ssh invocations that stop remote servers
+const sshCLITimeout = 15 * time.Second
+
+// remotePIDFile returns where the remote shell records a server's PID so that a later
+// ssh session can terminate it. It includes the gateway's host name and PID so that
+// several gateways sharing a remote host do not collide.
+func remotePIDFile(serverName string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("/tmp/mcp-gateway-%s-%d-%s.pid", host, os.Getpid(), serverName)
+}
+
+// sshArgs returns the ssh client arguments that select the server's remote host
+func sshArgs(cfg config.ServerConfig) []string {
+	args := []string{"-T", "-o", "BatchMode=yes"}
+	if cfg.SSH.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(cfg.SSH.Port))
+	}
+	if cfg.SSH.KeyPath != "" {
+		args = append(args, "-i", cfg.SSH.KeyPath)
+	}
+	if cfg.SSH.User != "" {
+		args = append(args, "-l", cfg.SSH.User)
+	}
+	return append(args, "--", cfg.SSH.Host)
+}
+
+// newSSHCommand creates an ssh command that runs the server on its remote host with stdio
+// piped over the SSH channel. The remote shell records its PID before exec'ing the server.
+func newSSHCommand(cfg config.ServerConfig) *exec.Cmd {
+	words := []string{"exec", "env"}
+	for _, e := range forwardedEnvs(cfg) {
+		words = append(words, shellQuote(e.Name+"="+e.Value))
+	}
+	words = append(words, shellQuote(cfg.Command))
+	for _, a := range cfg.Args {
+		words = append(words, shellQuote(a))
+	}
+	steps := []string{"echo $$ > " + shellQuote(remotePIDFile(cfg.Name))}
+	if cfg.WorkDir != "" {
+		steps = append(steps, "cd "+shellQuote(cfg.WorkDir))
+	}
+	script := strings.Join(append(steps, strings.Join(words, " ")), " && ")
+
+	cmd := exec.Command(sshCommand, append(sshArgs(cfg), remoteShell(script))...)
+	cmd.Env = inheritedEnv(slices.Concat(safeEnvVars, sshClientEnvVars))
+	return cmd
+}
+
+// stopRemoteProcess sends SIGTERM to a server's remote process, gives it grace to exit and
+// then kills it. Closing the local ssh client alone does not reliably end the remote process.
+func stopRemoteProcess(cfg config.ServerConfig, grace time.Duration) error {
+	script := fmt.Sprintf(`f=%s; pid=$(cat "$f" 2>/dev/null) || exit 0; kill -TERM "$pid" 2>/dev/null; `+
+		`i=0; while kill -0 "$pid" 2>/dev/null && [ "$i" -lt %d ]; do sleep 1; i=$((i+1)); done; `+
+		`kill -KILL "$pid" 2>/dev/null; rm -f "$f"`,
+		shellQuote(remotePIDFile(cfg.Name)), int(grace/time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace+sshCLITimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, sshCommand, append(sshArgs(cfg), remoteShell(script))...)
+	cmd.Env = inheritedEnv(slices.Concat(safeEnvVars, sshClientEnvVars))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ssh stop: %w: %s", err, out)
+	}
+	return nil
+}
+
+// killRemoteProcess kills a server's remote process without waiting for it to exit
+func killRemoteProcess(cfg config.ServerConfig) error {
+	return stopRemoteProcess(cfg, 0)
+}
+
+// remoteShell runs script with sh regardless of the remote user's login shell
+func remoteShell(script string) string {
+	return "sh -c " + shellQuote(script)
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
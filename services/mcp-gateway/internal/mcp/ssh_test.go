@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSSH replaces the ssh client with a script that appends its arguments to a log file
+// and runs the remote command locally, as if the remote host were this machine
+func fakeSSH(t *testing.T) (logPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath = filepath.Join(dir, "ssh.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\nfor a; do last=$a; done\nexec sh -c \"$last\"\n"
+	path := filepath.Join(dir, "ssh")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+
+	orig := sshCommand
+	sshCommand = path
+	t.Cleanup(func() { sshCommand = orig })
+	return logPath
+}
+
+func sshServerConfig(command string, args ...string) config.ServerConfig {
+	return config.ServerConfig{
+		Name:    "search",
+		Command: command,
+		Args:    args,
+		Runtime: config.RuntimeSSH,
+		SSH: &config.SSHConfig{
+			Host:    "build-01.internal",
+			User:    "mcp",
+			Port:    2222,
+			KeyPath: "/run/secrets/mcp_ed25519",
+		},
+		Envs: []config.EnvVar{{Name: "GREETING", Value: "it's here"}},
+	}
+}
+
+// startSSHServer starts cfg through the fake ssh client and waits for the remote PID file
+func startSSHServer(t *testing.T, cfg config.ServerConfig) *exec.Cmd {
+	t.Helper()
+	cmd := newServerCommand(cfg)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = os.Remove(remotePIDFile(cfg.Name))
+	})
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(remotePIDFile(cfg.Name))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return cmd
+}
+
+func TestNewServerCommand_SSH(t *testing.T) {
+	cmd := newServerCommand(sshServerConfig("/opt/mcp/search-server", "--index", "/var/lib/search"))
+
+	require.Greater(t, len(cmd.Args), 1)
+	assert.Equal(t, []string{
+		sshCommand, "-T", "-o", "BatchMode=yes",
+		"-p", "2222", "-i", "/run/secrets/mcp_ed25519", "-l", "mcp",
+		"--", "build-01.internal",
+	}, cmd.Args[:len(cmd.Args)-1])
+	remote := cmd.Args[len(cmd.Args)-1]
+	assert.True(t, strings.HasPrefix(remote, "sh -c "))
+	assert.Contains(t, remote, "/opt/mcp/search-server")
+}
+
+func TestNewServerCommand_SSHRunsRemoteCommand(t *testing.T) {
+	fakeSSH(t)
+	cmd := newServerCommand(sshServerConfig("sh", "-c", `printf '%s|%s' "$GREETING" "$1"`, "sh", "a b"))
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	// Envs and args survive quoting through the remote shell
+	assert.Equal(t, "it's here|a b", string(out))
+}
+
+func TestTeardownServer_KillsRemoteProcess(t *testing.T) {
+	logPath := fakeSSH(t)
+	cfg := sshServerConfig("sleep", "30")
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{cfg}
+
+	cmd := startSSHServer(t, cfg)
+	cm.processes["search"] = cmd
+
+	cm.teardownServer("search")
+
+	_ = cmd.Wait()
+	assert.NoFileExists(t, remotePIDFile("search"))
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(log), "build-01.internal"), "one session runs the server, one kills it")
+}
+
+func TestClose_StopsRemoteProcess(t *testing.T) {
+	fakeSSH(t)
+	cfg := sshServerConfig("sleep", "30")
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cm.configs = []config.ServerConfig{cfg}
+
+	cmd := startSSHServer(t, cfg)
+	cm.processes["search"] = cmd
+
+	start := time.Now()
+	require.NoError(t, cm.Close())
+
+	// The remote process exits on SIGTERM, so Close does not wait out the grace period
+	assert.Less(t, time.Since(start), 4*time.Second)
+	assert.NotNil(t, cmd.ProcessState, "the ssh client process should have been reaped")
+	assert.NoFileExists(t, remotePIDFile("search"))
+}
+
+func TestStopRemoteProcess_NoPIDFile(t *testing.T) {
+	fakeSSH(t)
+	assert.NoError(t, stopRemoteProcess(sshServerConfig("sleep", "30"), time.Second))
+}
+
+func TestNewServerCommand_SSHWorkDir(t *testing.T) {
+	fakeSSH(t)
+	dir := t.TempDir()
+	cfg := sshServerConfig("pwd")
+	cfg.WorkDir = dir
+
+	out, err := newServerCommand(cfg).Output()
+	require.NoError(t, err)
+	assert.Equal(t, dir+"\n", string(out))
+}
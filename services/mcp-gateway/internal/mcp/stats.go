@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"time"
+)
+
+// ewmaAlpha weights the latest call latency in the moving average
+const ewmaAlpha = 0.3
+
+// callStats tracks tool call load and latency for a server. Guarded by ClientManager.mu.
+type callStats struct {
+	inFlight   int
+	ewma       time.Duration
+	calls      uint64
+	errors     uint64
+	lastActive time.Time // when a call last started or ended, or the server connected; for idleTimeout
+}
+
+// ServerCallStats is a snapshot of the tool call statistics of a server
+type ServerCallStats struct {
+	InFlight    int           `json:"inFlight"`
+	EWMALatency time.Duration `json:"ewmaLatency"`
+	Calls       uint64        `json:"calls"`
+	Errors      uint64        `json:"errors"`
+}
+
+// observe folds a completed call into the statistics
+func (s *callStats) observe(latency time.Duration, failed bool) {
+	s.calls++
+	if failed {
+		s.errors++
+	}
+	// The first measurement seeds the average; counters restored from a snapshot carry no latency
+	if s.ewma == 0 {
+		s.ewma = latency
+		return
+	}
+	s.ewma = time.Duration(ewmaAlpha*float64(latency) + (1-ewmaAlpha)*float64(s.ewma))
+}
+
+// score estimates how long a new call would wait on the server; lower is better.
+// Servers without measurements score zero so that they receive traffic and get measured.
+func (s *callStats) score() float64 {
+	if s == nil {
+		return 0
+	}
+	return float64(s.ewma) * float64(s.inFlight+1)
+}
+
+// statsLocked returns the stats entry for a server, creating it if needed. Callers must hold m.mu.
+func (m *ClientManager) statsLocked(name string) *callStats {
+	s, ok := m.callStats[name]
+	if !ok {
+		s = &callStats{}
+		m.callStats[name] = s
+	}
+	return s
+}
+
+// recordCall records the latency and outcome of a completed tool call
+func (m *ClientManager) recordCall(name string, latency time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statsLocked(name).observe(latency, failed)
+}
+
+// GetCallStats returns a snapshot of tool call statistics keyed by server name
+func (m *ClientManager) GetCallStats() map[string]ServerCallStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make(map[string]ServerCallStats, len(m.callStats))
+	for name, s := range m.callStats {
+		out[name] = ServerCallStats{
+			InFlight:    s.inFlight,
+			EWMALatency: s.ewma,
+			Calls:       s.calls,
+			Errors:      s.errors,
+		}
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"time"
+
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+)
+
+const (
+	// MaxLogLines is the number of stderr lines kept per server
+	MaxLogLines = 1000
+	// maxLogLineSize truncates longer lines, e.g. binary output written to stderr
+	maxLogLineSize = 4096
+	// stderrWaitDelay bounds how long waiting for a server blocks on children that keep its stderr open
+	stderrWaitDelay = 2 * time.Second
+	// followBuffer is how far a follower may fall behind before it is dropped
+	followBuffer = 256
+)
+
+// LogLine is a line a server wrote to stderr
+type LogLine struct {
+	Time time.Time `json:"time"`
+	Line string    `json:"line"`
+}
+
+// serverLog logs the stderr of a server with the server's name and keeps its last lines.
+// It lives as long as the manager, so that the output of a crashed process survives its restart.
+type serverLog struct {
+	server  string
+	mu      sync.Mutex
+	lines   []LogLine // ring buffer of up to MaxLogLines
+	next    int       // where the next line goes once the buffer is full
+	partial []byte    // output after the last newline
+	follows map[chan LogLine]struct{}
+}
+
+// Write splits the output into lines. exec copies stderr to it from a single goroutine per process,
+// but a restarted process may briefly overlap with the previous one.
+func (l *serverLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data := append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		l.addLocked(data[:i])
+		data = data[i+1:]
+	}
+	if len(data) > maxLogLineSize {
+		l.addLocked(data)
+		data = nil
+	}
+	l.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// addLocked records a complete line. Callers must hold l.mu.
+func (l *serverLog) addLocked(raw []byte) {
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	if len(raw) > maxLogLineSize {
+		raw = raw[:maxLogLineSize]
+	}
+	line := LogLine{Time: time.Now(), Line: string(bytes.ToValidUTF8(raw, []byte("�")))}
+	slog.Info("MCP Server stderr", "server", l.server, "line", line.Line)
+
+	for ch := range l.follows {
+		select {
+		case ch <- line:
+		default:
+			// Never block the server on a slow reader; it learns from the closed channel
+			delete(l.follows, ch)
+			close(ch)
+		}
+	}
+
+	if len(l.lines) < MaxLogLines {
+		l.lines = append(l.lines, line)
+		return
+	}
+	l.lines[l.next] = line
+	l.next = (l.next + 1) % MaxLogLines
+}
+
+// follow returns up to n of the most recent lines and a channel receiving every line after them,
+// until unfollow is called or the reader falls followBuffer lines behind
+func (l *serverLog) follow(n int) (recent []LogLine, lines <-chan LogLine, unfollow func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent = l.lastLocked(n)
+	ch := make(chan LogLine, followBuffer)
+	if l.follows == nil {
+		l.follows = make(map[chan LogLine]struct{})
+	}
+	l.follows[ch] = struct{}{}
+	return recent, ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.follows[ch]; ok {
+			delete(l.follows, ch)
+			close(ch)
+		}
+	}
+}
+
+// last returns up to n of the most recent lines, oldest first
+func (l *serverLog) last(n int) []LogLine {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastLocked(n)
+}
+
+// lastLocked is last for callers holding l.mu
+func (l *serverLog) lastLocked(n int) []LogLine {
+	ordered := append(append(make([]LogLine, 0, len(l.lines)), l.lines[l.next:]...), l.lines[:l.next]...)
+	if n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// serverLogLocked returns the stderr log of a server, creating it if needed. Callers must hold m.mu.
+func (m *ClientManager) serverLogLocked(serverName string) *serverLog {
+	log, ok := m.logs[serverName]
+	if !ok {
+		log = &serverLog{server: serverName}
+		m.logs[serverName] = log
+	}
+	return log
+}
+
+// ServerLogs returns up to n of the most recent lines the server's processes wrote to stderr,
+// oldest first. Servers without a process, such as remote ones, have no lines.
+func (m *ClientManager) ServerLogs(serverName string, n int) ([]LogLine, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.getConfig(serverName); !ok {
+		return nil, mcpErrors.ErrServerNotFound
+	}
+	log, ok := m.logs[serverName]
+	if !ok {
+		return []LogLine{}, nil
+	}
+	return log.last(n), nil
+}
+
+// FollowServerLogs returns up to n of the most recent stderr lines of a server like ServerLogs, and a channel
+// receiving the lines written after them, including those of restarted processes. The channel is closed by
+// unfollow, which must be called, or when the reader falls too far behind.
+func (m *ClientManager) FollowServerLogs(serverName string, n int) (recent []LogLine, lines <-chan LogLine, unfollow func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.getConfig(serverName); !ok {
+		return nil, nil, nil, mcpErrors.ErrServerNotFound
+	}
+	recent, lines, unfollow = m.serverLogLocked(serverName).follow(n)
+	return recent, lines, unfollow, nil
+}
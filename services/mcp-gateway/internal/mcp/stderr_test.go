@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	mcpErrors "github.com/khirotaka/restexec/services/mcp-gateway/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logText(lines []LogLine) []string {
+	text := make([]string, len(lines))
+	for i, l := range lines {
+		text[i] = l.Line
+	}
+	return text
+}
+
+func TestServerLog_SplitsLines(t *testing.T) {
+	log := &serverLog{server: "weather"}
+
+	_, _ = log.Write([]byte("starting\nlisten"))
+	_, _ = log.Write([]byte("ing on stdio\r\n"))
+	_, _ = log.Write([]byte("no newline yet"))
+
+	assert.Equal(t, []string{"starting", "listening on stdio"}, logText(log.last(10)))
+	assert.Equal(t, []string{"listening on stdio"}, logText(log.last(1)))
+
+	_, _ = log.Write([]byte(strings.Repeat("x", maxLogLineSize+10)))
+	lines := log.last(1)
+	require.Len(t, lines, 1)
+	assert.Len(t, lines[0].Line, maxLogLineSize, "long lines are truncated rather than buffered")
+}
+
+func TestServerLog_KeepsLastLines(t *testing.T) {
+	log := &serverLog{server: "weather"}
+	for i := range MaxLogLines + 5 {
+		_, _ = fmt.Fprintf(log, "line %d\n", i)
+	}
+
+	lines := logText(log.last(MaxLogLines + 100))
+	require.Len(t, lines, MaxLogLines)
+	assert.Equal(t, "line 5", lines[0])
+	assert.Equal(t, fmt.Sprintf("line %d", MaxLogLines+4), lines[len(lines)-1])
+	assert.Equal(t, []string{"line 1003", "line 1004"}, logText(log.last(2)))
+}
+
+func TestServerLog_Follow(t *testing.T) {
+	log := &serverLog{server: "weather"}
+	_, _ = log.Write([]byte("old 1\nold 2\n"))
+
+	recent, lines, unfollow := log.follow(1)
+	assert.Equal(t, []string{"old 2"}, logText(recent))
+
+	_, _ = log.Write([]byte("new\n"))
+	select {
+	case line := <-lines:
+		assert.Equal(t, "new", line.Line)
+	case <-time.After(time.Second):
+		t.Fatal("followed line not received")
+	}
+
+	unfollow()
+	_, ok := <-lines
+	assert.False(t, ok, "unfollow closes the channel")
+	unfollow()
+}
+
+func TestServerLog_FollowDropsSlowReader(t *testing.T) {
+	log := &serverLog{server: "weather"}
+	_, lines, unfollow := log.follow(0)
+	defer unfollow()
+
+	for i := range followBuffer + 1 {
+		_, _ = fmt.Fprintf(log, "line %d\n", i)
+	}
+
+	received := 0
+	for range lines {
+		received++
+	}
+	assert.Equal(t, followBuffer, received, "the channel is closed once the buffer overflows")
+	assert.Len(t, log.last(MaxLogLines), followBuffer+1, "lines are still kept")
+}
+
+func TestServerLogs_CapturesCrashOutput(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+	t.Cleanup(func() { _ = cm.Close() })
+
+	require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{{
+		Name:    "crasher",
+		Command: "sh",
+		Args:    []string{"-c", "echo 'error: API_KEY is not set' >&2; exit 1"},
+		Timeout: 30000,
+	}}))
+
+	assert.Eventually(t, func() bool {
+		lines, err := cm.ServerLogs("crasher", 10)
+		return err == nil && len(lines) == 1 && lines[0].Line == "error: API_KEY is not set"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err := cm.ServerLogs("missing", 10)
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotFound)
+
+	_, _, _, err = cm.FollowServerLogs("missing", 10)
+	assert.ErrorIs(t, err, mcpErrors.ErrServerNotFound)
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+)
+
+// supervisorQueue is how many lifecycle jobs of a server may wait for its supervisor
+const supervisorQueue = 16
+
+var (
+	errRestartNotAllowed = errors.New("restart policy does not allow restart")
+	errRestartsExhausted = errors.New("max restart attempts reached")
+	errManagerClosed     = errors.New("client manager is closed")
+	errSupervisorBusy    = errors.New("too many pending lifecycle jobs")
+)
+
+// supervisor owns the restarts of one server. Crash notices, automatic and manual restarts of the server
+// run one at a time on its goroutine, so that a health check, the connection monitor and the admin API
+// cannot restart the same server concurrently.
+type supervisor struct {
+	jobs chan func(ctx context.Context)
+}
+
+// supervise queues a lifecycle job on the supervisor of a server, starting the supervisor if needed.
+// Jobs run with the manager's context, which is cancelled on Close.
+func (m *ClientManager) supervise(serverName string, job func(ctx context.Context)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.supervisors[serverName]
+	if !ok {
+		s = &supervisor{jobs: make(chan func(context.Context), supervisorQueue)}
+		if !m.workers.Go("supervisor:"+serverName, func(ctx context.Context) { m.runSupervisor(ctx, serverName, s) }) {
+			return errManagerClosed
+		}
+		m.supervisors[serverName] = s
+	}
+	// Sent under m.mu, so that a supervisor that unregistered itself receives no more jobs
+	select {
+	case s.jobs <- job:
+		return nil
+	default:
+		return errSupervisorBusy
+	}
+}
+
+// runSupervisor runs the jobs of a server until the manager is closed. Jobs queued by then still run,
+// with the cancelled context, so that they put their server back into a settled status.
+func (m *ClientManager) runSupervisor(ctx context.Context, serverName string, s *supervisor) {
+	for {
+		select {
+		case job := <-s.jobs:
+			job(ctx)
+		case <-ctx.Done():
+			m.mu.Lock()
+			delete(m.supervisors, serverName)
+			m.mu.Unlock()
+			for {
+				select {
+				case job := <-s.jobs:
+					job(ctx)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// notifyCrashed hands a server that was marked crashed to its supervisor, which restarts it if its
+// policy and remaining attempts allow, and otherwise removes its tools
+func (m *ClientManager) notifyCrashed(serverName string) {
+	if err := m.supervise(serverName, func(ctx context.Context) { m.handleCrash(ctx, serverName) }); err != nil {
+		// A busy supervisor already has a crash notice or restart of the server queued
+		slog.Debug("Crash notice not queued", "server", serverName, "error", err)
+	}
+}
+
+// handleCrash decides what becomes of a crashed server: it is restarted, quarantined once it has used
+// up its restart attempts, or left crashed when its policy does not restart it.
+// Runs on the server's supervisor.
+func (m *ClientManager) handleCrash(ctx context.Context, serverName string) {
+	cfg, ok := m.getConfig(serverName)
+	// The server may have been restarted or stopped since the notice was queued
+	if !ok || m.processManager.GetStatus(serverName) != StatusCrashed {
+		return
+	}
+
+	err := m.beginRestart(cfg)
+	switch {
+	case err == nil:
+		m.performRestart(ctx, ctx, cfg)
+	case errors.Is(err, errRestartsExhausted):
+		if m.transition(serverName, StatusQuarantined) {
+			slog.Error("Server quarantined until restarted manually", "server", serverName)
+			m.events.Publish(events.Event{
+				Type:        events.RestartExhausted,
+				Server:      serverName,
+				Attempt:     m.processManager.GetRestartAttempts(serverName),
+				MaxAttempts: m.processManager.MaxRestartAttempts(),
+				Reason:      string(m.processManager.GetDiagnostics(serverName).LastFailureReason),
+			})
+			m.removeTools(serverName)
+		}
+	case errors.Is(err, errRestartNotAllowed):
+		m.removeTools(serverName)
+	default:
+		slog.Debug("Crashed server not restarted", "server", serverName, "error", err)
+	}
+}
+
+// superviseAndWait runs fn, e.g. a manual restart, on the supervisor of a server and waits for its result
+func (m *ClientManager) superviseAndWait(ctx context.Context, serverName string, fn func() error) error {
+	done := make(chan error, 1)
+	if err := m.supervise(serverName, func(context.Context) { done <- fn() }); err != nil {
+		return fmt.Errorf("action on server %s not started: %w", serverName, err)
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/clock"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisor_RestartsCrashedServerOnce(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 10, Multiplier: 2, MaxDelay: 10, MaxAttempts: 3})
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+
+	// Several detectors report the same crash at once
+	require.NoError(t, pm.Transition("embedded", StatusCrashed))
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { pm.onServerCrashed("embedded") })
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool { return pm.GetStatus("embedded") == StatusAvailable }, 5*time.Second, 10*time.Millisecond)
+	// Let the remaining notices run; they find the server recovered
+	require.NoError(t, cm.superviseAndWait(context.Background(), "embedded", func() error { return nil }))
+	assert.Equal(t, StatusAvailable, pm.GetStatus("embedded"))
+	assert.Equal(t, uint64(1), pm.GetDiagnostics("embedded").Restarts)
+}
+
+func TestSupervisor_QuarantinesServerOutOfRestarts(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 10, Multiplier: 2, MaxDelay: 10, MaxAttempts: 2})
+	cm := NewClientManager(pm)
+	cm.SetStartupFailurePolicy(config.StartupFailurePolicyContinue)
+	t.Cleanup(func() { _ = cm.Close() })
+
+	require.NoError(t, cm.Initialize(context.Background(), []config.ServerConfig{
+		{Name: "broken", Command: "/nonexistent/mcp-server", Timeout: 1000},
+	}))
+
+	// Every failed reconnection is retried until the attempts are used up
+	require.Eventually(t, func() bool { return pm.GetStatus("broken") == StatusQuarantined }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, pm.GetRestartAttempts("broken"))
+	assert.Equal(t, uint64(2), pm.GetDiagnostics("broken").Restarts)
+
+	// Only a manual restart brings it back, which fails again for this server
+	assert.Error(t, cm.ForceRestartServer(context.Background(), "broken"))
+	assert.Equal(t, StatusCrashed, pm.GetStatus("broken"))
+}
+
+func TestSupervisor_BackoffFollowsClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	pm := NewProcessManager(30000, "on-failure")
+	pm.SetClock(fake)
+	pm.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 60000, Multiplier: 2, MaxDelay: 60000, MaxAttempts: 3})
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	// The health check waits for its first interval
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, pm.Transition("embedded", StatusCrashed))
+	pm.onServerCrashed("embedded")
+	require.Eventually(t, func() bool { return fake.Waiters() == 2 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, StatusRestarting, pm.GetStatus("embedded"))
+	assert.Equal(t, fake.Now().Add(time.Minute), pm.GetDiagnostics("embedded").NextRestartAt)
+
+	fake.Advance(59 * time.Second)
+	assert.Never(t, func() bool { return pm.GetStatus("embedded") != StatusRestarting }, 50*time.Millisecond, time.Millisecond)
+	fake.Advance(time.Second)
+	require.Eventually(t, func() bool { return pm.GetStatus("embedded") == StatusAvailable }, 5*time.Second, time.Millisecond)
+}
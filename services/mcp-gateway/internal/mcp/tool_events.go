@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"log/slog"
+	"time"
+)
+
+// toolEventBuffer is how many events a watcher may fall behind before it is dropped
+const toolEventBuffer = 64
+
+// ToolsChange classifies why the tools of a server changed
+type ToolsChange string
+
+const (
+	ToolsChangeUpdated ToolsChange = "updated" // the server was (re)connected or refreshed and lists other tools than before
+	ToolsChangeRemoved ToolsChange = "removed" // the server crashed and is not restarted, so its tools were removed
+)
+
+// ToolsEvent tells clients that the tools of a server changed, so that they refresh their tool registry
+type ToolsEvent struct {
+	Server string      `json:"server"`
+	Change ToolsChange `json:"change"`
+	Tools  int         `json:"tools"` // how many tools the server has now
+	At     time.Time   `json:"at"`
+}
+
+// WatchTools returns a channel receiving an event whenever the cached tools of a server change.
+// The channel is closed by unwatch, which must be called, or when the reader falls too far behind.
+func (m *ClientManager) WatchTools() (events <-chan ToolsEvent, unwatch func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan ToolsEvent, toolEventBuffer)
+	m.toolWatchers[ch] = struct{}{}
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.toolWatchers[ch]; ok {
+			delete(m.toolWatchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// emitToolsEventLocked notifies the watchers of a change to a server's tools. Callers must hold m.mu.
+func (m *ClientManager) emitToolsEventLocked(serverName string, change ToolsChange, tools int) {
+	ev := ToolsEvent{Server: serverName, Change: change, Tools: tools, At: time.Now()}
+	slog.Info("Server tools changed", "server", serverName, "change", change, "tools", tools)
+	for ch := range m.toolWatchers {
+		select {
+		case ch <- ev:
+		default:
+			// A watcher that missed an event would keep a stale registry; it learns from the closed channel
+			delete(m.toolWatchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// removeTools drops the cached tools of a server that crashed and is not restarted, or is outside its
+// active hours, so that they are not listed until the server is started again, which caches them anew.
+func (m *ClientManager) removeTools(serverName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for key, tool := range m.toolsCache {
+		if tool.Server == serverName {
+			delete(m.toolsCache, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		m.emitToolsEventLocked(serverName, ToolsChangeRemoved, 0)
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiveToolsEvent waits for the next event on a WatchTools channel
+func receiveToolsEvent(t *testing.T, events <-chan ToolsEvent) ToolsEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		require.True(t, ok, "watch channel closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tools event")
+		return ToolsEvent{}
+	}
+}
+
+func TestWatchTools_RemovesToolsOfServerOutOfRestarts(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	require.NoError(t, cm.RegisterInProcess("embedded", newRemoteMCPServer()))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	events, unwatch := cm.WatchTools()
+	defer unwatch()
+
+	for range 3 {
+		pm.IncrementRestartAttempts("embedded")
+	}
+	require.NoError(t, pm.Transition("embedded", StatusCrashed))
+	pm.onServerCrashed("embedded")
+
+	ev := receiveToolsEvent(t, events)
+	assert.Equal(t, "embedded", ev.Server)
+	assert.Equal(t, ToolsChangeRemoved, ev.Change)
+	assert.Equal(t, StatusQuarantined, pm.GetStatus("embedded"))
+	assert.Zero(t, ev.Tools)
+	_, found := cm.GetToolInfo("embedded", "echo")
+	assert.False(t, found)
+	assert.Empty(t, cm.GetTools())
+
+	// A manual restart brings the tools back
+	require.NoError(t, cm.ForceRestartServer(context.Background(), "embedded"))
+	ev = receiveToolsEvent(t, events)
+	assert.Equal(t, ToolsChangeUpdated, ev.Change)
+	assert.Equal(t, 1, ev.Tools)
+}
+
+func TestWatchTools_UpdatedOnlyWhenToolsChange(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	t.Cleanup(func() { _ = cm.Close() })
+	server := newRemoteMCPServer()
+	require.NoError(t, cm.RegisterInProcess("embedded", server))
+	require.NoError(t, cm.Initialize(context.Background(), nil))
+	events, unwatch := cm.WatchTools()
+
+	require.NoError(t, cm.RefreshTools(context.Background(), "embedded"))
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event for an unchanged tool list: %+v", ev)
+	default:
+	}
+
+	server.AddTool(&mcp.Tool{Name: "ping", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{}, nil
+		})
+	require.NoError(t, cm.RefreshTools(context.Background(), "embedded"))
+	ev := receiveToolsEvent(t, events)
+	assert.Equal(t, ToolsChangeUpdated, ev.Change)
+	assert.Equal(t, 2, ev.Tools)
+
+	unwatch()
+	_, ok := <-events
+	assert.False(t, ok, "unwatch closes the channel")
+	unwatch()
+}
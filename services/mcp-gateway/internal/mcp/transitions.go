@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// legalTransitions lists the statuses each status may move to.
+// Servers start out unavailable; only the lifecycle code moves them between statuses.
+var legalTransitions = map[ServerStatus][]ServerStatus{
+	StatusUnavailable: {StatusConnecting, StatusRestarting, StatusStopped, StatusInactive},
+	StatusConnecting:  {StatusAvailable, StatusCrashed, StatusStopped},
+	StatusAvailable:   {StatusUnhealthy, StatusCrashed, StatusUnavailable, StatusRestarting, StatusStopped, StatusIdle, StatusInactive},
+	StatusUnhealthy:   {StatusAvailable, StatusCrashed, StatusUnavailable, StatusRestarting, StatusStopped, StatusInactive},
+	StatusCrashed:     {StatusRestarting, StatusStopped, StatusQuarantined, StatusInactive},
+	StatusRestarting:  {StatusConnecting, StatusCrashed, StatusStopped},
+	StatusStopped:     {StatusRestarting},
+	StatusIdle:        {StatusConnecting, StatusRestarting, StatusStopped, StatusInactive},
+	StatusQuarantined: {StatusCrashed, StatusRestarting, StatusStopped},
+	StatusInactive:    {StatusConnecting, StatusStopped},
+}
+
+// CanTransition reports whether the lifecycle allows a server to move from one status to another
+func CanTransition(from, to ServerStatus) bool {
+	return slices.Contains(legalTransitions[from], to)
+}
+
+// TransitionError reports a status change that the lifecycle does not allow
+type TransitionError struct {
+	Server string
+	From   ServerStatus
+	To     ServerStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("server %s cannot change status from %s to %s", e.Server, e.From, e.To)
+}
+
+// StatusEvent describes a change of a server's status
+type StatusEvent struct {
+	Server string       `json:"server"`
+	From   ServerStatus `json:"from"`
+	To     ServerStatus `json:"to"`
+	At     time.Time    `json:"at"`
+}
+
+// emitStatusEvent logs a status change and notifies the registered listeners
+func emitStatusEvent(listeners []func(StatusEvent), ev StatusEvent) {
+	slog.Debug("Server status changed", "server", ev.Server, "from", ev.From, "to", ev.To)
+	for _, listener := range listeners {
+		listener(ev)
+	}
+}
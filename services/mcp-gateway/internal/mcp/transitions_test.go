@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to ServerStatus
+		want     bool
+	}{
+		{StatusUnavailable, StatusConnecting, true},
+		{StatusConnecting, StatusAvailable, true},
+		{StatusAvailable, StatusUnhealthy, true},
+		{StatusUnhealthy, StatusAvailable, true},
+		{StatusUnhealthy, StatusCrashed, true},
+		{StatusCrashed, StatusRestarting, true},
+		{StatusRestarting, StatusConnecting, true},
+		{StatusRestarting, StatusStopped, true},
+		{StatusStopped, StatusRestarting, true},
+		{StatusRestarting, StatusUnhealthy, false},
+		{StatusCrashed, StatusAvailable, false},
+		{StatusStopped, StatusConnecting, false},
+		{StatusConnecting, StatusRestarting, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestTransition_RejectsIllegalChange(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	pm.SetStatus("test-server", StatusRestarting)
+
+	err := pm.Transition("test-server", StatusUnhealthy)
+
+	var transitionErr *TransitionError
+	require.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, StatusRestarting, transitionErr.From)
+	assert.Equal(t, StatusUnhealthy, transitionErr.To)
+	assert.Equal(t, StatusRestarting, pm.GetStatus("test-server"))
+}
+
+func TestTransition_SameStatusIsNoop(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	pm.SetStatus("test-server", StatusStopped)
+
+	var events []StatusEvent
+	pm.OnStatusChange(func(ev StatusEvent) { events = append(events, ev) })
+
+	assert.NoError(t, pm.Transition("test-server", StatusStopped))
+	assert.Empty(t, events)
+}
+
+func TestTransition_EmitsEvents(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+
+	var (
+		mu     sync.Mutex
+		events []StatusEvent
+	)
+	pm.OnStatusChange(func(ev StatusEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	})
+
+	require.NoError(t, pm.Transition("test-server", StatusConnecting))
+	require.NoError(t, pm.Transition("test-server", StatusAvailable))
+	assert.False(t, pm.CompareAndSwapStatus("test-server", StatusAvailable, StatusConnecting))
+	assert.True(t, pm.CompareAndSwapStatus("test-server", StatusAvailable, StatusRestarting))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 3)
+	assert.Equal(t, StatusEvent{Server: "test-server", From: StatusUnavailable, To: StatusConnecting, At: events[0].At}, events[0])
+	assert.Equal(t, StatusConnecting, events[1].From)
+	assert.Equal(t, StatusAvailable, events[1].To)
+	assert.Equal(t, StatusRestarting, events[2].To)
+	assert.False(t, events[0].At.IsZero())
+}
+
+func TestStartHealthCheck_FailureMarksUnhealthyThenRecovers(t *testing.T) {
+	pm := NewProcessManager(50, "never")
+	cm := NewClientManager(pm)
+	defer cm.Close()
+
+	mockSession := new(MockMCPSession)
+	mockSession.On("Ping", mock.Anything, mock.Anything).Return(errors.New("timeout")).Once()
+	mockSession.On("Ping", mock.Anything, mock.Anything).Return(nil)
+	mockSession.On("Close").Return(nil)
+	cm.sessions["test-server"] = mockSession
+	pm.SetStatus("test-server", StatusAvailable)
+
+	var (
+		mu   sync.Mutex
+		seen []ServerStatus
+	)
+	pm.OnStatusChange(func(ev StatusEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, ev.To)
+	})
+
+	cm.StartHealthCheck(context.Background(), "test-server")
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == 2
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []ServerStatus{StatusUnhealthy, StatusAvailable}, seen)
+	mu.Unlock()
+}
+
+func TestStopServer_DuringRestartBackoffStaysStopped(t *testing.T) {
+	pm := NewProcessManager(30000, "on-failure")
+	cm := NewClientManager(pm)
+	defer cm.Close()
+
+	cfg := config.ServerConfig{Name: "test-server", Command: "/bin/cat"}
+	cm.configs = []config.ServerConfig{cfg}
+	pm.SetStatus("test-server", StatusCrashed)
+
+	// The restart waits 1s before reconnecting; stop the server in the meantime
+	require.NoError(t, cm.RestartServer(context.Background(), cfg))
+	require.NoError(t, cm.StopServer("test-server"))
+
+	time.Sleep(1500 * time.Millisecond)
+
+	assert.Equal(t, StatusStopped, pm.GetStatus("test-server"))
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	assert.Empty(t, cm.sessions)
+	assert.Empty(t, cm.processes)
+}
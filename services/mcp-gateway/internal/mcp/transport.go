@@ -0,0 +1,272 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// safeEnvVars are inherited from the gateway by spawned servers
+var safeEnvVars = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ", "TMPDIR"}
+
+// TransportDialer creates MCP transports for servers whose transport setting matches Name.
+// Code embedding the gateway can add transports with RegisterTransport.
+type TransportDialer interface {
+	// Name is the value of servers[].transport that selects this dialer
+	Name() string
+	// Dial returns the transport the client connects over. ctx bounds any setup done here;
+	// the connection itself is made and bounded by the transport's Connect.
+	// Returning an *mcp.CommandTransport lets the gateway track and stop the process.
+	Dial(ctx context.Context, cfg config.ServerConfig) (mcp.Transport, error)
+}
+
+var (
+	transportsMu sync.RWMutex
+	transports   = make(map[string]TransportDialer)
+)
+
+func init() {
+	for _, d := range []TransportDialer{stdioDialer{}, sseDialer{}, streamableHTTPDialer{}, tcpDialer{}, unixDialer{}, wasmDialer{}} {
+		transports[d.Name()] = d
+	}
+}
+
+// RegisterTransport makes a transport available to servers that set it as their transport.
+// It must be called before the configuration is loaded, and panics if the name is empty or taken.
+func RegisterTransport(d TransportDialer) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	name := d.Name()
+	if name == "" {
+		panic("mcp: RegisterTransport with an empty name")
+	}
+	if _, dup := transports[name]; dup {
+		panic("mcp: RegisterTransport called twice for transport " + name)
+	}
+	transports[name] = d
+	config.RegisterTransport(name)
+}
+
+// newTransport builds the MCP transport for a server. For stdio servers it also returns
+// the command so that the process can be tracked and killed; other transports return nil.
+func newTransport(ctx context.Context, cfg config.ServerConfig) (mcp.Transport, *exec.Cmd, error) {
+	name := cfg.Transport
+	if name == "" {
+		name = config.TransportStdio
+	}
+	transportsMu.RLock()
+	d, ok := transports[name]
+	transportsMu.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported transport %q", cfg.Transport)
+	}
+
+	t, err := d.Dial(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s transport: %w", name, err)
+	}
+	if ct, ok := t.(*mcp.CommandTransport); ok {
+		return t, ct.Command, nil
+	}
+	return t, nil, nil
+}
+
+// resolveEnvs returns env values with those read from files or secrets managers filled in.
+// It runs on every connect, so that restarts pick up rotated secret files and refreshed secrets.
+func resolveEnvs(envs []config.EnvVar, store *secrets.Store) ([]config.EnvVar, error) {
+	resolved := make([]config.EnvVar, len(envs))
+	for i, e := range envs {
+		value, err := e.Resolve(store)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = config.EnvVar{Name: e.Name, Value: value}
+	}
+	return resolved, nil
+}
+
+// stdioDialer spawns the server and talks to it over stdin/stdout
+type stdioDialer struct{}
+
+func (stdioDialer) Name() string { return config.TransportStdio }
+
+func (stdioDialer) Dial(_ context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	cmd := newServerCommand(cfg)
+	setProcessGroup(cmd)
+	// Containers are given the user with docker run --user instead
+	if cfg.RunAs != nil && !cfg.IsContainer() {
+		if err := setRunAs(cmd, cfg.RunAs); err != nil {
+			return nil, err
+		}
+	}
+	return &mcp.CommandTransport{Command: cmd}, nil
+}
+
+// sseDialer connects to a remote server with HTTP and Server-Sent Events
+type sseDialer struct{}
+
+func (sseDialer) Name() string { return config.TransportSSE }
+
+func (sseDialer) Dial(_ context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	return detachedTransport{&mcp.SSEClientTransport{Endpoint: cfg.URL}}, nil
+}
+
+// streamableHTTPDialer connects to a remote server over MCP Streamable HTTP
+type streamableHTTPDialer struct{}
+
+func (streamableHTTPDialer) Name() string { return config.TransportStreamableHTTP }
+
+func (streamableHTTPDialer) Dial(_ context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	return detachedTransport{&mcp.StreamableClientTransport{Endpoint: cfg.URL}}, nil
+}
+
+// tcpDialer connects to a remote server speaking newline-delimited JSON-RPC over TCP
+type tcpDialer struct{}
+
+func (tcpDialer) Name() string { return config.TransportTCP }
+
+func (tcpDialer) Dial(_ context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	return socketTransport{network: "tcp", address: cfg.Address}, nil
+}
+
+// unixDialer connects to a local server speaking newline-delimited JSON-RPC on a unix socket,
+// e.g. one managed by systemd outside the gateway's process tree
+type unixDialer struct{}
+
+func (unixDialer) Name() string { return config.TransportUnix }
+
+func (unixDialer) Dial(_ context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	return socketTransport{network: "unix", address: cfg.SocketPath}, nil
+}
+
+// newServerCommand creates the command for a stdio server with a minimal environment
+func newServerCommand(cfg config.ServerConfig) *exec.Cmd {
+	if cfg.IsContainer() {
+		return newDockerCommand(cfg)
+	}
+	if cfg.IsSSH() {
+		return newSSHCommand(cfg)
+	}
+
+	// ホワイトリストの環境変数のみ継承
+	var env []string
+	switch {
+	case cfg.InheritAll:
+		env = os.Environ()
+	case cfg.InheritEnv != nil:
+		env = inheritedEnv(cfg.InheritEnv)
+	default:
+		env = inheritedEnv(safeEnvVars)
+	}
+	for _, e := range cfg.Envs {
+		env = append(env, fmt.Sprintf("%s=%s", e.Name, e.Value))
+	}
+
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = env
+	cmd.Dir = cfg.WorkDir
+	return cmd
+}
+
+// forwardedEnvs returns the server's envs preceded by the inheritEnv variables that are set in the
+// gateway's environment and not overridden by envs, for runtimes that pass variables on to a
+// container or remote host
+func forwardedEnvs(cfg config.ServerConfig) []config.EnvVar {
+	envs := make([]config.EnvVar, 0, len(cfg.InheritEnv)+len(cfg.Envs))
+	for _, key := range cfg.InheritEnv {
+		overridden := slices.ContainsFunc(cfg.Envs, func(e config.EnvVar) bool { return e.Name == key })
+		if val := os.Getenv(key); val != "" && !overridden {
+			envs = append(envs, config.EnvVar{Name: key, Value: val})
+		}
+	}
+	return append(envs, cfg.Envs...)
+}
+
+// inheritedEnv returns the listed variables that are set in the gateway's environment
+func inheritedEnv(keys []string) []string {
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if val := os.Getenv(key); val != "" {
+			env = append(env, fmt.Sprintf("%s=%s", key, val))
+		}
+	}
+	return env
+}
+
+// socketTransport connects to a server that speaks newline-delimited JSON-RPC over a TCP or
+// unix socket, the same framing as stdio, so that stdio servers can be run outside the gateway unchanged
+type socketTransport struct {
+	network string // "tcp" or "unix"
+	address string
+}
+
+// Connect implements mcp.Transport. ctx bounds the dial only; the connection lives until closed.
+func (t socketTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, t.network, t.address)
+	if err != nil {
+		return nil, err
+	}
+	// The SDK closes both halves; the socket must only be closed once
+	return (&mcp.IOTransport{Reader: conn, Writer: nopCloser{conn}}).Connect(ctx)
+}
+
+// nopCloser turns a writer into an io.WriteCloser whose Close does nothing
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// detachedTransport decouples the lifetime of a network connection from the context passed to Connect.
+// The SDK's HTTP transports bind their streams to that context, but servers are connected with a
+// short-lived timeout context. The context here only bounds the handshake; the connection then
+// lives until it is closed.
+type detachedTransport struct {
+	mcp.Transport
+}
+
+// Connect implements mcp.Transport
+func (t detachedTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	connCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	// Abort the handshake if ctx ends first
+	stop := context.AfterFunc(ctx, cancel)
+
+	conn, err := t.Transport.Connect(connCtx)
+	if !stop() {
+		// ctx ended during the handshake
+		if err == nil {
+			_ = conn.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("connect: %w", context.Cause(ctx))
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelOnClose{Connection: conn, cancel: cancel}, nil
+}
+
+// cancelOnClose releases the connection context when the connection is closed
+type cancelOnClose struct {
+	mcp.Connection
+	cancel context.CancelFunc
+}
+
+// Close implements mcp.Connection
+func (c *cancelOnClose) Close() error {
+	err := c.Connection.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,452 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/secrets"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoInput struct {
+	Text string `json:"text"`
+}
+
+type echoOutput struct {
+	Text string `json:"text"`
+}
+
+// newRemoteMCPServer returns an in-process MCP server exposing a read-only "echo" tool
+func newRemoteMCPServer() *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "remote", Version: "test"}, nil)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "echo",
+		Description: "Echo the input text",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(_ context.Context, _ *mcp.CallToolRequest, in echoInput) (*mcp.CallToolResult, echoOutput, error) {
+		return nil, echoOutput{Text: in.Text}, nil
+	})
+	return server
+}
+
+// newSSETestManager serves the echo server over SSE and returns a manager configured for it
+func newSSETestManager(t *testing.T, restartPolicy string) (*ClientManager, *ProcessManager, config.ServerConfig) {
+	t.Helper()
+	server := newRemoteMCPServer()
+	ts := httptest.NewServer(mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(ts.Close)
+
+	pm := NewProcessManager(30000, restartPolicy)
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: config.TransportSSE, URL: ts.URL, Timeout: 5000}
+	cm.configs = []config.ServerConfig{cfg}
+	// Runs before ts.Close so that the SSE stream is released
+	t.Cleanup(func() { _ = cm.Close() })
+	return cm, pm, cfg
+}
+
+func TestConnectClient_SSE(t *testing.T) {
+	cm, pm, cfg := newSSETestManager(t, "never")
+
+	// The connect context only bounds the handshake; the stream must outlive it
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	require.NoError(t, cm.connectClient(ctx, cfg))
+	cancel()
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("remote"))
+	assert.Empty(t, cm.processes, "remote servers have no local process")
+
+	tool, ok := cm.GetToolInfo("remote", "echo")
+	require.True(t, ok)
+	assert.True(t, tool.ReadOnly)
+
+	result, err := cm.CallTool(context.Background(), "remote", "echo", map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "hello"}, result.(*mcp.CallToolResult).StructuredContent)
+}
+
+func TestConnectClient_SSEUnreachable(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: config.TransportSSE, URL: "http://127.0.0.1:1/sse", Timeout: 5000}
+
+	err := cm.connectClient(context.Background(), cfg)
+
+	assert.Error(t, err)
+	assert.NotEmpty(t, pm.GetDiagnostics("remote").LastConnectError)
+}
+
+func TestConnectClient_SSEHandshakeTimeout(t *testing.T) {
+	// A server that accepts the stream but never sends the endpoint event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: config.TransportSSE, URL: ts.URL, Timeout: 5000}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := cm.connectClient(ctx, cfg)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRestartServer_RemoteReconnectsRegardlessOfPolicy(t *testing.T) {
+	cm, pm, cfg := newSSETestManager(t, "never")
+	require.NoError(t, cm.connectClient(context.Background(), cfg))
+	pm.SetStatus("remote", StatusCrashed)
+
+	require.NoError(t, cm.RestartServer(context.Background(), cfg))
+
+	assert.Eventually(t, func() bool {
+		return pm.GetStatus("remote") == StatusAvailable
+	}, 10*time.Second, 50*time.Millisecond)
+
+	_, err := cm.CallTool(context.Background(), "remote", "echo", map[string]any{"text": "again"})
+	assert.NoError(t, err)
+}
+
+// newStreamableTestManager serves the echo server over Streamable HTTP and returns a manager configured for it
+func newStreamableTestManager(t *testing.T) (*ClientManager, *ProcessManager, config.ServerConfig) {
+	t.Helper()
+	server := newRemoteMCPServer()
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(ts.Close)
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: config.TransportStreamableHTTP, URL: ts.URL, Timeout: 5000}
+	cm.configs = []config.ServerConfig{cfg}
+	t.Cleanup(func() { _ = cm.Close() })
+	return cm, pm, cfg
+}
+
+func TestConnectClient_StreamableHTTP(t *testing.T) {
+	cm, pm, cfg := newStreamableTestManager(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	require.NoError(t, cm.connectClient(ctx, cfg))
+	cancel()
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("remote"))
+	assert.Empty(t, cm.processes, "remote servers have no local process")
+
+	result, err := cm.CallTool(context.Background(), "remote", "echo", map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "hello"}, result.(*mcp.CallToolResult).StructuredContent)
+}
+
+func TestRestartServer_StreamableHTTPReconnects(t *testing.T) {
+	cm, pm, cfg := newStreamableTestManager(t)
+	require.NoError(t, cm.connectClient(context.Background(), cfg))
+	cm.mu.RLock()
+	before := cm.sessions["remote"]
+	cm.mu.RUnlock()
+	pm.SetStatus("remote", StatusCrashed)
+
+	require.NoError(t, cm.RestartServer(context.Background(), cfg))
+
+	assert.Eventually(t, func() bool {
+		return pm.GetStatus("remote") == StatusAvailable
+	}, 10*time.Second, 50*time.Millisecond)
+
+	cm.mu.RLock()
+	after := cm.sessions["remote"]
+	cm.mu.RUnlock()
+	assert.NotSame(t, before, after, "restart should establish a new session")
+
+	_, err := cm.CallTool(context.Background(), "remote", "echo", map[string]any{"text": "again"})
+	assert.NoError(t, err)
+}
+
+// newTCPTestManager serves the echo server over newline-delimited JSON-RPC on a TCP listener
+// serveOnListener serves the test MCP server over newline-delimited JSON-RPC on every accepted connection
+func serveOnListener(t *testing.T, ln net.Listener) {
+	t.Helper()
+	server := newRemoteMCPServer()
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = server.Connect(context.Background(), &mcp.IOTransport{Reader: conn, Writer: conn}, nil)
+		}
+	}()
+}
+
+func newTCPTestManager(t *testing.T) (*ClientManager, *ProcessManager, config.ServerConfig, net.Listener) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveOnListener(t, ln)
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: config.TransportTCP, Address: ln.Addr().String(), Timeout: 5000}
+	cm.configs = []config.ServerConfig{cfg}
+	t.Cleanup(func() { _ = cm.Close() })
+	return cm, pm, cfg, ln
+}
+
+func TestConnectClient_TCP(t *testing.T) {
+	cm, pm, cfg, _ := newTCPTestManager(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	require.NoError(t, cm.connectClient(ctx, cfg))
+	cancel()
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("remote"))
+	assert.Empty(t, cm.processes, "remote servers have no local process")
+
+	cm.mu.RLock()
+	session := cm.sessions["remote"]
+	cm.mu.RUnlock()
+	require.NoError(t, session.Ping(context.Background(), &mcp.PingParams{}))
+
+	result, err := cm.CallTool(context.Background(), "remote", "echo", map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "hello"}, result.(*mcp.CallToolResult).StructuredContent)
+}
+
+func TestConnectClient_TCPRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: config.TransportTCP, Address: addr, Timeout: 5000}
+
+	err = cm.connectClient(context.Background(), cfg)
+
+	assert.Error(t, err)
+	assert.Equal(t, StatusCrashed, pm.GetStatus("remote"))
+}
+
+func TestRestartServer_TCPReconnects(t *testing.T) {
+	cm, pm, cfg, _ := newTCPTestManager(t)
+	require.NoError(t, cm.connectClient(context.Background(), cfg))
+	pm.SetStatus("remote", StatusCrashed)
+
+	require.NoError(t, cm.RestartServer(context.Background(), cfg))
+
+	assert.Eventually(t, func() bool {
+		return pm.GetStatus("remote") == StatusAvailable
+	}, 10*time.Second, 50*time.Millisecond)
+
+	_, err := cm.CallTool(context.Background(), "remote", "echo", map[string]any{"text": "again"})
+	assert.NoError(t, err)
+}
+
+func TestConnectClient_Unix(t *testing.T) {
+	// Socket paths are limited to ~100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "mcp")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "server.sock")
+	ln, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	serveOnListener(t, ln)
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "local", Transport: config.TransportUnix, SocketPath: socketPath, Timeout: 5000}
+	cm.configs = []config.ServerConfig{cfg}
+	t.Cleanup(func() { _ = cm.Close() })
+
+	require.NoError(t, cm.connectClient(context.Background(), cfg))
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("local"))
+	assert.Empty(t, cm.processes, "the socket's server is not managed by the gateway")
+	result, err := cm.CallTool(context.Background(), "local", "echo", map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "hello"}, result.(*mcp.CallToolResult).StructuredContent)
+}
+
+func TestConnectClient_UnixMissingSocket(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "local", Transport: config.TransportUnix, SocketPath: filepath.Join(t.TempDir(), "missing.sock")}
+	t.Cleanup(func() { _ = cm.Close() })
+
+	err := cm.connectClient(context.Background(), cfg)
+
+	require.Error(t, err)
+	assert.Equal(t, StatusCrashed, pm.GetStatus("local"))
+}
+
+// inMemoryDialer is a custom transport that serves each dial from an in-process MCP server
+type inMemoryDialer struct {
+	server *mcp.Server
+	dials  atomic.Int32
+}
+
+func (*inMemoryDialer) Name() string { return "in-memory" }
+
+func (d *inMemoryDialer) Dial(ctx context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	d.dials.Add(1)
+	clientT, serverT := mcp.NewInMemoryTransports()
+	if _, err := d.server.Connect(ctx, serverT, nil); err != nil {
+		return nil, err
+	}
+	return clientT, nil
+}
+
+// registerInMemory registers inMemoryDialer once per test binary, since registrations are permanent
+var registerInMemory = sync.OnceValue(func() *inMemoryDialer {
+	d := &inMemoryDialer{server: newRemoteMCPServer()}
+	RegisterTransport(d)
+	return d
+})
+
+func TestConnectClient_RegisteredTransport(t *testing.T) {
+	dialer := registerInMemory()
+	dialer.dials.Store(0)
+	assert.Panics(t, func() { RegisterTransport(dialer) }, "names are unique")
+
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "embedded", Transport: "in-memory", Timeout: 5000}
+	cm.configs = []config.ServerConfig{cfg}
+	t.Cleanup(func() { _ = cm.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	require.NoError(t, cm.connectClient(ctx, cfg))
+	cancel()
+
+	assert.Equal(t, StatusAvailable, pm.GetStatus("embedded"))
+	assert.Equal(t, int32(1), dialer.dials.Load())
+	result, err := cm.CallTool(context.Background(), "embedded", "echo", map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "hello"}, result.(*mcp.CallToolResult).StructuredContent)
+}
+
+func TestConnectClient_UnsupportedTransport(t *testing.T) {
+	pm := NewProcessManager(30000, "never")
+	cm := NewClientManager(pm)
+	cfg := config.ServerConfig{Name: "remote", Transport: "carrier-pigeon"}
+	t.Cleanup(func() { _ = cm.Close() })
+
+	err := cm.connectClient(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported transport "carrier-pigeon"`)
+	assert.Equal(t, StatusCrashed, pm.GetStatus("remote"))
+}
+
+func TestTransportFor_ResolvesEnvsOnEveryConnect(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "api_key")
+	require.NoError(t, os.WriteFile(secret, []byte("first\n"), 0600))
+	cfg := config.ServerConfig{
+		Name:    "stdio",
+		Command: "/bin/true",
+		Envs: []config.EnvVar{
+			{Name: "API_KEY", ValueFrom: &config.EnvVarSource{File: secret}},
+			{Name: "DB_PASSWORD", ValueFrom: &config.EnvVarSource{Vault: "secret/data/db#password"}},
+			{Name: "REGION", Value: "eu"},
+		},
+	}
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	t.Cleanup(func() { _ = cm.Close() })
+
+	// Without a store, values from secrets managers cannot be fetched
+	_, _, err := cm.transportFor(context.Background(), cfg)
+	assert.ErrorContains(t, err, "env DB_PASSWORD: no secrets store")
+
+	cm.SetSecretStore(secrets.NewStore(map[string]secrets.Provider{
+		secrets.Vault: staticProvider("pw"),
+	}))
+	connect := func() []string {
+		transport, _, err := cm.transportFor(context.Background(), cfg)
+		require.NoError(t, err)
+		return transport.(*mcp.CommandTransport).Command.Env
+	}
+	env := connect()
+	assert.Contains(t, env, "API_KEY=first")
+	assert.Contains(t, env, "DB_PASSWORD=pw")
+	assert.Contains(t, env, "REGION=eu")
+
+	// A rotated secret is used on the next spawn
+	require.NoError(t, os.WriteFile(secret, []byte("second\n"), 0600))
+	assert.Contains(t, connect(), "API_KEY=second")
+	assert.Empty(t, cfg.Envs[0].Value, "the config is not modified")
+
+	require.NoError(t, os.Remove(secret))
+	_, _, err = cm.transportFor(context.Background(), cfg)
+	assert.ErrorContains(t, err, "env API_KEY")
+}
+
+// staticProvider returns the same value for every secret
+type staticProvider string
+
+func (p staticProvider) Fetch(context.Context, string) (string, error) {
+	return string(p), nil
+}
+
+func TestNewServerCommand_InheritedEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("GATEWAY_ONLY", "x")
+
+	tests := []struct {
+		name       string
+		inheritEnv []string
+		inheritAll bool
+		want       []string
+		notWant    []string
+	}{
+		{name: "Default allowlist", want: []string{"PATH=/usr/bin"}, notWant: []string{"AWS_REGION=eu-west-1", "GATEWAY_ONLY=x"}},
+		{name: "Configured allowlist", inheritEnv: []string{"AWS_REGION", "UNSET_VAR"}, want: []string{"AWS_REGION=eu-west-1"}, notWant: []string{"PATH=/usr/bin"}},
+		{name: "Nothing", inheritEnv: []string{}, notWant: []string{"PATH=/usr/bin", "AWS_REGION=eu-west-1"}},
+		{name: "Everything", inheritAll: true, want: []string{"PATH=/usr/bin", "AWS_REGION=eu-west-1", "GATEWAY_ONLY=x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newServerCommand(config.ServerConfig{
+				Name:       "stdio",
+				Command:    "/bin/true",
+				InheritEnv: tt.inheritEnv,
+				InheritAll: tt.inheritAll,
+				Envs:       []config.EnvVar{{Name: "REGION", Value: "eu"}},
+			})
+			for _, kv := range tt.want {
+				assert.Contains(t, cmd.Env, kv)
+			}
+			for _, kv := range tt.notWant {
+				assert.NotContains(t, cmd.Env, kv)
+			}
+			assert.Contains(t, cmd.Env, "REGION=eu")
+		})
+	}
+}
+
+func TestNewServerCommand_WorkDir(t *testing.T) {
+	dir := t.TempDir()
+	cmd := newServerCommand(config.ServerConfig{Name: "stdio", Command: "pwd", WorkDir: dir})
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, dir+"\n", string(out))
+}
@@ -0,0 +1,288 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/validator"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Value types of WebAssembly function parameters and results that tools can exchange as JSON numbers
+const (
+	wasmI32 = "i32"
+	wasmI64 = "i64"
+	wasmF32 = "f32"
+	wasmF64 = "f64"
+)
+
+// wasmFunction is a function exported by a WebAssembly module.
+// Params and results hold the value types above, or another type name if the function is not eligible.
+type wasmFunction struct {
+	name    string
+	params  []string
+	results []string
+}
+
+// wasmModule is an instantiated WebAssembly module
+type wasmModule interface {
+	// functions lists the module's exported functions by name
+	functions() []wasmFunction
+	// call runs an exported function. args are int32, int64, float32 or float64 matching fn.params.
+	// A trap is returned as a *wasmTrapError; an interrupted call returns ctx's error.
+	call(ctx context.Context, fn wasmFunction, args []any) ([]any, error)
+}
+
+// wasmTrapError reports that a function aborted, e.g. on unreachable or an out-of-bounds access
+type wasmTrapError struct {
+	message string
+}
+
+func (e *wasmTrapError) Error() string {
+	return "wasm trap: " + e.message
+}
+
+// wasmDialer loads a WebAssembly module and serves its exported functions as tools from within the gateway.
+// Every dial, including reconnects after a restart, instantiates the module afresh.
+type wasmDialer struct{}
+
+func (wasmDialer) Name() string { return config.TransportWASM }
+
+func (wasmDialer) Dial(ctx context.Context, cfg config.ServerConfig) (mcp.Transport, error) {
+	module, err := loadWASMModule(ctx, cfg.WASM.Path)
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := selectWASMFunctions(cfg.Name, module.functions(), cfg.WASM.Functions)
+	if err != nil {
+		return nil, err
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: cfg.Name, Version: "wasm"}, nil)
+	for _, fn := range funcs {
+		server.AddTool(wasmTool(fn), wasmToolHandler(module, fn))
+	}
+	return connectInMemory(ctx, server)
+}
+
+// selectWASMFunctions picks the functions to serve as tools. Without a list, every eligible export
+// is served except those starting with "_", which toolchains use for runtime internals such as _start.
+func selectWASMFunctions(server string, exported []wasmFunction, names []string) ([]wasmFunction, error) {
+	byName := make(map[string]wasmFunction, len(exported))
+	for _, fn := range exported {
+		byName[fn.name] = fn
+	}
+
+	var selected []wasmFunction
+	if len(names) > 0 {
+		for _, name := range names {
+			fn, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("function %s is not exported by the module", name)
+			}
+			if err := wasmFunctionEligible(server, fn); err != nil {
+				return nil, err
+			}
+			selected = append(selected, fn)
+		}
+		return selected, nil
+	}
+
+	for _, fn := range exported {
+		if strings.HasPrefix(fn.name, "_") {
+			continue
+		}
+		if err := wasmFunctionEligible(server, fn); err != nil {
+			slog.Debug("Skipping WebAssembly export", "server", server, "error", err)
+			continue
+		}
+		selected = append(selected, fn)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("module exports no functions that can be served as tools")
+	}
+	return selected, nil
+}
+
+// wasmFunctionEligible reports why a function cannot be served as a tool, if it cannot
+func wasmFunctionEligible(server string, fn wasmFunction) error {
+	if err := validator.ValidateRequest(server, fn.name, map[string]any{}); err != nil {
+		return fmt.Errorf("function %s cannot be a tool: %w", fn.name, err)
+	}
+	for _, kind := range append(append([]string{}, fn.params...), fn.results...) {
+		if !isWASMNumber(kind) {
+			return fmt.Errorf("function %s cannot be a tool: %s values are not supported", fn.name, kind)
+		}
+	}
+	return nil
+}
+
+func isWASMNumber(kind string) bool {
+	switch kind {
+	case wasmI32, wasmI64, wasmF32, wasmF64:
+		return true
+	}
+	return false
+}
+
+// wasmValueSchema is the JSON Schema of a value of the given type
+func wasmValueSchema(kind string) map[string]any {
+	if kind == wasmF32 || kind == wasmF64 {
+		return map[string]any{"type": "number"}
+	}
+	schema := map[string]any{"type": "integer"}
+	if kind == wasmI32 {
+		schema["minimum"] = math.MinInt32
+		schema["maximum"] = math.MaxInt32
+	}
+	return schema
+}
+
+// wasmValuesSchema describes a fixed-length array holding one value per type
+func wasmValuesSchema(kinds []string) map[string]any {
+	prefix := make([]any, len(kinds))
+	items := map[string]any{"type": "integer"}
+	for i, kind := range kinds {
+		prefix[i] = wasmValueSchema(kind)
+		if kind == wasmF32 || kind == wasmF64 {
+			items = map[string]any{"type": "number"}
+		}
+	}
+	return map[string]any{
+		"type":        "array",
+		"prefixItems": prefix,
+		"items":       items,
+		"minItems":    len(kinds),
+		"maxItems":    len(kinds),
+	}
+}
+
+// wasmTool describes a function as a tool taking {"args": [...]} and returning {"results": [...]}
+func wasmTool(fn wasmFunction) *mcp.Tool {
+	input := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"args": wasmValuesSchema(fn.params)},
+		"additionalProperties": false,
+	}
+	if len(fn.params) > 0 {
+		input["required"] = []any{"args"}
+	}
+	return &mcp.Tool{
+		Name:        fn.name,
+		Description: fmt.Sprintf("WebAssembly function %s(%s) -> (%s)", fn.name, strings.Join(fn.params, ", "), strings.Join(fn.results, ", ")),
+		InputSchema: input,
+		OutputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"results": wasmValuesSchema(fn.results)},
+			"required":   []any{"results"},
+		},
+	}
+}
+
+// wasmToolHandler calls fn with the arguments of a tool call.
+// Bad arguments and traps are tool errors so that the caller can see what went wrong.
+func wasmToolHandler(module wasmModule, fn wasmFunction) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := decodeWASMArgs(req.Params.Arguments, fn.params)
+		if err != nil {
+			return wasmToolError(err), nil
+		}
+
+		results, err := module.call(ctx, fn, args)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return wasmToolError(err), nil
+		}
+		for _, r := range results {
+			if f, ok := r.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				return wasmToolError(fmt.Errorf("result %v is not representable in JSON", f)), nil
+			}
+		}
+
+		structured := map[string]any{"results": results}
+		text, err := json.Marshal(structured)
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{&mcp.TextContent{Text: string(text)}},
+			StructuredContent: structured,
+		}, nil
+	}
+}
+
+func wasmToolError(err error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}}
+}
+
+// decodeWASMArgs converts {"args": [...]} to Go values of the given types, rejecting values that don't fit
+func decodeWASMArgs(raw json.RawMessage, kinds []string) ([]any, error) {
+	var input struct {
+		Args []any `json:"args"`
+	}
+	if len(raw) > 0 && string(raw) != "null" {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&input); err != nil {
+			return nil, fmt.Errorf("input must be {\"args\": [numbers]}: %w", err)
+		}
+	}
+	if len(input.Args) != len(kinds) {
+		return nil, fmt.Errorf("expected %d args, got %d", len(kinds), len(input.Args))
+	}
+
+	args := make([]any, len(kinds))
+	for i, kind := range kinds {
+		n, ok := input.Args[i].(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("args[%d]: %v is not a number", i, input.Args[i])
+		}
+		v, err := parseWASMValue(n.String(), kind)
+		if err != nil {
+			return nil, fmt.Errorf("args[%d]: %w", i, err)
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+func parseWASMValue(s, kind string) (any, error) {
+	switch kind {
+	case wasmI32:
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an i32", s)
+		}
+		return int32(v), nil
+	case wasmI64:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an i64", s)
+		}
+		return v, nil
+	case wasmF32:
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an f32", s)
+		}
+		return float32(v), nil
+	case wasmF64:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an f64", s)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s values are not supported", kind)
+	}
+}
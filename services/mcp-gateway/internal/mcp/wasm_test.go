@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWASMArgs(t *testing.T) {
+	kinds := []string{wasmI32, wasmI64, wasmF32, wasmF64}
+	tests := []struct {
+		name    string
+		input   string
+		want    []any
+		wantErr string
+	}{
+		{name: "all types", input: `{"args": [-7, 9007199254740993, 1.5, 2.25]}`, want: []any{int32(-7), int64(9007199254740993), float32(1.5), 2.25}},
+		{name: "too few", input: `{"args": [1, 2, 3]}`, wantErr: "expected 4 args, got 3"},
+		{name: "missing", input: `{}`, wantErr: "expected 4 args, got 0"},
+		{name: "i32 overflow", input: `{"args": [2147483648, 0, 0, 0]}`, wantErr: "args[0]"},
+		{name: "fraction for an integer", input: `{"args": [1, 1.5, 0, 0]}`, wantErr: "args[1]"},
+		{name: "string", input: `{"args": ["1", 0, 0, 0]}`, wantErr: "args"},
+		{name: "unknown field", input: `{"args": [1, 2, 3, 4], "extra": true}`, wantErr: "extra"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeWASMArgs(json.RawMessage(tt.input), kinds)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	args, err := decodeWASMArgs(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, args, "functions without parameters need no input")
+}
+
+func TestSelectWASMFunctions(t *testing.T) {
+	exported := []wasmFunction{
+		{name: "add", params: []string{wasmI32, wasmI32}, results: []string{wasmI32}},
+		{name: "_start"},
+		{name: "apply", params: []string{"funcref"}},
+		{name: "scale", params: []string{wasmF64}, results: []string{wasmF64}},
+	}
+
+	funcs, err := selectWASMFunctions("math", exported, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []wasmFunction{exported[0], exported[3]}, funcs, "internal and ineligible exports are skipped")
+
+	funcs, err = selectWASMFunctions("math", exported, []string{"scale"})
+	require.NoError(t, err)
+	assert.Equal(t, []wasmFunction{exported[3]}, funcs)
+
+	_, err = selectWASMFunctions("math", exported, []string{"missing"})
+	assert.ErrorContains(t, err, "not exported")
+
+	_, err = selectWASMFunctions("math", exported, []string{"apply"})
+	assert.ErrorContains(t, err, "funcref")
+
+	_, err = selectWASMFunctions("math", exported[1:3], nil)
+	assert.ErrorContains(t, err, "no functions")
+}
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// wazeroModule runs a module with wazero, a WebAssembly runtime written in Go, so that static
+// builds (CGO_ENABLED=0) can serve modules. The module gets WASI without arguments, environment
+// variables, stdio or preopened directories, so it can only compute.
+type wazeroModule struct {
+	mu       sync.Mutex // an instance runs one call at a time
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	instance api.Module
+	funcs    []wasmFunction
+}
+
+func loadWASMModule(ctx context.Context, path string) (wasmModule, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load module %s: %w", path, err)
+	}
+
+	// Closes the instance running a call whose context is done, which is how calls are interrupted
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to define WASI: %w", err)
+	}
+	compiled, err := runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to load module %s: %w", path, err)
+	}
+
+	m := &wazeroModule{runtime: runtime, compiled: compiled}
+	if err := m.instantiate(ctx); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate module %s: %w", path, err)
+	}
+
+	for name, def := range compiled.ExportedFunctions() {
+		m.funcs = append(m.funcs, wasmFunction{
+			name:    name,
+			params:  wasmKinds(def.ParamTypes()),
+			results: wasmKinds(def.ResultTypes()),
+		})
+	}
+	slices.SortFunc(m.funcs, func(a, b wasmFunction) int { return strings.Compare(a.name, b.name) })
+	return m, nil
+}
+
+// instantiate creates a fresh instance of the module, with new memory and globals
+func (m *wazeroModule) instantiate(ctx context.Context) error {
+	// Anonymous, so that the module can be instantiated again after its instance is closed.
+	// WASI reactors expect _initialize to run before any other export; _start of commands is not run.
+	instance, err := m.runtime.InstantiateModule(ctx, m.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return err
+	}
+	m.instance = instance
+	return nil
+}
+
+func wasmKinds(types []api.ValueType) []string {
+	kinds := make([]string, len(types))
+	for i, ty := range types {
+		kinds[i] = api.ValueTypeName(ty)
+		// Types wazero has no name for, such as v128 and funcref
+		if kinds[i] == "unknown" {
+			kinds[i] = fmt.Sprintf("type %#x", ty)
+		}
+	}
+	return kinds
+}
+
+func (m *wazeroModule) functions() []wasmFunction {
+	return m.funcs
+}
+
+func (m *wazeroModule) call(ctx context.Context, fn wasmFunction, args []any) ([]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// An interrupted call or proc_exit closes the instance, and the module starts over
+	if m.instance.IsClosed() {
+		if err := m.instantiate(ctx); err != nil {
+			return nil, fmt.Errorf("failed to instantiate module: %w", err)
+		}
+	}
+	f := m.instance.ExportedFunction(fn.name)
+	if f == nil {
+		return nil, fmt.Errorf("function %s is not exported", fn.name)
+	}
+
+	params := make([]uint64, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case int32:
+			params[i] = api.EncodeI32(v)
+		case int64:
+			params[i] = api.EncodeI64(v)
+		case float32:
+			params[i] = api.EncodeF32(v)
+		case float64:
+			params[i] = api.EncodeF64(v)
+		default:
+			return nil, fmt.Errorf("args[%d]: unsupported value %T", i, arg)
+		}
+	}
+
+	ret, err := f.Call(ctx, params...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// "wasm error: integer divide by zero" followed by a stack trace
+		message, _, _ := strings.Cut(err.Error(), "\n")
+		return nil, &wasmTrapError{message: strings.TrimPrefix(message, "wasm error: ")}
+	}
+
+	// Results are widened so that callers only deal with int32, int64 and float64
+	results := make([]any, len(ret))
+	for i, v := range ret {
+		switch fn.results[i] {
+		case wasmI32:
+			results[i] = api.DecodeI32(v)
+		case wasmI64:
+			results[i] = int64(v)
+		case wasmF32:
+			results[i] = float64(api.DecodeF32(v))
+		case wasmF64:
+			results[i] = api.DecodeF64(v)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWASM is the binary of this module:
+//
+//	(module
+//	  (memory (export "memory") 1)
+//	  (func (export "add") (param i32 i32) (result i32)
+//	    local.get 0 local.get 1 i32.add)
+//	  (func (export "div") (param i64 i64) (result i64)
+//	    local.get 0 local.get 1 i64.div_s)
+//	  (func (export "divmod") (param i32 i32) (result i32 i32)
+//	    local.get 0 local.get 1 i32.div_u
+//	    local.get 0 local.get 1 i32.rem_u)
+//	  (func (export "half") (param f32) (result f32)
+//	    local.get 0 f32.const 2 f32.div)
+//	  (func (export "spin")
+//	    (loop br 0))
+//	  (func (export "_internal") (result i32)
+//	    i32.const 1))
+var testWASM = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x20, 0x06, 0x60, 0x02, 0x7f, 0x7f, 0x01,
+	0x7f, 0x60, 0x02, 0x7e, 0x7e, 0x01, 0x7e, 0x60, 0x02, 0x7f, 0x7f, 0x02, 0x7f, 0x7f, 0x60, 0x01,
+	0x7d, 0x01, 0x7d, 0x60, 0x00, 0x00, 0x60, 0x00, 0x01, 0x7f, 0x03, 0x07, 0x06, 0x00, 0x01, 0x02,
+	0x03, 0x04, 0x05, 0x05, 0x03, 0x01, 0x00, 0x01, 0x07, 0x39, 0x07, 0x06, 0x6d, 0x65, 0x6d, 0x6f,
+	0x72, 0x79, 0x02, 0x00, 0x03, 0x61, 0x64, 0x64, 0x00, 0x00, 0x03, 0x64, 0x69, 0x76, 0x00, 0x01,
+	0x06, 0x64, 0x69, 0x76, 0x6d, 0x6f, 0x64, 0x00, 0x02, 0x04, 0x68, 0x61, 0x6c, 0x66, 0x00, 0x03,
+	0x04, 0x73, 0x70, 0x69, 0x6e, 0x00, 0x04, 0x09, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x00, 0x05, 0x0a, 0x36, 0x06, 0x07, 0x00, 0x20, 0x00, 0x20, 0x01, 0x6a, 0x0b, 0x07, 0x00,
+	0x20, 0x00, 0x20, 0x01, 0x7f, 0x0b, 0x0c, 0x00, 0x20, 0x00, 0x20, 0x01, 0x6e, 0x20, 0x00, 0x20,
+	0x01, 0x70, 0x0b, 0x0a, 0x00, 0x20, 0x00, 0x43, 0x00, 0x00, 0x00, 0x40, 0x95, 0x0b, 0x07, 0x00,
+	0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b, 0x04, 0x00, 0x41, 0x01, 0x0b,
+}
+
+// connectWASMServer serves testWASM through the wasm transport
+func connectWASMServer(t *testing.T, functions ...string) *ClientManager {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "math.wasm")
+	require.NoError(t, os.WriteFile(path, testWASM, 0o644))
+
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	t.Cleanup(func() { _ = cm.Close() })
+	err := cm.Initialize(context.Background(), []config.ServerConfig{{
+		Name:      "math",
+		Transport: config.TransportWASM,
+		WASM:      &config.WASMConfig{Path: path, Functions: functions},
+		Timeout:   30000,
+	}})
+	require.NoError(t, err)
+	return cm
+}
+
+func callWASM(t *testing.T, ctx context.Context, cm *ClientManager, tool string, args ...any) *mcp.CallToolResult {
+	t.Helper()
+	result, err := cm.CallTool(ctx, "math", tool, map[string]any{"args": args})
+	require.NoError(t, err)
+	return result.(*mcp.CallToolResult)
+}
+
+func TestConnectClient_WASM(t *testing.T) {
+	cm := connectWASMServer(t)
+
+	var names []string
+	for _, tool := range cm.GetTools() {
+		names = append(names, tool.Name)
+	}
+	assert.ElementsMatch(t, []string{"add", "div", "divmod", "half", "spin"}, names)
+	assert.Empty(t, cm.processes, "no subprocess is spawned")
+
+	ctx := context.Background()
+	assert.Equal(t, map[string]any{"results": []any{float64(5)}}, callWASM(t, ctx, cm, "add", 2, 3).StructuredContent)
+	assert.Equal(t, map[string]any{"results": []any{float64(3), float64(1)}}, callWASM(t, ctx, cm, "divmod", 7, 2).StructuredContent)
+	assert.Equal(t, map[string]any{"results": []any{0.75}}, callWASM(t, ctx, cm, "half", 1.5).StructuredContent)
+
+	// Traps and bad arguments are tool errors, and the module stays usable
+	trapped := callWASM(t, ctx, cm, "div", 1, 0)
+	assert.True(t, trapped.IsError)
+	assert.Contains(t, trapped.Content[0].(*mcp.TextContent).Text, "wasm trap")
+	assert.True(t, callWASM(t, ctx, cm, "add", 1).IsError)
+	assert.Equal(t, map[string]any{"results": []any{float64(2)}}, callWASM(t, ctx, cm, "div", 4, 2).StructuredContent)
+}
+
+func TestConnectClient_WASMCancelledCall(t *testing.T) {
+	cm := connectWASMServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := cm.CallTool(ctx, "math", "spin", map[string]any{})
+	require.Error(t, err)
+
+	// The interrupted loop no longer holds the module
+	callCtx, callCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer callCancel()
+	assert.Equal(t, map[string]any{"results": []any{float64(3)}}, callWASM(t, callCtx, cm, "add", 1, 2).StructuredContent)
+}
+
+func TestConnectClient_WASMSelectedFunctions(t *testing.T) {
+	cm := connectWASMServer(t, "half")
+
+	tools := cm.GetTools()
+	require.Len(t, tools, 1)
+	assert.Equal(t, "half", tools[0].Name)
+}
+
+func TestConnectClient_WASMMissingModule(t *testing.T) {
+	cm := NewClientManager(NewProcessManager(30000, "never"))
+	t.Cleanup(func() { _ = cm.Close() })
+
+	err := cm.Initialize(context.Background(), []config.ServerConfig{{
+		Name:      "math",
+		Transport: config.TransportWASM,
+		WASM:      &config.WASMConfig{Path: filepath.Join(t.TempDir(), "missing.wasm")},
+	}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.wasm")
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// workerGroup owns a set of background goroutines so that shutdown can cancel them
+// and deterministically wait for them to exit. Once shut down it starts no new workers.
+type workerGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+}
+
+func newWorkerGroup() *workerGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workerGroup{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in a new goroutine with the group's context, which is cancelled on shutdown.
+// It reports false without running fn if the group has already been shut down.
+func (g *workerGroup) Go(name string, fn func(ctx context.Context)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		slog.Debug("Worker not started during shutdown", "worker", name)
+		return false
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+	return true
+}
+
+// Shutdown stops accepting new workers and cancels the running ones
+func (g *workerGroup) Shutdown() {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+	g.cancel()
+}
+
+// Wait blocks until every worker has exited or the timeout elapses, and reports whether all exited
+func (g *workerGroup) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
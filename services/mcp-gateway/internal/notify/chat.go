@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate renders a one-line summary of a notification for chat services
+const DefaultTemplate = `[mcp-gateway] {{.Server}} ` +
+	`{{if eq .Event "crash"}}crashed` +
+	`{{else if eq .Event "restart"}}is restarting in {{.BackoffMs}}ms (attempt {{.Attempt}}/{{.MaxAttempts}})` +
+	`{{else}}is quarantined after {{.Attempt}} restart attempts{{end}}` +
+	`{{with .Reason}}: {{.}}{{end}}`
+
+// discordContentLimit is the most characters Discord accepts in a message
+const discordContentLimit = 2000
+
+// ParseTemplate parses a message template, which is executed with a Notification. Empty text is DefaultTemplate.
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("notification").Parse(text)
+}
+
+// Slack posts notifications as messages to a Slack incoming webhook
+type Slack struct {
+	URL      string
+	Template *template.Template // default DefaultTemplate
+	Client   *http.Client       // default: http.DefaultClient
+}
+
+func (s Slack) Send(ctx context.Context, n Notification) error {
+	text, err := render(s.Template, n)
+	if err != nil {
+		return err
+	}
+	return postMessage(ctx, s.Client, s.URL, map[string]string{"text": text})
+}
+
+// Discord posts notifications as messages to a Discord webhook
+type Discord struct {
+	URL      string
+	Template *template.Template // default DefaultTemplate
+	Client   *http.Client       // default: http.DefaultClient
+}
+
+func (d Discord) Send(ctx context.Context, n Notification) error {
+	content, err := render(d.Template, n)
+	if err != nil {
+		return err
+	}
+	if runes := []rune(content); len(runes) > discordContentLimit {
+		content = string(runes[:discordContentLimit-1]) + "…"
+	}
+	return postMessage(ctx, d.Client, d.URL, map[string]string{"content": content})
+}
+
+// render executes a message template, failing permanently since the same notification renders the same way again
+func render(tmpl *template.Template, n Notification) (string, error) {
+	if tmpl == nil {
+		var err error
+		if tmpl, err = ParseTemplate(""); err != nil {
+			return "", &permanentError{err: err}
+		}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, n); err != nil {
+		return "", &permanentError{err: err}
+	}
+	return b.String(), nil
+}
+
+func postMessage(ctx context.Context, client *http.Client, url string, message map[string]string) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	return postJSON(ctx, client, url, nil, data)
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatReceiver records the JSON messages posted to it
+func chatReceiver(t *testing.T, status int) (*httptest.Server, <-chan map[string]string) {
+	messages := make(chan map[string]string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		messages <- message
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, messages
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		n    Notification
+		want string
+	}{
+		{"crash", Notification{Event: Crash, Server: "weather"}, "[mcp-gateway] weather crashed"},
+		{"restart", Notification{Event: Restart, Server: "weather", Attempt: 2, MaxAttempts: 3, BackoffMs: 2000, Reason: "transport_closed"}, "[mcp-gateway] weather is restarting in 2000ms (attempt 2/3): transport_closed"},
+		{"quarantine", Notification{Event: Quarantine, Server: "weather", Attempt: 3, MaxAttempts: 3}, "[mcp-gateway] weather is quarantined after 3 restart attempts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := render(tmpl, tt.n)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSlack_PostsText(t *testing.T) {
+	srv, messages := chatReceiver(t, http.StatusOK)
+	tmpl, err := ParseTemplate(`{{.Server}} {{.Event}}`)
+	require.NoError(t, err)
+
+	err = Slack{URL: srv.URL, Template: tmpl}.Send(context.Background(), Notification{Event: Crash, Server: "weather", At: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"text": "weather crash"}, <-messages)
+}
+
+func TestDiscord_PostsContent(t *testing.T) {
+	// Discord answers executed webhooks with 204
+	srv, messages := chatReceiver(t, http.StatusNoContent)
+
+	err := Discord{URL: srv.URL}.Send(context.Background(), Notification{Event: Crash, Server: "weather"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"content": "[mcp-gateway] weather crashed"}, <-messages)
+
+	tmpl, err := ParseTemplate(strings.Repeat("x", 3000))
+	require.NoError(t, err)
+	err = Discord{URL: srv.URL, Template: tmpl}.Send(context.Background(), Notification{Event: Crash, Server: "weather"})
+	require.NoError(t, err)
+	assert.Len(t, []rune((<-messages)["content"]), discordContentLimit)
+}
+
+func TestChat_TemplateErrorsArePermanent(t *testing.T) {
+	srv, _ := chatReceiver(t, http.StatusOK)
+	tmpl, err := ParseTemplate(`{{.Unknown}}`)
+	require.NoError(t, err)
+
+	err = Slack{URL: srv.URL, Template: tmpl}.Send(context.Background(), Notification{Event: Crash, Server: "weather"})
+	var permanent *permanentError
+	assert.ErrorAs(t, err, &permanent)
+}
@@ -0,0 +1,266 @@
+// Package notify tells external receivers, such as webhooks or Slack and Discord channels, when servers crash, restart or are quarantined.
+// A Notifier subscribes to the gateway's event bus and delivers in the background with retries, so that
+// a slow or unreachable receiver never delays the servers it reports on.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+)
+
+// Kind classifies notifications
+type Kind string
+
+const (
+	Crash      Kind = "crash"      // the server crashed
+	Restart    Kind = "restart"    // a restart of the server is waiting for its backoff
+	Quarantine Kind = "quarantine" // the server used up its restart attempts and is only restarted manually
+)
+
+// AllKinds are the kinds of all notifications
+var AllKinds = []Kind{Crash, Restart, Quarantine}
+
+// EventTypes are the bus events that notifications are made from
+var EventTypes = []events.Type{events.StatusChanged, events.RestartAttempted, events.RestartExhausted}
+
+// queueSize is how many notifications may wait for delivery to one target before new ones are dropped
+const queueSize = 64
+
+// DefaultRetryDelay is the wait before the first retry of a delivery, doubled for each further one
+const DefaultRetryDelay = time.Second
+
+// Notification is what receivers are told about a server. Which fields are set depends on Event.
+type Notification struct {
+	Event       Kind      `json:"event"`
+	Server      string    `json:"server"`
+	At          time.Time `json:"at"`
+	Attempt     int       `json:"attempt,omitempty"`     // restart, quarantine
+	MaxAttempts int       `json:"maxAttempts,omitempty"` // restart, quarantine
+	BackoffMs   int64     `json:"backoffMs,omitempty"`   // restart
+	Reason      string    `json:"reason,omitempty"`      // restart, quarantine
+}
+
+// FromEvent returns the notification made from a bus event, if the event is one that is notified
+func FromEvent(ev events.Event) (Notification, bool) {
+	n := Notification{Server: ev.Server, At: ev.At}
+	switch {
+	case ev.Type == events.StatusChanged && ev.To == string(mcp.StatusCrashed):
+		n.Event = Crash
+	case ev.Type == events.RestartAttempted:
+		n.Event = Restart
+		n.Attempt, n.MaxAttempts, n.BackoffMs, n.Reason = ev.Attempt, ev.MaxAttempts, ev.BackoffMs, ev.Reason
+	case ev.Type == events.RestartExhausted:
+		n.Event = Quarantine
+		n.Attempt, n.MaxAttempts, n.Reason = ev.Attempt, ev.MaxAttempts, ev.Reason
+	default:
+		return Notification{}, false
+	}
+	return n, true
+}
+
+// Sender delivers a notification to one receiver
+type Sender interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// permanentError is a failed delivery that is not retried, e.g. one the receiver rejected as invalid
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Webhook posts notifications as JSON
+type Webhook struct {
+	URL     string
+	Headers map[string]string // added to each request, e.g. Authorization
+	Client  *http.Client      // default: http.DefaultClient; the timeout of each attempt is the Target's
+}
+
+func (w Webhook) Send(ctx context.Context, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	return postJSON(ctx, w.Client, w.URL, w.Headers, data)
+}
+
+// postJSON posts a JSON body, failing permanently on responses other than 2xx that retrying cannot fix
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf("receiver responded with %s", resp.Status)
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+		return &permanentError{err: err}
+	}
+	return err
+}
+
+// Target is a receiver of notifications and how deliveries to it are made
+type Target struct {
+	Name       string // identifies the receiver in logs, e.g. its URL
+	Sender     Sender
+	Kinds      []Kind        // notified kinds, nil for all
+	Timeout    time.Duration // of each attempt, 0 for none
+	Retries    int           // attempts after the first that failed
+	RetryDelay time.Duration // default DefaultRetryDelay
+}
+
+type target struct {
+	Target
+	queue chan Notification
+}
+
+// Notifier delivers the notifications made from bus events to its targets, each on its own goroutine
+// in the order of the events
+type Notifier struct {
+	targets []*target
+	ctx     context.Context // cancelled when Close gives up on pending deliveries
+	cancel  context.CancelFunc
+	mu      sync.Mutex // guards closed, so that no notification is queued once the queues are closed
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// New creates a Notifier and starts delivering to targets. Subscribe it to a bus with EventTypes.
+func New(targets ...Target) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{ctx: ctx, cancel: cancel}
+	for _, t := range targets {
+		if t.RetryDelay <= 0 {
+			t.RetryDelay = DefaultRetryDelay
+		}
+		tgt := &target{Target: t, queue: make(chan Notification, queueSize)}
+		n.targets = append(n.targets, tgt)
+		n.wg.Go(func() { n.run(tgt) })
+	}
+	return n
+}
+
+// HandleEvent queues the notification made from ev for the targets that want it. It never blocks:
+// a target whose queue is full misses the notification.
+func (n *Notifier) HandleEvent(ev events.Event) {
+	notification, ok := FromEvent(ev)
+	if !ok {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	for _, t := range n.targets {
+		if t.Kinds != nil && !slices.Contains(t.Kinds, notification.Event) {
+			continue
+		}
+		select {
+		case t.queue <- notification:
+		default:
+			slog.Warn("Notification dropped, too many pending deliveries", "target", t.Name, "event", notification.Event, "server", notification.Server)
+		}
+	}
+}
+
+// Close stops accepting notifications and waits for the queued ones to be delivered. When ctx is done
+// first, pending deliveries are abandoned.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		for _, t := range n.targets {
+			close(t.queue)
+		}
+	}
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		n.cancel()
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// run delivers the notifications queued for a target until its queue is closed
+func (n *Notifier) run(t *target) {
+	for notification := range t.queue {
+		if err := n.deliver(t, notification); err != nil {
+			slog.Error("Failed to deliver notification", "target", t.Name, "event", notification.Event, "server", notification.Server, "error", err)
+		}
+	}
+}
+
+// deliver sends a notification, retrying failed attempts with a doubling delay
+func (n *Notifier) deliver(t *target, notification Notification) error {
+	delay := t.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := n.attempt(t, notification)
+		var permanent *permanentError
+		if err == nil || errors.As(err, &permanent) || attempt >= t.Retries {
+			return err
+		}
+		slog.Warn("Notification not delivered, retrying", "target", t.Name, "event", notification.Event, "server", notification.Server, "retryIn", delay, "error", err)
+		select {
+		case <-time.After(delay):
+		case <-n.ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// attempt sends a notification once, within the target's timeout
+func (n *Notifier) attempt(t *target, notification Notification) error {
+	ctx := n.ctx
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	return t.Sender.Send(ctx, notification)
+}
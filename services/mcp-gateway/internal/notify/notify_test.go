@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a webhook endpoint that answers with the queued statuses, then 200
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	received []Notification
+	headers  []http.Header
+	calls    atomic.Int32
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.calls.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if status == http.StatusOK {
+		var n Notification
+		if err := json.NewDecoder(req.Body).Decode(&n); err == nil {
+			r.received = append(r.received, n)
+			r.headers = append(r.headers, req.Header.Clone())
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) notifications() []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Notification(nil), r.received...)
+}
+
+func TestNotifier_PostsCrashRestartAndQuarantine(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	bus := events.NewBus()
+	n := New(Target{
+		Name:   srv.URL,
+		Sender: Webhook{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer secret"}},
+	})
+	unsubscribe := bus.Subscribe(n, EventTypes...)
+	defer unsubscribe()
+
+	bus.Publish(events.Event{Type: events.StatusChanged, Server: "weather", From: "available", To: "crashed"})
+	bus.Publish(events.Event{Type: events.StatusChanged, Server: "weather", From: "crashed", To: "restarting"})
+	bus.Publish(events.Event{Type: events.RestartAttempted, Server: "weather", Attempt: 1, MaxAttempts: 3, BackoffMs: 1000, Reason: "transport_closed"})
+	bus.Publish(events.Event{Type: events.RestartExhausted, Server: "weather", Attempt: 3, MaxAttempts: 3, Reason: "transport_closed"})
+	require.NoError(t, n.Close(context.Background()))
+
+	received := rcv.notifications()
+	require.Len(t, received, 3)
+	assert.Equal(t, Crash, received[0].Event)
+	assert.Equal(t, "weather", received[0].Server)
+	assert.False(t, received[0].At.IsZero())
+	assert.Equal(t, Notification{Event: Restart, Server: "weather", At: received[1].At, Attempt: 1, MaxAttempts: 3, BackoffMs: 1000, Reason: "transport_closed"}, received[1])
+	assert.Equal(t, Quarantine, received[2].Event)
+	assert.Equal(t, 3, received[2].Attempt)
+	assert.Equal(t, "Bearer secret", rcv.headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", rcv.headers[0].Get("Content-Type"))
+}
+
+func TestNotifier_FiltersKinds(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	n := New(Target{Name: srv.URL, Sender: Webhook{URL: srv.URL}, Kinds: []Kind{Quarantine}})
+	n.HandleEvent(events.Event{Type: events.StatusChanged, Server: "weather", To: "crashed", At: time.Now()})
+	n.HandleEvent(events.Event{Type: events.RestartExhausted, Server: "weather", At: time.Now()})
+	require.NoError(t, n.Close(context.Background()))
+
+	received := rcv.notifications()
+	require.Len(t, received, 1)
+	assert.Equal(t, Quarantine, received[0].Event)
+
+	// Closed notifiers drop further events
+	n.HandleEvent(events.Event{Type: events.RestartExhausted, Server: "weather", At: time.Now()})
+	assert.Len(t, rcv.notifications(), 1)
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	rcv := &receiver{statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	n := New(Target{Name: srv.URL, Sender: Webhook{URL: srv.URL}, Retries: 2, RetryDelay: time.Millisecond})
+	n.HandleEvent(events.Event{Type: events.RestartExhausted, Server: "weather", At: time.Now()})
+	require.NoError(t, n.Close(context.Background()))
+
+	assert.Equal(t, int32(3), rcv.calls.Load())
+	assert.Len(t, rcv.notifications(), 1)
+}
+
+func TestNotifier_GivesUp(t *testing.T) {
+	t.Run("after the retries", func(t *testing.T) {
+		rcv := &receiver{statuses: []int{500, 500, 500}}
+		srv := httptest.NewServer(rcv)
+		defer srv.Close()
+
+		n := New(Target{Name: srv.URL, Sender: Webhook{URL: srv.URL}, Retries: 1, RetryDelay: time.Millisecond})
+		n.HandleEvent(events.Event{Type: events.RestartExhausted, Server: "weather", At: time.Now()})
+		require.NoError(t, n.Close(context.Background()))
+		assert.Equal(t, int32(2), rcv.calls.Load())
+	})
+
+	t.Run("on a rejected notification", func(t *testing.T) {
+		rcv := &receiver{statuses: []int{http.StatusBadRequest}}
+		srv := httptest.NewServer(rcv)
+		defer srv.Close()
+
+		n := New(Target{Name: srv.URL, Sender: Webhook{URL: srv.URL}, Retries: 3, RetryDelay: time.Millisecond})
+		n.HandleEvent(events.Event{Type: events.RestartExhausted, Server: "weather", At: time.Now()})
+		require.NoError(t, n.Close(context.Background()))
+		assert.Equal(t, int32(1), rcv.calls.Load())
+	})
+}
+
+func TestNotifier_TimesOutAttempts(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	n := New(Target{Name: srv.URL, Sender: Webhook{URL: srv.URL}, Timeout: 20 * time.Millisecond, Retries: 1, RetryDelay: time.Millisecond})
+	start := time.Now()
+	n.HandleEvent(events.Event{Type: events.RestartExhausted, Server: "weather", At: time.Now()})
+	require.NoError(t, n.Close(context.Background()))
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// AWSProvider reads secrets from AWS Secrets Manager with the AWS SDK, which finds credentials and
+// the region the way the AWS CLI does: environment variables, shared config and credentials files
+// (AWS_PROFILE, SSO), web identity (IRSA), and ECS task or EC2 instance roles.
+// Secrets named by ARN are read from the ARN's region. AWS_ENDPOINT_URL_SECRETS_MANAGER or
+// AWS_ENDPOINT_URL overrides the endpoint, e.g. for a VPC endpoint.
+type AWSProvider struct {
+	mu     sync.Mutex
+	client *secretsmanager.Client
+}
+
+func (p *AWSProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	client, err := p.loadClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	secretID, key := splitKey(ref)
+
+	var optFns []func(*secretsmanager.Options)
+	if region := arnRegion(secretID); region != "" {
+		optFns = append(optFns, func(o *secretsmanager.Options) { o.Region = region })
+	}
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)}, optFns...)
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("binary secrets are not supported")
+	}
+	if key == "" {
+		return *out.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*out.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so #%s cannot be selected", key)
+	}
+	return selectField(fields, key)
+}
+
+// loadClient loads the AWS configuration on first use, so that gateways without AWS secrets never
+// look for credentials. A failed load is retried on the next fetch.
+func (p *AWSProvider) loadClient(ctx context.Context) (*secretsmanager.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+		}
+		p.client = secretsmanager.NewFromConfig(cfg)
+	}
+	return p.client, nil
+}
+
+// arnRegion returns the region of an ARN (arn:aws:secretsmanager:<region>:...), or "" for secret names
+func arnRegion(secretID string) string {
+	if parts := strings.Split(secretID, ":"); len(parts) > 3 && parts[0] == "arn" {
+		return parts[3]
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+// Package secrets fetches values such as the api_key field of secret/data/weather in Vault from
+// a secrets manager, caching them and optionally refreshing them in the background.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the built-in providers, as used in valueFrom of env values
+const (
+	Vault             = "vault"             // <path>[#<key>]
+	AWSSecretsManager = "awsSecretsManager" // <secret name or ARN>[#<key>]
+)
+
+// fetchTimeout bounds a single fetch from a provider
+const fetchTimeout = 10 * time.Second
+
+// Provider fetches a secret from a backend
+type Provider interface {
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// splitKey separates the path of a secret from the optional #key selecting a field of it
+func splitKey(ref string) (path, key string) {
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// selectField returns the field key of a secret holding several fields, or its only field when key is empty.
+// Non-string fields are returned as JSON.
+func selectField(fields map[string]any, key string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret has %d fields, select one with #key", len(fields))
+		}
+		for k := range fields {
+			key = k
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %s", key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// secretRef identifies a secret of a provider
+type secretRef struct {
+	provider string
+	ref      string
+}
+
+func (r secretRef) String() string {
+	return r.provider + " secret " + r.ref
+}
+
+// Store caches secrets by provider and reference
+type Store struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	values    map[secretRef]string
+}
+
+// NewStore creates a Store fetching secrets from the given providers by name
+func NewStore(providers map[string]Provider) *Store {
+	return &Store{providers: providers, values: make(map[secretRef]string)}
+}
+
+// NewDefaultStore creates a Store with the built-in providers, configured from the standard
+// VAULT_* environment variables and the AWS SDK's default configuration
+func NewDefaultStore() *Store {
+	return NewStore(map[string]Provider{
+		Vault:             VaultProvider{},
+		AWSSecretsManager: &AWSProvider{},
+	})
+}
+
+// Get returns the cached value of a provider's secret, fetching it on first use
+func (s *Store) Get(provider, ref string) (string, error) {
+	key := secretRef{provider: provider, ref: ref}
+	s.mu.RLock()
+	cached, ok := s.values[key]
+	s.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	fetched, err := s.fetch(context.Background(), key)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.values[key] = fetched
+	s.mu.Unlock()
+	return fetched, nil
+}
+
+func (s *Store) fetch(ctx context.Context, key secretRef) (string, error) {
+	provider, ok := s.providers[key.provider]
+	if !ok {
+		return "", fmt.Errorf("no provider for %s secrets", key.provider)
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	fetched, err := provider.Fetch(ctx, key.ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", key, err)
+	}
+	return fetched, nil
+}
+
+// Refresh fetches every cached reference again. References that fail keep their previous value.
+func (s *Store) Refresh(ctx context.Context) error {
+	s.mu.RLock()
+	refs := make([]secretRef, 0, len(s.values))
+	for ref := range s.values {
+		refs = append(refs, ref)
+	}
+	s.mu.RUnlock()
+
+	var errs []error
+	for _, ref := range refs {
+		fetched, err := s.fetch(ctx, ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.mu.Lock()
+		s.values[ref] = fetched
+		s.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Start refreshes the cache every interval until the returned function is called
+func (s *Store) Start(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+					slog.Warn("Failed to refresh secrets, keeping the previous values", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
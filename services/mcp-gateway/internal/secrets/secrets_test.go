@@ -0,0 +1,248 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider returns the values of a map and fails while err is set
+type fakeProvider struct {
+	mu     sync.Mutex
+	values map[string]string
+	err    error
+	calls  int
+}
+
+func (p *fakeProvider) Fetch(_ context.Context, ref string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.err != nil {
+		return "", p.err
+	}
+	value, ok := p.values[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func (p *fakeProvider) set(ref, value string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.values[ref] = value
+	p.err = err
+}
+
+func TestStore_CachesAndRefreshes(t *testing.T) {
+	provider := &fakeProvider{values: map[string]string{"kv/app#key": "v1"}}
+	store := NewStore(map[string]Provider{Vault: provider})
+
+	value, err := store.Get(Vault, "kv/app#key")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", value)
+	_, err = store.Get(Vault, "kv/app#key")
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.calls, "values are cached")
+
+	_, err = store.Get(Vault, "kv/missing")
+	assert.ErrorContains(t, err, "failed to fetch vault secret kv/missing")
+	_, err = store.Get(AWSSecretsManager, "weather")
+	assert.ErrorContains(t, err, "no provider")
+
+	provider.set("kv/app#key", "v2", errors.New("sealed"))
+	assert.Error(t, store.Refresh(context.Background()))
+	value, _ = store.Get(Vault, "kv/app#key")
+	assert.Equal(t, "v1", value, "a failed refresh keeps the previous value")
+
+	provider.set("kv/app#key", "v2", nil)
+	require.NoError(t, store.Refresh(context.Background()))
+	value, _ = store.Get(Vault, "kv/app#key")
+	assert.Equal(t, "v2", value)
+}
+
+func TestStore_StartRefreshesPeriodically(t *testing.T) {
+	provider := &fakeProvider{values: map[string]string{"kv/app#key": "v1"}}
+	store := NewStore(map[string]Provider{Vault: provider})
+	_, err := store.Get(Vault, "kv/app#key")
+	require.NoError(t, err)
+
+	stop := store.Start(10 * time.Millisecond)
+	defer stop()
+	provider.set("kv/app#key", "v2", nil)
+	assert.Eventually(t, func() bool {
+		value, _ := store.Get(Vault, "kv/app#key")
+		return value == "v2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func newVaultServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "root-token", r.Header.Get("X-Vault-Token"))
+		assert.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+		assert.Equal(t, "/v1/secret/data/weather", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root-token")
+	t.Setenv("VAULT_NAMESPACE", "team-a")
+	return srv
+}
+
+func TestVaultProvider(t *testing.T) {
+	kv2 := `{"data": {"data": {"api_key": "k-123", "port": 8080}, "metadata": {"version": 3}}}`
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "KV v2 field", status: 200, body: kv2, ref: "secret/data/weather#api_key", want: "k-123"},
+		{name: "Non-string field", status: 200, body: kv2, ref: "secret/data/weather#port", want: "8080"},
+		{name: "KV v1", status: 200, body: `{"data": {"api_key": "k-1"}}`, ref: "secret/data/weather", want: "k-1"},
+		{name: "Missing field", status: 200, body: kv2, ref: "secret/data/weather#token", wantErr: "no field token"},
+		{name: "Ambiguous field", status: 200, body: kv2, ref: "secret/data/weather", wantErr: "select one with #key"},
+		{name: "Forbidden", status: 403, body: `{"errors": ["permission denied"]}`, ref: "secret/data/weather#api_key", wantErr: "403 Forbidden: permission denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newVaultServer(t, tt.status, tt.body)
+
+			value, err := VaultProvider{}.Fetch(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestVaultProvider_RequiresToken(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	t.Setenv("VAULT_TOKEN", "")
+
+	_, err := VaultProvider{}.Fetch(context.Background(), "secret/data/weather#api_key")
+	assert.ErrorContains(t, err, "VAULT_TOKEN")
+}
+
+// isolateAWSConfig keeps the AWS SDK from finding the credentials and config of the machine
+func isolateAWSConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	for _, name := range []string{"AWS_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_ENDPOINT_URL", "AWS_DEFAULT_REGION"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_REGION", "us-east-1")
+}
+
+// newSecretsManagerServer answers GetSecretValue of secretID, signed by accessKeyID for region
+func newSecretsManagerServer(t *testing.T, secretID, accessKeyID, region string, status int, body string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"), auth)
+		assert.Contains(t, auth, "/"+region+"/secretsmanager/aws4_request")
+		data, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"SecretId": "`+secretID+`"}`, string(data))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", srv.URL)
+}
+
+func TestAWSProvider(t *testing.T) {
+	const arn = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:weather-AbCdEf"
+	jsonSecret := `{"SecretString": "{\"api_key\": \"k-123\"}"}`
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "Whole secret", status: 200, body: `{"SecretString": "plain"}`, ref: arn, want: "plain"},
+		{name: "JSON field", status: 200, body: jsonSecret, ref: arn + "#api_key", want: "k-123"},
+		{name: "Missing field", status: 200, body: jsonSecret, ref: arn + "#token", wantErr: "no field token"},
+		{name: "Not JSON", status: 200, body: `{"SecretString": "plain"}`, ref: arn + "#api_key", wantErr: "not a JSON object"},
+		{name: "Binary", status: 200, body: `{"SecretBinary": "AAEC"}`, ref: arn, wantErr: "binary secrets"},
+		{
+			name:    "Not found",
+			status:  400,
+			body:    `{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`,
+			ref:     arn,
+			wantErr: "ResourceNotFoundException",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateAWSConfig(t)
+			t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+			// Secrets named by ARN are read from the ARN's region rather than AWS_REGION
+			newSecretsManagerServer(t, arn, "AKID", "eu-west-1", tt.status, tt.body)
+
+			value, err := (&AWSProvider{}).Fetch(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestAWSProvider_SharedConfigProfile(t *testing.T) {
+	isolateAWSConfig(t)
+	credentials := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	require.NoError(t, os.WriteFile(credentials, []byte("[team-a]\naws_access_key_id = PROFILEKEY\naws_secret_access_key = secret\n"), 0o600))
+	config := os.Getenv("AWS_CONFIG_FILE")
+	require.NoError(t, os.WriteFile(config, []byte("[profile team-a]\nregion = ap-northeast-1\n"), 0o600))
+	t.Setenv("AWS_PROFILE", "team-a")
+	t.Setenv("AWS_REGION", "")
+	newSecretsManagerServer(t, "prod/db", "PROFILEKEY", "ap-northeast-1", 200, `{"SecretString": "{\"password\": \"pw\"}"}`)
+
+	value, err := (&AWSProvider{}).Fetch(context.Background(), "prod/db#password")
+
+	require.NoError(t, err)
+	assert.Equal(t, "pw", value)
+}
+
+func TestArnRegion(t *testing.T) {
+	assert.Equal(t, "eu-west-1", arnRegion("arn:aws:secretsmanager:eu-west-1:123456789012:secret:weather"))
+	assert.Empty(t, arnRegion("weather"))
+	assert.Empty(t, arnRegion("prod/db"))
+}
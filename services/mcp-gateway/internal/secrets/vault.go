@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// VaultProvider reads secrets from HashiCorp Vault over its HTTP API.
+// It is configured with VAULT_ADDR, VAULT_TOKEN and the optional VAULT_NAMESPACE.
+// The ref is the API path without /v1, e.g. secret/data/weather for the KV v2 engine mounted at secret/.
+type VaultProvider struct {
+	Client *http.Client // default: http.DefaultClient
+}
+
+func (p VaultProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN is not set")
+	}
+	path, key := splitKey(ref)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Data   map[string]any `json:"data"`
+		Errors []string       `json:"errors"`
+	}
+	// Error responses may not be JSON; the status is reported either way
+	_ = json.Unmarshal(body, &secret)
+	if resp.StatusCode != http.StatusOK {
+		if len(secret.Errors) > 0 {
+			return "", fmt.Errorf("vault responded with %s: %s", resp.Status, strings.Join(secret.Errors, "; "))
+		}
+		return "", fmt.Errorf("vault responded with %s", resp.Status)
+	}
+	if secret.Data == nil {
+		return "", fmt.Errorf("vault response has no data")
+	}
+
+	// KV v2 nests the fields under data.data next to data.metadata
+	fields := secret.Data
+	if inner, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = inner
+		}
+	}
+	return selectField(fields, key)
+}
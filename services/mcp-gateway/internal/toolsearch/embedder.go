@@ -0,0 +1,109 @@
+package toolsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultEmbedderTimeout is used when no timeout is configured
+	DefaultEmbedderTimeout = 5 * time.Second
+	// maxEmbedderErrorBytes caps how much of an error response is included in the error
+	maxEmbedderErrorBytes = 512
+)
+
+// HTTPEmbedderOptions configures an HTTPEmbedder
+type HTTPEmbedderOptions struct {
+	// URL is the embeddings endpoint, e.g. http://embeddings:8080/v1/embeddings
+	URL string
+	// Model is sent as the model of every request; empty omits it
+	Model string
+	// APIKey is sent as a bearer token; empty sends no Authorization header
+	APIKey string
+	// Timeout bounds each request
+	Timeout time.Duration
+}
+
+// HTTPEmbedder calls an embeddings API compatible with OpenAI's POST /v1/embeddings, which
+// self-hosted servers such as text-embeddings-inference, vLLM and Ollama also provide
+type HTTPEmbedder struct {
+	url    string
+	model  string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPEmbedder creates an embedder for an OpenAI-compatible embeddings API
+func NewHTTPEmbedder(opts HTTPEmbedderOptions) *HTTPEmbedder {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultEmbedderTimeout
+	}
+	return &HTTPEmbedder{
+		url:    opts.URL,
+		model:  opts.Model,
+		apiKey: opts.APIKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed implements Embedder
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(embeddingsRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxEmbedderErrorBytes))
+		return nil, fmt.Errorf("embeddings service responded with %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var out embeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	vectors := make([][]float64, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid embeddings response: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("invalid embeddings response: no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
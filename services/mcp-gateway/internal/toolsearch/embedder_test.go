@@ -0,0 +1,78 @@
+package toolsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPEmbedder_Embed(t *testing.T) {
+	var got embeddingsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		// Out of order, as the API allows
+		_, _ = w.Write([]byte(`{"data": [{"index": 1, "embedding": [0, 1]}, {"index": 0, "embedding": [1, 0]}]}`))
+	}))
+	defer server.Close()
+
+	e := NewHTTPEmbedder(HTTPEmbedderOptions{URL: server.URL, Model: "text-embedding-3-small", APIKey: "sk-test"})
+	vectors, err := e.Embed(context.Background(), []string{"first", "second"})
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0}, {0, 1}}, vectors)
+	assert.Equal(t, embeddingsRequest{Model: "text-embedding-3-small", Input: []string{"first", "second"}}, got)
+}
+
+func TestHTTPEmbedder_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedError string
+	}{
+		{name: "Error status", status: http.StatusUnauthorized, body: `{"error": "invalid key"}`, expectedError: `401 Unauthorized: {"error": "invalid key"}`},
+		{name: "Invalid JSON", status: http.StatusOK, body: `not json`, expectedError: "invalid embeddings response"},
+		{name: "Missing embedding", status: http.StatusOK, body: `{"data": [{"index": 0, "embedding": [1]}]}`, expectedError: "no embedding for input 1"},
+		{name: "Index out of range", status: http.StatusOK, body: `{"data": [{"index": 5, "embedding": [1]}]}`, expectedError: "index 5 out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewHTTPEmbedder(HTTPEmbedderOptions{URL: server.URL}).Embed(context.Background(), []string{"a", "b"})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestHTTPEmbedder_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	e := NewHTTPEmbedder(HTTPEmbedderOptions{URL: server.URL, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := e.Embed(context.Background(), []string{"a"})
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,233 @@
+// Package toolsearch ranks tools against a natural-language task description, so that agents
+// facing hundreds of tools can put only the relevant ones in their prompt.
+package toolsearch
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+)
+
+// Rankings reported with search results
+const (
+	RankingKeyword   = "keyword"   // BM25 over tool names and descriptions
+	RankingEmbedding = "embedding" // cosine similarity of embeddings
+)
+
+// Embedder turns texts into embedding vectors, one per text in the same order
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// Match is a tool and its relevance to the query; higher is more relevant.
+// Scores are comparable within one ranking only.
+type Match struct {
+	mcp.ToolInfo
+	Score float64 `json:"score"`
+}
+
+// Searcher ranks tools by embedding similarity when an embedder is configured, and by keywords
+// otherwise or when the embedder fails
+type Searcher struct {
+	embedder Embedder
+
+	mu      sync.Mutex
+	vectors map[string][]float64 // embeddings of tool texts, kept for the tools of the last search
+}
+
+// New creates a Searcher. With a nil embedder, tools are ranked by keywords.
+func New(embedder Embedder) *Searcher {
+	return &Searcher{embedder: embedder, vectors: make(map[string][]float64)}
+}
+
+// Search returns up to k tools most relevant to query, best first, and the ranking used.
+// Keyword ranking leaves out tools that share no term with the query.
+func (s *Searcher) Search(ctx context.Context, tools []mcp.ToolInfo, query string, k int) ([]Match, string) {
+	if s.embedder != nil {
+		matches, err := s.searchEmbeddings(ctx, tools, query)
+		if err == nil {
+			return top(matches, k), RankingEmbedding
+		}
+		slog.Warn("Embedding tool search failed, falling back to keyword ranking", "error", err)
+	}
+	return top(searchKeywords(tools, query), k), RankingKeyword
+}
+
+// top sorts matches by score, ties by server and tool name, and keeps the first k
+func top(matches []Match, k int) []Match {
+	slices.SortFunc(matches, func(a, b Match) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Server, b.Server), cmp.Compare(a.Name, b.Name))
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// toolText is the text a tool is indexed by
+func toolText(t mcp.ToolInfo) string {
+	return t.Server + " " + t.Name + ": " + t.Description
+}
+
+func (s *Searcher) searchEmbeddings(ctx context.Context, tools []mcp.ToolInfo, query string) ([]Match, error) {
+	texts := make([]string, len(tools))
+	for i, t := range tools {
+		texts[i] = toolText(t)
+	}
+
+	// Only tools that are new or changed since the last search are embedded, together with the query
+	s.mu.Lock()
+	cached := s.vectors
+	s.mu.Unlock()
+	missing := []string{query}
+	positions := make(map[string]int)
+	for _, text := range texts {
+		if _, ok := cached[text]; ok {
+			continue
+		}
+		if _, ok := positions[text]; !ok {
+			positions[text] = len(missing)
+			missing = append(missing, text)
+		}
+	}
+	embedded, err := s.embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embedded), len(missing))
+	}
+
+	vectors := make(map[string][]float64, len(texts))
+	for _, text := range texts {
+		if v, ok := cached[text]; ok {
+			vectors[text] = v
+		} else {
+			vectors[text] = embedded[positions[text]]
+		}
+	}
+	s.mu.Lock()
+	s.vectors = vectors
+	s.mu.Unlock()
+
+	matches := make([]Match, len(tools))
+	for i, t := range tools {
+		matches[i] = Match{ToolInfo: t, Score: cosine(embedded[0], vectors[texts[i]])}
+	}
+	return matches, nil
+}
+
+// cosine returns the cosine similarity of two vectors, or 0 if they differ in length or either is zero
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// nameWeight counts terms of the server and tool name this many times, as names are the
+	// most specific part of a tool's text
+	nameWeight = 3
+)
+
+// searchKeywords ranks tools by BM25 over their names and descriptions
+func searchKeywords(tools []mcp.ToolInfo, query string) []Match {
+	terms := tokenize(query)
+	docs := make([]map[string]int, len(tools))
+	lengths := make([]int, len(tools))
+	docFreq := make(map[string]int)
+	total := 0
+	for i, t := range tools {
+		doc := make(map[string]int)
+		for _, term := range tokenize(t.Server + " " + t.Name) {
+			doc[term] += nameWeight
+			lengths[i] += nameWeight
+		}
+		for _, term := range tokenize(t.Description) {
+			doc[term]++
+			lengths[i]++
+		}
+		for term := range doc {
+			docFreq[term]++
+		}
+		docs[i] = doc
+		total += lengths[i]
+	}
+	if total == 0 {
+		return []Match{}
+	}
+	avgLength := float64(total) / float64(len(tools))
+
+	matches := []Match{}
+	for i, doc := range docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(doc[term])
+			if tf == 0 {
+				continue
+			}
+			n := float64(docFreq[term])
+			idf := math.Log(1 + (float64(len(tools))-n+0.5)/(n+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avgLength))
+		}
+		if score > 0 {
+			matches = append(matches, Match{ToolInfo: tools[i], Score: score})
+		}
+	}
+	return matches
+}
+
+// stopWords are English words too common to tell tools apart
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "for": true, "from": true, "how": true, "i": true, "in": true, "is": true,
+	"it": true, "me": true, "my": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"there": true, "this": true, "to": true, "what": true, "with": true,
+}
+
+// tokenize lowercases text and splits it into words without stop words, also at camelCase
+// boundaries so that getWeather matches "weather"
+func tokenize(text string) []string {
+	var terms []string
+	var word []rune
+	flush := func() {
+		if term := strings.ToLower(string(word)); term != "" && !stopWords[term] {
+			terms = append(terms, term)
+		}
+		word = word[:0]
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	return terms
+}
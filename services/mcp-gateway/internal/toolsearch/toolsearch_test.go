@@ -0,0 +1,131 @@
+package toolsearch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTools = []mcp.ToolInfo{
+	{Server: "weather", Name: "getForecast", Description: "Get the weather forecast for a city"},
+	{Server: "weather", Name: "getAlerts", Description: "List active severe weather alerts for a region"},
+	{Server: "billing", Name: "create_invoice", Description: "Create an invoice for a customer"},
+	{Server: "billing", Name: "refund", Description: "Refund a payment"},
+}
+
+func names(matches []Match) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.Server + "/" + m.Name
+	}
+	return out
+}
+
+func TestSearch_Keywords(t *testing.T) {
+	s := New(nil)
+
+	matches, ranking := s.Search(context.Background(), testTools, "What is the forecast in Tokyo?", 5)
+
+	assert.Equal(t, RankingKeyword, ranking)
+	assert.Equal(t, []string{"weather/getForecast"}, names(matches))
+	assert.Positive(t, matches[0].Score)
+}
+
+func TestSearch_KeywordsIgnoreStopWords(t *testing.T) {
+	s := New(nil)
+
+	matches, _ := s.Search(context.Background(), testTools, "invoice the customer for a refund", 5)
+
+	require.Len(t, matches, 2)
+	assert.ElementsMatch(t, []string{"billing/create_invoice", "billing/refund"}, names(matches))
+	assert.Greater(t, matches[0].Score, matches[1].Score)
+}
+
+func TestSearch_TopK(t *testing.T) {
+	s := New(nil)
+
+	matches, _ := s.Search(context.Background(), testTools, "weather", 1)
+
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "weather", matches[0].Server)
+}
+
+func TestSearch_NoMatches(t *testing.T) {
+	s := New(nil)
+
+	matches, _ := s.Search(context.Background(), testTools, "translate a poem", 5)
+
+	assert.NotNil(t, matches)
+	assert.Empty(t, matches)
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"get", "forecast", "v2", "city", "name"}, tokenize("getForecast_v2 for the (cityName)"))
+	assert.Equal(t, []string{"天気", "予報"}, tokenize("天気 予報"))
+}
+
+// keywordEmbedder embeds texts as counts of a fixed vocabulary and records what it was asked for
+type keywordEmbedder struct {
+	vocabulary []string
+	calls      [][]string
+	err        error
+}
+
+func (e *keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	e.calls = append(e.calls, texts)
+	if e.err != nil {
+		return nil, e.err
+	}
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(e.vocabulary))
+		for j, word := range e.vocabulary {
+			vectors[i][j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+	}
+	return vectors, nil
+}
+
+func TestSearch_Embeddings(t *testing.T) {
+	embedder := &keywordEmbedder{vocabulary: []string{"weather", "alert", "invoice", "payment"}}
+	s := New(embedder)
+
+	matches, ranking := s.Search(context.Background(), testTools, "is there a storm alert?", 2)
+
+	assert.Equal(t, RankingEmbedding, ranking)
+	assert.Equal(t, []string{"weather/getAlerts", "billing/create_invoice"}, names(matches))
+	assert.InDelta(t, 0.71, matches[0].Score, 0.01)
+
+	// Tool embeddings are reused; only the query is embedded again
+	_, _ = s.Search(context.Background(), testTools, "pay back a payment", 2)
+	require.Len(t, embedder.calls, 2)
+	assert.Len(t, embedder.calls[0], 1+len(testTools))
+	assert.Equal(t, []string{"pay back a payment"}, embedder.calls[1])
+}
+
+func TestSearch_EmbeddingsReembedChangedTools(t *testing.T) {
+	embedder := &keywordEmbedder{vocabulary: []string{"weather"}}
+	s := New(embedder)
+	_, _ = s.Search(context.Background(), testTools, "weather", 5)
+
+	changed := append([]mcp.ToolInfo(nil), testTools...)
+	changed[3].Description = "Refund a payment to the original card"
+	_, _ = s.Search(context.Background(), changed, "weather", 5)
+
+	require.Len(t, embedder.calls, 2)
+	assert.Equal(t, []string{"weather", "billing refund: Refund a payment to the original card"}, embedder.calls[1])
+}
+
+func TestSearch_EmbedderFailureFallsBackToKeywords(t *testing.T) {
+	s := New(&keywordEmbedder{err: errors.New("connection refused")})
+
+	matches, ranking := s.Search(context.Background(), testTools, "forecast", 5)
+
+	assert.Equal(t, RankingKeyword, ranking)
+	assert.Equal(t, []string{"weather/getForecast"}, names(matches))
+}
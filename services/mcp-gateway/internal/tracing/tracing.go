@@ -0,0 +1,113 @@
+// Package tracing exports OpenTelemetry traces of tool calls with head-based sampling
+// that still retains every errored or slow call.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetainReasonKey records why a span outside the head sample was exported
+const RetainReasonKey = attribute.Key("mcp_gateway.sampling.retained")
+
+// Options configures sampling
+type Options struct {
+	SampleRatio   float64       // fraction of traces sampled when they start
+	SlowThreshold time.Duration // spans at least this long are always exported; 0 disables
+}
+
+// NewTracerProvider builds a provider that samples SampleRatio of traces at their start, but records
+// every span so that those ending with an error or lasting SlowThreshold are exported as well
+func NewTracerProvider(exporter sdktrace.SpanExporter, opts Options, extra ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	sampler := recordingSampler{sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))}
+	processor := &retainProcessor{next: sdktrace.NewBatchSpanProcessor(exporter), slow: opts.SlowThreshold}
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(processor),
+	}, extra...)...)
+}
+
+// Setup exports traces to an OTLP/HTTP endpoint and installs the provider and W3C trace context
+// propagation globally. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, endpoint string, opts Options) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	res := resource.NewSchemaless(semconv.ServiceName("mcp-gateway"))
+	tp := NewTracerProvider(exporter, opts, sdktrace.WithResource(res))
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp.Shutdown, nil
+}
+
+// recordingSampler records the spans its inner sampler drops instead of discarding them,
+// so that retainProcessor can still see how they end
+type recordingSampler struct {
+	inner sdktrace.Sampler
+}
+
+func (s recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	res := s.inner.ShouldSample(p)
+	if res.Decision == sdktrace.Drop {
+		res.Decision = sdktrace.RecordOnly
+	}
+	return res
+}
+
+func (s recordingSampler) Description() string {
+	return "RecordingSampler{" + s.inner.Description() + "}"
+}
+
+// retainProcessor forwards sampled spans, plus unsampled spans that ended with an error or were slow
+type retainProcessor struct {
+	next sdktrace.SpanProcessor
+	slow time.Duration
+}
+
+func (p *retainProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(ctx, s)
+}
+
+func (p *retainProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.next.OnEnd(s)
+		return
+	}
+	switch {
+	case s.Status().Code == codes.Error:
+		p.next.OnEnd(retainedSpan{s, "error"})
+	case p.slow > 0 && s.EndTime().Sub(s.StartTime()) >= p.slow:
+		p.next.OnEnd(retainedSpan{s, "slow"})
+	}
+}
+
+func (p *retainProcessor) Shutdown(ctx context.Context) error   { return p.next.Shutdown(ctx) }
+func (p *retainProcessor) ForceFlush(ctx context.Context) error { return p.next.ForceFlush(ctx) }
+
+// retainedSpan presents an unsampled span as sampled so that the batch processor exports it
+type retainedSpan struct {
+	sdktrace.ReadOnlySpan
+	reason string
+}
+
+func (s retainedSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+func (s retainedSpan) Attributes() []attribute.KeyValue {
+	return append(s.ReadOnlySpan.Attributes(), RetainReasonKey.String(s.reason))
+}
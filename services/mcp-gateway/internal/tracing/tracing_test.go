@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func exportedSpans(t *testing.T, opts Options, record func(tr trace.Tracer)) tracetest.SpanStubs {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := NewTracerProvider(exporter, opts)
+	record(tp.Tracer("test"))
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	// The in-memory exporter discards its spans on shutdown
+	spans := exporter.GetSpans()
+	require.NoError(t, tp.Shutdown(context.Background()))
+	return spans
+}
+
+func TestNewTracerProvider_HeadSampling(t *testing.T) {
+	record := func(tr trace.Tracer) {
+		_, span := tr.Start(context.Background(), "call")
+		span.End()
+	}
+
+	assert.Len(t, exportedSpans(t, Options{SampleRatio: 1}, record), 1)
+	assert.Empty(t, exportedSpans(t, Options{SampleRatio: 0}, record))
+}
+
+func TestNewTracerProvider_RetainsErroredSpans(t *testing.T) {
+	spans := exportedSpans(t, Options{SampleRatio: 0}, func(tr trace.Tracer) {
+		_, span := tr.Start(context.Background(), "call")
+		span.SetStatus(codes.Error, "tool failed")
+		span.End()
+	})
+
+	require.Len(t, spans, 1)
+	assert.True(t, spans[0].SpanContext.IsSampled())
+	assert.Contains(t, spans[0].Attributes, RetainReasonKey.String("error"))
+}
+
+func TestNewTracerProvider_RetainsSlowSpans(t *testing.T) {
+	opts := Options{SampleRatio: 0, SlowThreshold: time.Second}
+	spans := exportedSpans(t, opts, func(tr trace.Tracer) {
+		start := time.Now()
+		_, fast := tr.Start(context.Background(), "fast", trace.WithTimestamp(start))
+		fast.End(trace.WithTimestamp(start.Add(10 * time.Millisecond)))
+		_, slow := tr.Start(context.Background(), "slow", trace.WithTimestamp(start))
+		slow.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+	})
+
+	require.Len(t, spans, 1)
+	assert.Equal(t, "slow", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, RetainReasonKey.String("slow"))
+}
+
+func TestNewTracerProvider_SampledSpansAreNotTagged(t *testing.T) {
+	spans := exportedSpans(t, Options{SampleRatio: 1}, func(tr trace.Tracer) {
+		_, span := tr.Start(context.Background(), "call")
+		span.SetStatus(codes.Error, "tool failed")
+		span.End()
+	})
+
+	require.Len(t, spans, 1)
+	assert.NotContains(t, spans[0].Attributes, RetainReasonKey.String("error"))
+}
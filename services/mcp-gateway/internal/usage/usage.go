@@ -0,0 +1,217 @@
+// Package usage aggregates tool calls per hour, tool and caller and exports them as NDJSON
+// so that they can be loaded into a warehouse such as BigQuery.
+package usage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AnonymousCaller identifies calls made without an API key
+const AnonymousCaller = "anonymous"
+
+// Record is the usage of one tool by one caller within an hour, accumulated since the previous export.
+// An hour may be exported in several records; consumers sum them.
+type Record struct {
+	Hour       time.Time `json:"hour"` // start of the UTC hour
+	Server     string    `json:"server"`
+	Tool       string    `json:"tool"`
+	Caller     string    `json:"caller"`
+	Calls      int64     `json:"calls"`
+	Errors     int64     `json:"errors"`
+	DurationMs int64     `json:"durationMs"` // total duration of the calls
+}
+
+type recordKey struct {
+	hour                 time.Time
+	server, tool, caller string
+}
+
+// Recorder aggregates calls in memory until they are exported
+type Recorder struct {
+	mu      sync.Mutex
+	pending map[recordKey]*Record
+	now     func() time.Time
+}
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{pending: make(map[recordKey]*Record), now: time.Now}
+}
+
+// Record counts a call. An empty caller is recorded as AnonymousCaller.
+func (r *Recorder) Record(server, tool, caller string, d time.Duration, failed bool) {
+	if caller == "" {
+		caller = AnonymousCaller
+	}
+	key := recordKey{hour: r.now().UTC().Truncate(time.Hour), server: server, tool: tool, caller: caller}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.pending[key]
+	if !ok {
+		rec = &Record{Hour: key.hour, Server: server, Tool: tool, Caller: caller}
+		r.pending[key] = rec
+	}
+	rec.Calls++
+	if failed {
+		rec.Errors++
+	}
+	rec.DurationMs += d.Milliseconds()
+}
+
+// drain removes and returns the pending records ordered by hour, server, tool and caller
+func (r *Recorder) drain() []Record {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[recordKey]*Record)
+	r.mu.Unlock()
+
+	records := make([]Record, 0, len(pending))
+	for _, rec := range pending {
+		records = append(records, *rec)
+	}
+	slices.SortFunc(records, func(a, b Record) int {
+		return cmp.Or(a.Hour.Compare(b.Hour), cmp.Compare(a.Server, b.Server), cmp.Compare(a.Tool, b.Tool), cmp.Compare(a.Caller, b.Caller))
+	})
+	return records
+}
+
+// restore puts back records that could not be exported, merging them with calls recorded since
+func (r *Recorder) restore(records []Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range records {
+		key := recordKey{hour: rec.Hour, server: rec.Server, tool: rec.Tool, caller: rec.Caller}
+		if cur, ok := r.pending[key]; ok {
+			cur.Calls += rec.Calls
+			cur.Errors += rec.Errors
+			cur.DurationMs += rec.DurationMs
+			continue
+		}
+		restored := rec
+		r.pending[key] = &restored
+	}
+}
+
+// Sink receives exported records
+type Sink interface {
+	Write(ctx context.Context, records []Record) error
+}
+
+// encodeNDJSON writes one JSON object per line
+func encodeNDJSON(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// FileSink appends records to an NDJSON file
+type FileSink struct {
+	Path string
+}
+
+func (s FileSink) Write(_ context.Context, records []Record) error {
+	data, err := encodeNDJSON(records)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HTTPSink posts records to a collector as an application/x-ndjson body
+type HTTPSink struct {
+	URL    string
+	Client *http.Client // default: a client with a 10s timeout
+}
+
+func (s HTTPSink) Write(ctx context.Context, records []Record) error {
+	data, err := encodeNDJSON(records)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Flush exports the pending records. Records are kept for the next flush if the sink fails.
+func (r *Recorder) Flush(ctx context.Context, sink Sink) error {
+	records := r.drain()
+	if len(records) == 0 {
+		return nil
+	}
+	if err := sink.Write(ctx, records); err != nil {
+		r.restore(records)
+		return err
+	}
+	return nil
+}
+
+// Start flushes to sink every interval until the returned function is called,
+// which stops the loop and flushes one last time within ctx
+func (r *Recorder) Start(sink Sink, interval time.Duration) func(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Flush(ctx, sink); err != nil {
+					slog.Warn("Failed to export usage, retrying at the next flush", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func(stopCtx context.Context) error {
+		cancel()
+		<-done
+		return r.Flush(stopCtx, sink)
+	}
+}
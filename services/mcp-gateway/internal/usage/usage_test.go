@@ -0,0 +1,152 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySink collects written records and fails while err is set
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+	err     error
+}
+
+func (s *memorySink) Write(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *memorySink) written() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.records...)
+}
+
+func newTestRecorder(now *time.Time) *Recorder {
+	r := NewRecorder()
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestRecorder_AggregatesPerHourToolAndCaller(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	r.Record("weather", "forecast", "agent-a", 100*time.Millisecond, false)
+	r.Record("weather", "forecast", "agent-a", 300*time.Millisecond, true)
+	r.Record("weather", "forecast", "", 50*time.Millisecond, false)
+	now = now.Add(time.Hour)
+	r.Record("weather", "forecast", "agent-a", 10*time.Millisecond, false)
+
+	sink := &memorySink{}
+	require.NoError(t, r.Flush(context.Background(), sink))
+
+	nine := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, []Record{
+		{Hour: nine, Server: "weather", Tool: "forecast", Caller: "agent-a", Calls: 2, Errors: 1, DurationMs: 400},
+		{Hour: nine, Server: "weather", Tool: "forecast", Caller: AnonymousCaller, Calls: 1, DurationMs: 50},
+		{Hour: nine.Add(time.Hour), Server: "weather", Tool: "forecast", Caller: "agent-a", Calls: 1, DurationMs: 10},
+	}, sink.written())
+
+	// Flushed records are not exported again
+	require.NoError(t, r.Flush(context.Background(), sink))
+	assert.Len(t, sink.written(), 3)
+}
+
+func TestRecorder_KeepsRecordsWhenTheSinkFails(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+	sink := &memorySink{err: errors.New("collector down")}
+
+	r.Record("weather", "forecast", "agent-a", 100*time.Millisecond, false)
+	require.Error(t, r.Flush(context.Background(), sink))
+	r.Record("weather", "forecast", "agent-a", 200*time.Millisecond, true)
+
+	sink.err = nil
+	require.NoError(t, r.Flush(context.Background(), sink))
+	assert.Equal(t, []Record{{
+		Hour: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), Server: "weather", Tool: "forecast", Caller: "agent-a",
+		Calls: 2, Errors: 1, DurationMs: 300,
+	}}, sink.written())
+}
+
+func TestRecorder_StartFlushesPeriodicallyAndOnStop(t *testing.T) {
+	r := NewRecorder()
+	sink := &memorySink{}
+	stop := r.Start(sink, 20*time.Millisecond)
+
+	r.Record("weather", "forecast", "agent-a", time.Millisecond, false)
+	assert.Eventually(t, func() bool { return len(sink.written()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	r.Record("weather", "alerts", "agent-a", time.Millisecond, false)
+	require.NoError(t, stop(context.Background()))
+	assert.Len(t, sink.written(), 2, "stop flushes what is left")
+}
+
+func readNDJSON(t *testing.T, r io.Reader) []Record {
+	t.Helper()
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var rec Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestFileSink_AppendsNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.ndjson")
+	sink := FileSink{Path: path}
+	first := Record{Hour: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), Server: "s", Tool: "a", Caller: "c", Calls: 1}
+	second := first
+	second.Tool = "b"
+
+	require.NoError(t, sink.Write(context.Background(), []Record{first}))
+	require.NoError(t, sink.Write(context.Background(), []Record{second}))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []Record{first, second}, readNDJSON(t, f))
+}
+
+func TestHTTPSink(t *testing.T) {
+	var got []Record
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		got = readNDJSON(t, r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	records := []Record{{Hour: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), Server: "s", Tool: "a", Caller: "c", Calls: 3, Errors: 1, DurationMs: 12}}
+	sink := HTTPSink{URL: srv.URL}
+	require.NoError(t, sink.Write(context.Background(), records))
+	assert.Equal(t, records, got)
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, sink.Write(context.Background(), records), "503")
+}
@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// forbiddenKey matches the key named by a forbidden key violation
+var forbiddenKey = regexp.MustCompile(`(forbidden key: )[^;]*`)
+
+// FuzzValidateRequest checks that arbitrary names and JSON inputs are rejected with a ValidationError, never a panic
+func FuzzValidateRequest(f *testing.F) {
+	f.Add("test-server", "calculate-bmi", []byte(`{"weight_kg":70,"height_m":1.75}`))
+	f.Add("", "", []byte(`null`))
+	f.Add("server@1", "tool name", []byte(`[1,2,3]`))
+	f.Add("s", "t", []byte(`{"__proto__":{"polluted":true}}`))
+	f.Add("s", "t", []byte(`{"a":{"b":{"c":{"d":{"e":{"f":{"g":{"h":{"i":{"j":{"k":1}}}}}}}}}}}`))
+	f.Add("s", "天気", []byte(`{"city":"東京"}`))
+	f.Add("s", "t", []byte(`{"list":[[[[[[[[[[[]]]]]]]]]]]}`))
+
+	f.Fuzz(func(t *testing.T, server, toolName string, inputJSON []byte) {
+		var input any
+		if err := json.Unmarshal(inputJSON, &input); err != nil {
+			return
+		}
+		err := ValidateRequest(server, toolName, input)
+		if data, marshalErr := json.Marshal(input); marshalErr == nil {
+			if size, ok := jsonSize(input, len(data)); !ok || size != len(data) {
+				t.Fatalf("jsonSize = %d, %v; json.Marshal produced %d bytes for %s", size, ok, len(data), inputJSON)
+			}
+			// Inputs passed on as sent are validated alike, given the same encoding. Of several forbidden
+			// keys, the decoded input reports whichever its map yields first.
+			rawErr := ValidateRequest(server, toolName, json.RawMessage(data))
+			got := forbiddenKey.ReplaceAllString(fmt.Sprint(rawErr), "$1")
+			want := forbiddenKey.ReplaceAllString(fmt.Sprint(err), "$1")
+			if got != want {
+				t.Fatalf("raw input %s: %s; decoded input: %s", data, got, want)
+			}
+		}
+		if err == nil {
+			if _, ok := input.(map[string]any); !ok {
+				t.Fatalf("accepted non-object input %s", inputJSON)
+			}
+			return
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Violations) == 0 {
+			t.Fatalf("expected a ValidationError with violations, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package validator
+
+// raceEnabled reports whether the race detector is on, whose instrumentation allocates
+const raceEnabled = true
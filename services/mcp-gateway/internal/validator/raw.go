@@ -0,0 +1,140 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// inputSizeLimit overrides maxInputSize when set, see SetMaxInputSize
+var inputSizeLimit atomic.Int64
+
+// SetMaxInputSize sets the maximum size of a tool input in bytes, e.g. to the request ceiling of large
+// payloads. Zero restores the default of 100KB.
+func SetMaxInputSize(n int64) {
+	inputSizeLimit.Store(n)
+}
+
+// maxInput returns the maximum size of a tool input in bytes
+func maxInput() int {
+	if n := inputSizeLimit.Load(); n > 0 {
+		return int(n)
+	}
+	return maxInputSize
+}
+
+// validateRawInput applies the checks of validateInput to an input that is passed on as sent instead of
+// being decoded. The input is scanned in place, so that validating a multi-MB input neither decodes nor
+// copies it. Its size is its length as sent.
+func validateRawInput(raw json.RawMessage) []error {
+	if !json.Valid(raw) || raw[skipSpace(raw, 0)] != '{' {
+		return []error{errors.New("input must be a JSON object")}
+	}
+
+	var errs []error
+	dangerousKey, depth := scanObject(raw)
+	if dangerousKey != "" {
+		errs = append(errs, fmt.Errorf("input contains forbidden key: %s", dangerousKey))
+	}
+	if limit := maxInput(); len(raw) > limit {
+		errs = append(errs, fmt.Errorf("input exceeds maximum size (%d bytes)", limit))
+	}
+	if depth > maxNestDepth {
+		errs = append(errs, fmt.Errorf("input nesting exceeds maximum depth (%d)", maxNestDepth))
+	}
+	return errs
+}
+
+// scanObject walks valid JSON and returns the first dangerous key found and the nesting depth, counted
+// like getObjectDepth: the outermost value is at depth 1 and every value inside a container one deeper
+func scanObject(raw []byte) (dangerousKey string, depth int) {
+	// Whether each open container is an object, and whether the next string is a key
+	var stack []bool
+	expectKey := false
+	for i := 0; i < len(raw); {
+		switch c := raw[i]; c {
+		case '{', '[':
+			depth = max(depth, len(stack)+1)
+			stack = append(stack, c == '{')
+			expectKey = c == '{'
+			i++
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+			expectKey = false
+			i++
+		case ',':
+			expectKey = stack[len(stack)-1]
+			i++
+		case ':':
+			i++
+		case '"':
+			end := stringEnd(raw, i)
+			if expectKey {
+				if dangerousKey == "" {
+					dangerousKey = matchDangerousKey(raw[i:end])
+				}
+				expectKey = false
+			} else {
+				depth = max(depth, len(stack)+1)
+			}
+			i = end
+		default:
+			if isSpace(c) {
+				i++
+				continue
+			}
+			// A number, true, false or null
+			depth = max(depth, len(stack)+1)
+			for i < len(raw) && !isSpace(raw[i]) && raw[i] != ',' && raw[i] != ']' && raw[i] != '}' {
+				i++
+			}
+		}
+	}
+	return dangerousKey, depth
+}
+
+// stringEnd returns the index after the closing quote of the string starting at raw[start]
+func stringEnd(raw []byte, start int) int {
+	for i := start + 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(raw)
+}
+
+// matchDangerousKey returns the key if the quoted string is one of dangerousKeys, unescaping it only
+// if it contains escapes, as in "__pr\u006fto__"
+func matchDangerousKey(quoted []byte) string {
+	key := quoted[1 : len(quoted)-1]
+	if bytes.IndexByte(key, '\\') >= 0 {
+		var unescaped string
+		if err := json.Unmarshal(quoted, &unescaped); err != nil {
+			return ""
+		}
+		key = []byte(unescaped)
+	}
+	for _, dangerous := range dangerousKeys {
+		if string(key) == dangerous {
+			return dangerous
+		}
+	}
+	return ""
+}
+
+// skipSpace returns the index of the first byte at or after i that is not JSON whitespace
+func skipSpace(raw []byte, i int) int {
+	for i < len(raw) && isSpace(raw[i]) {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
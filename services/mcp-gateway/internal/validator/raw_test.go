@@ -0,0 +1,133 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// violationMessages returns the messages of a ValidationError, or nil for no error
+func violationMessages(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	messages := make([]string, len(validationErr.Violations))
+	for i, v := range validationErr.Violations {
+		messages[i] = v.Message
+	}
+	return messages
+}
+
+func TestValidateRequest_RawInputMatchesDecoded(t *testing.T) {
+	inputs := []string{
+		`{}`,
+		`{"weight_kg":70,"height_m":1.75}`,
+		` { "city" : "東京", "days" : [ 1, 2, 3 ] } `,
+		`{"__proto__":{"polluted":true}}`,
+		`{"a":[{"constructor":1}]}`,
+		`{"__proto__":1}`,
+		`{"text":"__proto__"}`,
+		`{"text":"quote \" and \\ backslash","n":null,"b":false}`,
+		`{"a":{"b":{"c":{"d":{"e":{"f":{"g":{"h":{"i":1}}}}}}}}}`,
+		`{"a":{"b":{"c":{"d":{"e":{"f":{"g":{"h":{"i":{"j":1}}}}}}}}}}`,
+		`{"a":{"b":{"c":{"d":{"e":{"f":{"g":{"h":{"i":{"j":{}}}}}}}}}}}`,
+		`{"list":[[[[[[[[[]]]]]]]]]}`,
+		`{"list":[[[[[[[[[[]]]]]]]]]]}`,
+		`[1,2,3]`,
+		`"text"`,
+		`null`,
+	}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			var decoded any
+			if err := json.Unmarshal([]byte(input), &decoded); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+			want := violationMessages(t, ValidateRequest("s", "t", decoded))
+			got := violationMessages(t, ValidateRequest("s", "t", json.RawMessage(input)))
+			if strings.Join(got, "; ") != strings.Join(want, "; ") {
+				t.Errorf("raw input violations %q, decoded input violations %q", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateRequest_RawInputInvalid(t *testing.T) {
+	for _, input := range []string{``, `{`, `{"a":1}{"b":2}`, `{"a":}`} {
+		t.Run(input, func(t *testing.T) {
+			got := violationMessages(t, ValidateRequest("s", "t", json.RawMessage(input)))
+			if len(got) != 1 || got[0] != "input must be a JSON object" {
+				t.Errorf("expected only a JSON object violation, got %q", got)
+			}
+		})
+	}
+}
+
+func TestSetMaxInputSize(t *testing.T) {
+	t.Cleanup(func() { SetMaxInputSize(0) })
+	raw := json.RawMessage(`{"data":"` + strings.Repeat("a", 2*maxInputSize) + `"}`)
+	decoded := map[string]any{"data": strings.Repeat("a", 2*maxInputSize)}
+
+	for _, input := range []any{raw, decoded} {
+		if err := ValidateRequest("s", "t", input); err == nil {
+			t.Fatalf("expected %T input above the default size to be rejected", input)
+		}
+	}
+
+	SetMaxInputSize(4 * maxInputSize)
+	for _, input := range []any{raw, decoded} {
+		if err := ValidateRequest("s", "t", input); err != nil {
+			t.Fatalf("expected %T input below the raised size to be accepted, got %v", input, err)
+		}
+	}
+
+	SetMaxInputSize(0)
+	if err := ValidateRequest("s", "t", raw); err == nil || !strings.Contains(err.Error(), "102400 bytes") {
+		t.Fatalf("expected the default size to be restored, got %v", err)
+	}
+}
+
+func TestValidateRequest_RawInputDoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are counted without the race detector; BenchmarkValidateRequest_RawInput reports them")
+	}
+	t.Cleanup(func() { SetMaxInputSize(0) })
+	SetMaxInputSize(16 << 20)
+	data, err := json.Marshal(generateLargeObject(4 << 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := json.RawMessage(data)
+	allocs := testing.AllocsPerRun(10, func() {
+		if err := ValidateRequest("test-server", "calculate-bmi", raw); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Only the stack of open containers is allocated
+	if allocs > 1 {
+		t.Errorf("expected at most 1 allocation, got %v", allocs)
+	}
+}
+
+func BenchmarkValidateRequest_RawInput(b *testing.B) {
+	b.Cleanup(func() { SetMaxInputSize(0) })
+	SetMaxInputSize(16 << 20)
+	data, err := json.Marshal(generateLargeObject(4 << 20))
+	if err != nil {
+		b.Fatal(err)
+	}
+	raw := json.RawMessage(data)
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := ValidateRequest("test-server", "calculate-bmi", raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// jsonSize returns the length of v encoded by json.Marshal without encoding it, so that validating a
+// request does not serialize its input a second time. Counting stops once the size exceeds limit.
+// ok is false for values other than those json.Unmarshal decodes into an any, and for those that
+// json.Marshal rejects, such as NaN; they must be marshaled to be measured.
+func jsonSize(v any, limit int) (size int, ok bool) {
+	switch v := v.(type) {
+	case nil:
+		return len("null"), true
+	case bool:
+		if v {
+			return len("true"), true
+		}
+		return len("false"), true
+	case float64:
+		return floatSize(v)
+	case string:
+		return stringSize(v), true
+	case map[string]any:
+		size = len("{}") + max(len(v)-1, 0) // braces and commas
+		for key, val := range v {
+			n, ok := jsonSize(val, limit-size)
+			if !ok {
+				return 0, false
+			}
+			size += stringSize(key) + len(":") + n
+			if size > limit {
+				return size, true
+			}
+		}
+		return size, true
+	case []any:
+		size = len("[]") + max(len(v)-1, 0)
+		for _, item := range v {
+			n, ok := jsonSize(item, limit-size)
+			if !ok {
+				return 0, false
+			}
+			size += n
+			if size > limit {
+				return size, true
+			}
+		}
+		return size, true
+	default:
+		return 0, false
+	}
+}
+
+// floatSize measures a float64 the way encoding/json formats it: in exponent notation only when it is
+// very small or very large, and with a single-digit negative exponent written without a leading zero
+func floatSize(f float64) (int, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	var buf [32]byte
+	b := strconv.AppendFloat(buf[:0], f, format, -1, 64)
+	n := len(b)
+	if format == 'e' && n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+		n-- // e-07 is written e-7
+	}
+	return n, true
+}
+
+// stringSize measures a quoted string as escaped by encoding/json, including its HTML escaping of <, > and &
+func stringSize(s string) int {
+	size := len(`""`)
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			switch {
+			case c == '\\' || c == '"' || c == '\b' || c == '\f' || c == '\n' || c == '\r' || c == '\t':
+				size += 2
+			case c < 0x20 || c == '<' || c == '>' || c == '&':
+				size += len(`\u0000`)
+			default:
+				size++
+			}
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && n == 1:
+			size += utf8.RuneLen(utf8.RuneError) // an invalid byte becomes �
+		case r == '\u2028' || r == '\u2029':
+			size += len(`\u2028`)
+		default:
+			size += n
+		}
+		i += n
+	}
+	return size
+}
+
+// inputSize returns the encoded size of an input, measuring it without marshaling when possible
+func inputSize(input any) (int, error) {
+	if size, ok := jsonSize(input, maxInput()); ok {
+		return size, nil
+	}
+	data, err := json.Marshal(input)
+	return len(data), err
+}
@@ -0,0 +1,92 @@
+package validator
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestJSONSize_MatchesMarshal(t *testing.T) {
+	values := []any{
+		nil, true, false,
+		0.0, -0.0, 1.0, -1.5, 3.14159, 1e20, 1e21, 1.5e-6, 1e-7, 123456789e-30, math.MaxFloat64, math.SmallestNonzeroFloat64,
+		"", "plain", `quote " and \ backslash`, "\b\f\n\r\t", "\x00\x1f", "<a href=\"x\">&</a>",
+		"日本語", "  ", "bad \xff\xfe utf-8", "emoji 🎉",
+		map[string]any{}, []any{},
+		map[string]any{"a": 1.0, "<key>": []any{"x", nil, true, map[string]any{"nested": -2.5e-10}}},
+		[]any{[]any{[]any{}}, map[string]any{"": ""}},
+	}
+	for _, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size, ok := jsonSize(v, maxInputSize)
+		if !ok || size != len(data) {
+			t.Errorf("jsonSize(%#v) = %d, %v; json.Marshal produced %d bytes: %s", v, size, ok, len(data), data)
+		}
+	}
+}
+
+func TestJSONSize_Fallback(t *testing.T) {
+	type point struct{ X, Y int }
+	for _, v := range []any{math.NaN(), math.Inf(1), point{1, 2}, map[string]any{"p": point{}}, json.Number("1")} {
+		if _, ok := jsonSize(v, maxInputSize); ok {
+			t.Errorf("expected jsonSize to leave %#v to json.Marshal", v)
+		}
+	}
+
+	size, err := inputSize(map[string]any{"p": point{1, 2}})
+	if err != nil || size != len(`{"p":{"X":1,"Y":2}}`) {
+		t.Errorf("inputSize = %d, %v", size, err)
+	}
+	if _, err := inputSize(map[string]any{"nan": math.NaN()}); err == nil {
+		t.Error("expected NaN to fail as json.Marshal fails it")
+	}
+}
+
+func TestJSONSize_StopsAtLimit(t *testing.T) {
+	input := generateLargeObject(2 * maxInputSize)
+	size, ok := jsonSize(input, maxInputSize)
+	if !ok || size <= maxInputSize || size > maxInputSize+100 {
+		t.Errorf("expected counting to stop just past the limit, got %d", size)
+	}
+}
+
+func BenchmarkValidateRequest(b *testing.B) {
+	input := map[string]any{
+		"query":   "weather in <Tokyo> & Osaka",
+		"limit":   10.0,
+		"filters": map[string]any{"units": "metric", "days": []any{1.0, 2.0, 3.0}},
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := ValidateRequest("weather", "get-forecast", input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateRequest_LargeInput(b *testing.B) {
+	input := generateLargeObject(maxInputSize / 2)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := ValidateRequest("weather", "get-forecast", input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestValidateRequest_DoesNotAllocate guards the /mcp/call hot path: valid requests are checked without
+// marshaling their input
+func TestValidateRequest_DoesNotAllocate(t *testing.T) {
+	input := map[string]any{"query": "weather", "days": []any{1.0, 2.0}, "options": map[string]any{"units": "metric"}}
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := ValidateRequest("weather", "get-forecast", input); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v per request", allocs)
+	}
+}
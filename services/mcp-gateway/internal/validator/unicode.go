@@ -0,0 +1,110 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// unicodeToolNames allows tool names with letters and digits of any script, see SetUnicodeToolNames
+var unicodeToolNames atomic.Bool
+
+// SetUnicodeToolNames allows localized tool names such as "天気を取得" instead of only ASCII letters,
+// digits, hyphens and underscores. Names must still be NFC-normalized and must not mix scripts that
+// can be confused with each other, so that a tool cannot be impersonated by a look-alike name.
+func SetUnicodeToolNames(enabled bool) {
+	unicodeToolNames.Store(enabled)
+}
+
+// scriptSets are the scripts that may be combined in a name, after the "highly restrictive" level of
+// Unicode Technical Standard #39: Latin with Japanese, Chinese or Korean. Any other mix, e.g. Latin with
+// Cyrillic as in "pаypal" with a Cyrillic а, is rejected.
+var scriptSets = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// prolongedSoundMark is the katakana-hiragana prolonged sound mark, as in データ, which belongs to no script
+const prolongedSoundMark = 'ー'
+
+// validateUnicodeName checks a name of Unicode letters, marks and digits, plus hyphens and underscores
+func validateUnicodeName(name, field string, maxLength int) error {
+	if name == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("%s is not valid UTF-8", field)
+	}
+	if utf8.RuneCountInString(name) > maxLength {
+		return fmt.Errorf("%s exceeds maximum length (%d characters)", field, maxLength)
+	}
+	if !norm.NFC.IsNormalString(name) {
+		return fmt.Errorf("%s must be in Unicode normalization form NFC", field)
+	}
+
+	scripts := make(map[string]bool)
+	for _, r := range name {
+		switch {
+		case r < utf8.RuneSelf:
+			if !namePattern.MatchString(string(r)) {
+				return fmt.Errorf("%s contains invalid characters", field)
+			}
+			if unicode.IsLetter(r) {
+				scripts["Latin"] = true
+			}
+		case r == prolongedSoundMark:
+		case unicode.IsLetter(r):
+			script := scriptOf(r)
+			if script == "" {
+				return fmt.Errorf("%s contains invalid characters", field)
+			}
+			scripts[script] = true
+		case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r):
+			// Combining marks, e.g. Devanagari vowel signs, take the script of the letter they follow
+		default:
+			// Digits other than 0-9, spaces, punctuation, symbols and invisible formatting characters
+			return fmt.Errorf("%s contains invalid characters", field)
+		}
+	}
+	if err := checkScriptMix(scripts); err != nil {
+		return fmt.Errorf("%s %w", field, err)
+	}
+	return nil
+}
+
+// scriptOf returns the script of a letter, or "" for letters of no specific script
+func scriptOf(r rune) string {
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			continue
+		}
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkScriptMix rejects letters of several scripts unless they are a combination in scriptSets
+func checkScriptMix(scripts map[string]bool) error {
+	if len(scripts) <= 1 {
+		return nil
+	}
+	for _, set := range scriptSets {
+		allowed := 0
+		for _, script := range set {
+			if scripts[script] {
+				allowed++
+			}
+		}
+		if allowed == len(scripts) {
+			return nil
+		}
+	}
+	return errors.New("mixes letters of scripts that can be confused with each other")
+}
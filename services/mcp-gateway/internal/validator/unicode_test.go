@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateUnicodeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		// 正常系
+		{name: "ASCII", input: "get_weather-v2"},
+		{name: "Japanese", input: "天気を取得"},
+		{name: "Katakana with prolonged sound mark", input: "データ取得"},
+		{name: "Latin with Japanese", input: "get_天気"},
+		{name: "Korean", input: "날씨_조회"},
+		{name: "Cyrillic", input: "погода"},
+		{name: "Devanagari with vowel signs", input: "मौसम"},
+		{name: "Accented Latin", input: "météo"},
+
+		// エラー系
+		{name: "Empty", input: "", wantErr: "is required"},
+		{name: "Too long", input: strings.Repeat("天", 101), wantErr: "exceeds maximum length"},
+		{name: "Not NFC", input: "me\u0301te\u0301o", wantErr: "NFC"},
+		{name: "Latin mixed with Cyrillic", input: "p\u0430ypal", wantErr: "mixes letters of scripts"},
+		{name: "Greek mixed with Latin", input: "t\u03bfol", wantErr: "mixes letters of scripts"},
+		{name: "Korean mixed with Japanese kana", input: "날씨の", wantErr: "mixes letters of scripts"},
+		{name: "Space", input: "天気 取得", wantErr: "invalid characters"},
+		{name: "Zero width joiner", input: "天気\u200d取得", wantErr: "invalid characters"},
+		{name: "Fullwidth digit", input: "天気１", wantErr: "invalid characters"},
+		{name: "Symbol", input: "天気★", wantErr: "invalid characters"},
+		{name: "ASCII punctuation", input: "天気.取得", wantErr: "invalid characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUnicodeName(tt.input, "toolName", 100)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateUnicodeName(%q) unexpected error = %v", tt.input, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateUnicodeName(%q) error = %v, want error containing %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRequest_UnicodeToolNames(t *testing.T) {
+	input := map[string]any{}
+
+	if err := ValidateRequest("weather", "天気を取得", input); err == nil {
+		t.Errorf("ValidateRequest() expected non-ASCII tool names to be rejected by default")
+	}
+
+	SetUnicodeToolNames(true)
+	t.Cleanup(func() { SetUnicodeToolNames(false) })
+
+	if err := ValidateRequest("weather", "天気を取得", input); err != nil {
+		t.Errorf("ValidateRequest() unexpected error = %v", err)
+	}
+	// サーバー名は ASCII のまま
+	if err := ValidateRequest("天気", "get", input); err == nil {
+		t.Errorf("ValidateRequest() expected non-ASCII server names to be rejected")
+	}
+}
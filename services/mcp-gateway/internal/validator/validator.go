@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"slices"
+	"strings"
 )
 
 const (
@@ -41,16 +42,47 @@ func findDangerousKey(obj any) string {
 	return ""
 }
 
-// ValidateRequest validates the MCP tool call request parameters
+// Violation is one problem found in a tool call request
+type Violation struct {
+	Field   string `json:"field"` // server, toolName or input
+	Message string `json:"message"`
+}
+
+// ValidationError lists every violation found in a request, so that clients can fix them in one round trip
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error joins the messages of the violations
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateRequest validates the MCP tool call request parameters.
+// The input is either decoded JSON or a json.RawMessage that is passed on as sent.
+// It returns a *ValidationError listing every violation.
 func ValidateRequest(server, toolName string, input any) error {
-	if err := validateName(server, "server", 50); err != nil {
-		return err
+	var violations []Violation
+	add := func(field string, err error) {
+		if err != nil {
+			violations = append(violations, Violation{Field: field, Message: err.Error()})
+		}
+	}
+	add("server", validateName(server, "server", 50))
+	if unicodeToolNames.Load() {
+		add("toolName", validateUnicodeName(toolName, "toolName", 100))
+	} else {
+		add("toolName", validateName(toolName, "toolName", 100))
 	}
-	if err := validateName(toolName, "toolName", 100); err != nil {
-		return err
+	for _, err := range validateInput(input) {
+		add("input", err)
 	}
-	if err := validateInput(input); err != nil {
-		return err
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
 	}
 	return nil
 }
@@ -68,32 +100,36 @@ func validateName(name, field string, maxLength int) error {
 	return nil
 }
 
-func validateInput(input any) error {
+func validateInput(input any) []error {
+	if raw, ok := input.(json.RawMessage); ok {
+		return validateRawInput(raw)
+	}
+
 	// Check if input is a map (JSON object)
 	inputMap, ok := input.(map[string]any)
 	if !ok {
-		return errors.New("input must be a JSON object")
+		return []error{errors.New("input must be a JSON object")}
 	}
 
+	var errs []error
 	if dangerousKey := findDangerousKey(inputMap); dangerousKey != "" {
-		return fmt.Errorf("input contains forbidden key: %s", dangerousKey)
+		errs = append(errs, fmt.Errorf("input contains forbidden key: %s", dangerousKey))
 	}
 
 	// Check size
-	jsonBytes, err := json.Marshal(input)
+	size, err := inputSize(input)
 	if err != nil {
-		return fmt.Errorf("failed to marshal input: %w", err)
-	}
-	if len(jsonBytes) > maxInputSize {
-		return fmt.Errorf("input exceeds maximum size (%d bytes)", maxInputSize)
+		errs = append(errs, fmt.Errorf("failed to marshal input: %w", err))
+	} else if limit := maxInput(); size > limit {
+		errs = append(errs, fmt.Errorf("input exceeds maximum size (%d bytes)", limit))
 	}
 
 	// Check nesting depth
 	if depth := getObjectDepth(inputMap, 1); depth > maxNestDepth {
-		return fmt.Errorf("input nesting exceeds maximum depth (%d)", maxNestDepth)
+		errs = append(errs, fmt.Errorf("input nesting exceeds maximum depth (%d)", maxNestDepth))
 	}
 
-	return nil
+	return errs
 }
 
 func getObjectDepth(obj any, currentDepth int) int {
@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"errors"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -514,7 +516,7 @@ func TestValidateInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateInput(tt.input)
+			err := errors.Join(validateInput(tt.input)...)
 
 			if tt.wantErr {
 				if err == nil {
@@ -684,3 +686,32 @@ func TestValidateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRequest_ListsEveryViolation(t *testing.T) {
+	err := ValidateRequest("", "bad tool", map[string]any{
+		"__proto__": generateDeepNestedObject(maxNestDepth + 1),
+	})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ValidateRequest() error = %v, want *ValidationError", err)
+	}
+	want := []Violation{
+		{Field: "server", Message: "server is required"},
+		{Field: "toolName", Message: "toolName contains invalid characters"},
+		{Field: "input", Message: "input contains forbidden key: __proto__"},
+		{Field: "input", Message: "input nesting exceeds maximum depth (10)"},
+	}
+	if !reflect.DeepEqual(verr.Violations, want) {
+		t.Errorf("Violations = %+v, want %+v", verr.Violations, want)
+	}
+	if !strings.Contains(err.Error(), "server is required; toolName contains invalid characters") {
+		t.Errorf("Error() = %q, want the messages joined", err.Error())
+	}
+
+	// 単一の違反のメッセージは従来と同じ
+	err = ValidateRequest("valid-server", "valid-tool", "not an object")
+	if err == nil || err.Error() != "input must be a JSON object" {
+		t.Errorf("ValidateRequest() error = %v, want the single message", err)
+	}
+}
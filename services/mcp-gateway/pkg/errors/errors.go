@@ -1,6 +1,10 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"maps"
+	"net/http"
+)
 
 type ErrorCode string
 
@@ -11,13 +15,81 @@ const (
 	ErrCodeTimeout          ErrorCode = "TIMEOUT_ERROR"
 	ErrCodeServerNotRunning ErrorCode = "SERVER_NOT_RUNNING"
 	ErrCodeServerCrashed    ErrorCode = "SERVER_CRASHED"
+	ErrCodeServerBusy       ErrorCode = "SERVER_BUSY"
 	ErrCodeToolExecution    ErrorCode = "TOOL_EXECUTION_ERROR"
 	ErrCodeInternal         ErrorCode = "INTERNAL_ERROR"
+	ErrCodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	ErrCodeForbidden        ErrorCode = "FORBIDDEN"
+	ErrCodeAuthorization    ErrorCode = "AUTHORIZATION_ERROR"
+	ErrCodeQuotaExceeded    ErrorCode = "QUOTA_EXCEEDED"
+	ErrCodeResponseTooLarge ErrorCode = "RESPONSE_TOO_LARGE"
 )
 
 var (
-	ErrServerNotFound   = errors.New("server not found")
-	ErrServerNotRunning = errors.New("server not running")
-	ErrServerCrashed    = errors.New("server crashed")
-	ErrToolNotFound     = errors.New("tool not found")
+	ErrServerNotFound   = New(ErrCodeServerNotFound, http.StatusNotFound, "server not found")
+	ErrServerNotRunning = New(ErrCodeServerNotRunning, http.StatusServiceUnavailable, "server not running")
+	ErrServerCrashed    = New(ErrCodeServerCrashed, http.StatusBadGateway, "server crashed")
+	ErrServerBusy       = New(ErrCodeServerBusy, http.StatusTooManyRequests, "server busy")
+	ErrToolNotFound     = New(ErrCodeToolNotFound, http.StatusNotFound, "tool not found")
+	ErrInvalidInput     = New(ErrCodeValidation, http.StatusBadRequest, "invalid input")
 )
+
+// GatewayError is an error that knows how it is reported to API clients: the error code and HTTP
+// status of the response, its message and optional details. Err is the underlying cause, if any.
+type GatewayError struct {
+	Code       ErrorCode
+	HTTPStatus int
+	Message    string
+	Details    map[string]any
+	Err        error
+}
+
+// New creates a GatewayError without a cause
+func New(code ErrorCode, httpStatus int, message string) *GatewayError {
+	return &GatewayError{Code: code, HTTPStatus: httpStatus, Message: message}
+}
+
+func (e *GatewayError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *GatewayError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a GatewayError with the same code and message, so that errors derived from a
+// sentinel by Wrap or WithDetails still match it with errors.Is
+func (e *GatewayError) Is(target error) bool {
+	t, ok := target.(*GatewayError)
+	return ok && t.Code == e.Code && t.Message == e.Message
+}
+
+// Wrap returns a copy of e caused by err
+func (e *GatewayError) Wrap(err error) *GatewayError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithDetails returns a copy of e with details added to its own
+func (e *GatewayError) WithDetails(details map[string]any) *GatewayError {
+	detailed := *e
+	detailed.Details = maps.Clone(e.Details)
+	if detailed.Details == nil {
+		detailed.Details = make(map[string]any, len(details))
+	}
+	maps.Copy(detailed.Details, details)
+	return &detailed
+}
+
+// As returns the first GatewayError in err's chain
+func As(err error) (*GatewayError, bool) {
+	var gatewayErr *GatewayError
+	if errors.As(err, &gatewayErr) {
+		return gatewayErr, true
+	}
+	return nil, false
+}
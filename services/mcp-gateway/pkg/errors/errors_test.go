@@ -0,0 +1,37 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGatewayError(t *testing.T) {
+	cause := errors.New(`unknown tool "missing"`)
+	err := ErrToolNotFound.Wrap(cause).WithDetails(map[string]any{"toolName": "missing"})
+
+	if got, want := err.Error(), `tool not found: unknown tool "missing"`; got != want {
+		t.Fatalf("expected message %q, got %q", want, got)
+	}
+	if !errors.Is(err, ErrToolNotFound) || !errors.Is(err, cause) {
+		t.Fatal("expected the error to match its sentinel and its cause")
+	}
+	if errors.Is(err, ErrServerNotFound) {
+		t.Fatal("expected the error not to match another sentinel")
+	}
+	if ErrToolNotFound.Err != nil || ErrToolNotFound.Details != nil {
+		t.Fatal("expected Wrap and WithDetails to leave the sentinel unchanged")
+	}
+
+	gatewayErr, ok := As(fmt.Errorf("call failed: %w", err))
+	if !ok {
+		t.Fatal("expected As to find the GatewayError in a wrapped chain")
+	}
+	if gatewayErr.Code != ErrCodeToolNotFound || gatewayErr.HTTPStatus != http.StatusNotFound || gatewayErr.Details["toolName"] != "missing" {
+		t.Fatalf("unexpected GatewayError %+v", gatewayErr)
+	}
+	if _, ok := As(cause); ok {
+		t.Fatal("expected As to find no GatewayError in a plain error")
+	}
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/config"
+	internalHttp "github.com/khirotaka/restexec/services/mcp-gateway/internal/http"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binaryData is what the test server returns in binary mode, see tests/test_server/server/behavior.go
+var binaryData = []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe, '\r', '\n'}
+
+// flakyGateway serves the test server through the HTTP API, misbehaving as selected by envs
+type flakyGateway struct {
+	router         *gin.Engine
+	processManager *mcp.ProcessManager
+}
+
+// buildTestServer builds tests/test_server into a temporary directory
+func buildTestServer(t *testing.T) string {
+	t.Helper()
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	bin := filepath.Join(t.TempDir(), "test_server_bin")
+	cmd := exec.Command("go", "build", "-o", bin)
+	cmd.Dir = filepath.Join(cwd, "test_server")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "Failed to build test server: %s", string(output))
+	return bin
+}
+
+// startFlakyGateway connects to the test server started with envs, restarting it according to restartPolicy
+func startFlakyGateway(t *testing.T, bin, restartPolicy string, timeoutMs int, envs map[string]string) *flakyGateway {
+	t.Helper()
+	var configContent strings.Builder
+	fmt.Fprintf(&configContent, "servers:\n  - name: flaky\n    command: %s\n    timeout: %d\n    envs:\n", bin, timeoutMs)
+	for name, value := range envs {
+		fmt.Fprintf(&configContent, "      - name: %s\n        value: %q\n", name, value)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(configContent.String()), 0o644))
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+
+	processManager := mcp.NewProcessManager(30000, restartPolicy)
+	processManager.SetRestartBackoff(config.RestartBackoffConfig{InitialDelay: 50, Multiplier: 1, MaxDelay: 50, MaxAttempts: 3})
+	clientManager := mcp.NewClientManager(processManager)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, clientManager.Initialize(ctx, cfg.Servers))
+	t.Cleanup(func() {
+		// Servers left crashed report how their process exited
+		if err := clientManager.Close(); err != nil {
+			t.Logf("Closing client manager: %v", err)
+		}
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := internalHttp.SetupRouter(internalHttp.NewHandler(clientManager, processManager))
+	return &flakyGateway{router: router, processManager: processManager}
+}
+
+// callResponse is the body of POST /mcp/call
+type callResponse struct {
+	Success bool `json:"success"`
+	Result  struct {
+		Content []map[string]any `json:"content"`
+	} `json:"result"`
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call calls calculate-bmi and returns the status and decoded body
+func (g *flakyGateway) call(t *testing.T) (int, callResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"server":   "flaky",
+		"toolName": "calculate-bmi",
+		"input":    map[string]any{"height_m": 1.75, "weight_kg": 70.0},
+	})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	g.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body)))
+	var resp callResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestFlakyServer(t *testing.T) {
+	bin := buildTestServer(t)
+
+	t.Run("Crash is restarted with on-failure", func(t *testing.T) {
+		g := startFlakyGateway(t, bin, config.RestartPolicyOnFailure, 5000, map[string]string{
+			"TEST_SERVER_MODE":        "crash",
+			"TEST_SERVER_CRASH_AFTER": "2",
+		})
+		for range 2 {
+			status, resp := g.call(t)
+			require.Equal(t, http.StatusOK, status, resp.Error.Message)
+		}
+
+		// The third call crashes the server
+		status, resp := g.call(t)
+		assert.NotEqual(t, http.StatusOK, status)
+		assert.False(t, resp.Success)
+
+		// The server may still be listed as available until the crash is noticed, so wait for the restart
+		// to begin before waiting for it to finish
+		require.Eventually(t, func() bool {
+			return g.processManager.GetDiagnostics("flaky").Restarts >= 1
+		}, 10*time.Second, 20*time.Millisecond)
+
+		// The restarted server answers again
+		require.Eventually(t, func() bool {
+			return g.processManager.GetStatus("flaky") == mcp.StatusAvailable
+		}, 10*time.Second, 20*time.Millisecond)
+		status, resp = g.call(t)
+		assert.Equal(t, http.StatusOK, status, resp.Error.Message)
+		diagnostics := g.processManager.GetDiagnostics("flaky")
+		assert.Equal(t, uint64(1), diagnostics.Restarts)
+		assert.NotEmpty(t, diagnostics.LastFailureReason)
+	})
+
+	t.Run("Crash is not restarted with never", func(t *testing.T) {
+		g := startFlakyGateway(t, bin, config.RestartPolicyNever, 5000, map[string]string{
+			"TEST_SERVER_MODE":        "crash",
+			"TEST_SERVER_CRASH_AFTER": "0",
+		})
+		status, _ := g.call(t)
+		assert.NotEqual(t, http.StatusOK, status)
+
+		require.Eventually(t, func() bool {
+			return g.processManager.GetStatus("flaky") == mcp.StatusCrashed
+		}, 5*time.Second, 20*time.Millisecond)
+		status, resp := g.call(t)
+		assert.Equal(t, http.StatusBadGateway, status)
+		assert.Equal(t, "SERVER_CRASHED", resp.Error.Code)
+		assert.Zero(t, g.processManager.GetDiagnostics("flaky").Restarts)
+	})
+
+	t.Run("Hanging call times out", func(t *testing.T) {
+		g := startFlakyGateway(t, bin, config.RestartPolicyNever, 300, map[string]string{"TEST_SERVER_MODE": "hang"})
+		start := time.Now()
+		status, resp := g.call(t)
+		assert.Equal(t, http.StatusGatewayTimeout, status)
+		assert.Equal(t, "TIMEOUT_ERROR", resp.Error.Code)
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		// A timeout is not a crash
+		assert.Equal(t, mcp.StatusAvailable, g.processManager.GetStatus("flaky"))
+	})
+
+	t.Run("Slow call within the timeout succeeds", func(t *testing.T) {
+		g := startFlakyGateway(t, bin, config.RestartPolicyNever, 5000, map[string]string{
+			"TEST_SERVER_MODE":     "slow",
+			"TEST_SERVER_DELAY_MS": "200",
+		})
+		start := time.Now()
+		status, resp := g.call(t)
+		assert.Equal(t, http.StatusOK, status, resp.Error.Message)
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	t.Run("Slow call past the timeout fails", func(t *testing.T) {
+		g := startFlakyGateway(t, bin, config.RestartPolicyNever, 200, map[string]string{
+			"TEST_SERVER_MODE":     "slow",
+			"TEST_SERVER_DELAY_MS": "3000",
+		})
+		status, resp := g.call(t)
+		assert.Equal(t, http.StatusGatewayTimeout, status)
+		assert.Equal(t, "TIMEOUT_ERROR", resp.Error.Code)
+	})
+
+	t.Run("Huge output is returned whole", func(t *testing.T) {
+		const size = 4 << 20
+		g := startFlakyGateway(t, bin, config.RestartPolicyNever, 10000, map[string]string{
+			"TEST_SERVER_MODE":         "huge-output",
+			"TEST_SERVER_OUTPUT_BYTES": fmt.Sprint(size),
+		})
+		status, resp := g.call(t)
+		require.Equal(t, http.StatusOK, status, resp.Error.Message)
+		require.Len(t, resp.Result.Content, 1)
+		assert.Equal(t, "text", resp.Result.Content[0]["type"])
+		assert.Len(t, resp.Result.Content[0]["text"], size)
+	})
+
+	t.Run("Binary content is base64-encoded", func(t *testing.T) {
+		g := startFlakyGateway(t, bin, config.RestartPolicyNever, 5000, map[string]string{"TEST_SERVER_MODE": "binary"})
+		status, resp := g.call(t)
+		require.Equal(t, http.StatusOK, status, resp.Error.Message)
+		require.Len(t, resp.Result.Content, 3)
+
+		encoded := base64.StdEncoding.EncodeToString(binaryData)
+		image, audio, resource := resp.Result.Content[0], resp.Result.Content[1], resp.Result.Content[2]
+		assert.Equal(t, "image", image["type"])
+		assert.Equal(t, "image/png", image["mimeType"])
+		assert.Equal(t, encoded, image["data"])
+		assert.Equal(t, "audio", audio["type"])
+		assert.Equal(t, encoded, audio["data"])
+		assert.Equal(t, "resource", resource["type"])
+		assert.Equal(t, map[string]any{"uri": "test://binary", "mimeType": "application/octet-stream", "blob": encoded}, resource["resource"])
+	})
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	internalHttp "github.com/khirotaka/restexec/services/mcp-gateway/internal/http"
+	"github.com/khirotaka/restexec/services/mcp-gateway/internal/mcp"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greetInput struct {
+	Name string `json:"name"`
+}
+
+type greetOutput struct {
+	Greeting string `json:"greeting"`
+}
+
+// TestInProcessServer serves a Go MCP server registered in-process through the HTTP API
+func TestInProcessServer(t *testing.T) {
+	server := sdk.NewServer(&sdk.Implementation{Name: "embedded", Version: "test"}, nil)
+	sdk.AddTool(server, &sdk.Tool{Name: "greet", Description: "Greets someone"},
+		func(_ context.Context, _ *sdk.CallToolRequest, in greetInput) (*sdk.CallToolResult, greetOutput, error) {
+			return nil, greetOutput{Greeting: "hello, " + in.Name}, nil
+		})
+
+	processManager := mcp.NewProcessManager(30000, "never")
+	clientManager := mcp.NewClientManager(processManager)
+	require.NoError(t, clientManager.RegisterInProcess("embedded", server))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, clientManager.Initialize(ctx, nil))
+	defer func() {
+		if err := clientManager.Close(); err != nil {
+			t.Errorf("Failed to close client manager: %v", err)
+		}
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := internalHttp.SetupRouter(internalHttp.NewHandler(clientManager, processManager))
+
+	// The tool is listed
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mcp/tools", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var tools struct {
+		Tools []mcp.ToolInfo `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tools))
+	require.Len(t, tools.Tools, 1)
+	assert.Equal(t, "greet", tools.Tools[0].Name)
+	assert.Equal(t, "embedded", tools.Tools[0].Server)
+
+	// And callable
+	body, err := json.Marshal(map[string]any{"server": "embedded", "toolName": "greet", "input": map[string]any{"name": "gopher"}})
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp/call", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Success bool `json:"success"`
+		Result  struct {
+			StructuredContent greetOutput `json:"structuredContent"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Success)
+	assert.Equal(t, "hello, gopher", resp.Result.StructuredContent.Greeting)
+}
@@ -3,11 +3,17 @@ package main
 import (
 	"context"
 	"log/slog"
+	"os"
 	"sample-mcp-server/server"
 )
 
 func main() {
-	server := server.NewMCPServer()
+	behavior, err := server.BehaviorFromEnv()
+	if err != nil {
+		slog.Error("invalid test server behavior", slog.Any("error", err))
+		os.Exit(2)
+	}
+	server := server.NewMCPServerWithBehavior(behavior)
 	server.Setup()
 	if err := server.Run(context.Background()); err != nil {
 		slog.Error("failed to run server", slog.Any("error", err))
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Mode selects how the server misbehaves on tool calls, so that gateway tests can exercise failures
+type Mode string
+
+const (
+	ModeNormal     Mode = ""            // tools answer normally
+	ModeCrash      Mode = "crash"       // the process exits on the call after TEST_SERVER_CRASH_AFTER calls
+	ModeHang       Mode = "hang"        // tool calls are never answered
+	ModeSlow       Mode = "slow"        // tool calls are answered after TEST_SERVER_DELAY_MS
+	ModeHugeOutput Mode = "huge-output" // tool calls return TEST_SERVER_OUTPUT_BYTES of text
+	ModeBinary     Mode = "binary"      // tool calls return image, audio and blob resource content
+)
+
+// Environment variables that configure the behavior
+const (
+	EnvMode        = "TEST_SERVER_MODE"
+	EnvCrashAfter  = "TEST_SERVER_CRASH_AFTER"
+	EnvDelayMs     = "TEST_SERVER_DELAY_MS"
+	EnvOutputBytes = "TEST_SERVER_OUTPUT_BYTES"
+)
+
+// BinaryData is the content returned by every item in binary mode
+var BinaryData = []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe, '\r', '\n'}
+
+// Behavior is how tool calls are answered
+type Behavior struct {
+	Mode        Mode
+	CrashAfter  int           // calls answered before crashing
+	Delay       time.Duration // before slow answers
+	OutputBytes int           // length of huge outputs
+}
+
+// BehaviorFromEnv reads the behavior from the environment
+func BehaviorFromEnv() (Behavior, error) {
+	b := Behavior{
+		Mode:        Mode(os.Getenv(EnvMode)),
+		CrashAfter:  1,
+		Delay:       time.Second,
+		OutputBytes: 1 << 20,
+	}
+	switch b.Mode {
+	case ModeNormal, ModeCrash, ModeHang, ModeSlow, ModeHugeOutput, ModeBinary:
+	default:
+		return Behavior{}, fmt.Errorf("unknown %s: %s", EnvMode, b.Mode)
+	}
+	for name, dst := range map[string]*int{EnvCrashAfter: &b.CrashAfter, EnvOutputBytes: &b.OutputBytes} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return Behavior{}, fmt.Errorf("invalid %s: %s", name, v)
+			}
+			*dst = n
+		}
+	}
+	if v := os.Getenv(EnvDelayMs); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return Behavior{}, fmt.Errorf("invalid %s: %s", EnvDelayMs, v)
+		}
+		b.Delay = time.Duration(ms) * time.Millisecond
+	}
+	return b, nil
+}
+
+// middleware applies the behavior to tools/call requests. Other requests, such as pings and
+// tools/list, are always answered, so that the server starts and passes health checks.
+func (b Behavior) middleware() mcp.Middleware {
+	var calls atomic.Int64
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/call" {
+				return next(ctx, method, req)
+			}
+			switch b.Mode {
+			case ModeCrash:
+				if calls.Add(1) > int64(b.CrashAfter) {
+					fmt.Fprintln(os.Stderr, "crashing as configured")
+					os.Exit(1)
+				}
+			case ModeHang:
+				<-ctx.Done()
+				return nil, ctx.Err()
+			case ModeSlow:
+				select {
+				case <-time.After(b.Delay):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			case ModeHugeOutput:
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{&mcp.TextContent{Text: strings.Repeat("x", b.OutputBytes)}},
+				}, nil
+			case ModeBinary:
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.ImageContent{Data: BinaryData, MIMEType: "image/png"},
+						&mcp.AudioContent{Data: BinaryData, MIMEType: "audio/wav"},
+						&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{
+							URI:      "test://binary",
+							MIMEType: "application/octet-stream",
+							Blob:     BinaryData,
+						}},
+					},
+				}, nil
+			}
+			return next(ctx, method, req)
+		}
+	}
+}
@@ -10,11 +10,20 @@ type MCPServer struct {
 	server *mcp.Server
 }
 
+// NewMCPServer creates a server whose tools answer normally
 func NewMCPServer() *MCPServer {
+	return NewMCPServerWithBehavior(Behavior{})
+}
+
+// NewMCPServerWithBehavior creates a server whose tool calls are answered according to behavior
+func NewMCPServerWithBehavior(behavior Behavior) *MCPServer {
 	mcpServer := mcp.NewServer(
 		&mcp.Implementation{Name: "sample-mcp-server", Version: "1.0.0"},
 		nil,
 	)
+	if behavior.Mode != ModeNormal {
+		mcpServer.AddReceivingMiddleware(behavior.middleware())
+	}
 	return &MCPServer{
 		server: mcpServer,
 	}